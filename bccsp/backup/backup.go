@@ -0,0 +1,137 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package backup
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"filippo.io/age"
+	"filippo.io/age/armor"
+)
+
+// 备份使用 age（https://age-encryption.org/v1）格式加密，接收者是 age1 开头的 X25519
+// 公钥，运维人员可以直接使用 age 命令行工具解密。
+
+const armorHeader = "-----BEGIN AGE ENCRYPTED FILE-----"
+
+// GenerateIdentity 生成一个新的 X25519 身份，返回 AGE-SECRET-KEY-1 开头的私钥与 age1
+// 开头的接收者公钥。
+func GenerateIdentity() (identity string, recipient string, err error) {
+	id, err := age.GenerateX25519Identity()
+	if err != nil {
+		return "", "", fmt.Errorf("failed to generate age identity [%v]", err)
+	}
+
+	return id.String(), id.Recipient().String(), nil
+}
+
+// IdentitySKI 返回审计记录中 AGE-SECRET-KEY-1 开头的身份的密钥标识，即身份对应的
+// age1 接收者字符串的 SHA-256 哈希值。
+func IdentitySKI(identity string) ([]byte, error) {
+	id, err := age.ParseX25519Identity(strings.TrimSpace(identity))
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse age identity [%v]", err)
+	}
+
+	hash := sha256.Sum256([]byte(id.Recipient().String()))
+	return hash[:], nil
+}
+
+// ParseRecipients 解析 age1 开头的 X25519 接收者，空行与 # 开头的注释行会被忽略，
+// 因此可以直接传入 age 接收者文件中的各行。
+func ParseRecipients(lines ...string) ([]age.Recipient, error) {
+	var recipients []age.Recipient
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		r, err := age.ParseX25519Recipient(line)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse age recipient [%v]", err)
+		}
+		recipients = append(recipients, r)
+	}
+	if len(recipients) == 0 {
+		return nil, errors.New("at least one age recipient is required")
+	}
+
+	return recipients, nil
+}
+
+// Encrypt 返回一个 io.WriteCloser，写入其中的数据会被加密给 recipients 中的每个接收者
+// 并写入 dst。armored 为 true 时输出 PEM 风格的 ASCII 编码。调用方必须调用 Close
+// 才能写出最后一个数据块。
+func Encrypt(dst io.Writer, armored bool, recipients ...string) (io.WriteCloser, error) {
+	rs, err := ParseRecipients(recipients...)
+	if err != nil {
+		return nil, err
+	}
+
+	if !armored {
+		w, err := age.Encrypt(dst, rs...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to encrypt backup [%v]", err)
+		}
+		return w, nil
+	}
+
+	aw := armor.NewWriter(dst)
+	w, err := age.Encrypt(aw, rs...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt backup [%v]", err)
+	}
+
+	return &armoredWriter{WriteCloser: w, armor: aw}, nil
+}
+
+type armoredWriter struct {
+	io.WriteCloser
+	armor io.WriteCloser
+}
+
+func (w *armoredWriter) Close() error {
+	if err := w.WriteCloser.Close(); err != nil {
+		return err
+	}
+	return w.armor.Close()
+}
+
+// Decrypt 使用 AGE-SECRET-KEY-1 开头的身份解密 src 中的备份，自动识别 ASCII 编码。
+func Decrypt(src io.Reader, identities ...string) (io.Reader, error) {
+	var ids []age.Identity
+	for _, identity := range identities {
+		id, err := age.ParseX25519Identity(strings.TrimSpace(identity))
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse age identity [%v]", err)
+		}
+		ids = append(ids, id)
+	}
+	if len(ids) == 0 {
+		return nil, errors.New("at least one age identity is required")
+	}
+
+	br := bufio.NewReader(src)
+	if start, _ := br.Peek(len(armorHeader)); bytes.Equal(start, []byte(armorHeader)) {
+		src = armor.NewReader(br)
+	} else {
+		src = br
+	}
+
+	r, err := age.Decrypt(src, ids...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt backup [%v]", err)
+	}
+
+	return r, nil
+}
@@ -0,0 +1,72 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package backup
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEncryptAndDecrypt(t *testing.T) {
+	identity1, recipient1, err := GenerateIdentity()
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(identity1, "AGE-SECRET-KEY-1"))
+	require.True(t, strings.HasPrefix(recipient1, "age1"))
+	identity2, recipient2, err := GenerateIdentity()
+	require.NoError(t, err)
+	other, _, err := GenerateIdentity()
+	require.NoError(t, err)
+
+	plaintext := bytes.Repeat([]byte("ledger snapshot "), 10000)
+	for _, armored := range []bool{false, true} {
+		encrypted := new(bytes.Buffer)
+		w, err := Encrypt(encrypted, armored, "# ops team", recipient1, "", recipient2)
+		require.NoError(t, err)
+		_, err = w.Write(plaintext)
+		require.NoError(t, err)
+		require.NoError(t, w.Close())
+		require.Equal(t, armored, strings.HasPrefix(encrypted.String(), armorHeader))
+
+		// 每个接收者都可以独立解密。
+		for _, identity := range []string{identity1, identity2} {
+			r, err := Decrypt(bytes.NewReader(encrypted.Bytes()), identity)
+			require.NoError(t, err)
+			decrypted, err := io.ReadAll(r)
+			require.NoError(t, err)
+			require.Equal(t, plaintext, decrypted)
+		}
+
+		_, err = Decrypt(bytes.NewReader(encrypted.Bytes()), other)
+		require.ErrorContains(t, err, "failed to decrypt backup")
+	}
+
+	_, err = Encrypt(io.Discard, false)
+	require.EqualError(t, err, "at least one age recipient is required")
+	_, err = Encrypt(io.Discard, false, "age1invalid")
+	require.ErrorContains(t, err, "failed to parse age recipient")
+	_, err = Decrypt(bytes.NewReader(nil))
+	require.EqualError(t, err, "at least one age identity is required")
+	_, err = Decrypt(bytes.NewReader(nil), "AGE-SECRET-KEY-1INVALID")
+	require.ErrorContains(t, err, "failed to parse age identity")
+}
+
+func TestIdentitySKI(t *testing.T) {
+	identity, recipient, err := GenerateIdentity()
+	require.NoError(t, err)
+
+	ski, err := IdentitySKI(identity + "\n")
+	require.NoError(t, err)
+	expected := sha256.Sum256([]byte(recipient))
+	require.Equal(t, expected[:], ski)
+
+	_, err = IdentitySKI(recipient)
+	require.ErrorContains(t, err, "failed to parse age identity")
+}
@@ -0,0 +1,308 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package bbs
+
+import (
+	"crypto"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	bls12381 "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/cloudflare/circl/expander"
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+	"github.com/geistwelt/quarkx/bccsp/secret"
+)
+
+// 这里的匿名凭证基于 BLS12-381 上的 BBS 签名，构造与 IETF 草案
+// draft-irtf-cfrg-bbs-signatures 一致：发行者用私钥对一组属性签名得到凭证，持有者
+// 可以在不暴露凭证本身的情况下，只披露其中一部分属性并证明这些属性由发行者签发。
+// 不同的证明之间无法关联，因此成员可以证明自己属于某个组织而不暴露身份。
+//
+// 生成元与哈希的域分隔标签使用 quarkx 自己的套件标识，编码与草案的测试向量不兼容。
+const (
+	IssuerPublicKeySize  = bls12381.G2SizeCompressed
+	IssuerPrivateKeySize = bls12381.ScalarSize
+	CredentialSize       = bls12381.G1SizeCompressed + bls12381.ScalarSize
+
+	// MaxAttributes 是一张凭证最多包含的属性数量，属性生成元会被缓存，这个上限同时限制了
+	// 缓存的大小。
+	MaxAttributes = 256
+
+	ciphersuiteID = "QUARKX_BBS_BLS12381G1_XMD:SHA-256_SSWU_RO_"
+)
+
+var (
+	// ErrInvalidCredential 表示凭证不是发行者对给定属性的有效签名。
+	ErrInvalidCredential = errors.New("invalid credential")
+	// ErrInvalidProof 表示选择性披露证明无效。
+	ErrInvalidProof = errors.New("invalid proof")
+)
+
+// IssuerKey 是凭证发行者的私钥，在 fmt 与日志中只显示为 [REDACTED]。
+type IssuerKey struct {
+	secret.Redacted
+
+	sk bls12381.Scalar
+}
+
+// IssuerPublicKey 是凭证发行者的公钥 W = sk·BP2。
+type IssuerPublicKey struct {
+	w bls12381.G2
+}
+
+// Credential 是发行者对一组属性的 BBS 签名 (A, e)，其中 A = B·1/(sk+e)。
+type Credential struct {
+	a bls12381.G1
+	e bls12381.Scalar
+}
+
+// GenerateIssuerKey 使用 entropy.Reader 生成一个随机的发行者私钥。
+func GenerateIssuerKey() (*IssuerKey, error) {
+	return GenerateIssuerKeyWithRand(entropy.Reader)
+}
+
+// GenerateIssuerKeyWithRand 使用随机源 rand 生成一个随机的发行者私钥。
+func GenerateIssuerKeyWithRand(rand io.Reader) (*IssuerKey, error) {
+	k := &IssuerKey{}
+	for k.sk.IsZero() == 1 {
+		if err := k.sk.Random(rand); err != nil {
+			return nil, fmt.Errorf("failed to generate issuer key [%v]", err)
+		}
+	}
+	return k, nil
+}
+
+// Destroy 将私钥清零，之后私钥不能再用于签发凭证。
+func (k *IssuerKey) Destroy() {
+	k.sk.SetUint64(0)
+}
+
+// PublicKey 返回发行者私钥对应的公钥。
+func (k *IssuerKey) PublicKey() *IssuerPublicKey {
+	pub := &IssuerPublicKey{}
+	pub.w.ScalarMult(&k.sk, bls12381.G2Generator())
+	return pub
+}
+
+// Bytes 返回私钥的 32 字节大端编码。
+func (k *IssuerKey) Bytes() []byte {
+	raw, _ := k.sk.MarshalBinary()
+	return raw
+}
+
+// ParseIssuerKey 解析 Bytes 返回的编码。
+func ParseIssuerKey(raw []byte) (*IssuerKey, error) {
+	if len(raw) != IssuerPrivateKeySize {
+		return nil, fmt.Errorf("invalid issuer private key length %d, must be %d", len(raw), IssuerPrivateKeySize)
+	}
+	k := &IssuerKey{}
+	if err := k.sk.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("invalid issuer private key [%v]", err)
+	}
+	if k.sk.IsZero() == 1 {
+		return nil, errors.New("invalid issuer private key, it must be different from zero")
+	}
+	return k, nil
+}
+
+// Bytes 返回公钥的 96 字节压缩编码。
+func (k *IssuerPublicKey) Bytes() []byte {
+	return k.w.BytesCompressed()
+}
+
+// Equal 判断两个公钥是否相同。
+func (k *IssuerPublicKey) Equal(other *IssuerPublicKey) bool {
+	return other != nil && k.w.IsEqual(&other.w)
+}
+
+// ParseIssuerPublicKey 解析压缩编码的公钥，公钥必须位于 G2 的素数阶子群中，并且不能是
+// 单位元。
+func ParseIssuerPublicKey(raw []byte) (*IssuerPublicKey, error) {
+	if len(raw) != IssuerPublicKeySize {
+		return nil, fmt.Errorf("invalid issuer public key length %d, must be %d", len(raw), IssuerPublicKeySize)
+	}
+	k := &IssuerPublicKey{}
+	if err := k.w.SetBytes(raw); err != nil {
+		return nil, fmt.Errorf("invalid issuer public key [%v]", err)
+	}
+	if k.w.IsIdentity() {
+		return nil, errors.New("invalid issuer public key, it is the identity")
+	}
+	return k, nil
+}
+
+// Issue 为 attributes 签发凭证，header 是发行者与验证者约定的、与所有属性绑定但不属于
+// 属性的上下文信息（例如凭证类型），可以为空。
+func (k *IssuerKey) Issue(header []byte, attributes [][]byte) (*Credential, error) {
+	if len(attributes) == 0 {
+		return nil, errors.New("at least one attribute is required")
+	}
+	if len(attributes) > MaxAttributes {
+		return nil, fmt.Errorf("too many attributes %d, must not be larger than %d", len(attributes), MaxAttributes)
+	}
+	if k.sk.IsZero() == 1 {
+		return nil, errors.New("issuer private key has been destroyed")
+	}
+
+	pub := k.PublicKey()
+	domain := calculateDomain(pub, len(attributes), header)
+	messages := mapAttributes(attributes)
+	b := calculateB(domain, messages)
+
+	// 与草案一致，e 由私钥、domain 与属性确定性地导出。
+	skBytes, _ := k.sk.MarshalBinary()
+	input := append(skBytes, scalarBytes(domain)...)
+	for i := range messages {
+		input = append(input, scalarBytes(&messages[i])...)
+	}
+	cred := &Credential{}
+	cred.e = hashToScalar(input, "H2S_")
+
+	var inv bls12381.Scalar
+	inv.Add(&k.sk, &cred.e)
+	if inv.IsZero() == 1 {
+		return nil, errors.New("failed to issue credential, sk + e is zero")
+	}
+	inv.Inv(&inv)
+	cred.a.ScalarMult(&inv, b)
+
+	return cred, nil
+}
+
+// Verify 验证 cred 是否为发行者对 header 与 attributes 签发的凭证。
+func (k *IssuerPublicKey) Verify(cred *Credential, header []byte, attributes [][]byte) error {
+	if cred == nil || len(attributes) == 0 || len(attributes) > MaxAttributes || cred.a.IsIdentity() {
+		return ErrInvalidCredential
+	}
+
+	domain := calculateDomain(k, len(attributes), header)
+	b := calculateB(domain, mapAttributes(attributes))
+
+	// e(A, W + e·BP2) == e(B, BP2)
+	var we bls12381.G2
+	we.ScalarMult(&cred.e, bls12381.G2Generator())
+	we.Add(&we, &k.w)
+	if !bls12381.ProdPairFrac(
+		[]*bls12381.G1{&cred.a, b},
+		[]*bls12381.G2{&we, bls12381.G2Generator()},
+		[]int{1, -1},
+	).IsIdentity() {
+		return ErrInvalidCredential
+	}
+
+	return nil
+}
+
+// Bytes 返回凭证的编码，即 A 的 48 字节压缩编码与 e 的 32 字节大端编码。
+func (c *Credential) Bytes() []byte {
+	return append(c.a.BytesCompressed(), scalarBytes(&c.e)...)
+}
+
+// ParseCredential 解析 Bytes 返回的编码。
+func ParseCredential(raw []byte) (*Credential, error) {
+	if len(raw) != CredentialSize {
+		return nil, fmt.Errorf("invalid credential length %d, must be %d", len(raw), CredentialSize)
+	}
+	c := &Credential{}
+	if err := c.a.SetBytes(raw[:bls12381.G1SizeCompressed]); err != nil {
+		return nil, fmt.Errorf("invalid credential [%v]", err)
+	}
+	if err := c.e.UnmarshalBinary(raw[bls12381.G1SizeCompressed:]); err != nil {
+		return nil, fmt.Errorf("invalid credential [%v]", err)
+	}
+	return c, nil
+}
+
+// 生成元 P1、Q1 与 H_1, H_2, ... 都通过哈希到曲线得到，没有人知道它们之间的离散对数关系。
+var (
+	generatorsMutex sync.Mutex
+	p1, q1          *bls12381.G1
+	hs              []*bls12381.G1
+)
+
+// generators 返回 P1、Q1 以及前 n 个属性生成元，n 不能超过 MaxAttributes。
+func generators(n int) (*bls12381.G1, *bls12381.G1, []*bls12381.G1) {
+	if n > MaxAttributes {
+		panic(fmt.Sprintf("bbs: %d generators requested, at most %d are supported", n, MaxAttributes))
+	}
+
+	generatorsMutex.Lock()
+	defer generatorsMutex.Unlock()
+
+	if p1 == nil {
+		p1, q1 = hashToG1([]byte("P1")), hashToG1([]byte("Q1"))
+	}
+	for i := len(hs); i < n; i++ {
+		hs = append(hs, hashToG1(binary.BigEndian.AppendUint64([]byte("H"), uint64(i))))
+	}
+
+	return p1, q1, hs[:n:n]
+}
+
+func hashToG1(input []byte) *bls12381.G1 {
+	p := &bls12381.G1{}
+	p.Hash(input, []byte(ciphersuiteID+"H2G_"))
+	return p
+}
+
+// hashToScalar 使用 expand_message_xmd 将输入扩展为 48 字节后对群的阶取模，偏差可以
+// 忽略不计。
+func hashToScalar(input []byte, dst string) bls12381.Scalar {
+	uniform := expander.NewExpanderMD(crypto.SHA256, []byte(ciphersuiteID+dst)).Expand(input, 48)
+	var s bls12381.Scalar
+	s.SetBytes(uniform)
+	return s
+}
+
+func scalarBytes(s *bls12381.Scalar) []byte {
+	raw, _ := s.MarshalBinary()
+	return raw
+}
+
+// mapAttributes 将属性映射为标量。
+func mapAttributes(attributes [][]byte) []bls12381.Scalar {
+	messages := make([]bls12381.Scalar, len(attributes))
+	for i, attribute := range attributes {
+		messages[i] = hashToScalar(attribute, "MAP_MSG_TO_SCALAR_AS_HASH_")
+	}
+	return messages
+}
+
+// calculateDomain 将公钥、属性数量、生成元与 header 绑定到一个标量中。
+func calculateDomain(pub *IssuerPublicKey, count int, header []byte) *bls12381.Scalar {
+	_, q1, hs := generators(count)
+
+	input := pub.Bytes()
+	input = binary.BigEndian.AppendUint64(input, uint64(count))
+	input = append(input, q1.BytesCompressed()...)
+	for _, h := range hs {
+		input = append(input, h.BytesCompressed()...)
+	}
+	input = append(input, ciphersuiteID...)
+	input = binary.BigEndian.AppendUint64(input, uint64(len(header)))
+	input = append(input, header...)
+
+	domain := hashToScalar(input, "H2S_")
+	return &domain
+}
+
+// calculateB 计算 B = P1 + Q1·domain + Σ H_i·m_i。
+func calculateB(domain *bls12381.Scalar, messages []bls12381.Scalar) *bls12381.G1 {
+	p1, q1, hs := generators(len(messages))
+
+	b := &bls12381.G1{}
+	b.ScalarMult(domain, q1)
+	b.Add(b, p1)
+	for i := range messages {
+		var t bls12381.G1
+		t.ScalarMult(&messages[i], hs[i])
+		b.Add(b, &t)
+	}
+	return b
+}
@@ -0,0 +1,159 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package bbs
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/geistwelt/quarkx/bccsp/secret"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	header     = []byte("quarkx membership credential")
+	attributes = [][]byte{
+		[]byte("org=Org1MSP"),
+		[]byte("role=client"),
+		[]byte("enrollment=alice"),
+		[]byte("expiry=2027-10-19"),
+	}
+)
+
+func TestIssueAndVerify(t *testing.T) {
+	sk, err := GenerateIssuerKey()
+	require.NoError(t, err)
+	pub := sk.PublicKey()
+
+	cred, err := sk.Issue(header, attributes)
+	require.NoError(t, err)
+	require.NoError(t, pub.Verify(cred, header, attributes))
+
+	tampered := [][]byte{attributes[0], []byte("role=admin"), attributes[2], attributes[3]}
+	require.ErrorIs(t, pub.Verify(cred, header, tampered), ErrInvalidCredential)
+	require.ErrorIs(t, pub.Verify(cred, []byte("other header"), attributes), ErrInvalidCredential)
+	require.ErrorIs(t, pub.Verify(cred, header, attributes[:3]), ErrInvalidCredential)
+
+	other, err := GenerateIssuerKey()
+	require.NoError(t, err)
+	require.ErrorIs(t, other.PublicKey().Verify(cred, header, attributes), ErrInvalidCredential)
+
+	_, err = sk.Issue(header, nil)
+	require.EqualError(t, err, "at least one attribute is required")
+
+	require.Equal(t, secret.Placeholder, fmt.Sprint(sk))
+	sk.Destroy()
+	_, err = sk.Issue(header, attributes)
+	require.EqualError(t, err, "issuer private key has been destroyed")
+}
+
+func TestEncoding(t *testing.T) {
+	sk, err := GenerateIssuerKey()
+	require.NoError(t, err)
+	cred, err := sk.Issue(header, attributes)
+	require.NoError(t, err)
+
+	parsedSK, err := ParseIssuerKey(sk.Bytes())
+	require.NoError(t, err)
+	require.True(t, sk.PublicKey().Equal(parsedSK.PublicKey()))
+
+	pub, err := ParseIssuerPublicKey(sk.PublicKey().Bytes())
+	require.NoError(t, err)
+	require.True(t, pub.Equal(sk.PublicKey()))
+
+	parsedCred, err := ParseCredential(cred.Bytes())
+	require.NoError(t, err)
+	require.NoError(t, pub.Verify(parsedCred, header, attributes))
+
+	_, err = ParseIssuerKey(make([]byte, IssuerPrivateKeySize))
+	require.EqualError(t, err, "invalid issuer private key, it must be different from zero")
+	_, err = ParseIssuerPublicKey(make([]byte, 10))
+	require.EqualError(t, err, "invalid issuer public key length 10, must be 96")
+	_, err = ParseCredential(make([]byte, 10))
+	require.EqualError(t, err, "invalid credential length 10, must be 80")
+}
+
+func TestSelectiveDisclosure(t *testing.T) {
+	sk, err := GenerateIssuerKey()
+	require.NoError(t, err)
+	pub := sk.PublicKey()
+	cred, err := sk.Issue(header, attributes)
+	require.NoError(t, err)
+
+	nonce := []byte("verifier nonce")
+	// 只披露组织与有效期，隐藏角色与注册身份。
+	proof, err := CreateProof(pub, cred, header, nonce, attributes, []int{3, 0})
+	require.NoError(t, err)
+	disclosed := map[int][]byte{0: attributes[0], 3: attributes[3]}
+	require.NoError(t, VerifyProof(pub, proof, header, nonce, len(attributes), disclosed))
+
+	raw := proof.Bytes()
+	parsed, err := ParseProof(raw)
+	require.NoError(t, err)
+	require.NoError(t, VerifyProof(pub, parsed, header, nonce, len(attributes), disclosed))
+
+	// 同一张凭证生成的两个证明互不相同。
+	again, err := CreateProof(pub, cred, header, nonce, attributes, []int{0, 3})
+	require.NoError(t, err)
+	require.NotEqual(t, raw, again.Bytes())
+	require.NoError(t, VerifyProof(pub, again, header, nonce, len(attributes), disclosed))
+
+	require.ErrorIs(t, VerifyProof(pub, proof, header, []byte("replayed"), len(attributes), disclosed), ErrInvalidProof)
+	require.ErrorIs(t, VerifyProof(pub, proof, []byte("other header"), nonce, len(attributes), disclosed), ErrInvalidProof)
+	require.ErrorIs(t, VerifyProof(pub, proof, header, nonce, len(attributes), map[int][]byte{0: []byte("org=Org2MSP"), 3: attributes[3]}), ErrInvalidProof)
+	require.ErrorIs(t, VerifyProof(pub, proof, header, nonce, len(attributes), map[int][]byte{1: attributes[1], 3: attributes[3]}), ErrInvalidProof)
+	require.ErrorIs(t, VerifyProof(pub, proof, header, nonce, len(attributes), map[int][]byte{0: attributes[0], 7: attributes[3]}), ErrInvalidProof)
+
+	other, err := GenerateIssuerKey()
+	require.NoError(t, err)
+	require.ErrorIs(t, VerifyProof(other.PublicKey(), proof, header, nonce, len(attributes), disclosed), ErrInvalidProof)
+
+	// 全部披露与全部隐藏都是合法的。
+	proof, err = CreateProof(pub, cred, header, nil, attributes, nil)
+	require.NoError(t, err)
+	require.NoError(t, VerifyProof(pub, proof, header, nil, len(attributes), nil))
+	proof, err = CreateProof(pub, cred, header, nil, attributes, []int{0, 1, 2, 3})
+	require.NoError(t, err)
+	require.NoError(t, VerifyProof(pub, proof, header, nil, len(attributes), map[int][]byte{0: attributes[0], 1: attributes[1], 2: attributes[2], 3: attributes[3]}))
+
+	_, err = CreateProof(pub, cred, header, nonce, attributes, []int{4})
+	require.EqualError(t, err, "invalid disclosed index 4, must be in [0, 4)")
+	_, err = CreateProof(pub, cred, header, nonce, attributes, []int{1, 1})
+	require.EqualError(t, err, "duplicate disclosed index 1")
+	_, err = CreateProof(pub, cred, header, nonce, attributes[:3], []int{0})
+	require.ErrorIs(t, err, ErrInvalidCredential)
+
+	_, err = ParseProof(raw[:len(raw)-1])
+	require.EqualError(t, err, "invalid proof length 335")
+}
+
+func TestAttributeCount(t *testing.T) {
+	header := []byte("quarkx membership credential")
+	attributes := [][]byte{[]byte("org=Org1MSP"), []byte("role=member")}
+	sk, err := GenerateIssuerKey()
+	require.NoError(t, err)
+	pub := sk.PublicKey()
+	cred, err := sk.Issue(header, attributes)
+	require.NoError(t, err)
+	proof, err := CreateProof(pub, cred, header, nil, attributes, []int{0})
+	require.NoError(t, err)
+	disclosed := map[int][]byte{0: attributes[0]}
+
+	// 属性总数由验证者给出，与证明中的属性数量不一致时直接拒绝。
+	require.NoError(t, VerifyProof(pub, proof, header, nil, 2, disclosed))
+	require.ErrorIs(t, VerifyProof(pub, proof, header, nil, 3, disclosed), ErrInvalidProof)
+	require.ErrorIs(t, VerifyProof(pub, proof, header, nil, 0, nil), ErrInvalidProof)
+	require.ErrorIs(t, VerifyProof(pub, proof, header, nil, MaxAttributes+1, disclosed), ErrInvalidProof)
+
+	_, err = sk.Issue(header, make([][]byte, MaxAttributes+1))
+	require.EqualError(t, err, "too many attributes 257, must not be larger than 256")
+
+	raw := proof.Bytes()
+	forged := append(raw[:len(raw)-32:len(raw)-32], make([]byte, MaxAttributes*32)...)
+	forged = append(forged, raw[len(raw)-32:]...)
+	_, err = ParseProof(forged)
+	require.ErrorContains(t, err, "too many attributes")
+}
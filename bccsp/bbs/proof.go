@@ -0,0 +1,265 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package bbs
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+
+	bls12381 "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+)
+
+// Proof 是持有者对凭证的选择性披露证明，它证明持有者拥有发行者对全部属性签发的凭证，
+// 并且其中被披露的属性取给定的值，未披露的属性不会泄露。每次生成的证明都经过重新
+// 随机化，同一张凭证生成的多个证明之间无法关联。
+type Proof struct {
+	abar, bbar, d      bls12381.G1
+	eHat, r1Hat, r3Hat bls12381.Scalar
+	mHat               []bls12381.Scalar
+	c                  bls12381.Scalar
+}
+
+// CreateProof 使用 cred 生成只披露 disclosed 所指属性的证明，attributes 是凭证中的全部
+// 属性，header 与签发凭证时相同。presentationHeader 通常是验证者提供的随机挑战，它被
+// 绑定到证明中以防止重放。证明的随机化因子取自 entropy.Reader。
+func CreateProof(pub *IssuerPublicKey, cred *Credential, header, presentationHeader []byte, attributes [][]byte, disclosed []int) (*Proof, error) {
+	return CreateProofWithRand(entropy.Reader, pub, cred, header, presentationHeader, attributes, disclosed)
+}
+
+// CreateProofWithRand 与 CreateProof 相同，证明的随机化因子取自 rand。
+func CreateProofWithRand(rand io.Reader, pub *IssuerPublicKey, cred *Credential, header, presentationHeader []byte, attributes [][]byte, disclosed []int) (*Proof, error) {
+	if err := pub.Verify(cred, header, attributes); err != nil {
+		return nil, err
+	}
+
+	disclosed = slices.Clone(disclosed)
+	sort.Ints(disclosed)
+	for i, index := range disclosed {
+		if index < 0 || index >= len(attributes) {
+			return nil, fmt.Errorf("invalid disclosed index %d, must be in [0, %d)", index, len(attributes))
+		}
+		if i > 0 && disclosed[i-1] == index {
+			return nil, fmt.Errorf("duplicate disclosed index %d", index)
+		}
+	}
+	var undisclosed []int
+	for i := range attributes {
+		if _, found := slices.BinarySearch(disclosed, i); !found {
+			undisclosed = append(undisclosed, i)
+		}
+	}
+
+	domain := calculateDomain(pub, len(attributes), header)
+	messages := mapAttributes(attributes)
+	b := calculateB(domain, messages)
+	_, _, hs := generators(len(attributes))
+
+	randoms, err := randomScalars(rand, 5+len(undisclosed))
+	if err != nil {
+		return nil, err
+	}
+	r1, r2, eTilde, r1Tilde, r3Tilde := &randoms[0], &randoms[1], &randoms[2], &randoms[3], &randoms[4]
+	mTilde := randoms[5:]
+
+	proof := &Proof{mHat: make([]bls12381.Scalar, len(undisclosed))}
+
+	// D = B·r2，Abar = A·(r1·r2)，Bbar = D·r1 - Abar·e
+	var r1r2 bls12381.Scalar
+	r1r2.Mul(r1, r2)
+	proof.d.ScalarMult(r2, b)
+	proof.abar.ScalarMult(&r1r2, &cred.a)
+	var t bls12381.G1
+	proof.bbar.ScalarMult(r1, &proof.d)
+	t.ScalarMult(&cred.e, &proof.abar)
+	t.Neg()
+	proof.bbar.Add(&proof.bbar, &t)
+
+	// T1 = Abar·e~ + D·r1~，T2 = D·r3~ + Σ H_j·m~_j
+	var t1, t2 bls12381.G1
+	t1.ScalarMult(eTilde, &proof.abar)
+	t.ScalarMult(r1Tilde, &proof.d)
+	t1.Add(&t1, &t)
+	t2.ScalarMult(r3Tilde, &proof.d)
+	for i, j := range undisclosed {
+		t.ScalarMult(&mTilde[i], hs[j])
+		t2.Add(&t2, &t)
+	}
+
+	proof.c = challenge(&proof.abar, &proof.bbar, &proof.d, &t1, &t2, disclosed, messages, domain, presentationHeader)
+
+	var r3, tmp bls12381.Scalar
+	r3.Inv(r2)
+	// e^ = e~ + e·c，r1^ = r1~ - r1·c，r3^ = r3~ - r3·c，m^_j = m~_j + m_j·c
+	tmp.Mul(&cred.e, &proof.c)
+	proof.eHat.Add(eTilde, &tmp)
+	tmp.Mul(r1, &proof.c)
+	proof.r1Hat.Sub(r1Tilde, &tmp)
+	tmp.Mul(&r3, &proof.c)
+	proof.r3Hat.Sub(r3Tilde, &tmp)
+	for i, j := range undisclosed {
+		tmp.Mul(&messages[j], &proof.c)
+		proof.mHat[i].Add(&mTilde[i], &tmp)
+	}
+
+	return proof, nil
+}
+
+// VerifyProof 验证 proof 是否证明了持有者拥有 pub 签发的、包含 count 个属性的凭证，
+// 并且凭证中下标为 disclosed 的键的属性取对应的值。count 由验证者根据凭证的类型给出，
+// 证明中未披露的属性数量必须与之相符。
+func VerifyProof(pub *IssuerPublicKey, proof *Proof, header, presentationHeader []byte, count int, disclosed map[int][]byte) error {
+	if pub == nil || proof == nil || proof.abar.IsIdentity() {
+		return ErrInvalidProof
+	}
+	if count <= 0 || count > MaxAttributes {
+		return fmt.Errorf("%w: invalid number of attributes %d, must be in [1, %d]", ErrInvalidProof, count, MaxAttributes)
+	}
+	if len(disclosed)+len(proof.mHat) != count {
+		return fmt.Errorf("%w: proof covers %d attributes, expected %d", ErrInvalidProof, len(disclosed)+len(proof.mHat), count)
+	}
+
+	indexes := make([]int, 0, len(disclosed))
+	for index := range disclosed {
+		if index < 0 || index >= count {
+			return fmt.Errorf("%w: disclosed index %d out of range [0, %d)", ErrInvalidProof, index, count)
+		}
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	domain := calculateDomain(pub, count, header)
+	p1, q1, hs := generators(count)
+
+	// T1 = Bbar·c + Abar·e^ + D·r1^
+	var t1, t2, t bls12381.G1
+	t1.ScalarMult(&proof.c, &proof.bbar)
+	t.ScalarMult(&proof.eHat, &proof.abar)
+	t1.Add(&t1, &t)
+	t.ScalarMult(&proof.r1Hat, &proof.d)
+	t1.Add(&t1, &t)
+
+	// Bv = P1 + Q1·domain + Σ H_i·m_i（已披露），T2 = Bv·c + D·r3^ + Σ H_j·m^_j（未披露）
+	messages := make([]bls12381.Scalar, count)
+	var bv bls12381.G1
+	bv.ScalarMult(domain, q1)
+	bv.Add(&bv, p1)
+	for _, i := range indexes {
+		messages[i] = hashToScalar(disclosed[i], "MAP_MSG_TO_SCALAR_AS_HASH_")
+		t.ScalarMult(&messages[i], hs[i])
+		bv.Add(&bv, &t)
+	}
+	t2.ScalarMult(&proof.c, &bv)
+	t.ScalarMult(&proof.r3Hat, &proof.d)
+	t2.Add(&t2, &t)
+	next := 0
+	for j := 0; j < count; j++ {
+		if _, ok := disclosed[j]; ok {
+			continue
+		}
+		t.ScalarMult(&proof.mHat[next], hs[j])
+		t2.Add(&t2, &t)
+		next++
+	}
+
+	c := challenge(&proof.abar, &proof.bbar, &proof.d, &t1, &t2, indexes, messages, domain, presentationHeader)
+	if c.IsEqual(&proof.c) != 1 {
+		return ErrInvalidProof
+	}
+
+	// e(Abar, W) == e(Bbar, BP2)
+	if !bls12381.ProdPairFrac(
+		[]*bls12381.G1{&proof.abar, &proof.bbar},
+		[]*bls12381.G2{&pub.w, bls12381.G2Generator()},
+		[]int{1, -1},
+	).IsIdentity() {
+		return ErrInvalidProof
+	}
+
+	return nil
+}
+
+// Bytes 返回证明的编码：Abar、Bbar、D 的压缩编码，随后依次是 e^、r1^、r3^、每个 m^ 与
+// c 的 32 字节大端编码。
+func (p *Proof) Bytes() []byte {
+	raw := make([]byte, 0, 3*bls12381.G1SizeCompressed+(4+len(p.mHat))*bls12381.ScalarSize)
+	raw = append(raw, p.abar.BytesCompressed()...)
+	raw = append(raw, p.bbar.BytesCompressed()...)
+	raw = append(raw, p.d.BytesCompressed()...)
+	raw = append(raw, scalarBytes(&p.eHat)...)
+	raw = append(raw, scalarBytes(&p.r1Hat)...)
+	raw = append(raw, scalarBytes(&p.r3Hat)...)
+	for i := range p.mHat {
+		raw = append(raw, scalarBytes(&p.mHat[i])...)
+	}
+	return append(raw, scalarBytes(&p.c)...)
+}
+
+// ParseProof 解析 Bytes 返回的编码。
+func ParseProof(raw []byte) (*Proof, error) {
+	const fixed = 3*bls12381.G1SizeCompressed + 4*bls12381.ScalarSize
+	if len(raw) < fixed || (len(raw)-fixed)%bls12381.ScalarSize != 0 {
+		return nil, fmt.Errorf("invalid proof length %d", len(raw))
+	}
+
+	if (len(raw)-fixed)/bls12381.ScalarSize > MaxAttributes {
+		return nil, fmt.Errorf("invalid proof length %d, too many attributes", len(raw))
+	}
+
+	p := &Proof{mHat: make([]bls12381.Scalar, (len(raw)-fixed)/bls12381.ScalarSize)}
+	for _, point := range []*bls12381.G1{&p.abar, &p.bbar, &p.d} {
+		if err := point.SetBytes(raw[:bls12381.G1SizeCompressed]); err != nil {
+			return nil, fmt.Errorf("invalid proof [%v]", err)
+		}
+		raw = raw[bls12381.G1SizeCompressed:]
+	}
+	scalars := []*bls12381.Scalar{&p.eHat, &p.r1Hat, &p.r3Hat}
+	for i := range p.mHat {
+		scalars = append(scalars, &p.mHat[i])
+	}
+	scalars = append(scalars, &p.c)
+	for _, s := range scalars {
+		if err := s.UnmarshalBinary(raw[:bls12381.ScalarSize]); err != nil {
+			return nil, fmt.Errorf("invalid proof [%v]", err)
+		}
+		raw = raw[bls12381.ScalarSize:]
+	}
+
+	return p, nil
+}
+
+func randomScalars(rand io.Reader, n int) ([]bls12381.Scalar, error) {
+	scalars := make([]bls12381.Scalar, n)
+	for i := range scalars {
+		for scalars[i].IsZero() == 1 {
+			if err := scalars[i].Random(rand); err != nil {
+				return nil, fmt.Errorf("failed to generate random scalar [%v]", err)
+			}
+		}
+	}
+	return scalars, nil
+}
+
+// challenge 计算 Fiat-Shamir 挑战，disclosed 必须按升序排列。
+func challenge(abar, bbar, d, t1, t2 *bls12381.G1, disclosed []int, messages []bls12381.Scalar, domain *bls12381.Scalar, presentationHeader []byte) bls12381.Scalar {
+	var input []byte
+	for _, p := range []*bls12381.G1{abar, bbar, d, t1, t2} {
+		input = append(input, p.BytesCompressed()...)
+	}
+	input = binary.BigEndian.AppendUint64(input, uint64(len(disclosed)))
+	for _, i := range disclosed {
+		input = binary.BigEndian.AppendUint64(input, uint64(i))
+		input = append(input, scalarBytes(&messages[i])...)
+	}
+	input = append(input, scalarBytes(domain)...)
+	input = binary.BigEndian.AppendUint64(input, uint64(len(presentationHeader)))
+	input = append(input, presentationHeader...)
+
+	return hashToScalar(input, "H2S_")
+}
@@ -0,0 +1,250 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package bls
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	bls12381 "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+	"github.com/geistwelt/quarkx/bccsp/secret"
+)
+
+// 这里实现的是 IETF BLS 签名草案中的 BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_ 套件：
+// 公钥位于 G1（48 字节），签名位于 G2（96 字节）。对同一消息的签名可以聚合成一个签名，
+// 为了防止恶意公钥攻击，参与聚合的公钥都必须先通过持有性证明（proof of possession）的验证。
+const (
+	PublicKeySize  = bls12381.G1SizeCompressed
+	SignatureSize  = bls12381.G2SizeCompressed
+	PrivateKeySize = bls12381.ScalarSize
+
+	signatureDST = "BLS_SIG_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_"
+	popDST       = "BLS_POP_BLS12381G2_XMD:SHA-256_SSWU_RO_POP_"
+)
+
+// PrivateKey 是 BLS 私钥，在 fmt 与日志中只显示为 [REDACTED]。
+type PrivateKey struct {
+	secret.Redacted
+
+	x bls12381.Scalar
+}
+
+// PublicKey 是 BLS 公钥 x·G1。
+type PublicKey struct {
+	p bls12381.G1
+}
+
+// Signature 是 BLS 签名 x·H(m)，H 将消息映射到 G2。
+type Signature struct {
+	s bls12381.G2
+}
+
+// GenerateKey 使用 entropy.Reader 生成一个随机的 BLS 私钥。
+func GenerateKey() (*PrivateKey, error) {
+	return GenerateKeyWithRand(entropy.Reader)
+}
+
+// GenerateKeyWithRand 使用随机源 rand 生成一个随机的 BLS 私钥。
+func GenerateKeyWithRand(rand io.Reader) (*PrivateKey, error) {
+	k := &PrivateKey{}
+	for k.x.IsZero() == 1 {
+		if err := k.x.Random(rand); err != nil {
+			return nil, fmt.Errorf("failed to generate BLS key [%v]", err)
+		}
+	}
+	return k, nil
+}
+
+// Destroy 将私钥清零，之后私钥不能再使用。
+func (k *PrivateKey) Destroy() {
+	k.x.SetUint64(0)
+}
+
+// PublicKey 返回私钥对应的公钥。
+func (k *PrivateKey) PublicKey() *PublicKey {
+	pub := &PublicKey{}
+	pub.p.ScalarMult(&k.x, bls12381.G1Generator())
+	return pub
+}
+
+// Bytes 返回私钥的 32 字节大端编码。
+func (k *PrivateKey) Bytes() []byte {
+	raw, _ := k.x.MarshalBinary()
+	return raw
+}
+
+// ParsePrivateKey 解析 Bytes 返回的编码。
+func ParsePrivateKey(raw []byte) (*PrivateKey, error) {
+	if len(raw) != PrivateKeySize {
+		return nil, fmt.Errorf("invalid BLS private key length %d, must be %d", len(raw), PrivateKeySize)
+	}
+	k := &PrivateKey{}
+	if err := k.x.UnmarshalBinary(raw); err != nil {
+		return nil, fmt.Errorf("invalid BLS private key [%v]", err)
+	}
+	if k.x.IsZero() == 1 {
+		return nil, errors.New("invalid BLS private key, it must be different from zero")
+	}
+	return k, nil
+}
+
+// Sign 对 msg 进行签名。
+func (k *PrivateKey) Sign(msg []byte) *Signature {
+	return k.sign(msg, signatureDST)
+}
+
+func (k *PrivateKey) sign(msg []byte, dst string) *Signature {
+	var h bls12381.G2
+	h.Hash(msg, []byte(dst))
+	sig := &Signature{}
+	sig.s.ScalarMult(&k.x, &h)
+	return sig
+}
+
+// ProvePossession 生成私钥的持有性证明，即对公钥编码的签名（使用独立的域分隔标签）。
+func (k *PrivateKey) ProvePossession() *Signature {
+	return k.sign(k.PublicKey().Bytes(), popDST)
+}
+
+// Bytes 返回公钥的 48 字节压缩编码。
+func (k *PublicKey) Bytes() []byte {
+	return k.p.BytesCompressed()
+}
+
+// Equal 判断两个公钥是否相同。
+func (k *PublicKey) Equal(other *PublicKey) bool {
+	return other != nil && k.p.IsEqual(&other.p)
+}
+
+// ParsePublicKey 解析压缩编码的公钥，公钥必须位于 G1 的素数阶子群中，并且不能是单位元。
+func ParsePublicKey(raw []byte) (*PublicKey, error) {
+	if len(raw) != PublicKeySize {
+		return nil, fmt.Errorf("invalid BLS public key length %d, must be %d", len(raw), PublicKeySize)
+	}
+	k := &PublicKey{}
+	if err := k.p.SetBytes(raw); err != nil {
+		return nil, fmt.Errorf("invalid BLS public key [%v]", err)
+	}
+	if k.p.IsIdentity() {
+		return nil, errors.New("invalid BLS public key, it is the identity")
+	}
+	return k, nil
+}
+
+// Verify 验证 sig 是否为 msg 的有效签名。
+func (k *PublicKey) Verify(msg []byte, sig *Signature) bool {
+	return k.verify(msg, sig, signatureDST)
+}
+
+// VerifyPossession 验证公钥的持有性证明。
+func (k *PublicKey) VerifyPossession(proof *Signature) bool {
+	return k.verify(k.Bytes(), proof, popDST)
+}
+
+func (k *PublicKey) verify(msg []byte, sig *Signature, dst string) bool {
+	if k == nil || sig == nil || k.p.IsIdentity() {
+		return false
+	}
+
+	// e(pk, H(m)) == e(G1, sig)
+	var h bls12381.G2
+	h.Hash(msg, []byte(dst))
+	return bls12381.ProdPairFrac(
+		[]*bls12381.G1{&k.p, bls12381.G1Generator()},
+		[]*bls12381.G2{&h, &sig.s},
+		[]int{1, -1},
+	).IsIdentity()
+}
+
+// Bytes 返回签名的 96 字节压缩编码。
+func (s *Signature) Bytes() []byte {
+	return s.s.BytesCompressed()
+}
+
+// Equal 判断两个签名是否相同。
+func (s *Signature) Equal(other *Signature) bool {
+	return other != nil && s.s.IsEqual(&other.s)
+}
+
+// ParseSignature 解析压缩编码的签名，签名必须位于 G2 的素数阶子群中。
+func ParseSignature(raw []byte) (*Signature, error) {
+	if len(raw) != SignatureSize {
+		return nil, fmt.Errorf("invalid BLS signature length %d, must be %d", len(raw), SignatureSize)
+	}
+	s := &Signature{}
+	if err := s.s.SetBytes(raw); err != nil {
+		return nil, fmt.Errorf("invalid BLS signature [%v]", err)
+	}
+	return s, nil
+}
+
+// AggregateSignatures 将多个签名聚合成一个签名。
+func AggregateSignatures(sigs ...*Signature) (*Signature, error) {
+	if len(sigs) == 0 {
+		return nil, errors.New("at least one signature is required")
+	}
+
+	agg := &Signature{}
+	agg.s.SetIdentity()
+	for _, sig := range sigs {
+		agg.s.Add(&agg.s, &sig.s)
+	}
+	return agg, nil
+}
+
+// AggregatePublicKeys 将多个公钥聚合成一个公钥，调用者必须确保每个公钥的持有性证明都已
+// 通过验证。
+func AggregatePublicKeys(pubs ...*PublicKey) (*PublicKey, error) {
+	if len(pubs) == 0 {
+		return nil, errors.New("at least one public key is required")
+	}
+
+	agg := &PublicKey{}
+	agg.p.SetIdentity()
+	for _, pub := range pubs {
+		agg.p.Add(&agg.p, &pub.p)
+	}
+	return agg, nil
+}
+
+// FastAggregateVerify 验证 sig 是否为所有公钥对同一消息 msg 的聚合签名，公钥的持有性证明
+// 必须已经通过验证。
+func FastAggregateVerify(pubs []*PublicKey, msg []byte, sig *Signature) bool {
+	agg, err := AggregatePublicKeys(pubs...)
+	if err != nil {
+		return false
+	}
+	return agg.Verify(msg, sig)
+}
+
+// AggregateVerify 验证 sig 是否为 pubs[i] 对 msgs[i] 的签名的聚合签名。
+func AggregateVerify(pubs []*PublicKey, msgs [][]byte, sig *Signature) bool {
+	if len(pubs) == 0 || len(pubs) != len(msgs) || sig == nil {
+		return false
+	}
+
+	// Π e(pkᵢ, H(mᵢ)) == e(G1, sig)
+	g1s := make([]*bls12381.G1, 0, len(pubs)+1)
+	g2s := make([]*bls12381.G2, 0, len(pubs)+1)
+	signs := make([]int, 0, len(pubs)+1)
+	for i, pub := range pubs {
+		if pub.p.IsIdentity() {
+			return false
+		}
+		h := &bls12381.G2{}
+		h.Hash(msgs[i], []byte(signatureDST))
+		g1s = append(g1s, &pub.p)
+		g2s = append(g2s, h)
+		signs = append(signs, 1)
+	}
+	g1s = append(g1s, bls12381.G1Generator())
+	g2s = append(g2s, &sig.s)
+	signs = append(signs, -1)
+
+	return bls12381.ProdPairFrac(g1s, g2s, signs).IsIdentity()
+}
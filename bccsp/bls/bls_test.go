@@ -0,0 +1,273 @@
+package bls
+
+import (
+	"context"
+	"encoding/asn1"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	bls12381 "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/geistwelt/quarkx/bccsp/secret"
+	"github.com/stretchr/testify/require"
+)
+
+func newKey(t *testing.T) *PrivateKey {
+	sk, err := GenerateKey()
+	require.NoError(t, err)
+	return sk
+}
+
+func TestSignAndVerify(t *testing.T) {
+	sk := newKey(t)
+	pub := sk.PublicKey()
+	msg := []byte("block 42")
+
+	sig := sk.Sign(msg)
+	require.True(t, pub.Verify(msg, sig))
+	require.False(t, pub.Verify([]byte("block 43"), sig))
+	require.False(t, newKey(t).PublicKey().Verify(msg, sig))
+
+	// 持有性证明与普通签名使用不同的域分隔标签。
+	require.False(t, pub.Verify(pub.Bytes(), sk.ProvePossession()))
+	require.True(t, pub.VerifyPossession(sk.ProvePossession()))
+	require.False(t, pub.VerifyPossession(sk.Sign(pub.Bytes())))
+
+	parsedKey, err := ParsePrivateKey(sk.Bytes())
+	require.NoError(t, err)
+	require.True(t, parsedKey.PublicKey().Equal(pub))
+	parsedPub, err := ParsePublicKey(pub.Bytes())
+	require.NoError(t, err)
+	require.True(t, parsedPub.Equal(pub))
+	parsedSig, err := ParseSignature(sig.Bytes())
+	require.NoError(t, err)
+	require.True(t, parsedSig.Equal(sig))
+
+	var identity bls12381.G1
+	identity.SetIdentity()
+	_, err = ParsePublicKey(identity.BytesCompressed())
+	require.EqualError(t, err, "invalid BLS public key, it is the identity")
+	_, err = ParsePublicKey(pub.Bytes()[1:])
+	require.EqualError(t, err, "invalid BLS public key length 47, must be 48")
+	_, err = ParsePrivateKey(make([]byte, PrivateKeySize))
+	require.EqualError(t, err, "invalid BLS private key, it must be different from zero")
+
+	require.Equal(t, secret.Placeholder, fmt.Sprintf("%#v", sk))
+	sk.Destroy()
+	_, err = ParsePrivateKey(sk.Bytes())
+	require.EqualError(t, err, "invalid BLS private key, it must be different from zero")
+}
+
+func TestAggregate(t *testing.T) {
+	msg := []byte("block 42")
+	var pubs []*PublicKey
+	var sigs []*Signature
+	var msgs [][]byte
+	var distinctSigs []*Signature
+	for i := 0; i < 4; i++ {
+		sk := newKey(t)
+		require.True(t, sk.PublicKey().VerifyPossession(sk.ProvePossession()))
+		pubs = append(pubs, sk.PublicKey())
+		sigs = append(sigs, sk.Sign(msg))
+		m := []byte{byte(i)}
+		msgs = append(msgs, m)
+		distinctSigs = append(distinctSigs, sk.Sign(m))
+	}
+
+	agg, err := AggregateSignatures(sigs...)
+	require.NoError(t, err)
+	require.True(t, FastAggregateVerify(pubs, msg, agg))
+	require.False(t, FastAggregateVerify(pubs[1:], msg, agg))
+	require.False(t, FastAggregateVerify(pubs, []byte("block 43"), agg))
+
+	agg, err = AggregateSignatures(distinctSigs...)
+	require.NoError(t, err)
+	require.True(t, AggregateVerify(pubs, msgs, agg))
+	msgs[0], msgs[1] = msgs[1], msgs[0]
+	require.False(t, AggregateVerify(pubs, msgs, agg))
+
+	// 恶意公钥 x·G - pk₀ 可以伪造聚合签名，但是无法给出持有性证明。
+	x := newKey(t)
+	rogue := &PublicKey{}
+	neg := pubs[0].p
+	neg.Neg()
+	rogue.p.Add(&x.PublicKey().p, &neg)
+	require.True(t, FastAggregateVerify([]*PublicKey{pubs[0], rogue}, msg, x.Sign(msg)))
+	require.False(t, rogue.VerifyPossession(x.ProvePossession()))
+
+	_, err = AggregateSignatures()
+	require.EqualError(t, err, "at least one signature is required")
+}
+
+func TestDealShares(t *testing.T) {
+	tpk, shares, err := DealShares(5, 3)
+	require.NoError(t, err)
+	msg := []byte("block 42")
+
+	var partials []*PartialSignature
+	for _, share := range shares {
+		partials = append(partials, share.Sign(msg))
+	}
+	for _, ps := range partials {
+		require.NoError(t, tpk.VerifyPartial(msg, ps))
+	}
+
+	sig1, err := tpk.Combine(msg, partials[:3])
+	require.NoError(t, err)
+	require.True(t, tpk.GroupKey.Verify(msg, sig1))
+
+	// 无效与重复的部分签名被忽略，不同的分片集合得到相同的签名。
+	forged := &PartialSignature{Index: 1, Signature: shares[1].Key.Sign(msg)}
+	sig2, err := tpk.Combine(msg, []*PartialSignature{forged, partials[4], partials[4], partials[2], partials[1]})
+	require.NoError(t, err)
+	require.True(t, sig1.Equal(sig2))
+
+	_, err = tpk.Combine(msg, []*PartialSignature{forged, partials[4], partials[2]})
+	require.EqualError(t, err, "not enough valid partial signatures, got 2, need 3 [invalid partial signature from share 1]")
+
+	raw := partials[0].Bytes()
+	ps, err := ParsePartialSignature(raw)
+	require.NoError(t, err)
+	require.Equal(t, 1, ps.Index)
+	require.True(t, ps.Signature.Equal(partials[0].Signature))
+
+	raw, err = tpk.Bytes()
+	require.NoError(t, err)
+	parsed, err := ParseThresholdPublicKey(raw)
+	require.NoError(t, err)
+	require.Equal(t, 3, parsed.Threshold)
+	require.True(t, parsed.GroupKey.Equal(tpk.GroupKey))
+	require.Len(t, parsed.Shares, 5)
+	require.True(t, parsed.Shares[5].Equal(tpk.Shares[5]))
+
+	_, _, err = DealShares(3, 4)
+	require.EqualError(t, err, "invalid threshold 4, must be between 1 and 3")
+}
+
+// faultyTransport 篡改发给 victim 的分片，如果 lie 为真，还篡改对投诉的申辩。
+type faultyTransport struct {
+	Transport
+	victim int
+	lie    bool
+}
+
+func corruptShare(raw []byte) []byte {
+	var share, one bls12381.Scalar
+	share.SetBytes(raw)
+	one.SetOne()
+	share.Add(&share, &one)
+	raw, _ = share.MarshalBinary()
+	return raw
+}
+
+func (ft *faultyTransport) rewrite(payload []byte, to int) []byte {
+	msg := &dkgMessage{}
+	if _, err := asn1.Unmarshal(payload, msg); err != nil {
+		return payload
+	}
+	switch {
+	case msg.Kind == dkgShare && to == ft.victim:
+		msg.Share = corruptShare(msg.Share)
+	case msg.Kind == dkgJustifications && ft.lie:
+		for i := range msg.Justifications {
+			msg.Justifications[i].Share = corruptShare(msg.Justifications[i].Share)
+		}
+	default:
+		return payload
+	}
+	raw, _ := asn1.Marshal(*msg)
+	return raw
+}
+
+func (ft *faultyTransport) Send(ctx context.Context, to int, payload []byte) error {
+	return ft.Transport.Send(ctx, to, ft.rewrite(payload, to))
+}
+
+func (ft *faultyTransport) Broadcast(ctx context.Context, payload []byte) error {
+	return ft.Transport.Broadcast(ctx, ft.rewrite(payload, 0))
+}
+
+func runDKG(t *testing.T, transports []Transport, threshold int) []*DKGResult {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	results := make([]*DKGResult, len(transports))
+	errs := make([]error, len(transports))
+	var wg sync.WaitGroup
+	for i := range transports {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], errs[i] = RunDKG(ctx, DKGConfig{Index: i + 1, Participants: len(transports), Threshold: threshold, Transport: transports[i]})
+		}(i)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		require.NoError(t, err)
+	}
+	return results
+}
+
+func requireConsistent(t *testing.T, results []*DKGResult, qualified []int) {
+	msg := []byte("block 42")
+	tpk := results[0].PublicKey
+	var partials []*PartialSignature
+	for _, result := range results {
+		require.Equal(t, qualified, result.Qualified)
+		require.True(t, result.PublicKey.GroupKey.Equal(tpk.GroupKey))
+		for index, pub := range tpk.Shares {
+			require.True(t, result.PublicKey.Shares[index].Equal(pub))
+		}
+		require.True(t, tpk.Shares[result.Share.Index].Equal(result.Share.Key.PublicKey()))
+		partials = append(partials, result.Share.Sign(msg))
+	}
+
+	sig, err := tpk.Combine(msg, partials)
+	require.NoError(t, err)
+	require.True(t, tpk.GroupKey.Verify(msg, sig))
+
+	n := len(partials)
+	reversed := make([]*PartialSignature, n)
+	for i := range partials {
+		reversed[n-1-i] = partials[i]
+	}
+	other, err := tpk.Combine(msg, reversed)
+	require.NoError(t, err)
+	require.True(t, sig.Equal(other))
+}
+
+func TestDKG(t *testing.T) {
+	results := runDKG(t, NewMemoryTransports(4), 3)
+	requireConsistent(t, results, []int{1, 2, 3, 4})
+}
+
+func TestDKGComplaintAnswered(t *testing.T) {
+	transports := NewMemoryTransports(4)
+	transports[0] = &faultyTransport{Transport: transports[0], victim: 2}
+
+	// 分发者 1 发给 2 的分片无效，但它在申辩中公开了正确的分片，因此仍然合格。
+	results := runDKG(t, transports, 3)
+	requireConsistent(t, results, []int{1, 2, 3, 4})
+}
+
+func TestDKGDealerDisqualified(t *testing.T) {
+	transports := NewMemoryTransports(4)
+	transports[0] = &faultyTransport{Transport: transports[0], victim: 2, lie: true}
+
+	// 分发者 1 无法给出有效的分片，诚实的参与者一致地取消它的资格。
+	results := runDKG(t, transports, 3)
+	requireConsistent(t, results[1:], []int{2, 3, 4})
+}
+
+func TestDKGConfig(t *testing.T) {
+	transports := NewMemoryTransports(2)
+	_, err := RunDKG(context.Background(), DKGConfig{Index: 3, Participants: 2, Threshold: 2, Transport: transports[0]})
+	require.EqualError(t, err, "invalid participant index 3, must be between 1 and 2")
+
+	// 缺少其它参与者时，协议在 ctx 结束时失败。
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = RunDKG(ctx, DKGConfig{Index: 1, Participants: 2, Threshold: 2, Transport: transports[0]})
+	require.ErrorContains(t, err, "failed to receive DKG messages, got 0 of 1 in round 1")
+}
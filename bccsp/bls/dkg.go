@@ -0,0 +1,357 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package bls
+
+import (
+	"context"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+
+	bls12381 "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+	"github.com/geistwelt/quarkx/common/qlogging"
+)
+
+var dkgLogger = qlogging.MustGetLogger("bccsp.bls")
+
+// Transport 是 DKG 参与者之间的通信通道，参与者的编号从 1 开始。实现必须认证消息的发送者，
+// 并保证 Broadcast 的一致性，即所有诚实的参与者收到的是同一条消息；Send 发送的消息包含
+// 秘密分片，实现还必须保证其机密性，例如使用 common/comm 建立的双向 TLS 连接。
+type Transport interface {
+	// Broadcast 将消息发送给所有其它的参与者。
+	Broadcast(ctx context.Context, payload []byte) error
+	// Send 将消息只发送给参与者 to。
+	Send(ctx context.Context, to int, payload []byte) error
+	// Receive 返回下一条发给本参与者的消息及其发送者的编号。
+	Receive(ctx context.Context) (from int, payload []byte, err error)
+}
+
+// DKGConfig 是分布式密钥生成的参数。
+type DKGConfig struct {
+	// Index 是本参与者的编号，取值范围是 [1, Participants]。
+	Index        int
+	Participants int
+	Threshold    int
+	Transport    Transport
+
+	// Rand 是生成本参与者秘密与多项式的随机源，为 nil 时使用 entropy.Reader。
+	Rand io.Reader
+}
+
+// DKGResult 是分布式密钥生成的结果，所有诚实的参与者得到相同的 PublicKey 与 Qualified。
+type DKGResult struct {
+	Share     *Share
+	PublicKey *ThresholdPublicKey
+	// Qualified 是没有被取消资格的分发者，群私钥是它们的秘密之和。
+	Qualified []int
+}
+
+// DKG 消息的类型。
+const (
+	dkgCommitments = iota + 1
+	dkgShare
+	dkgComplaints
+	dkgJustifications
+)
+
+type dkgMessage struct {
+	Kind           int
+	Commitments    [][]byte
+	Share          []byte
+	Complaints     []int
+	Justifications []dkgJustification
+}
+
+// dkgJustification 是被投诉的分发者公开的发给投诉者的分片。
+type dkgJustification struct {
+	Complainer int
+	Share      []byte
+}
+
+type dkg struct {
+	cfg     DKGConfig
+	pending map[int]map[int]*dkgMessage
+}
+
+// RunDKG 与其它参与者一起执行 Joint-Feldman 分布式密钥生成，没有任何一方知道群私钥，任意
+// Threshold 个分片即可合成群公钥下的签名。协议共有三轮：
+//  1. 每个参与者作为分发者选择一个 Threshold-1 次的随机多项式，广播系数的承诺，并将多项式
+//     在每个参与者编号处的取值秘密地发送给对方；
+//  2. 每个参与者用承诺检查收到的分片，广播对分片无效的分发者的投诉；
+//  3. 被投诉的分发者广播发给投诉者的分片，无法给出有效分片的分发者被取消资格。
+//
+// 每一轮都需要收到所有其它参与者的消息，协议在 ctx 结束时失败。
+func RunDKG(ctx context.Context, cfg DKGConfig) (*DKGResult, error) {
+	if err := checkThreshold(cfg.Participants, cfg.Threshold); err != nil {
+		return nil, err
+	}
+	if cfg.Index < 1 || cfg.Index > cfg.Participants {
+		return nil, fmt.Errorf("invalid participant index %d, must be between 1 and %d", cfg.Index, cfg.Participants)
+	}
+	if cfg.Transport == nil {
+		return nil, errors.New("invalid transport, it must be different from nil")
+	}
+
+	d := &dkg{cfg: cfg, pending: make(map[int]map[int]*dkgMessage)}
+	return d.run(ctx)
+}
+
+func (d *dkg) run(ctx context.Context) (*DKGResult, error) {
+	n, t, me := d.cfg.Participants, d.cfg.Threshold, d.cfg.Index
+	rand := entropy.Or(d.cfg.Rand)
+
+	// 第一轮：分发。
+	var secret bls12381.Scalar
+	if err := secret.Random(rand); err != nil {
+		return nil, fmt.Errorf("failed to generate secret [%v]", err)
+	}
+	p, err := randomPolynomial(rand, &secret, t-1)
+	if err != nil {
+		return nil, err
+	}
+	myCommitments := p.commit()
+	msg := &dkgMessage{Kind: dkgCommitments}
+	for i := range myCommitments {
+		msg.Commitments = append(msg.Commitments, myCommitments[i].BytesCompressed())
+	}
+	if err := d.broadcast(ctx, msg); err != nil {
+		return nil, err
+	}
+	for j := 1; j <= n; j++ {
+		if j == me {
+			continue
+		}
+		share := p.eval(j)
+		raw, _ := share.MarshalBinary()
+		if err := d.send(ctx, j, &dkgMessage{Kind: dkgShare, Share: raw}); err != nil {
+			return nil, err
+		}
+	}
+
+	commitMsgs, err := d.collect(ctx, dkgCommitments)
+	if err != nil {
+		return nil, err
+	}
+	shareMsgs, err := d.collect(ctx, dkgShare)
+	if err != nil {
+		return nil, err
+	}
+
+	commitments := map[int][]bls12381.G1{me: myCommitments}
+	shares := map[int]bls12381.Scalar{me: p.eval(me)}
+	disqualified := map[int]bool{}
+	var complaints []int
+	for j := 1; j <= n; j++ {
+		if j == me {
+			continue
+		}
+		c, err := parseCommitments(commitMsgs[j].Commitments, t)
+		if err != nil {
+			// 承诺是广播的，所有诚实的参与者都会得出相同的结论。
+			dkgLogger.Warnf("Dealer %d sent invalid commitments, disqualifying it: %s", j, err)
+			disqualified[j] = true
+			continue
+		}
+		commitments[j] = c
+
+		share, err := parseShare(shareMsgs[j].Share)
+		if err == nil && verifyShare(c, me, &share) {
+			shares[j] = share
+			continue
+		}
+		dkgLogger.Warnf("Dealer %d sent an invalid share, filing a complaint", j)
+		complaints = append(complaints, j)
+	}
+
+	// 第二轮：投诉。
+	if err := d.broadcast(ctx, &dkgMessage{Kind: dkgComplaints, Complaints: complaints}); err != nil {
+		return nil, err
+	}
+	complaintMsgs, err := d.collect(ctx, dkgComplaints)
+	if err != nil {
+		return nil, err
+	}
+	complaintMsgs[me] = &dkgMessage{Complaints: complaints}
+
+	// 第三轮：申辩。
+	justification := &dkgMessage{Kind: dkgJustifications}
+	for j := 1; j <= n; j++ {
+		if j != me && slices.Contains(complaintMsgs[j].Complaints, me) {
+			share := p.eval(j)
+			raw, _ := share.MarshalBinary()
+			justification.Justifications = append(justification.Justifications, dkgJustification{Complainer: j, Share: raw})
+		}
+	}
+	if err := d.broadcast(ctx, justification); err != nil {
+		return nil, err
+	}
+	justificationMsgs, err := d.collect(ctx, dkgJustifications)
+	if err != nil {
+		return nil, err
+	}
+	justificationMsgs[me] = justification
+
+	for complainer := 1; complainer <= n; complainer++ {
+		for _, dealer := range complaintMsgs[complainer].Complaints {
+			if dealer < 1 || dealer > n || dealer == complainer || disqualified[dealer] {
+				continue
+			}
+			share, ok := justifiedShare(justificationMsgs[dealer], complainer, commitments[dealer])
+			if !ok {
+				dkgLogger.Warnf("Dealer %d failed to answer the complaint of participant %d, disqualifying it", dealer, complainer)
+				disqualified[dealer] = true
+				continue
+			}
+			if complainer == me {
+				shares[dealer] = share
+			}
+		}
+	}
+
+	var qualified []int
+	for j := 1; j <= n; j++ {
+		if !disqualified[j] {
+			qualified = append(qualified, j)
+		}
+	}
+	if disqualified[me] {
+		return nil, errors.New("this participant has been disqualified")
+	}
+	if len(qualified) < t {
+		return nil, fmt.Errorf("only %d dealers are qualified, need at least %d", len(qualified), t)
+	}
+
+	// 分片与公钥都是合格分发者的贡献之和。
+	result := &DKGResult{
+		Share:     &Share{Index: me, Key: &PrivateKey{}},
+		PublicKey: &ThresholdPublicKey{Threshold: t, GroupKey: &PublicKey{}, Shares: make(map[int]*PublicKey, n)},
+		Qualified: qualified,
+	}
+	result.PublicKey.GroupKey.p.SetIdentity()
+	for _, j := range qualified {
+		share := shares[j]
+		result.Share.Key.x.Add(&result.Share.Key.x, &share)
+		result.PublicKey.GroupKey.p.Add(&result.PublicKey.GroupKey.p, &commitments[j][0])
+	}
+	for m := 1; m <= n; m++ {
+		pub := &PublicKey{}
+		pub.p.SetIdentity()
+		for _, j := range qualified {
+			term := evalCommitments(commitments[j], m)
+			pub.p.Add(&pub.p, &term)
+		}
+		result.PublicKey.Shares[m] = pub
+	}
+	if result.PublicKey.GroupKey.p.IsIdentity() {
+		return nil, errors.New("the group public key is the identity")
+	}
+
+	return result, nil
+}
+
+func (d *dkg) broadcast(ctx context.Context, msg *dkgMessage) error {
+	raw, err := asn1.Marshal(*msg)
+	if err != nil {
+		return err
+	}
+	if err := d.cfg.Transport.Broadcast(ctx, raw); err != nil {
+		return fmt.Errorf("failed to broadcast DKG message [%v]", err)
+	}
+	return nil
+}
+
+func (d *dkg) send(ctx context.Context, to int, msg *dkgMessage) error {
+	raw, err := asn1.Marshal(*msg)
+	if err != nil {
+		return err
+	}
+	if err := d.cfg.Transport.Send(ctx, to, raw); err != nil {
+		return fmt.Errorf("failed to send DKG message to participant %d [%v]", to, err)
+	}
+	return nil
+}
+
+// collect 接收消息，直到收到所有其它参与者类型为 kind 的消息。其它类型的消息被暂存起来，
+// 同一个参与者重复发送的同类消息只保留第一条。
+func (d *dkg) collect(ctx context.Context, kind int) (map[int]*dkgMessage, error) {
+	for len(d.pending[kind]) < d.cfg.Participants-1 {
+		from, raw, err := d.cfg.Transport.Receive(ctx)
+		if err != nil {
+			return nil, fmt.Errorf("failed to receive DKG messages, got %d of %d in round %d [%v]", len(d.pending[kind]), d.cfg.Participants-1, kind, err)
+		}
+		if from < 1 || from > d.cfg.Participants || from == d.cfg.Index {
+			dkgLogger.Warnf("Dropping DKG message from unknown participant %d", from)
+			continue
+		}
+
+		msg := &dkgMessage{}
+		if rest, err := asn1.Unmarshal(raw, msg); err != nil || len(rest) != 0 {
+			dkgLogger.Warnf("Dropping malformed DKG message from participant %d", from)
+			continue
+		}
+		if msg.Kind < dkgCommitments || msg.Kind > dkgJustifications {
+			dkgLogger.Warnf("Dropping DKG message of unknown kind %d from participant %d", msg.Kind, from)
+			continue
+		}
+		if d.pending[msg.Kind] == nil {
+			d.pending[msg.Kind] = make(map[int]*dkgMessage)
+		}
+		if _, ok := d.pending[msg.Kind][from]; !ok {
+			d.pending[msg.Kind][from] = msg
+		}
+	}
+
+	return d.pending[kind], nil
+}
+
+func parseCommitments(raw [][]byte, t int) ([]bls12381.G1, error) {
+	if len(raw) != t {
+		return nil, fmt.Errorf("expected %d commitments, got %d", t, len(raw))
+	}
+	commitments := make([]bls12381.G1, t)
+	for i := range raw {
+		if len(raw[i]) != PublicKeySize {
+			return nil, fmt.Errorf("invalid commitment length %d", len(raw[i]))
+		}
+		if err := commitments[i].SetBytes(raw[i]); err != nil {
+			return nil, fmt.Errorf("invalid commitment [%v]", err)
+		}
+	}
+	return commitments, nil
+}
+
+func parseShare(raw []byte) (bls12381.Scalar, error) {
+	var share bls12381.Scalar
+	if len(raw) != PrivateKeySize {
+		return share, fmt.Errorf("invalid share length %d", len(raw))
+	}
+	return share, share.UnmarshalBinary(raw)
+}
+
+// verifyShare 检查 share·G1 是否等于承诺在 index 处的取值。
+func verifyShare(commitments []bls12381.G1, index int, share *bls12381.Scalar) bool {
+	expected := evalCommitments(commitments, index)
+	var actual bls12381.G1
+	actual.ScalarMult(share, bls12381.G1Generator())
+	return actual.IsEqual(&expected)
+}
+
+func justifiedShare(msg *dkgMessage, complainer int, commitments []bls12381.G1) (bls12381.Scalar, bool) {
+	for _, j := range msg.Justifications {
+		if j.Complainer != complainer {
+			continue
+		}
+		share, err := parseShare(j.Share)
+		if err == nil && verifyShare(commitments, complainer, &share) {
+			return share, true
+		}
+		return share, false
+	}
+	return bls12381.Scalar{}, false
+}
@@ -0,0 +1,288 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package bls
+
+import (
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	bls12381 "github.com/cloudflare/circl/ecc/bls12381"
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+	"github.com/geistwelt/quarkx/bccsp/secret"
+)
+
+// Share 是门限私钥的一个分片，编号从 1 开始，它是群私钥多项式在 Index 处的取值。分片
+// 在 fmt 与日志中只显示为 [REDACTED]。
+type Share struct {
+	secret.Redacted
+
+	Index int
+	Key   *PrivateKey
+}
+
+// Destroy 将分片的私钥清零。
+func (s *Share) Destroy() {
+	if s.Key != nil {
+		s.Key.Destroy()
+	}
+}
+
+// PartialSignature 是某个分片对消息的签名，任意 Threshold 个有效的部分签名可以合成群公钥
+// 下的签名。
+type PartialSignature struct {
+	Index     int
+	Signature *Signature
+}
+
+// ThresholdPublicKey 是门限签名的公开信息：群公钥，以及用来验证部分签名的每个分片的公钥。
+type ThresholdPublicKey struct {
+	Threshold int
+	GroupKey  *PublicKey
+	Shares    map[int]*PublicKey
+}
+
+type thresholdPublicKeyASN1 struct {
+	Threshold int
+	GroupKey  []byte
+	Indexes   []int
+	Shares    [][]byte
+}
+
+// polynomial 是系数位于标量域上的多项式，polynomial[0] 是常数项。
+type polynomial []bls12381.Scalar
+
+func randomPolynomial(rand io.Reader, secret *bls12381.Scalar, degree int) (polynomial, error) {
+	p := make(polynomial, degree+1)
+	p[0].Set(secret)
+	for i := 1; i < len(p); i++ {
+		if err := p[i].Random(rand); err != nil {
+			return nil, fmt.Errorf("failed to generate polynomial [%v]", err)
+		}
+	}
+	return p, nil
+}
+
+func (p polynomial) eval(index int) bls12381.Scalar {
+	var x, y bls12381.Scalar
+	x.SetUint64(uint64(index))
+	for i := len(p) - 1; i >= 0; i-- {
+		y.Mul(&y, &x)
+		y.Add(&y, &p[i])
+	}
+	return y
+}
+
+// commit 返回多项式系数的 Feldman 承诺 aᵢ·G1。
+func (p polynomial) commit() []bls12381.G1 {
+	commitments := make([]bls12381.G1, len(p))
+	for i := range p {
+		commitments[i].ScalarMult(&p[i], bls12381.G1Generator())
+	}
+	return commitments
+}
+
+// evalCommitments 返回 Σ index^k·C_k，即多项式在 index 处的取值与 G1 的乘积。
+func evalCommitments(commitments []bls12381.G1, index int) bls12381.G1 {
+	var x bls12381.Scalar
+	x.SetUint64(uint64(index))
+	var result bls12381.G1
+	result.SetIdentity()
+	for k := len(commitments) - 1; k >= 0; k-- {
+		result.ScalarMult(&x, &result)
+		result.Add(&result, &commitments[k])
+	}
+	return result
+}
+
+// lagrangeAtZero 返回 index 在集合 indexes 上的拉格朗日系数 Π j/(j-index)。
+func lagrangeAtZero(index int, indexes []int) bls12381.Scalar {
+	var num, den, xi, xj, diff bls12381.Scalar
+	num.SetOne()
+	den.SetOne()
+	xi.SetUint64(uint64(index))
+	for _, j := range indexes {
+		if j == index {
+			continue
+		}
+		xj.SetUint64(uint64(j))
+		num.Mul(&num, &xj)
+		diff.Sub(&xj, &xi)
+		den.Mul(&den, &diff)
+	}
+	den.Inv(&den)
+	num.Mul(&num, &den)
+	return num
+}
+
+func checkThreshold(n, t int) error {
+	if t < 1 || t > n {
+		return fmt.Errorf("invalid threshold %d, must be between 1 and %d", t, n)
+	}
+	if n > 0xffff {
+		return fmt.Errorf("invalid number of participants %d, must not be larger than %d", n, 0xffff)
+	}
+	return nil
+}
+
+// DealShares 由可信的分发者生成群私钥，并将其拆分成 n 个分片，任意 t 个分片即可合成签名。
+// 分发者知道群私钥，没有可信分发者时应该使用 RunDKG。随机数取自 entropy.Reader。
+func DealShares(n, t int) (*ThresholdPublicKey, []*Share, error) {
+	return DealSharesWithRand(entropy.Reader, n, t)
+}
+
+// DealSharesWithRand 与 DealShares 相同，群私钥与多项式的系数取自 rand。
+func DealSharesWithRand(rand io.Reader, n, t int) (*ThresholdPublicKey, []*Share, error) {
+	if err := checkThreshold(n, t); err != nil {
+		return nil, nil, err
+	}
+
+	secret, err := GenerateKeyWithRand(rand)
+	if err != nil {
+		return nil, nil, err
+	}
+	p, err := randomPolynomial(rand, &secret.x, t-1)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	tpk := &ThresholdPublicKey{Threshold: t, GroupKey: secret.PublicKey(), Shares: make(map[int]*PublicKey, n)}
+	shares := make([]*Share, n)
+	for i := range shares {
+		share := &Share{Index: i + 1, Key: &PrivateKey{x: p.eval(i + 1)}}
+		shares[i] = share
+		tpk.Shares[share.Index] = share.Key.PublicKey()
+	}
+
+	return tpk, shares, nil
+}
+
+// Sign 使用分片对 msg 生成部分签名。
+func (s *Share) Sign(msg []byte) *PartialSignature {
+	return &PartialSignature{Index: s.Index, Signature: s.Key.Sign(msg)}
+}
+
+// Bytes 将部分签名编码成 2 字节的大端编号与 96 字节的签名。
+func (ps *PartialSignature) Bytes() []byte {
+	return append(binary.BigEndian.AppendUint16(nil, uint16(ps.Index)), ps.Signature.Bytes()...)
+}
+
+// ParsePartialSignature 解析 Bytes 返回的编码。
+func ParsePartialSignature(raw []byte) (*PartialSignature, error) {
+	if len(raw) != 2+SignatureSize {
+		return nil, fmt.Errorf("invalid partial signature length %d, must be %d", len(raw), 2+SignatureSize)
+	}
+	sig, err := ParseSignature(raw[2:])
+	if err != nil {
+		return nil, err
+	}
+	return &PartialSignature{Index: int(binary.BigEndian.Uint16(raw)), Signature: sig}, nil
+}
+
+// VerifyPartial 使用分片的公钥验证部分签名。
+func (tpk *ThresholdPublicKey) VerifyPartial(msg []byte, ps *PartialSignature) error {
+	if ps == nil || ps.Signature == nil {
+		return errors.New("invalid partial signature, it must be different from nil")
+	}
+	pub, ok := tpk.Shares[ps.Index]
+	if !ok {
+		return fmt.Errorf("unknown share index %d", ps.Index)
+	}
+	if !pub.Verify(msg, ps.Signature) {
+		return fmt.Errorf("invalid partial signature from share %d", ps.Index)
+	}
+	return nil
+}
+
+// Combine 从部分签名中选出 Threshold 个有效的签名，通过拉格朗日插值合成群公钥下的签名。
+// 无效的以及重复的部分签名会被忽略，有效的部分签名不足时返回错误。
+func (tpk *ThresholdPublicKey) Combine(msg []byte, partials []*PartialSignature) (*Signature, error) {
+	valid := make(map[int]*Signature, tpk.Threshold)
+	var errs []error
+	for _, ps := range partials {
+		if len(valid) == tpk.Threshold {
+			break
+		}
+		if ps == nil || valid[ps.Index] != nil {
+			continue
+		}
+		if err := tpk.VerifyPartial(msg, ps); err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		valid[ps.Index] = ps.Signature
+	}
+	if len(valid) < tpk.Threshold {
+		return nil, fmt.Errorf("not enough valid partial signatures, got %d, need %d [%v]", len(valid), tpk.Threshold, errors.Join(errs...))
+	}
+
+	indexes := make([]int, 0, len(valid))
+	for index := range valid {
+		indexes = append(indexes, index)
+	}
+	sort.Ints(indexes)
+
+	sig := &Signature{}
+	sig.s.SetIdentity()
+	for _, index := range indexes {
+		lambda := lagrangeAtZero(index, indexes)
+		var term bls12381.G2
+		term.ScalarMult(&lambda, &valid[index].s)
+		sig.s.Add(&sig.s, &term)
+	}
+
+	return sig, nil
+}
+
+// Bytes 将门限公钥编码成 ASN.1 DER。
+func (tpk *ThresholdPublicKey) Bytes() ([]byte, error) {
+	enc := thresholdPublicKeyASN1{Threshold: tpk.Threshold, GroupKey: tpk.GroupKey.Bytes()}
+	for index := range tpk.Shares {
+		enc.Indexes = append(enc.Indexes, index)
+	}
+	sort.Ints(enc.Indexes)
+	for _, index := range enc.Indexes {
+		enc.Shares = append(enc.Shares, tpk.Shares[index].Bytes())
+	}
+	return asn1.Marshal(enc)
+}
+
+// ParseThresholdPublicKey 解析 Bytes 返回的编码。
+func ParseThresholdPublicKey(raw []byte) (*ThresholdPublicKey, error) {
+	var enc thresholdPublicKeyASN1
+	rest, err := asn1.Unmarshal(raw, &enc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse threshold public key [%v]", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after threshold public key")
+	}
+	if len(enc.Indexes) != len(enc.Shares) {
+		return nil, errors.New("invalid threshold public key, indexes and shares do not match")
+	}
+	if err := checkThreshold(len(enc.Shares), enc.Threshold); err != nil {
+		return nil, err
+	}
+
+	groupKey, err := ParsePublicKey(enc.GroupKey)
+	if err != nil {
+		return nil, err
+	}
+	tpk := &ThresholdPublicKey{Threshold: enc.Threshold, GroupKey: groupKey, Shares: make(map[int]*PublicKey, len(enc.Shares))}
+	for i, index := range enc.Indexes {
+		if index < 1 || tpk.Shares[index] != nil {
+			return nil, fmt.Errorf("invalid threshold public key, share index %d is invalid or duplicated", index)
+		}
+		if tpk.Shares[index], err = ParsePublicKey(enc.Shares[i]); err != nil {
+			return nil, err
+		}
+	}
+
+	return tpk, nil
+}
@@ -0,0 +1,72 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package bls
+
+import (
+	"context"
+	"fmt"
+)
+
+type memoryMessage struct {
+	from    int
+	payload []byte
+}
+
+// memoryTransport 是进程内的 Transport，参与者之间通过 channel 通信，用于测试以及在单个
+// 进程内运行多个参与者。
+type memoryTransport struct {
+	index   int
+	inboxes []chan memoryMessage
+}
+
+// NewMemoryTransports 创建 n 个相互连通的进程内 Transport，第 i 个 Transport 属于编号为
+// i+1 的参与者。
+func NewMemoryTransports(n int) []Transport {
+	inboxes := make([]chan memoryMessage, n)
+	for i := range inboxes {
+		inboxes[i] = make(chan memoryMessage, 8*n)
+	}
+
+	transports := make([]Transport, n)
+	for i := range transports {
+		transports[i] = &memoryTransport{index: i + 1, inboxes: inboxes}
+	}
+	return transports
+}
+
+func (t *memoryTransport) Broadcast(ctx context.Context, payload []byte) error {
+	for to := 1; to <= len(t.inboxes); to++ {
+		if to == t.index {
+			continue
+		}
+		if err := t.Send(ctx, to, payload); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (t *memoryTransport) Send(ctx context.Context, to int, payload []byte) error {
+	if to < 1 || to > len(t.inboxes) {
+		return fmt.Errorf("unknown participant %d", to)
+	}
+
+	select {
+	case t.inboxes[to-1] <- memoryMessage{from: t.index, payload: append([]byte{}, payload...)}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (t *memoryTransport) Receive(ctx context.Context) (int, []byte, error) {
+	select {
+	case msg := <-t.inboxes[t.index-1]:
+		return msg.from, msg.payload, nil
+	case <-ctx.Done():
+		return 0, nil, ctx.Err()
+	}
+}
@@ -0,0 +1,45 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package entropy
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/sha256"
+	"io"
+	"sync"
+)
+
+// Deterministic 是由种子确定的伪随机源，输出是以 SHA-256(seed) 为密钥的 AES-256-CTR
+// 密钥流。相同的种子总是产生相同的输出，它只能用于测试，绝不能用于生产环境。
+//
+// 注意 crypto/ecdsa 等标准库实现会在读取随机源时混入额外的随机性，即使使用
+// Deterministic，它们生成的结果也不一定可复现。
+type Deterministic struct {
+	mutex  sync.Mutex
+	stream cipher.Stream
+}
+
+var _ io.Reader = (*Deterministic)(nil)
+
+// NewDeterministic 创建一个由 seed 确定的伪随机源。
+func NewDeterministic(seed []byte) *Deterministic {
+	key := sha256.Sum256(seed)
+	block, _ := aes.NewCipher(key[:]) // 32 字节的密钥不会出错
+	iv := make([]byte, aes.BlockSize)
+
+	return &Deterministic{stream: cipher.NewCTR(block, iv)}
+}
+
+func (d *Deterministic) Read(p []byte) (int, error) {
+	d.mutex.Lock()
+	defer d.mutex.Unlock()
+
+	clear(p)
+	d.stream.XORKeyStream(p, p)
+
+	return len(p), nil
+}
@@ -0,0 +1,69 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package entropy
+
+import (
+	"crypto/rand"
+	"io"
+	"sync"
+
+	"github.com/geistwelt/quarkx/common/qlogging"
+)
+
+var logger = qlogging.MustGetLogger("bccsp.entropy")
+
+var (
+	mutex   sync.RWMutex
+	current io.Reader = NewHealthTested(rand.Reader)
+)
+
+// Reader 是进程范围的默认随机源，它把读取请求转交给通过 Set 配置的随机源，默认是经过
+// 连续健康测试的 crypto/rand.Reader。
+//
+// 消耗随机性的组件都可以单独注入随机源，例如 ca.CA.Rand、msp.Config.Entropy、
+// remote.Server.SetEntropy 以及各个包中的 WithRand 函数，只有没有注入时才使用 Reader。
+// 需要隔离的测试应当注入随机源，而不是修改 Reader。
+var Reader io.Reader = reader{}
+
+type reader struct{}
+
+func (reader) Read(p []byte) (int, error) {
+	mutex.RLock()
+	r := current
+	mutex.RUnlock()
+
+	return r.Read(p)
+}
+
+// Or 返回 r，r 为 nil 时返回 Reader。注入随机源的组件用它在没有配置时回退到默认随机源。
+func Or(r io.Reader) io.Reader {
+	if r == nil {
+		return Reader
+	}
+	return r
+}
+
+// Set 替换 Reader 使用的随机源并返回原来的随机源，传入 nil 会恢复默认随机源。它影响
+// 整个进程中所有没有注入随机源的组件，通常只在进程启动时调用一次，例如切换到经过认证
+// 的随机源。返回值可以交给 Restore：
+//
+//	defer entropy.Restore(entropy.Set(source))
+func Set(r io.Reader) (previous io.Reader) {
+	if r == nil {
+		r = NewHealthTested(rand.Reader)
+	}
+
+	mutex.Lock()
+	previous, current = current, r
+	mutex.Unlock()
+
+	return previous
+}
+
+// Restore 是 Set 的便捷形式，用于恢复 Set 返回的随机源，配合 defer 使用。
+func Restore(previous io.Reader) {
+	Set(previous)
+}
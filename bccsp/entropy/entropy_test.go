@@ -0,0 +1,91 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package entropy
+
+import (
+	"bytes"
+	"crypto/rand"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeterministic(t *testing.T) {
+	a, b := make([]byte, 100), make([]byte, 100)
+	_, err := io.ReadFull(NewDeterministic([]byte("seed")), a)
+	require.NoError(t, err)
+
+	// 分多次读取与一次读取得到的输出相同。
+	d := NewDeterministic([]byte("seed"))
+	_, err = io.ReadFull(d, b[:7])
+	require.NoError(t, err)
+	_, err = io.ReadFull(d, b[7:])
+	require.NoError(t, err)
+	require.Equal(t, a, b)
+
+	_, err = io.ReadFull(NewDeterministic([]byte("other seed")), b)
+	require.NoError(t, err)
+	require.NotEqual(t, a, b)
+}
+
+func TestHealthTested(t *testing.T) {
+	h := NewHealthTested(rand.Reader)
+	buf := make([]byte, 1000)
+	n, err := h.Read(buf)
+	require.NoError(t, err)
+	require.Equal(t, len(buf), n)
+
+	stuck := NewHealthTested(bytes.NewReader(make([]byte, 10*BlockSize)))
+	_, err = stuck.Read(make([]byte, 1))
+	require.ErrorIs(t, err, ErrHealthTest)
+
+	// 测试失败后随机源保持在错误状态。
+	_, err = stuck.Read(make([]byte, 1))
+	require.ErrorIs(t, err, ErrHealthTest)
+
+	// 输出在若干块之后卡住的随机源同样会被检测出来。
+	source := make([]byte, 5*BlockSize)
+	_, err = rand.Read(source[:3*BlockSize])
+	require.NoError(t, err)
+	copy(source[3*BlockSize:], source[2*BlockSize:3*BlockSize])
+	stuck = NewHealthTested(bytes.NewReader(source))
+	n, err = stuck.Read(make([]byte, 3*BlockSize))
+	require.ErrorIs(t, err, ErrHealthTest)
+	require.Equal(t, 2*BlockSize, n)
+
+	_, err = NewHealthTested(bytes.NewReader(nil)).Read(make([]byte, 1))
+	require.EqualError(t, err, "failed to read entropy [EOF]")
+}
+
+func TestSet(t *testing.T) {
+	previous := Set(NewDeterministic([]byte("seed")))
+	a := make([]byte, 32)
+	_, err := io.ReadFull(Reader, a)
+	require.NoError(t, err)
+
+	Set(NewDeterministic([]byte("seed")))
+	b := make([]byte, 32)
+	_, err = io.ReadFull(Reader, b)
+	require.NoError(t, err)
+	require.Equal(t, a, b)
+
+	Restore(previous)
+	_, err = io.ReadFull(Reader, b)
+	require.NoError(t, err)
+	require.NotEqual(t, a, b)
+
+	Set(nil)
+	_, ok := current.(*HealthTested)
+	require.True(t, ok)
+}
+
+func TestOr(t *testing.T) {
+	require.Equal(t, Reader, Or(nil))
+
+	r := NewDeterministic([]byte("seed"))
+	require.Equal(t, r, Or(r))
+}
@@ -0,0 +1,87 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package entropy
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// BlockSize 是连续随机数测试中比较的块大小，单位为字节。
+const BlockSize = 16
+
+// ErrHealthTest 表示随机源没有通过连续健康测试，随机源一旦失败就不会再输出任何数据。
+var ErrHealthTest = errors.New("entropy source failed continuous health test")
+
+// HealthTested 对底层随机源执行 FIPS 140-2 风格的连续随机数测试：随机源的输出按照
+// BlockSize 分块，第一块只作为比较基准，之后的每一块都必须与前一块不同。测试失败后
+// HealthTested 会锁定在错误状态。
+type HealthTested struct {
+	mutex    sync.Mutex
+	source   io.Reader
+	previous []byte
+	buffer   []byte
+	err      error
+}
+
+// NewHealthTested 创建一个对 source 执行连续健康测试的随机源。
+func NewHealthTested(source io.Reader) *HealthTested {
+	return &HealthTested{source: source}
+}
+
+func (h *HealthTested) Read(p []byte) (int, error) {
+	h.mutex.Lock()
+	defer h.mutex.Unlock()
+
+	n := 0
+	for n < len(p) {
+		if len(h.buffer) == 0 {
+			if err := h.next(); err != nil {
+				return n, err
+			}
+		}
+		copied := copy(p[n:], h.buffer)
+		clear(h.buffer[:copied])
+		h.buffer = h.buffer[copied:]
+		n += copied
+	}
+
+	return n, nil
+}
+
+// next 从底层随机源读取新的一块并执行连续测试，调用方需要持有锁。
+func (h *HealthTested) next() error {
+	if h.err != nil {
+		return h.err
+	}
+
+	if h.previous == nil {
+		h.previous = make([]byte, BlockSize)
+		if _, err := io.ReadFull(h.source, h.previous); err != nil {
+			h.previous = nil
+			return fmt.Errorf("failed to read entropy [%v]", err)
+		}
+	}
+
+	block := make([]byte, BlockSize)
+	if _, err := io.ReadFull(h.source, block); err != nil {
+		return fmt.Errorf("failed to read entropy [%v]", err)
+	}
+	if bytes.Equal(block, h.previous) {
+		logger.Errorf("Entropy source produced two identical consecutive %d-byte blocks, refusing to produce more output", BlockSize)
+		h.err = ErrHealthTest
+		clear(block)
+		return h.err
+	}
+
+	copy(h.previous, block)
+	h.buffer = block
+
+	return nil
+}
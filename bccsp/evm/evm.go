@@ -0,0 +1,331 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package evm
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"strings"
+
+	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"golang.org/x/crypto/sha3"
+)
+
+// AddressSize 是地址的字节长度，SignatureSize 是 r || s || v 形式的可恢复签名的字节长度。
+const (
+	AddressSize   = 20
+	SignatureSize = 65
+)
+
+// ErrRecoveryFailed 表示无法从签名中恢复出公钥。
+var ErrRecoveryFailed = errors.New("failed to recover public key")
+
+// S256 返回 secp256k1 曲线，EVM 账户使用这条曲线。
+func S256() elliptic.Curve {
+	return secp.S256()
+}
+
+// checkCurve 确认曲线受支持，目前支持 secp256k1 与 P-256，两者的坐标都是 32 字节。
+func checkCurve(curve elliptic.Curve) error {
+	switch curve {
+	case secp.S256(), elliptic.P256():
+		return nil
+	case nil:
+		return errors.New("invalid curve, it must be different from nil")
+	default:
+		return fmt.Errorf("unsupported curve [%s], must be secp256k1 or P-256", curve.Params().Name)
+	}
+}
+
+// Keccak256 计算所有输入拼接之后的 Keccak-256 哈希。它是以太坊使用的原始 Keccak，与
+// FIPS 202 标准化之后的 SHA3-256 填充方式不同，两者的结果不相等。
+func Keccak256(data ...[]byte) []byte {
+	h := sha3.NewLegacyKeccak256()
+	for _, d := range data {
+		h.Write(d)
+	}
+	return h.Sum(nil)
+}
+
+// Address 是由公钥派生的 20 字节地址。
+type Address [AddressSize]byte
+
+// PublicKeyToAddress 计算公钥的地址，即未压缩公钥去掉 0x04 前缀之后的 Keccak-256 哈希
+// 的后 20 字节。
+func PublicKeyToAddress(pub *ecdsa.PublicKey) (Address, error) {
+	if pub == nil {
+		return Address{}, errors.New("invalid public key, it must be different from nil")
+	}
+	if err := checkCurve(pub.Curve); err != nil {
+		return Address{}, err
+	}
+	if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+		return Address{}, errors.New("invalid public key, the point is not on the curve")
+	}
+
+	raw := make([]byte, 64)
+	pub.X.FillBytes(raw[:32])
+	pub.Y.FillBytes(raw[32:])
+
+	var addr Address
+	copy(addr[:], Keccak256(raw)[32-AddressSize:])
+	return addr, nil
+}
+
+// Hex 返回地址带有 EIP-55 大小写校验和的十六进制编码，以 0x 开头。
+func (a Address) Hex() string {
+	lower := hex.EncodeToString(a[:])
+	hash := Keccak256([]byte(lower))
+
+	checksummed := []byte(lower)
+	for i, c := range checksummed {
+		// 哈希中对应的半字节不小于 8 时，字母写成大写。
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0f
+		}
+		if c >= 'a' && nibble >= 8 {
+			checksummed[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(checksummed)
+}
+
+// String 返回 Hex()。
+func (a Address) String() string {
+	return a.Hex()
+}
+
+// ParseAddress 解析十六进制编码的地址，0x 前缀可以省略。全部小写或全部大写的地址不带有
+// 校验和，大小写混合的地址必须符合 EIP-55 校验和。
+func ParseAddress(s string) (Address, error) {
+	raw := strings.TrimPrefix(strings.TrimPrefix(s, "0x"), "0X")
+	if len(raw) != 2*AddressSize {
+		return Address{}, fmt.Errorf("invalid address length %d, must be %d hex characters", len(raw), 2*AddressSize)
+	}
+
+	var addr Address
+	if _, err := hex.Decode(addr[:], []byte(raw)); err != nil {
+		return Address{}, fmt.Errorf("failed to decode address [%v]", err)
+	}
+
+	if raw != strings.ToLower(raw) && raw != strings.ToUpper(raw) && addr.Hex()[2:] != raw {
+		return Address{}, fmt.Errorf("invalid address checksum [%s]", s)
+	}
+	return addr, nil
+}
+
+// Signature 是可以恢复出公钥的 ECDSA 签名。V 是恢复标识，取值为 0 到 3：第 0 位是签名
+// 时随机点 R 的 y 坐标的奇偶性，第 1 位表示 R 的 x 坐标是否大于等于曲线的阶（r 是 x
+// 坐标对阶取模的结果）。
+type Signature struct {
+	R, S *big.Int
+	V    byte
+}
+
+// Bytes 将签名编码成 32 字节的 r、32 字节的 s 与 1 字节的 v。v 取 0 到 3，需要 27/28
+// 形式的调用方自行加上 27。
+func (sig *Signature) Bytes() []byte {
+	raw := make([]byte, SignatureSize)
+	sig.R.FillBytes(raw[:32])
+	sig.S.FillBytes(raw[32:64])
+	raw[64] = sig.V
+	return raw
+}
+
+// ParseSignature 解析 Bytes 返回的编码，v 也可以是以太坊传统的 27 或 28。
+func ParseSignature(raw []byte) (*Signature, error) {
+	if len(raw) != SignatureSize {
+		return nil, fmt.Errorf("invalid signature length %d, must be %d", len(raw), SignatureSize)
+	}
+
+	v := raw[64]
+	if v == 27 || v == 28 {
+		v -= 27
+	}
+	if v > 3 {
+		return nil, fmt.Errorf("invalid recovery id %d", raw[64])
+	}
+
+	return &Signature{
+		R: new(big.Int).SetBytes(raw[:32]),
+		S: new(big.Int).SetBytes(raw[32:64]),
+		V: v,
+	}, nil
+}
+
+// Sign 使用 signer 对 digest 签名，并计算签名的恢复标识。signer 可以是本地私钥、签名服务
+// 提供的 crypto.Signer 等任何返回 DER 编码 ECDSA 签名的实现，其公钥必须位于 secp256k1
+// 或 P-256 上。返回的签名总是 low-S 形式。
+func Sign(rand io.Reader, signer crypto.Signer, digest []byte) (*Signature, error) {
+	pub, ok := signer.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type [%T], must be *ecdsa.PublicKey", signer.Public())
+	}
+	if err := checkCurve(pub.Curve); err != nil {
+		return nil, err
+	}
+
+	// Keccak-256 不在 crypto.Hash 中，用输出长度相同的 SHA3-256 告知 signer digest 已经
+	// 是 32 字节的哈希值。
+	der, err := signer.Sign(rand, digest, crypto.SHA3_256)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign [%v]", err)
+	}
+	r, s, err := utils.UnmarshalECDSASignature(der)
+	if err != nil {
+		return nil, err
+	}
+
+	v, err := RecoveryID(pub, digest, r, s)
+	if err != nil {
+		return nil, err
+	}
+	sig := &Signature{R: r, S: s, V: v}
+	toLowS(pub.Curve, sig)
+	return sig, nil
+}
+
+// toLowS 在 s 大于阶的一半时用 N-s 替换 s。(r, N-s) 对应的随机点是 -R，它与 R 的 x
+// 坐标相同而 y 坐标的奇偶性相反，因此恢复标识的第 0 位也要翻转。
+func toLowS(curve elliptic.Curve, sig *Signature) {
+	n := curve.Params().N
+	if sig.S.Cmp(new(big.Int).Rsh(n, 1)) > 0 {
+		sig.S = new(big.Int).Sub(n, sig.S)
+		sig.V ^= 1
+	}
+}
+
+// RecoveryID 计算签名 (r, s) 的恢复标识，即使用哪个 v 可以从签名中恢复出 pub。签名服务
+// 只返回 (r, s) 时，可以用它补上 v。
+func RecoveryID(pub *ecdsa.PublicKey, digest []byte, r, s *big.Int) (byte, error) {
+	if pub == nil {
+		return 0, errors.New("invalid public key, it must be different from nil")
+	}
+	for v := byte(0); v < 4; v++ {
+		recovered, err := RecoverPublicKey(pub.Curve, digest, &Signature{R: r, S: s, V: v})
+		if err == nil && recovered.Equal(pub) {
+			return v, nil
+		}
+	}
+	return 0, errors.New("failed to compute recovery id, the signature does not match the public key")
+}
+
+// RecoverPublicKey 从 digest 与签名中恢复出签名者的公钥 Q = r⁻¹(sR - eG)，其中 R 是
+// 由 r 与恢复标识确定的随机点。恢复出的公钥总能验证该签名，调用方需要自行判断它是否为
+// 期望的签名者，例如比较由它派生的地址。
+func RecoverPublicKey(curve elliptic.Curve, digest []byte, sig *Signature) (*ecdsa.PublicKey, error) {
+	if err := checkCurve(curve); err != nil {
+		return nil, err
+	}
+	if sig == nil || sig.R == nil || sig.S == nil {
+		return nil, fmt.Errorf("%w, signature must be different from nil", ErrRecoveryFailed)
+	}
+	if sig.V > 3 {
+		return nil, fmt.Errorf("%w, invalid recovery id %d", ErrRecoveryFailed, sig.V)
+	}
+
+	params := curve.Params()
+	n := params.N
+	if sig.R.Sign() != 1 || sig.R.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("%w, R must be in [1, N-1]", ErrRecoveryFailed)
+	}
+	if sig.S.Sign() != 1 || sig.S.Cmp(n) >= 0 {
+		return nil, fmt.Errorf("%w, S must be in [1, N-1]", ErrRecoveryFailed)
+	}
+
+	x := new(big.Int).Set(sig.R)
+	if sig.V&2 != 0 {
+		x.Add(x, n)
+	}
+	if x.Cmp(params.P) >= 0 {
+		return nil, fmt.Errorf("%w, x coordinate of R is not smaller than the field size", ErrRecoveryFailed)
+	}
+	y, err := decompressY(curve, x, sig.V&1 == 1)
+	if err != nil {
+		return nil, err
+	}
+
+	e := hashToInt(digest, n)
+	rInv := new(big.Int).ModInverse(sig.R, n)
+	u1 := new(big.Int).Mul(e, rInv)
+	u1.Neg(u1).Mod(u1, n)
+	u2 := new(big.Int).Mul(sig.S, rInv)
+	u2.Mod(u2, n)
+
+	x1, y1 := curve.ScalarBaseMult(u1.Bytes())
+	x2, y2 := curve.ScalarMult(x, y, u2.Bytes())
+	qx, qy := curve.Add(x1, y1, x2, y2)
+	if qx.Sign() == 0 && qy.Sign() == 0 {
+		return nil, fmt.Errorf("%w, the recovered point is the identity", ErrRecoveryFailed)
+	}
+
+	pub := &ecdsa.PublicKey{Curve: curve, X: qx, Y: qy}
+	if !ecdsa.Verify(pub, digest, sig.R, sig.S) {
+		return nil, fmt.Errorf("%w, the recovered key does not verify the signature", ErrRecoveryFailed)
+	}
+	return pub, nil
+}
+
+// RecoverAddress 从 digest 与签名中恢复出 secp256k1 公钥并返回它的地址，与 EVM 的
+// ecrecover 预编译合约一致。
+func RecoverAddress(digest []byte, sig *Signature) (Address, error) {
+	pub, err := RecoverPublicKey(S256(), digest, sig)
+	if err != nil {
+		return Address{}, err
+	}
+	return PublicKeyToAddress(pub)
+}
+
+// decompressY 返回曲线上 x 坐标为 x、奇偶性为 odd 的点的 y 坐标。secp256k1 的方程为
+// y² = x³ + 7，P-256 的方程为 y² = x³ - 3x + b。
+func decompressY(curve elliptic.Curve, x *big.Int, odd bool) (*big.Int, error) {
+	params := curve.Params()
+	p := params.P
+
+	rhs := new(big.Int).Exp(x, big.NewInt(3), p)
+	if curve != secp.S256() {
+		threeX := new(big.Int).Lsh(x, 1)
+		threeX.Add(threeX, x)
+		rhs.Sub(rhs, threeX)
+	}
+	rhs.Add(rhs, params.B)
+	rhs.Mod(rhs, p)
+
+	y := new(big.Int).ModSqrt(rhs, p)
+	if y == nil {
+		return nil, fmt.Errorf("%w, R is not on the curve", ErrRecoveryFailed)
+	}
+	if (y.Bit(0) == 1) != odd {
+		y.Sub(p, y)
+	}
+	if !curve.IsOnCurve(x, y) {
+		return nil, fmt.Errorf("%w, R is not on the curve", ErrRecoveryFailed)
+	}
+	return y, nil
+}
+
+// hashToInt 与 crypto/ecdsa 一致，取 digest 的前 bitlen(N) 位作为整数。
+func hashToInt(digest []byte, n *big.Int) *big.Int {
+	orderBits := n.BitLen()
+	orderBytes := (orderBits + 7) / 8
+	if len(digest) > orderBytes {
+		digest = digest[:orderBytes]
+	}
+
+	e := new(big.Int).SetBytes(digest)
+	if excess := len(digest)*8 - orderBits; excess > 0 {
+		e.Rsh(e, uint(excess))
+	}
+	return e
+}
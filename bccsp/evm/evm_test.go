@@ -0,0 +1,140 @@
+package evm
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"math/big"
+	"testing"
+
+	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeccak256(t *testing.T) {
+	require.Equal(t, "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470", hex.EncodeToString(Keccak256()))
+	require.Equal(t, Keccak256([]byte("hello, quarkx")), Keccak256([]byte("hello, "), []byte("quarkx")))
+}
+
+func TestAddress(t *testing.T) {
+	raw, err := hex.DecodeString("4c0883a69102937d6231471b5dbb6204fe5129617082792ae468d01a3f362318")
+	require.NoError(t, err)
+	sk := secp.PrivKeyFromBytes(raw).ToECDSA()
+	addr, err := PublicKeyToAddress(&sk.PublicKey)
+	require.NoError(t, err)
+	require.Equal(t, "0x2c7536E3605D9C16a7a3D7b1898e529396a65c23", addr.Hex())
+
+	// EIP-55 给出的校验和示例。
+	for _, s := range []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	} {
+		parsed, err := ParseAddress(s)
+		require.NoError(t, err)
+		require.Equal(t, s, parsed.String())
+	}
+
+	parsed, err := ParseAddress("5aaeb6053f3e94c9b9a09f33669435e7ef1beaed")
+	require.NoError(t, err)
+	require.Equal(t, "0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", parsed.Hex())
+	_, err = ParseAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD")
+	require.EqualError(t, err, "invalid address checksum [0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAeD]")
+	_, err = ParseAddress("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeA")
+	require.EqualError(t, err, "invalid address length 38, must be 40 hex characters")
+
+	p384, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	_, err = PublicKeyToAddress(&p384.PublicKey)
+	require.EqualError(t, err, "unsupported curve [P-384], must be secp256k1 or P-256")
+}
+
+func TestSignAndRecover(t *testing.T) {
+	for _, curve := range []elliptic.Curve{S256(), elliptic.P256()} {
+		t.Run(curve.Params().Name, func(t *testing.T) {
+			sk, err := ecdsa.GenerateKey(curve, rand.Reader)
+			require.NoError(t, err)
+			halfOrder := new(big.Int).Rsh(curve.Params().N, 1)
+
+			for i := 0; i < 32; i++ {
+				digest := Keccak256([]byte{byte(i)})
+				sig, err := Sign(rand.Reader, sk, digest)
+				require.NoError(t, err)
+				require.True(t, sig.S.Cmp(halfOrder) <= 0)
+
+				recovered, err := RecoverPublicKey(curve, digest, sig)
+				require.NoError(t, err)
+				require.True(t, recovered.Equal(&sk.PublicKey))
+
+				// 27/28 形式的 v 也能被解析。
+				raw := sig.Bytes()
+				raw[64] += 27
+				parsed, err := ParseSignature(raw)
+				require.NoError(t, err)
+				require.Equal(t, sig, parsed)
+
+				// 其他的恢复标识恢复不出签名者的公钥。
+				wrong, err := RecoverPublicKey(curve, digest, &Signature{R: sig.R, S: sig.S, V: sig.V ^ 1})
+				require.NoError(t, err)
+				require.False(t, wrong.Equal(&sk.PublicKey))
+			}
+		})
+	}
+}
+
+func TestLowSFlipsRecoveryID(t *testing.T) {
+	sk, err := ecdsa.GenerateKey(S256(), rand.Reader)
+	require.NoError(t, err)
+	n := S256().Params().N
+	digest := Keccak256([]byte("block 42"))
+
+	r, s, err := ecdsa.Sign(rand.Reader, sk, digest)
+	require.NoError(t, err)
+	if s.Cmp(new(big.Int).Rsh(n, 1)) <= 0 {
+		s.Sub(n, s)
+	}
+
+	// high-S 形式的签名有自己的恢复标识，转换成 low-S 之后恢复标识的奇偶位翻转。
+	v, err := RecoveryID(&sk.PublicKey, digest, r, s)
+	require.NoError(t, err)
+	sig := &Signature{R: r, S: new(big.Int).Set(s), V: v}
+	toLowS(S256(), sig)
+	require.Equal(t, v^1, sig.V)
+	require.Equal(t, new(big.Int).Sub(n, s), sig.S)
+
+	recovered, err := RecoverPublicKey(S256(), digest, sig)
+	require.NoError(t, err)
+	require.True(t, recovered.Equal(&sk.PublicKey))
+
+	addr, err := RecoverAddress(digest, sig)
+	require.NoError(t, err)
+	expected, err := PublicKeyToAddress(&sk.PublicKey)
+	require.NoError(t, err)
+	require.Equal(t, expected, addr)
+}
+
+func TestRecoverErrors(t *testing.T) {
+	digest := Keccak256([]byte("block 42"))
+	n := S256().Params().N
+
+	_, err := RecoverPublicKey(S256(), digest, &Signature{R: big.NewInt(0), S: big.NewInt(1)})
+	require.ErrorIs(t, err, ErrRecoveryFailed)
+	_, err = RecoverPublicKey(S256(), digest, &Signature{R: big.NewInt(1), S: new(big.Int).Set(n)})
+	require.ErrorIs(t, err, ErrRecoveryFailed)
+	_, err = RecoverPublicKey(S256(), digest, &Signature{R: big.NewInt(1), S: big.NewInt(1), V: 4})
+	require.EqualError(t, err, "failed to recover public key, invalid recovery id 4")
+	// r + N 超出了 secp256k1 的域。
+	_, err = RecoverPublicKey(S256(), digest, &Signature{R: new(big.Int).Sub(n, big.NewInt(1)), S: big.NewInt(1), V: 2})
+	require.EqualError(t, err, "failed to recover public key, x coordinate of R is not smaller than the field size")
+	_, err = RecoverPublicKey(elliptic.P384(), digest, &Signature{R: big.NewInt(1), S: big.NewInt(1)})
+	require.EqualError(t, err, "unsupported curve [P-384], must be secp256k1 or P-256")
+
+	_, err = ParseSignature(make([]byte, 64))
+	require.EqualError(t, err, "invalid signature length 64, must be 65")
+	raw := make([]byte, SignatureSize)
+	raw[64] = 5
+	_, err = ParseSignature(raw)
+	require.EqualError(t, err, "invalid recovery id 5")
+}
@@ -0,0 +1,153 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package keyaudit
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"github.com/geistwelt/quarkx/common/metrics"
+	"github.com/geistwelt/quarkx/common/qlogging"
+)
+
+var logger = qlogging.MustGetLogger("bccsp.keyaudit")
+
+// Operation 是被审计的私钥操作。
+type Operation string
+
+const (
+	OpSign     Operation = "sign"
+	OpDecrypt  Operation = "decrypt"
+	OpKeyDeriv Operation = "keyderiv"
+	OpExport   Operation = "export"
+)
+
+// Auditor 记录每一次私钥操作的 SKI、调用组件、时间与结果。记录会写入 bccsp.keyaudit
+// 日志器，并按照密钥、组件、操作与结果分别计数；如果配置了 trail，记录还会写入 trail，
+// 通常 trail 来自 audit.NewLogging，从而得到一个防篡改的只追加审计文件。
+type Auditor struct {
+	trail      *qlogging.QuarkXLogger
+	operations metrics.Counter
+}
+
+// NewAuditor 创建一个 Auditor，provider 用来创建操作计数器，trail 可以为 nil。provider
+// 为 nil 时不计数，只记录日志，适用于命令行工具这类没有指标的场景。
+func NewAuditor(provider metrics.Provider, trail *qlogging.QuarkXLogger) *Auditor {
+	a := &Auditor{trail: trail}
+	if provider != nil {
+		a.operations = provider.NewCounter(operationsOpts)
+	}
+	return a
+}
+
+// Record 记录一次私钥操作，err 为 nil 表示操作成功。KeyDeriv 与 Export 这类没有统一
+// 接口的操作由调用方在操作完成后直接调用 Record。
+func (a *Auditor) Record(ski []byte, component string, op Operation, err error) {
+	outcome := "success"
+	if err != nil {
+		outcome = "failure"
+	}
+	encodedSKI := hex.EncodeToString(ski)
+
+	if a.operations != nil {
+		a.operations.With("ski", encodedSKI, "component", component, "operation", string(op), "outcome", outcome).Add(1)
+	}
+
+	keysAndValues := []interface{}{"ski", encodedSKI, "component", component, "operation", string(op), "outcome", outcome}
+	if err != nil {
+		keysAndValues = append(keysAndValues, "error", err.Error())
+		logger.Warnw("private key operation failed", keysAndValues...)
+	} else {
+		logger.Infow("private key operation", keysAndValues...)
+	}
+	if a.trail != nil {
+		a.trail.Infow("private key operation", keysAndValues...)
+	}
+}
+
+// Signer 包装 signer，每次签名都以 component 的名义记录一次 OpSign 操作。
+func (a *Auditor) Signer(signer crypto.Signer, component string) (*Signer, error) {
+	if signer == nil {
+		return nil, errors.New("invalid signer, it must be different from nil")
+	}
+	ski, err := SKI(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Signer{signer: signer, auditor: a, component: component, ski: ski}, nil
+}
+
+// Decrypter 包装 decrypter，每次解密都以 component 的名义记录一次 OpDecrypt 操作。
+func (a *Auditor) Decrypter(decrypter crypto.Decrypter, component string) (*Decrypter, error) {
+	if decrypter == nil {
+		return nil, errors.New("invalid decrypter, it must be different from nil")
+	}
+	ski, err := SKI(decrypter.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	return &Decrypter{decrypter: decrypter, auditor: a, component: component, ski: ski}, nil
+}
+
+// Signer 是记录审计事件的 crypto.Signer。
+type Signer struct {
+	signer    crypto.Signer
+	auditor   *Auditor
+	component string
+	ski       []byte
+}
+
+func (s *Signer) Public() crypto.PublicKey {
+	return s.signer.Public()
+}
+
+func (s *Signer) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	signature, err := s.signer.Sign(rand, digest, opts)
+	s.auditor.Record(s.ski, s.component, OpSign, err)
+	return signature, err
+}
+
+// Decrypter 是记录审计事件的 crypto.Decrypter。
+type Decrypter struct {
+	decrypter crypto.Decrypter
+	auditor   *Auditor
+	component string
+	ski       []byte
+}
+
+func (d *Decrypter) Public() crypto.PublicKey {
+	return d.decrypter.Public()
+}
+
+func (d *Decrypter) Decrypt(rand io.Reader, msg []byte, opts crypto.DecrypterOpts) ([]byte, error) {
+	plaintext, err := d.decrypter.Decrypt(rand, msg, opts)
+	d.auditor.Record(d.ski, d.component, OpDecrypt, err)
+	return plaintext, err
+}
+
+// SKI 返回审计记录中使用的密钥标识。ECDSA 公钥与 utils.SKI 保持一致，其他公钥使用
+// PKIX DER 编码的 SHA-256 哈希值。
+func SKI(pub crypto.PublicKey) ([]byte, error) {
+	if k, ok := pub.(*ecdsa.PublicKey); ok {
+		return utils.SKI(k), nil
+	}
+
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, fmt.Errorf("unsupported public key type [%T]", pub)
+	}
+	hash := sha256.Sum256(der)
+	return hash[:], nil
+}
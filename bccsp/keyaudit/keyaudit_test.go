@@ -0,0 +1,121 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package keyaudit
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"github.com/geistwelt/quarkx/common/metrics/metricsfakes"
+	"github.com/geistwelt/quarkx/common/qlogging/audit"
+	"github.com/stretchr/testify/require"
+)
+
+type failingSigner struct {
+	crypto.Signer
+}
+
+func (failingSigner) Sign(io.Reader, []byte, crypto.SignerOpts) ([]byte, error) {
+	return nil, errors.New("hsm unavailable")
+}
+
+func TestAuditedSigner(t *testing.T) {
+	counter := &metricsfakes.Counter{}
+	counter.WithReturns(counter)
+	provider := &metricsfakes.Provider{}
+	provider.NewCounterReturns(counter)
+
+	auditKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	sink, err := audit.NewSink(buf, auditKey, 0)
+	require.NoError(t, err)
+	logging, err := audit.NewLogging(sink, "info")
+	require.NoError(t, err)
+
+	auditor := NewAuditor(provider, logging.Logger("keyaudit"))
+	require.Equal(t, operationsOpts, provider.NewCounterArgsForCall(0))
+
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	signer, err := auditor.Signer(sk, "orderer")
+	require.NoError(t, err)
+	require.Equal(t, &sk.PublicKey, signer.Public())
+
+	digest := sha256.Sum256([]byte("block"))
+	signature, err := signer.Sign(rand.Reader, digest[:], nil)
+	require.NoError(t, err)
+	require.True(t, ecdsa.VerifyASN1(&sk.PublicKey, digest[:], signature))
+
+	failing, err := auditor.Signer(failingSigner{sk}, "gateway")
+	require.NoError(t, err)
+	_, err = failing.Sign(rand.Reader, digest[:], nil)
+	require.EqualError(t, err, "hsm unavailable")
+
+	ski := hex.EncodeToString(utils.SKI(&sk.PublicKey))
+	require.Equal(t, 2, counter.WithCallCount())
+	require.Equal(t, []string{"ski", ski, "component", "orderer", "operation", "sign", "outcome", "success"}, counter.WithArgsForCall(0))
+	require.Equal(t, []string{"ski", ski, "component", "gateway", "operation", "sign", "outcome", "failure"}, counter.WithArgsForCall(1))
+	require.Equal(t, 2, counter.AddCallCount())
+
+	// 审计文件中的记录带有时间戳，并且可以用审计日志的公钥校验。
+	require.NoError(t, logging.Sync())
+	report, err := audit.Verify(bytes.NewReader(buf.Bytes()), &auditKey.PublicKey, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), report.Entries)
+
+	lines := strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+	record := &audit.Record{}
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), record))
+	entry := map[string]interface{}{}
+	require.NoError(t, json.Unmarshal([]byte(record.Entry), &entry))
+	require.Equal(t, ski, entry["ski"])
+	require.Equal(t, "gateway", entry["component"])
+	require.Equal(t, "failure", entry["outcome"])
+	require.Equal(t, "hsm unavailable", entry["error"])
+	require.NotEmpty(t, entry["ts"])
+}
+
+func TestAuditedDecrypter(t *testing.T) {
+	counter := &metricsfakes.Counter{}
+	counter.WithReturns(counter)
+	provider := &metricsfakes.Provider{}
+	provider.NewCounterReturns(counter)
+	auditor := NewAuditor(provider, nil)
+
+	sk, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	decrypter, err := auditor.Decrypter(sk, "backup")
+	require.NoError(t, err)
+
+	ciphertext, err := rsa.EncryptOAEP(sha256.New(), rand.Reader, &sk.PublicKey, []byte("secret"), nil)
+	require.NoError(t, err)
+	plaintext, err := decrypter.Decrypt(rand.Reader, ciphertext, &rsa.OAEPOptions{Hash: crypto.SHA256})
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret"), plaintext)
+
+	ski, err := SKI(&sk.PublicKey)
+	require.NoError(t, err)
+	require.Equal(t, []string{"ski", hex.EncodeToString(ski), "component", "backup", "operation", "decrypt", "outcome", "success"}, counter.WithArgsForCall(0))
+
+	auditor.Record(ski, "cli", OpExport, nil)
+	require.Equal(t, []string{"ski", hex.EncodeToString(ski), "component", "cli", "operation", "export", "outcome", "success"}, counter.WithArgsForCall(1))
+
+	_, err = auditor.Signer(nil, "orderer")
+	require.EqualError(t, err, "invalid signer, it must be different from nil")
+}
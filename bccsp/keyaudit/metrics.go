@@ -0,0 +1,17 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package keyaudit
+
+import "github.com/geistwelt/quarkx/common/metrics"
+
+var operationsOpts = metrics.CounterOpts{
+	Namespace:    "bccsp",
+	Subsystem:    "keyaudit",
+	Name:         "operations",
+	Help:         "The number of private key operations, by key, component, operation and outcome.",
+	LabelNames:   []string{"ski", "component", "operation", "outcome"},
+	StatsdFormat: "%{#fqname}.%{ski}.%{component}.%{operation}.%{outcome}",
+}
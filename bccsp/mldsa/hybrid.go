@@ -0,0 +1,339 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package mldsa
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+	"github.com/geistwelt/quarkx/bccsp/secret"
+	"github.com/geistwelt/quarkx/bccsp/utils"
+)
+
+// hybridLabel 是混合签名的域分隔标签。两种算法签的都是 hybridLabel || msg，这样从混合签名
+// 中剥离出来的单个签名无法被当作同一消息的普通 ECDSA 或 ML-DSA 签名。
+var hybridLabel = []byte("QUARKX-HYBRID-ECDSA-MLDSA\x00")
+
+// 混合密钥的 PEM 类型。
+const (
+	HybridPublicKeyPEMType  = "QUARKX HYBRID PUBLIC KEY"
+	HybridPrivateKeyPEMType = "QUARKX HYBRID PRIVATE KEY"
+)
+
+// HybridPublicKey 是 ECDSA 与 ML-DSA 组合而成的公钥，签名只有在两种算法都验证通过时才有效。
+type HybridPublicKey struct {
+	ECDSA *ecdsa.PublicKey
+	MLDSA *PublicKey
+}
+
+// HybridPrivateKey 是 ECDSA 与 ML-DSA 组合而成的私钥，它实现了 crypto.Signer。ECDSA 私钥
+// 以 utils.ECDSAPrivateKey 的形式保存，在 fmt 与日志中只显示为 [REDACTED]。
+type HybridPrivateKey struct {
+	secret.Redacted
+
+	mutex     sync.RWMutex
+	destroyed bool
+
+	ECDSA *utils.ECDSAPrivateKey
+	MLDSA *PrivateKey
+}
+
+// NewHybridPrivateKey 由 ECDSA 私钥与 ML-DSA 私钥组成混合私钥，并接管两者的所有权。
+func NewHybridPrivateKey(ecdsaKey *ecdsa.PrivateKey, mldsaKey *PrivateKey) (*HybridPrivateKey, error) {
+	if mldsaKey == nil {
+		return nil, errors.New("invalid ML-DSA private key, it must be different from nil")
+	}
+	wrapped, err := utils.NewECDSAPrivateKey(ecdsaKey)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HybridPrivateKey{ECDSA: wrapped, MLDSA: mldsaKey}, nil
+}
+
+type hybridSignature struct {
+	ECDSA []byte
+	MLDSA []byte
+}
+
+type hybridKey struct {
+	ECDSA asn1.RawValue
+	MLDSA asn1.RawValue
+}
+
+// GenerateHybridKey 使用 entropy.Reader 生成曲线为 curve 的 ECDSA 私钥与参数集为
+// parameterSet 的 ML-DSA 私钥。
+func GenerateHybridKey(curve elliptic.Curve, parameterSet string) (*HybridPrivateKey, error) {
+	return GenerateHybridKeyWithRand(entropy.Reader, curve, parameterSet)
+}
+
+// GenerateHybridKeyWithRand 与 GenerateHybridKey 相同，两个私钥都使用随机源 rand 生成。
+func GenerateHybridKeyWithRand(rand io.Reader, curve elliptic.Curve, parameterSet string) (*HybridPrivateKey, error) {
+	ecdsaKey, err := ecdsa.GenerateKey(curve, rand)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate ECDSA key [%v]", err)
+	}
+
+	mldsaKey, err := GenerateKeyWithRand(rand, parameterSet)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewHybridPrivateKey(ecdsaKey, mldsaKey)
+}
+
+// Public 返回 *HybridPublicKey。
+func (k *HybridPrivateKey) Public() crypto.PublicKey {
+	return k.PublicKey()
+}
+
+// PublicKey 返回私钥对应的混合公钥。
+func (k *HybridPrivateKey) PublicKey() *HybridPublicKey {
+	return &HybridPublicKey{ECDSA: k.ECDSA.Public().(*ecdsa.PublicKey), MLDSA: k.MLDSA.PublicKey()}
+}
+
+// Sign 对完整的消息进行混合签名，opts.HashFunc() 必须为 0。ECDSA 签名使用与曲线强度匹配
+// 的哈希函数，并且总是 low-S 形式。
+func (k *HybridPrivateKey) Sign(rand io.Reader, msg []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts != nil && opts.HashFunc() != 0 {
+		return nil, errors.New("hybrid signatures sign the message itself, opts.HashFunc() must be zero")
+	}
+
+	// 签名期间持有读锁，Destroy 会等待签名完成，不会只清零其中一个私钥。
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	if k.destroyed {
+		return nil, errHybridDestroyed
+	}
+
+	labeled := append(append([]byte{}, hybridLabel...), msg...)
+
+	pub := k.ECDSA.Public().(*ecdsa.PublicKey)
+	digest, err := hybridDigest(pub.Curve, labeled)
+	if err != nil {
+		return nil, err
+	}
+	ecdsaSig, err := k.ECDSA.Sign(rand, digest, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with ECDSA [%v]", err)
+	}
+	ecdsaSig, err = utils.SignatureToLowS(pub, ecdsaSig)
+	if err != nil {
+		return nil, err
+	}
+
+	mldsaSig, err := k.MLDSA.Sign(rand, labeled, crypto.Hash(0))
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(hybridSignature{ECDSA: ecdsaSig, MLDSA: mldsaSig})
+}
+
+// Destroy 将 ECDSA 私钥与 ML-DSA 私钥清零，之后私钥不能再用于签名。Destroy 会等待
+// 正在进行的签名完成。标准库内部可能缓存的 ECDSA 私钥副本无法被清零。
+func (k *HybridPrivateKey) Destroy() {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if k.ECDSA != nil {
+		k.ECDSA.Destroy()
+	}
+	if k.MLDSA != nil {
+		k.MLDSA.Destroy()
+	}
+	k.destroyed = true
+}
+
+var errHybridDestroyed = errors.New("hybrid private key has been destroyed")
+
+// Verify 验证 sig 是否为 msg 的有效混合签名，两个签名都必须有效，并且 ECDSA 签名必须是
+// low-S 形式。
+func (k *HybridPublicKey) Verify(msg, sig []byte) error {
+	var hs hybridSignature
+	rest, err := asn1.Unmarshal(sig, &hs)
+	if err != nil {
+		return fmt.Errorf("failed to unmarshal hybrid signature [%v]", err)
+	}
+	if len(rest) != 0 {
+		return errors.New("trailing data after hybrid signature")
+	}
+
+	labeled := append(append([]byte{}, hybridLabel...), msg...)
+
+	r, s, err := utils.UnmarshalECDSASignature(hs.ECDSA)
+	if err != nil {
+		return err
+	}
+	if err := utils.CheckLowS(k.ECDSA, s); err != nil {
+		return err
+	}
+	digest, err := hybridDigest(k.ECDSA.Curve, labeled)
+	if err != nil {
+		return err
+	}
+	ecdsaValid := ecdsa.Verify(k.ECDSA, digest, r, s)
+	mldsaValid := k.MLDSA.Verify(labeled, hs.MLDSA)
+
+	switch {
+	case !ecdsaValid && !mldsaValid:
+		return errors.New("invalid hybrid signature, both the ECDSA and the ML-DSA signatures are not valid")
+	case !ecdsaValid:
+		return errors.New("invalid hybrid signature, the ECDSA signature is not valid")
+	case !mldsaValid:
+		return errors.New("invalid hybrid signature, the ML-DSA signature is not valid")
+	}
+
+	return nil
+}
+
+// Equal 判断两个混合公钥是否相同。
+func (k *HybridPublicKey) Equal(x crypto.PublicKey) bool {
+	other, ok := x.(*HybridPublicKey)
+	return ok && k.ECDSA.Equal(other.ECDSA) && k.MLDSA.Equal(other.MLDSA)
+}
+
+func hybridDigest(curve elliptic.Curve, msg []byte) ([]byte, error) {
+	switch curve {
+	case elliptic.P256():
+		digest := sha256.Sum256(msg)
+		return digest[:], nil
+	case elliptic.P384():
+		digest := sha512.Sum384(msg)
+		return digest[:], nil
+	case elliptic.P521():
+		digest := sha512.Sum512(msg)
+		return digest[:], nil
+	default:
+		return nil, fmt.Errorf("unsupported curve [%s] for hybrid signatures", curve.Params().Name)
+	}
+}
+
+// HybridPublicKeyToPEM 将混合公钥编码成 PEM，内容是两个 SubjectPublicKeyInfo 组成的序列。
+func HybridPublicKeyToPEM(k *HybridPublicKey) ([]byte, error) {
+	if k == nil || k.ECDSA == nil || k.MLDSA == nil {
+		return nil, errors.New("invalid hybrid public key, it must be different from nil")
+	}
+
+	ecdsaDER, err := utils.PublicKeyToDER(k.ECDSA)
+	if err != nil {
+		return nil, err
+	}
+	mldsaDER, err := MarshalPKIXPublicKey(k.MLDSA)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := asn1.Marshal(hybridKey{ECDSA: asn1.RawValue{FullBytes: ecdsaDER}, MLDSA: asn1.RawValue{FullBytes: mldsaDER}})
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: HybridPublicKeyPEMType, Bytes: der}), nil
+}
+
+// PEMToHybridPublicKey 解析 PEM 格式的混合公钥。
+func PEMToHybridPublicKey(raw []byte) (*HybridPublicKey, error) {
+	hk, err := decodeHybridKey(raw, HybridPublicKeyPEMType)
+	if err != nil {
+		return nil, err
+	}
+
+	ecdsaKey, err := utils.DERToPublicKey(hk.ECDSA.FullBytes)
+	if err != nil {
+		return nil, err
+	}
+	mldsaKey, err := ParsePKIXPublicKey(hk.MLDSA.FullBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return &HybridPublicKey{ECDSA: ecdsaKey, MLDSA: mldsaKey}, nil
+}
+
+// HybridPrivateKeyToPEM 将混合私钥编码成 PEM，内容是两个 PKCS #8 私钥组成的序列。
+func HybridPrivateKeyToPEM(k *HybridPrivateKey) ([]byte, error) {
+	if k == nil || k.ECDSA == nil || k.MLDSA == nil {
+		return nil, errors.New("invalid hybrid private key, it must be different from nil")
+	}
+
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	if k.destroyed {
+		return nil, errHybridDestroyed
+	}
+
+	var ecdsaDER []byte
+	err := k.ECDSA.Export(func(sk *ecdsa.PrivateKey) error {
+		var err error
+		ecdsaDER, err = utils.PrivateKeyToDER(sk)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	mldsaDER, err := MarshalPKCS8PrivateKey(k.MLDSA)
+	if err != nil {
+		return nil, err
+	}
+
+	der, err := asn1.Marshal(hybridKey{ECDSA: asn1.RawValue{FullBytes: ecdsaDER}, MLDSA: asn1.RawValue{FullBytes: mldsaDER}})
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: HybridPrivateKeyPEMType, Bytes: der}), nil
+}
+
+// PEMToHybridPrivateKey 解析 PEM 格式的混合私钥。
+func PEMToHybridPrivateKey(raw []byte) (*HybridPrivateKey, error) {
+	hk, err := decodeHybridKey(raw, HybridPrivateKeyPEMType)
+	if err != nil {
+		return nil, err
+	}
+
+	ecdsaKey, err := utils.DERToPrivateKey(hk.ECDSA.FullBytes)
+	if err != nil {
+		return nil, err
+	}
+	mldsaKey, err := ParsePKCS8PrivateKey(hk.MLDSA.FullBytes)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewHybridPrivateKey(ecdsaKey, mldsaKey)
+}
+
+func decodeHybridKey(raw []byte, pemType string) (*hybridKey, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("failed decoding PEM, block must be different from nil")
+	}
+	if block.Type != pemType {
+		return nil, fmt.Errorf("unexpected PEM type [%s], expected [%s]", block.Type, pemType)
+	}
+
+	var hk hybridKey
+	rest, err := asn1.Unmarshal(block.Bytes, &hk)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse hybrid key [%v]", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after hybrid key")
+	}
+
+	return &hk, nil
+}
@@ -0,0 +1,433 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package mldsa
+
+import (
+	"crypto"
+	"crypto/subtle"
+	"encoding/asn1"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+
+	"github.com/cloudflare/circl/sign"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa44"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa65"
+	"github.com/cloudflare/circl/sign/mldsa/mldsa87"
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+	"github.com/geistwelt/quarkx/bccsp/secret"
+)
+
+// 参数集的名称，与 FIPS 204 一致。
+const (
+	MLDSA44 = "ML-DSA-44"
+	MLDSA65 = "ML-DSA-65"
+	MLDSA87 = "ML-DSA-87"
+)
+
+// SeedSize 是私钥种子的字节长度。
+const SeedSize = 32
+
+var schemes = map[string]sign.Scheme{
+	MLDSA44: mldsa44.Scheme(),
+	MLDSA65: mldsa65.Scheme(),
+	MLDSA87: mldsa87.Scheme(),
+}
+
+// oids 是 NIST 为各参数集分配的算法标识（csor sigAlgs 分支），这里不使用 circl 提供的
+// Oid()，它少了 sigAlgs 这一级。
+var oids = map[string]asn1.ObjectIdentifier{
+	MLDSA44: {2, 16, 840, 1, 101, 3, 4, 3, 17},
+	MLDSA65: {2, 16, 840, 1, 101, 3, 4, 3, 18},
+	MLDSA87: {2, 16, 840, 1, 101, 3, 4, 3, 19},
+}
+
+func schemeByName(name string) (sign.Scheme, error) {
+	scheme, ok := schemes[name]
+	if !ok {
+		return nil, fmt.Errorf("unsupported ML-DSA parameter set [%s]", name)
+	}
+	return scheme, nil
+}
+
+func schemeByOID(oid asn1.ObjectIdentifier) (sign.Scheme, error) {
+	for name, id := range oids {
+		if id.Equal(oid) {
+			return schemes[name], nil
+		}
+	}
+	return nil, fmt.Errorf("unsupported ML-DSA algorithm identifier [%s]", oid)
+}
+
+func oidOf(scheme sign.Scheme) asn1.ObjectIdentifier {
+	return oids[scheme.Name()]
+}
+
+// PublicKey 是 ML-DSA 公钥。
+type PublicKey struct {
+	scheme sign.Scheme
+	pk     sign.PublicKey
+}
+
+// PrivateKey 是 ML-DSA 私钥，它实现了 crypto.Signer。私钥由 32 字节的种子派生，导出时
+// 只保存种子。私钥在 fmt 与日志中只显示为 [REDACTED]。
+type PrivateKey struct {
+	secret.Redacted
+
+	mutex  sync.RWMutex
+	scheme sign.Scheme
+	seed   []byte
+	sk     sign.PrivateKey
+	pub    *PublicKey
+}
+
+// GenerateKey 使用 entropy.Reader 生成参数集为 parameterSet 的 ML-DSA 私钥。
+func GenerateKey(parameterSet string) (*PrivateKey, error) {
+	return GenerateKeyWithRand(entropy.Reader, parameterSet)
+}
+
+// GenerateKeyWithRand 使用随机源 rand 生成参数集为 parameterSet 的 ML-DSA 私钥。
+func GenerateKeyWithRand(rand io.Reader, parameterSet string) (*PrivateKey, error) {
+	seed := make([]byte, SeedSize)
+	if _, err := io.ReadFull(rand, seed); err != nil {
+		return nil, fmt.Errorf("failed to generate ML-DSA seed [%v]", err)
+	}
+
+	return NewKeyFromSeed(parameterSet, seed)
+}
+
+// NewKeyFromSeed 由 32 字节的种子确定性地派生私钥。
+func NewKeyFromSeed(parameterSet string, seed []byte) (*PrivateKey, error) {
+	scheme, err := schemeByName(parameterSet)
+	if err != nil {
+		return nil, err
+	}
+
+	return newKeyFromSeed(scheme, seed)
+}
+
+func newKeyFromSeed(scheme sign.Scheme, seed []byte) (*PrivateKey, error) {
+	if len(seed) != SeedSize {
+		return nil, fmt.Errorf("invalid ML-DSA seed length %d, must be %d", len(seed), SeedSize)
+	}
+
+	pk, sk := scheme.DeriveKey(seed)
+	return &PrivateKey{
+		scheme: scheme,
+		seed:   append([]byte{}, seed...),
+		sk:     sk,
+		pub:    &PublicKey{scheme: scheme, pk: pk},
+	}, nil
+}
+
+// ParameterSet 返回私钥的参数集。
+func (k *PrivateKey) ParameterSet() string {
+	return k.scheme.Name()
+}
+
+// Seed 返回派生私钥的种子。
+func (k *PrivateKey) Seed() []byte {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	return append([]byte{}, k.seed...)
+}
+
+// Public 返回 *PublicKey。
+func (k *PrivateKey) Public() crypto.PublicKey {
+	return k.pub
+}
+
+// PublicKey 返回私钥对应的公钥。
+func (k *PrivateKey) PublicKey() *PublicKey {
+	return k.pub
+}
+
+// Sign 对完整的消息进行签名（纯 ML-DSA，上下文为空）。ML-DSA 内部会对消息进行哈希，
+// 因此 opts.HashFunc() 必须为 0，不能传入消息的摘要。
+//
+// rand 不为 nil 时使用 FIPS 204 推荐的 hedged 签名，签名中混入 32 字节的随机数，能够抵御
+// 针对确定性签名的故障注入与侧信道攻击。circl 只从 crypto/rand 读取这 32 字节，不能指定
+// 随机源，因此 rand 只决定是否使用 hedged 签名，其内容不会被读取。rand 为 nil 时使用
+// 确定性签名，相同的私钥与消息总是得到相同的签名。
+func (k *PrivateKey) Sign(rand io.Reader, msg []byte, opts crypto.SignerOpts) ([]byte, error) {
+	if opts != nil && opts.HashFunc() != 0 {
+		return nil, errors.New("ML-DSA signs the message itself, opts.HashFunc() must be zero")
+	}
+
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	if k.sk == nil {
+		return nil, errDestroyed
+	}
+
+	sig := make([]byte, k.scheme.SignatureSize())
+	var err error
+	switch sk := k.sk.(type) {
+	case *mldsa44.PrivateKey:
+		err = mldsa44.SignTo(sk, msg, nil, rand != nil, sig)
+	case *mldsa65.PrivateKey:
+		err = mldsa65.SignTo(sk, msg, nil, rand != nil, sig)
+	case *mldsa87.PrivateKey:
+		err = mldsa87.SignTo(sk, msg, nil, rand != nil, sig)
+	default:
+		return nil, fmt.Errorf("unsupported ML-DSA private key type [%T]", k.sk)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign with ML-DSA [%v]", err)
+	}
+
+	return sig, nil
+}
+
+// Equal 判断两个私钥是否相同。
+func (k *PrivateKey) Equal(x crypto.PrivateKey) bool {
+	other, ok := x.(*PrivateKey)
+	if !ok {
+		return false
+	}
+
+	// 私钥总是由种子派生，比较种子即可。依次读取两个种子，避免同时持有两把锁。
+	otherSeed := other.Seed()
+	defer clear(otherSeed)
+
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	return k.sk != nil && len(otherSeed) != 0 && k.scheme == other.scheme && subtle.ConstantTimeCompare(k.seed, otherSeed) == 1
+}
+
+var errDestroyed = errors.New("ML-DSA private key has been destroyed")
+
+// Destroy 将种子与展开后的私钥清零，之后私钥不能再用于签名或导出。Destroy 会等待正在
+// 进行的签名完成。
+func (k *PrivateKey) Destroy() {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	clear(k.seed)
+	k.seed = nil
+
+	switch sk := k.sk.(type) {
+	case *mldsa44.PrivateKey:
+		*sk = mldsa44.PrivateKey{}
+	case *mldsa65.PrivateKey:
+		*sk = mldsa65.PrivateKey{}
+	case *mldsa87.PrivateKey:
+		*sk = mldsa87.PrivateKey{}
+	}
+	k.sk = nil
+}
+
+// ParameterSet 返回公钥的参数集。
+func (k *PublicKey) ParameterSet() string {
+	return k.scheme.Name()
+}
+
+// Bytes 返回 FIPS 204 定义的公钥编码。
+func (k *PublicKey) Bytes() []byte {
+	raw, _ := k.pk.MarshalBinary()
+	return raw
+}
+
+// Equal 判断两个公钥是否相同。
+func (k *PublicKey) Equal(x crypto.PublicKey) bool {
+	other, ok := x.(*PublicKey)
+	return ok && k.scheme == other.scheme && subtle.ConstantTimeCompare(k.Bytes(), other.Bytes()) == 1
+}
+
+// Verify 验证 sig 是否为 msg 的有效签名。
+func (k *PublicKey) Verify(msg, sig []byte) bool {
+	if len(sig) != k.scheme.SignatureSize() {
+		return false
+	}
+	return k.scheme.Verify(k.pk, msg, sig, nil)
+}
+
+// NewPublicKey 由 FIPS 204 定义的公钥编码创建公钥。
+func NewPublicKey(parameterSet string, raw []byte) (*PublicKey, error) {
+	scheme, err := schemeByName(parameterSet)
+	if err != nil {
+		return nil, err
+	}
+
+	return newPublicKey(scheme, raw)
+}
+
+func newPublicKey(scheme sign.Scheme, raw []byte) (*PublicKey, error) {
+	pk, err := scheme.UnmarshalBinaryPublicKey(raw)
+	if err != nil {
+		return nil, fmt.Errorf("invalid %s public key [%v]", scheme.Name(), err)
+	}
+	return &PublicKey{scheme: scheme, pk: pk}, nil
+}
+
+type algorithmIdentifier struct {
+	Algorithm asn1.ObjectIdentifier
+}
+
+type subjectPublicKeyInfo struct {
+	Algorithm algorithmIdentifier
+	PublicKey asn1.BitString
+}
+
+type oneAsymmetricKey struct {
+	Version    int
+	Algorithm  algorithmIdentifier
+	PrivateKey []byte
+	Attributes asn1.RawValue `asn1:"optional,tag:0"`
+	PublicKey  asn1.RawValue `asn1:"optional,tag:1"`
+}
+
+// bothFormat 是同时包含种子与展开之后的私钥的编码。
+type bothFormat struct {
+	Seed        []byte
+	ExpandedKey []byte
+}
+
+// MarshalPKIXPublicKey 将公钥编码成 SubjectPublicKeyInfo，算法标识没有参数。
+func MarshalPKIXPublicKey(pub *PublicKey) ([]byte, error) {
+	if pub == nil {
+		return nil, errors.New("invalid ML-DSA public key, it must be different from nil")
+	}
+
+	raw := pub.Bytes()
+	return asn1.Marshal(subjectPublicKeyInfo{
+		Algorithm: algorithmIdentifier{Algorithm: oidOf(pub.scheme)},
+		PublicKey: asn1.BitString{Bytes: raw, BitLength: 8 * len(raw)},
+	})
+}
+
+// ParsePKIXPublicKey 解析 SubjectPublicKeyInfo 格式的 ML-DSA 公钥。
+func ParsePKIXPublicKey(der []byte) (*PublicKey, error) {
+	var spki subjectPublicKeyInfo
+	rest, err := asn1.Unmarshal(der, &spki)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ML-DSA public key [%v]", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after ML-DSA public key")
+	}
+
+	scheme, err := schemeByOID(spki.Algorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+	if spki.PublicKey.BitLength != 8*len(spki.PublicKey.Bytes) {
+		return nil, errors.New("invalid ML-DSA public key bit string")
+	}
+
+	return newPublicKey(scheme, spki.PublicKey.Bytes)
+}
+
+// MarshalPKCS8PrivateKey 将私钥编码成 PKCS #8，私钥只保存 32 字节的种子。
+func MarshalPKCS8PrivateKey(key *PrivateKey) ([]byte, error) {
+	if key == nil {
+		return nil, errors.New("invalid ML-DSA private key, it must be different from nil")
+	}
+
+	key.mutex.RLock()
+	defer key.mutex.RUnlock()
+
+	if key.sk == nil {
+		return nil, errDestroyed
+	}
+
+	seed, err := asn1.Marshal(asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 0, Bytes: key.seed})
+	if err != nil {
+		return nil, err
+	}
+
+	return asn1.Marshal(oneAsymmetricKey{
+		Algorithm:  algorithmIdentifier{Algorithm: oidOf(key.scheme)},
+		PrivateKey: seed,
+	})
+}
+
+// ParsePKCS8PrivateKey 解析 PKCS #8 格式的 ML-DSA 私钥，私钥可以是种子、展开之后的私钥
+// 或者两者，只有包含种子的私钥可以被重新导出。
+func ParsePKCS8PrivateKey(der []byte) (*PrivateKey, error) {
+	var key oneAsymmetricKey
+	rest, err := asn1.Unmarshal(der, &key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse ML-DSA private key [%v]", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after ML-DSA private key")
+	}
+
+	scheme, err := schemeByOID(key.Algorithm.Algorithm)
+	if err != nil {
+		return nil, err
+	}
+
+	var inner asn1.RawValue
+	if rest, err := asn1.Unmarshal(key.PrivateKey, &inner); err != nil || len(rest) != 0 {
+		return nil, errors.New("malformed ML-DSA private key")
+	}
+
+	switch {
+	case inner.Class == asn1.ClassContextSpecific && inner.Tag == 0:
+		return newKeyFromSeed(scheme, inner.Bytes)
+	case inner.Class == asn1.ClassUniversal && inner.Tag == asn1.TagSequence:
+		var both bothFormat
+		if _, err := asn1.Unmarshal(inner.FullBytes, &both); err != nil {
+			return nil, fmt.Errorf("malformed ML-DSA private key [%v]", err)
+		}
+		k, err := newKeyFromSeed(scheme, both.Seed)
+		if err != nil {
+			return nil, err
+		}
+		expanded, _ := k.sk.MarshalBinary()
+		if subtle.ConstantTimeCompare(expanded, both.ExpandedKey) != 1 {
+			return nil, errors.New("ML-DSA expanded private key does not match its seed")
+		}
+		return k, nil
+	case inner.Class == asn1.ClassUniversal && inner.Tag == asn1.TagOctetString:
+		return nil, errors.New("ML-DSA private keys without a seed are not supported")
+	default:
+		return nil, errors.New("malformed ML-DSA private key")
+	}
+}
+
+// PrivateKeyToPEM 将私钥编码成 PEM 格式的 PKCS #8。
+func PrivateKeyToPEM(key *PrivateKey) ([]byte, error) {
+	der, err := MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// PEMToPrivateKey 解析 PEM 格式的 PKCS #8 私钥。
+func PEMToPrivateKey(raw []byte) (*PrivateKey, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("failed decoding PEM, block must be different from nil")
+	}
+	return ParsePKCS8PrivateKey(block.Bytes)
+}
+
+// PublicKeyToPEM 将公钥编码成 PEM 格式的 SubjectPublicKeyInfo。
+func PublicKeyToPEM(pub *PublicKey) ([]byte, error) {
+	der, err := MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, err
+	}
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// PEMToPublicKey 解析 PEM 格式的 SubjectPublicKeyInfo 公钥。
+func PEMToPublicKey(raw []byte) (*PublicKey, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("failed decoding PEM, block must be different from nil")
+	}
+	return ParsePKIXPublicKey(block.Bytes)
+}
@@ -0,0 +1,274 @@
+package mldsa
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/pem"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+	"github.com/geistwelt/quarkx/bccsp/secret"
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSignAndVerify(t *testing.T) {
+	msg := []byte("hello, quarkx")
+	for _, set := range []string{MLDSA44, MLDSA65, MLDSA87} {
+		t.Run(set, func(t *testing.T) {
+			sk, err := GenerateKey(set)
+			require.NoError(t, err)
+			require.Equal(t, set, sk.ParameterSet())
+
+			var signer crypto.Signer = sk
+			sig, err := signer.Sign(rand.Reader, msg, crypto.Hash(0))
+			require.NoError(t, err)
+
+			pub := sk.PublicKey()
+			require.True(t, pub.Verify(msg, sig))
+			require.False(t, pub.Verify([]byte("hello, fabric"), sig))
+			require.False(t, pub.Verify(msg, sig[1:]))
+
+			_, err = sk.Sign(rand.Reader, msg, crypto.SHA256)
+			require.EqualError(t, err, "ML-DSA signs the message itself, opts.HashFunc() must be zero")
+
+			require.Equal(t, secret.Placeholder, fmt.Sprintf("%v", sk))
+			require.Equal(t, secret.Placeholder, fmt.Sprintf("%+v", sk))
+
+			sk.Destroy()
+			_, err = sk.Sign(rand.Reader, msg, crypto.Hash(0))
+			require.EqualError(t, err, "ML-DSA private key has been destroyed")
+			_, err = MarshalPKCS8PrivateKey(sk)
+			require.EqualError(t, err, "ML-DSA private key has been destroyed")
+		})
+	}
+
+	_, err := GenerateKey("ML-DSA-128")
+	require.EqualError(t, err, "unsupported ML-DSA parameter set [ML-DSA-128]")
+}
+
+func TestNewKeyFromSeed(t *testing.T) {
+	seed := bytes.Repeat([]byte{7}, SeedSize)
+	sk1, err := NewKeyFromSeed(MLDSA65, seed)
+	require.NoError(t, err)
+	sk2, err := NewKeyFromSeed(MLDSA65, seed)
+	require.NoError(t, err)
+	require.True(t, sk1.Equal(sk2))
+	require.True(t, sk1.PublicKey().Equal(sk2.Public()))
+	require.Equal(t, seed, sk1.Seed())
+
+	other, err := NewKeyFromSeed(MLDSA44, seed)
+	require.NoError(t, err)
+	require.False(t, sk1.Equal(other))
+
+	pub, err := NewPublicKey(MLDSA65, sk1.PublicKey().Bytes())
+	require.NoError(t, err)
+	require.True(t, pub.Equal(sk1.PublicKey()))
+
+	_, err = NewKeyFromSeed(MLDSA65, seed[1:])
+	require.EqualError(t, err, "invalid ML-DSA seed length 31, must be 32")
+}
+
+func TestGenerateKeyWithDeterministicEntropy(t *testing.T) {
+	a, err := GenerateKeyWithRand(entropy.NewDeterministic([]byte("seed")), MLDSA65)
+	require.NoError(t, err)
+	b, err := GenerateKeyWithRand(entropy.NewDeterministic([]byte("seed")), MLDSA65)
+	require.NoError(t, err)
+	require.True(t, a.Equal(b))
+
+	c, err := GenerateKeyWithRand(entropy.NewDeterministic([]byte("other seed")), MLDSA65)
+	require.NoError(t, err)
+	require.False(t, a.Equal(c))
+}
+
+func TestPEM(t *testing.T) {
+	sk, err := GenerateKey(MLDSA44)
+	require.NoError(t, err)
+
+	raw, err := PrivateKeyToPEM(sk)
+	require.NoError(t, err)
+	block, _ := pem.Decode(raw)
+	require.Equal(t, "PRIVATE KEY", block.Type)
+	parsed, err := PEMToPrivateKey(raw)
+	require.NoError(t, err)
+	require.True(t, sk.Equal(parsed))
+
+	raw, err = PublicKeyToPEM(sk.PublicKey())
+	require.NoError(t, err)
+	block, _ = pem.Decode(raw)
+	require.Equal(t, "PUBLIC KEY", block.Type)
+	pub, err := PEMToPublicKey(raw)
+	require.NoError(t, err)
+	require.True(t, pub.Equal(sk.PublicKey()))
+
+	// 算法标识为 2.16.840.1.101.3.4.3.17，并且没有参数。
+	var spki subjectPublicKeyInfo
+	_, err = asn1.Unmarshal(block.Bytes, &spki)
+	require.NoError(t, err)
+	require.Equal(t, "2.16.840.1.101.3.4.3.17", spki.Algorithm.Algorithm.String())
+
+	// 同时包含种子和展开之后的私钥的编码也可以被解析，两者必须一致。
+	expanded, err := sk.sk.MarshalBinary()
+	require.NoError(t, err)
+	both, err := asn1.Marshal(bothFormat{Seed: sk.seed, ExpandedKey: expanded})
+	require.NoError(t, err)
+	der, err := asn1.Marshal(oneAsymmetricKey{Algorithm: algorithmIdentifier{Algorithm: oidOf(sk.scheme)}, PrivateKey: both})
+	require.NoError(t, err)
+	parsed, err = ParsePKCS8PrivateKey(der)
+	require.NoError(t, err)
+	require.True(t, sk.Equal(parsed))
+
+	expanded[0] ^= 1
+	both, err = asn1.Marshal(bothFormat{Seed: sk.seed, ExpandedKey: expanded})
+	require.NoError(t, err)
+	der, err = asn1.Marshal(oneAsymmetricKey{Algorithm: algorithmIdentifier{Algorithm: oidOf(sk.scheme)}, PrivateKey: both})
+	require.NoError(t, err)
+	_, err = ParsePKCS8PrivateKey(der)
+	require.EqualError(t, err, "ML-DSA expanded private key does not match its seed")
+
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	raw, err = utils.PublicKeyToPEM(&ecdsaKey.PublicKey)
+	require.NoError(t, err)
+	_, err = PEMToPublicKey(raw)
+	require.ErrorContains(t, err, "unsupported ML-DSA algorithm identifier [1.2.840.10045.2.1]")
+}
+
+func TestHybrid(t *testing.T) {
+	msg := []byte("hello, quarkx")
+	sk, err := GenerateHybridKey(elliptic.P256(), MLDSA65)
+	require.NoError(t, err)
+
+	sig, err := sk.Sign(rand.Reader, msg, nil)
+	require.NoError(t, err)
+	pub := sk.Public().(*HybridPublicKey)
+	require.NoError(t, pub.Verify(msg, sig))
+	require.EqualError(t, pub.Verify([]byte("hello, fabric"), sig), "invalid hybrid signature, both the ECDSA and the ML-DSA signatures are not valid")
+
+	var hs hybridSignature
+	_, err = asn1.Unmarshal(sig, &hs)
+	require.NoError(t, err)
+
+	// 任意一个签名被替换，混合签名都无效。
+	otherSig, err := sk.MLDSA.Sign(rand.Reader, []byte("hello, fabric"), nil)
+	require.NoError(t, err)
+	forged, err := asn1.Marshal(hybridSignature{ECDSA: hs.ECDSA, MLDSA: otherSig})
+	require.NoError(t, err)
+	require.EqualError(t, pub.Verify(msg, forged), "invalid hybrid signature, the ML-DSA signature is not valid")
+
+	digest := sha256.Sum256(msg)
+	otherSig, err = sk.ECDSA.Sign(rand.Reader, digest[:], nil)
+	require.NoError(t, err)
+	otherSig, err = utils.SignatureToLowS(pub.ECDSA, otherSig)
+	require.NoError(t, err)
+	forged, err = asn1.Marshal(hybridSignature{ECDSA: otherSig, MLDSA: hs.MLDSA})
+	require.NoError(t, err)
+	require.EqualError(t, pub.Verify(msg, forged), "invalid hybrid signature, the ECDSA signature is not valid")
+
+	// 剥离出来的 ML-DSA 签名不是该消息的普通 ML-DSA 签名。
+	require.False(t, pub.MLDSA.Verify(msg, hs.MLDSA))
+
+	// high-S 形式的 ECDSA 签名会被拒绝。
+	r, s, err := utils.UnmarshalECDSASignature(hs.ECDSA)
+	require.NoError(t, err)
+	highS, err := utils.MarshalECDSASignature(r, s.Sub(elliptic.P256().Params().N, s))
+	require.NoError(t, err)
+	forged, err = asn1.Marshal(hybridSignature{ECDSA: highS, MLDSA: hs.MLDSA})
+	require.NoError(t, err)
+	require.ErrorContains(t, pub.Verify(msg, forged), "invalid S, must be smaller than half the order")
+
+	_, err = sk.Sign(rand.Reader, msg, crypto.SHA256)
+	require.Error(t, err)
+
+	sk.Destroy()
+	_, err = sk.ECDSA.Sign(rand.Reader, digest[:], nil)
+	require.ErrorIs(t, err, utils.ErrKeyDestroyed)
+	_, err = sk.Sign(rand.Reader, msg, nil)
+	require.EqualError(t, err, "hybrid private key has been destroyed")
+	_, err = HybridPrivateKeyToPEM(sk)
+	require.EqualError(t, err, "hybrid private key has been destroyed")
+}
+
+func TestHybridPEM(t *testing.T) {
+	sk, err := GenerateHybridKey(elliptic.P384(), MLDSA87)
+	require.NoError(t, err)
+
+	raw, err := HybridPrivateKeyToPEM(sk)
+	require.NoError(t, err)
+	parsed, err := PEMToHybridPrivateKey(raw)
+	require.NoError(t, err)
+	require.True(t, sk.PublicKey().Equal(parsed.PublicKey()))
+	require.True(t, sk.MLDSA.Equal(parsed.MLDSA))
+	require.Equal(t, secret.Placeholder, fmt.Sprintf("%+v", parsed))
+
+	_, err = PEMToHybridPublicKey(raw)
+	require.EqualError(t, err, "unexpected PEM type [QUARKX HYBRID PRIVATE KEY], expected [QUARKX HYBRID PUBLIC KEY]")
+
+	raw, err = HybridPublicKeyToPEM(sk.PublicKey())
+	require.NoError(t, err)
+	pub, err := PEMToHybridPublicKey(raw)
+	require.NoError(t, err)
+	require.True(t, pub.Equal(sk.PublicKey()))
+
+	msg := []byte("hello, quarkx")
+	sig, err := parsed.Sign(rand.Reader, msg, nil)
+	require.NoError(t, err)
+	require.NoError(t, pub.Verify(msg, sig))
+}
+
+func TestHybridDestroyWhileSigning(t *testing.T) {
+	msg := []byte("hello, quarkx")
+	sk, err := GenerateHybridKey(elliptic.P256(), MLDSA44)
+	require.NoError(t, err)
+	pub := sk.PublicKey()
+
+	// 签名要么在 Destroy 之前完成并且有效，要么返回私钥已经被清零的错误。
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				sig, err := sk.Sign(rand.Reader, msg, nil)
+				if err != nil {
+					require.EqualError(t, err, "hybrid private key has been destroyed")
+					return
+				}
+				require.NoError(t, pub.Verify(msg, sig))
+			}
+		}()
+	}
+	sk.Destroy()
+	wg.Wait()
+}
+
+func TestHedgedSigning(t *testing.T) {
+	msg := []byte("hello, quarkx")
+	sk, err := GenerateKey(MLDSA44)
+	require.NoError(t, err)
+	pub := sk.PublicKey()
+
+	// 传入随机源时使用 hedged 签名，每次签名都不相同。
+	a, err := sk.Sign(rand.Reader, msg, nil)
+	require.NoError(t, err)
+	b, err := sk.Sign(rand.Reader, msg, nil)
+	require.NoError(t, err)
+	require.NotEqual(t, a, b)
+	require.True(t, pub.Verify(msg, a))
+	require.True(t, pub.Verify(msg, b))
+
+	// 随机源为 nil 时使用确定性签名。
+	a, err = sk.Sign(nil, msg, nil)
+	require.NoError(t, err)
+	b, err = sk.Sign(nil, msg, nil)
+	require.NoError(t, err)
+	require.Equal(t, a, b)
+	require.True(t, pub.Verify(msg, a))
+}
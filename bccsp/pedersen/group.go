@@ -0,0 +1,219 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package pedersen
+
+import (
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"math/big"
+	"sync"
+)
+
+// 承诺与范围证明都建立在 P-256 上，与 bccsp/utils 中的 ECDSA 密钥使用同一条曲线。
+var (
+	curve = elliptic.P256()
+	order = curve.Params().N
+)
+
+// pointSize 是压缩编码之后的点的字节长度。
+const pointSize = 33
+
+// point 是曲线上的点，(0, 0) 表示无穷远点，与 crypto/elliptic 的约定一致。
+type point struct {
+	x, y *big.Int
+}
+
+func identity() point {
+	return point{x: new(big.Int), y: new(big.Int)}
+}
+
+func basePoint() point {
+	params := curve.Params()
+	return point{x: new(big.Int).Set(params.Gx), y: new(big.Int).Set(params.Gy)}
+}
+
+func (p point) isIdentity() bool {
+	return p.x.Sign() == 0 && p.y.Sign() == 0
+}
+
+func (p point) add(q point) point {
+	x, y := curve.Add(p.x, p.y, q.x, q.y)
+	return point{x: x, y: y}
+}
+
+func (p point) neg() point {
+	if p.isIdentity() {
+		return p
+	}
+	return point{x: new(big.Int).Set(p.x), y: new(big.Int).Sub(curve.Params().P, p.y)}
+}
+
+func (p point) sub(q point) point {
+	return p.add(q.neg())
+}
+
+func (p point) mul(k *big.Int) point {
+	x, y := curve.ScalarMult(p.x, p.y, new(big.Int).Mod(k, order).Bytes())
+	return point{x: x, y: y}
+}
+
+func (p point) equal(q point) bool {
+	return p.x.Cmp(q.x) == 0 && p.y.Cmp(q.y) == 0
+}
+
+// bytes 返回点的 SEC 1 压缩编码，无穷远点编码成 33 个 0。
+func (p point) bytes() []byte {
+	if p.isIdentity() {
+		return make([]byte, pointSize)
+	}
+	return elliptic.MarshalCompressed(curve, p.x, p.y)
+}
+
+func parsePoint(raw []byte) (point, error) {
+	if len(raw) != pointSize {
+		return point{}, fmt.Errorf("invalid point length %d, must be %d", len(raw), pointSize)
+	}
+	if raw[0] == 0 {
+		for _, b := range raw {
+			if b != 0 {
+				return point{}, errors.New("invalid point encoding")
+			}
+		}
+		return identity(), nil
+	}
+
+	x, y := elliptic.UnmarshalCompressed(curve, raw)
+	if x == nil {
+		return point{}, errors.New("invalid point encoding, it is not on the curve")
+	}
+	return point{x: x, y: y}, nil
+}
+
+// multiScalarMul 计算 Σ scalars[i]·points[i]。
+func multiScalarMul(scalars []*big.Int, points []point) point {
+	result := identity()
+	for i := range scalars {
+		result = result.add(points[i].mul(scalars[i]))
+	}
+	return result
+}
+
+// hashToPoint 以 try-and-increment 的方式将标签映射到曲线上的点，没有人知道这个点相对于
+// 基点的离散对数。
+func hashToPoint(label string, index uint32) point {
+	params := curve.Params()
+	three := big.NewInt(3)
+	for counter := uint32(0); ; counter++ {
+		h := sha256.New()
+		h.Write([]byte(label))
+		binary.Write(h, binary.BigEndian, index)
+		binary.Write(h, binary.BigEndian, counter)
+		x := new(big.Int).SetBytes(h.Sum(nil))
+		if x.Cmp(params.P) >= 0 {
+			continue
+		}
+
+		// y² = x³ - 3x + b
+		y2 := new(big.Int).Exp(x, three, params.P)
+		y2.Sub(y2, new(big.Int).Mul(three, x))
+		y2.Add(y2, params.B)
+		y2.Mod(y2, params.P)
+		y := new(big.Int).ModSqrt(y2, params.P)
+		if y == nil {
+			continue
+		}
+		if y.Bit(0) == 1 {
+			y.Sub(params.P, y)
+		}
+		return point{x: x, y: y}
+	}
+}
+
+var (
+	generatorsOnce sync.Once
+	// h 是承诺中与盲化因子相乘的生成元。
+	h point
+	// u 是内积论证中与内积相乘的生成元。
+	u point
+	// gs 与 hs 是范围证明中的向量生成元。
+	gs, hs []point
+)
+
+func generators() {
+	generatorsOnce.Do(func() {
+		h = hashToPoint("quarkx/pedersen/H", 0)
+		u = hashToPoint("quarkx/bulletproofs/U", 0)
+		gs = make([]point, MaxBits)
+		hs = make([]point, MaxBits)
+		for i := range gs {
+			gs[i] = hashToPoint("quarkx/bulletproofs/G", uint32(i))
+			hs[i] = hashToPoint("quarkx/bulletproofs/H", uint32(i))
+		}
+	})
+}
+
+func randomScalar(random io.Reader) (*big.Int, error) {
+	for {
+		k, err := rand.Int(random, order)
+		if err != nil {
+			return nil, fmt.Errorf("failed to generate random scalar [%v]", err)
+		}
+		if k.Sign() != 0 {
+			return k, nil
+		}
+	}
+}
+
+func scalarBytes(k *big.Int) []byte {
+	return new(big.Int).Mod(k, order).FillBytes(make([]byte, 32))
+}
+
+// transcript 是 Fiat-Shamir 变换的记录，挑战值由此前写入的所有数据决定。
+type transcript struct {
+	h hash.Hash
+}
+
+func newTranscript(label string) *transcript {
+	t := &transcript{h: sha256.New()}
+	t.append("domain", []byte(label))
+	return t
+}
+
+func (t *transcript) append(label string, data []byte) {
+	binary.Write(t.h, binary.BigEndian, uint32(len(label)))
+	t.h.Write([]byte(label))
+	binary.Write(t.h, binary.BigEndian, uint32(len(data)))
+	t.h.Write(data)
+}
+
+func (t *transcript) appendPoint(label string, p point) {
+	t.append(label, p.bytes())
+}
+
+func (t *transcript) appendScalar(label string, k *big.Int) {
+	t.append(label, scalarBytes(k))
+}
+
+// challenge 返回一个非零的挑战值，并将其写回记录中。
+func (t *transcript) challenge(label string) *big.Int {
+	for {
+		t.append("challenge", []byte(label))
+		sum := t.h.Sum(nil)
+		t.h.Reset()
+		t.h.Write(sum)
+		c := new(big.Int).SetBytes(sum)
+		c.Mod(c, order)
+		if c.Sign() != 0 {
+			return c
+		}
+	}
+}
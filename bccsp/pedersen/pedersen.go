@@ -0,0 +1,105 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package pedersen
+
+import (
+	"errors"
+	"io"
+	"math/big"
+
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+)
+
+// Commitment 是 Pedersen 承诺 C = v·G + r·H，其中 v 是被隐藏的数值，r 是盲化因子，G 是
+// P-256 的基点，H 是没有人知道其离散对数的生成元。承诺是加法同态的：
+// Commit(v1, r1) + Commit(v2, r2) = Commit(v1 + v2, r1 + r2)。
+type Commitment struct {
+	p point
+}
+
+// NewBlinding 返回一个取自 entropy.Reader 的随机盲化因子。
+func NewBlinding() (*big.Int, error) {
+	return randomScalar(entropy.Reader)
+}
+
+// NewBlindingWithRand 返回一个取自 rand 的随机盲化因子。
+func NewBlindingWithRand(rand io.Reader) (*big.Int, error) {
+	return randomScalar(rand)
+}
+
+// AddBlindings 返回 a + b mod n，与承诺相加之后的盲化因子对应。
+func AddBlindings(a, b *big.Int) *big.Int {
+	sum := new(big.Int).Add(a, b)
+	return sum.Mod(sum, order)
+}
+
+// SubBlindings 返回 a - b mod n，与承诺相减之后的盲化因子对应。
+func SubBlindings(a, b *big.Int) *big.Int {
+	diff := new(big.Int).Sub(a, b)
+	return diff.Mod(diff, order)
+}
+
+// Commit 计算 value 在盲化因子 blinding 下的承诺，两者都按曲线的阶取模。
+func Commit(value, blinding *big.Int) *Commitment {
+	generators()
+	return &Commitment{p: basePoint().mul(value).add(h.mul(blinding))}
+}
+
+// CommitUint64 计算金额 value 在盲化因子 blinding 下的承诺。
+func CommitUint64(value uint64, blinding *big.Int) *Commitment {
+	return Commit(new(big.Int).SetUint64(value), blinding)
+}
+
+// Add 返回 c + other，它是两个数值之和在两个盲化因子之和下的承诺。
+func (c *Commitment) Add(other *Commitment) *Commitment {
+	return &Commitment{p: c.p.add(other.p)}
+}
+
+// Sub 返回 c - other，它是两个数值之差在两个盲化因子之差下的承诺。
+func (c *Commitment) Sub(other *Commitment) *Commitment {
+	return &Commitment{p: c.p.sub(other.p)}
+}
+
+// Equal 判断两个承诺是否相同。
+func (c *Commitment) Equal(other *Commitment) bool {
+	return other != nil && c.p.equal(other.p)
+}
+
+// Opens 判断 (value, blinding) 是否为承诺的打开值。
+func (c *Commitment) Opens(value, blinding *big.Int) bool {
+	return c.Equal(Commit(value, blinding))
+}
+
+// Bytes 返回承诺的 33 字节压缩编码。
+func (c *Commitment) Bytes() []byte {
+	return c.p.bytes()
+}
+
+// ParseCommitment 解析 Bytes 返回的编码。
+func ParseCommitment(raw []byte) (*Commitment, error) {
+	p, err := parsePoint(raw)
+	if err != nil {
+		return nil, err
+	}
+	return &Commitment{p: p}, nil
+}
+
+// VerifyBalance 检查输入承诺之和与输出承诺之和相差的正好是 excess·H，即输入与输出的金额
+// 相等。excess 是输入的盲化因子之和减去输出的盲化因子之和，由交易的创建者给出。
+func VerifyBalance(inputs, outputs []*Commitment, excess *big.Int) error {
+	sum := identity()
+	for _, c := range inputs {
+		sum = sum.add(c.p)
+	}
+	for _, c := range outputs {
+		sum = sum.sub(c.p)
+	}
+
+	if !sum.equal(Commit(new(big.Int), excess).p) {
+		return errors.New("the inputs and the outputs are not balanced")
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+package pedersen
+
+import (
+	"math"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func newBlinding(t *testing.T) *big.Int {
+	r, err := NewBlinding()
+	require.NoError(t, err)
+	return r
+}
+
+func TestCommitment(t *testing.T) {
+	r1, r2 := newBlinding(t), newBlinding(t)
+	c1 := CommitUint64(30, r1)
+	c2 := CommitUint64(12, r2)
+
+	require.True(t, c1.Opens(big.NewInt(30), r1))
+	require.False(t, c1.Opens(big.NewInt(31), r1))
+	require.False(t, c1.Opens(big.NewInt(30), r2))
+
+	require.True(t, c1.Add(c2).Equal(CommitUint64(42, AddBlindings(r1, r2))))
+	require.True(t, c1.Sub(c2).Equal(CommitUint64(18, SubBlindings(r1, r2))))
+	require.True(t, c2.Sub(c1).Opens(big.NewInt(-18), SubBlindings(r2, r1)))
+
+	parsed, err := ParseCommitment(c1.Bytes())
+	require.NoError(t, err)
+	require.True(t, parsed.Equal(c1))
+
+	zero := Commit(new(big.Int), new(big.Int))
+	require.Equal(t, make([]byte, 33), zero.Bytes())
+	parsed, err = ParseCommitment(zero.Bytes())
+	require.NoError(t, err)
+	require.True(t, parsed.Equal(c1.Sub(c1)))
+
+	_, err = ParseCommitment(c1.Bytes()[1:])
+	require.EqualError(t, err, "invalid point length 32, must be 33")
+	raw := c1.Bytes()
+	raw[0] = 5
+	_, err = ParseCommitment(raw)
+	require.EqualError(t, err, "invalid point encoding, it is not on the curve")
+}
+
+func TestVerifyBalance(t *testing.T) {
+	in1, in2, out1, out2 := newBlinding(t), newBlinding(t), newBlinding(t), newBlinding(t)
+	inputs := []*Commitment{CommitUint64(70, in1), CommitUint64(30, in2)}
+	outputs := []*Commitment{CommitUint64(55, out1), CommitUint64(45, out2)}
+	excess := SubBlindings(AddBlindings(in1, in2), AddBlindings(out1, out2))
+	require.NoError(t, VerifyBalance(inputs, outputs, excess))
+
+	outputs[1] = CommitUint64(46, out2)
+	require.EqualError(t, VerifyBalance(inputs, outputs, excess), "the inputs and the outputs are not balanced")
+}
+
+func TestRangeProof(t *testing.T) {
+	for _, tc := range []struct {
+		value uint64
+		bits  int
+	}{
+		{0, 8},
+		{255, 8},
+		{1, 1},
+		{40000, 16},
+		{math.MaxUint64, 64},
+	} {
+		r := newBlinding(t)
+		c := CommitUint64(tc.value, r)
+		proof, err := ProveRange(tc.value, r, tc.bits)
+		require.NoError(t, err)
+		require.Equal(t, tc.bits, proof.Bits())
+		require.NoError(t, VerifyRange(c, proof))
+
+		raw, err := proof.Bytes()
+		require.NoError(t, err)
+		parsed, err := ParseRangeProof(raw)
+		require.NoError(t, err)
+		require.NoError(t, VerifyRange(c, parsed))
+
+		// 证明与其它的承诺不匹配。
+		require.ErrorIs(t, VerifyRange(CommitUint64(tc.value, newBlinding(t)), proof), ErrInvalidRangeProof)
+	}
+
+	_, err := ProveRange(256, newBlinding(t), 8)
+	require.EqualError(t, err, "value 256 is out of the range [0, 2^8)")
+	_, err = ProveRange(1, newBlinding(t), 12)
+	require.EqualError(t, err, "invalid number of bits 12, must be a power of 2 not larger than 64")
+}
+
+func TestRangeProofTampering(t *testing.T) {
+	r := newBlinding(t)
+	c := CommitUint64(1000, r)
+	proof, err := ProveRange(1000, r, 32)
+	require.NoError(t, err)
+
+	// 超出范围的数值：对 2^32 + 1000 的承诺使用同一个证明无法通过验证。
+	overflow := c.Add(Commit(new(big.Int).Lsh(big.NewInt(1), 32), new(big.Int)))
+	require.ErrorIs(t, VerifyRange(overflow, proof), ErrInvalidRangeProof)
+
+	// 负数对应的承诺也无法通过验证。
+	negative := c.Sub(CommitUint64(1001, new(big.Int)))
+	require.ErrorIs(t, VerifyRange(negative, proof), ErrInvalidRangeProof)
+
+	tampered := *proof
+	tampered.t = AddBlindings(proof.t, big.NewInt(1))
+	require.ErrorIs(t, VerifyRange(c, &tampered), ErrInvalidRangeProof)
+
+	tampered = *proof
+	tampered.aFinal = AddBlindings(proof.aFinal, big.NewInt(1))
+	require.ErrorIs(t, VerifyRange(c, &tampered), ErrInvalidRangeProof)
+
+	tampered = *proof
+	tampered.ls = append([]point{proof.rs[0]}, proof.ls[1:]...)
+	require.ErrorIs(t, VerifyRange(c, &tampered), ErrInvalidRangeProof)
+
+	raw, err := proof.Bytes()
+	require.NoError(t, err)
+	_, err = ParseRangeProof(append(raw, 0))
+	require.EqualError(t, err, "trailing data after range proof")
+}
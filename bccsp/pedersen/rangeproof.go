@@ -0,0 +1,411 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package pedersen
+
+import (
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+)
+
+// MaxBits 是范围证明支持的最大位数，金额以 uint64 表示。
+const MaxBits = 64
+
+const rangeProofLabel = "quarkx/bulletproofs/range"
+
+// ErrInvalidRangeProof 表示范围证明没有通过验证。
+var ErrInvalidRangeProof = errors.New("invalid range proof")
+
+// RangeProof 是 Bulletproofs 范围证明，证明承诺中的数值 v 满足 0 <= v < 2^bits，证明的
+// 长度只与 bits 的对数相关。
+type RangeProof struct {
+	a, s, t1, t2 point
+	tauX, mu, t  *big.Int
+	ls, rs       []point
+	aFinal       *big.Int
+	bFinal       *big.Int
+}
+
+type rangeProofASN1 struct {
+	A, S, T1, T2 []byte
+	TauX, Mu, T  *big.Int
+	L, R         [][]byte
+	AFinal       *big.Int
+	BFinal       *big.Int
+}
+
+// Bits 返回证明所覆盖的位数。
+func (p *RangeProof) Bits() int {
+	return 1 << len(p.ls)
+}
+
+func checkBits(bits int) error {
+	if bits <= 0 || bits > MaxBits || bits&(bits-1) != 0 {
+		return fmt.Errorf("invalid number of bits %d, must be a power of 2 not larger than %d", bits, MaxBits)
+	}
+	return nil
+}
+
+// ProveRange 证明 CommitUint64(value, blinding) 中的数值位于 [0, 2^bits) 内，bits 必须是
+// 不超过 64 的 2 的幂。证明中的随机数取自 entropy.Reader。
+func ProveRange(value uint64, blinding *big.Int, bits int) (*RangeProof, error) {
+	return ProveRangeWithRand(entropy.Reader, value, blinding, bits)
+}
+
+// ProveRangeWithRand 与 ProveRange 相同，证明中的随机数取自 rand。
+func ProveRangeWithRand(rand io.Reader, value uint64, blinding *big.Int, bits int) (*RangeProof, error) {
+	if err := checkBits(bits); err != nil {
+		return nil, err
+	}
+	if bits < 64 && value>>bits != 0 {
+		return nil, fmt.Errorf("value %d is out of the range [0, 2^%d)", value, bits)
+	}
+
+	generators()
+	n := bits
+	g := basePoint()
+	v := CommitUint64(value, blinding)
+
+	tr := newTranscript(rangeProofLabel)
+	tr.append("n", []byte{byte(n)})
+	tr.appendPoint("V", v.p)
+
+	// aL 是数值的二进制表示，aR = aL - 1。
+	aL := make([]*big.Int, n)
+	aR := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		aL[i] = big.NewInt(int64(value >> i & 1))
+		aR[i] = mod(new(big.Int).Sub(aL[i], one))
+	}
+
+	alpha, err := randomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	A := h.mul(alpha).add(multiScalarMul(aL, gs[:n])).add(multiScalarMul(aR, hs[:n]))
+
+	sL, err := randomScalars(rand, n)
+	if err != nil {
+		return nil, err
+	}
+	sR, err := randomScalars(rand, n)
+	if err != nil {
+		return nil, err
+	}
+	rho, err := randomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	S := h.mul(rho).add(multiScalarMul(sL, gs[:n])).add(multiScalarMul(sR, hs[:n]))
+
+	tr.appendPoint("A", A)
+	tr.appendPoint("S", S)
+	y := tr.challenge("y")
+	z := tr.challenge("z")
+	zz := mod(new(big.Int).Mul(z, z))
+	yn := powers(y, n)
+	twon := powers(two, n)
+
+	// l(X) = (aL - z·1) + sL·X，r(X) = yⁿ∘(aR + z·1 + sR·X) + z²·2ⁿ
+	l0 := make([]*big.Int, n)
+	r0 := make([]*big.Int, n)
+	r1 := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		l0[i] = mod(new(big.Int).Sub(aL[i], z))
+		r0[i] = mod(new(big.Int).Add(new(big.Int).Mul(yn[i], new(big.Int).Add(aR[i], z)), new(big.Int).Mul(zz, twon[i])))
+		r1[i] = mod(new(big.Int).Mul(yn[i], sR[i]))
+	}
+	t1 := mod(new(big.Int).Add(innerProduct(l0, r1), innerProduct(sL, r0)))
+	t2 := innerProduct(sL, r1)
+
+	tau1, err := randomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	tau2, err := randomScalar(rand)
+	if err != nil {
+		return nil, err
+	}
+	T1 := g.mul(t1).add(h.mul(tau1))
+	T2 := g.mul(t2).add(h.mul(tau2))
+
+	tr.appendPoint("T1", T1)
+	tr.appendPoint("T2", T2)
+	x := tr.challenge("x")
+
+	// τx = τ2·x² + τ1·x + z²·γ，μ = α + ρ·x
+	tauX := mod(new(big.Int).Add(new(big.Int).Add(new(big.Int).Mul(tau2, new(big.Int).Mul(x, x)), new(big.Int).Mul(tau1, x)), new(big.Int).Mul(zz, blinding)))
+	mu := mod(new(big.Int).Add(alpha, new(big.Int).Mul(rho, x)))
+
+	l := make([]*big.Int, n)
+	r := make([]*big.Int, n)
+	for i := 0; i < n; i++ {
+		l[i] = mod(new(big.Int).Add(l0[i], new(big.Int).Mul(sL[i], x)))
+		r[i] = mod(new(big.Int).Add(r0[i], new(big.Int).Mul(r1[i], x)))
+	}
+	t := innerProduct(l, r)
+
+	tr.appendScalar("tauX", tauX)
+	tr.appendScalar("mu", mu)
+	tr.appendScalar("t", t)
+	uw := u.mul(tr.challenge("w"))
+
+	// H'ᵢ = y⁻ⁱ·Hᵢ，此时 P = <l, G> + <r, H'>。
+	hPrime := scaledHs(y, n)
+
+	proof := &RangeProof{a: A, s: S, t1: T1, t2: T2, tauX: tauX, mu: mu, t: t}
+	proof.ls, proof.rs, proof.aFinal, proof.bFinal = proveInnerProduct(tr, append([]point{}, gs[:n]...), hPrime, uw, l, r)
+
+	return proof, nil
+}
+
+// proveInnerProduct 生成内积论证，证明 P = <a, G> + <b, H> + <a, b>·u。
+func proveInnerProduct(tr *transcript, G, H []point, u point, a, b []*big.Int) ([]point, []point, *big.Int, *big.Int) {
+	var ls, rs []point
+	for len(a) > 1 {
+		n := len(a) / 2
+		cL := innerProduct(a[:n], b[n:])
+		cR := innerProduct(a[n:], b[:n])
+		L := multiScalarMul(a[:n], G[n:]).add(multiScalarMul(b[n:], H[:n])).add(u.mul(cL))
+		R := multiScalarMul(a[n:], G[:n]).add(multiScalarMul(b[:n], H[n:])).add(u.mul(cR))
+		ls = append(ls, L)
+		rs = append(rs, R)
+
+		tr.appendPoint("L", L)
+		tr.appendPoint("R", R)
+		x := tr.challenge("u")
+		xInv := new(big.Int).ModInverse(x, order)
+
+		G = foldPoints(G, xInv, x)
+		H = foldPoints(H, x, xInv)
+		a = foldScalars(a, x, xInv)
+		b = foldScalars(b, xInv, x)
+	}
+
+	return ls, rs, a[0], b[0]
+}
+
+// VerifyRange 验证 proof 是否证明了承诺 c 中的数值位于 [0, 2^proof.Bits()) 内。
+func VerifyRange(c *Commitment, proof *RangeProof) error {
+	if c == nil || proof == nil {
+		return errors.New("invalid range proof, commitment and proof must be different from nil")
+	}
+	n := proof.Bits()
+	if err := checkBits(n); err != nil {
+		return err
+	}
+	if len(proof.rs) != len(proof.ls) {
+		return errors.New("invalid range proof, L and R must have the same length")
+	}
+
+	generators()
+	g := basePoint()
+
+	tr := newTranscript(rangeProofLabel)
+	tr.append("n", []byte{byte(n)})
+	tr.appendPoint("V", c.p)
+	tr.appendPoint("A", proof.a)
+	tr.appendPoint("S", proof.s)
+	y := tr.challenge("y")
+	z := tr.challenge("z")
+	tr.appendPoint("T1", proof.t1)
+	tr.appendPoint("T2", proof.t2)
+	x := tr.challenge("x")
+	tr.appendScalar("tauX", proof.tauX)
+	tr.appendScalar("mu", proof.mu)
+	tr.appendScalar("t", proof.t)
+	uw := u.mul(tr.challenge("w"))
+
+	zz := mod(new(big.Int).Mul(z, z))
+	xx := mod(new(big.Int).Mul(x, x))
+	yn := powers(y, n)
+	twon := powers(two, n)
+
+	// t·G + τx·H = z²·V + δ(y, z)·G + x·T1 + x²·T2
+	// δ(y, z) = (z - z²)·<1, yⁿ> - z³·<1, 2ⁿ>
+	delta := new(big.Int).Mul(new(big.Int).Sub(z, zz), sum(yn))
+	delta.Sub(delta, new(big.Int).Mul(new(big.Int).Mul(zz, z), sum(twon)))
+	lhs := g.mul(proof.t).add(h.mul(proof.tauX))
+	rhs := c.p.mul(zz).add(g.mul(delta)).add(proof.t1.mul(x)).add(proof.t2.mul(xx))
+	if !lhs.equal(rhs) {
+		return ErrInvalidRangeProof
+	}
+
+	// P = A + x·S - z·<1, G> + <z·yⁿ + z²·2ⁿ, H'> - μ·H + t·u
+	hPrime := scaledHs(y, n)
+	negZ := mod(new(big.Int).Neg(z))
+	P := proof.a.add(proof.s.mul(x))
+	for i := 0; i < n; i++ {
+		P = P.add(gs[i].mul(negZ))
+		P = P.add(hPrime[i].mul(new(big.Int).Add(new(big.Int).Mul(z, yn[i]), new(big.Int).Mul(zz, twon[i]))))
+	}
+	P = P.sub(h.mul(proof.mu)).add(uw.mul(proof.t))
+
+	G := append([]point{}, gs[:n]...)
+	H := hPrime
+	for i := range proof.ls {
+		tr.appendPoint("L", proof.ls[i])
+		tr.appendPoint("R", proof.rs[i])
+		u := tr.challenge("u")
+		uInv := new(big.Int).ModInverse(u, order)
+
+		// P' = u²·L + P + u⁻²·R
+		P = proof.ls[i].mul(new(big.Int).Mul(u, u)).add(P).add(proof.rs[i].mul(new(big.Int).Mul(uInv, uInv)))
+		G = foldPoints(G, uInv, u)
+		H = foldPoints(H, u, uInv)
+	}
+
+	ab := mod(new(big.Int).Mul(proof.aFinal, proof.bFinal))
+	expected := G[0].mul(proof.aFinal).add(H[0].mul(proof.bFinal)).add(uw.mul(ab))
+	if !P.equal(expected) {
+		return ErrInvalidRangeProof
+	}
+
+	return nil
+}
+
+// Bytes 将范围证明编码成 ASN.1 DER。
+func (p *RangeProof) Bytes() ([]byte, error) {
+	enc := rangeProofASN1{
+		A: p.a.bytes(), S: p.s.bytes(), T1: p.t1.bytes(), T2: p.t2.bytes(),
+		TauX: p.tauX, Mu: p.mu, T: p.t,
+		AFinal: p.aFinal, BFinal: p.bFinal,
+	}
+	for i := range p.ls {
+		enc.L = append(enc.L, p.ls[i].bytes())
+		enc.R = append(enc.R, p.rs[i].bytes())
+	}
+	return asn1.Marshal(enc)
+}
+
+// ParseRangeProof 解析 Bytes 返回的编码，所有的标量都必须小于曲线的阶。
+func ParseRangeProof(raw []byte) (*RangeProof, error) {
+	var enc rangeProofASN1
+	rest, err := asn1.Unmarshal(raw, &enc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse range proof [%v]", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after range proof")
+	}
+	if len(enc.L) != len(enc.R) || 1<<len(enc.L) > MaxBits {
+		return nil, errors.New("invalid range proof, wrong number of inner product rounds")
+	}
+	for _, k := range []*big.Int{enc.TauX, enc.Mu, enc.T, enc.AFinal, enc.BFinal} {
+		if k.Sign() < 0 || k.Cmp(order) >= 0 {
+			return nil, errors.New("invalid range proof, scalar is out of range")
+		}
+	}
+
+	p := &RangeProof{tauX: enc.TauX, mu: enc.Mu, t: enc.T, aFinal: enc.AFinal, bFinal: enc.BFinal}
+	if p.a, err = parsePoint(enc.A); err != nil {
+		return nil, err
+	}
+	if p.s, err = parsePoint(enc.S); err != nil {
+		return nil, err
+	}
+	if p.t1, err = parsePoint(enc.T1); err != nil {
+		return nil, err
+	}
+	if p.t2, err = parsePoint(enc.T2); err != nil {
+		return nil, err
+	}
+	for i := range enc.L {
+		L, err := parsePoint(enc.L[i])
+		if err != nil {
+			return nil, err
+		}
+		R, err := parsePoint(enc.R[i])
+		if err != nil {
+			return nil, err
+		}
+		p.ls = append(p.ls, L)
+		p.rs = append(p.rs, R)
+	}
+
+	return p, nil
+}
+
+var (
+	one = big.NewInt(1)
+	two = big.NewInt(2)
+)
+
+func mod(k *big.Int) *big.Int {
+	return k.Mod(k, order)
+}
+
+func randomScalars(rand io.Reader, n int) ([]*big.Int, error) {
+	scalars := make([]*big.Int, n)
+	for i := range scalars {
+		k, err := randomScalar(rand)
+		if err != nil {
+			return nil, err
+		}
+		scalars[i] = k
+	}
+	return scalars, nil
+}
+
+// powers 返回 [1, k, k², ..., k^(n-1)]。
+func powers(k *big.Int, n int) []*big.Int {
+	result := make([]*big.Int, n)
+	result[0] = big.NewInt(1)
+	for i := 1; i < n; i++ {
+		result[i] = mod(new(big.Int).Mul(result[i-1], k))
+	}
+	return result
+}
+
+func sum(scalars []*big.Int) *big.Int {
+	result := new(big.Int)
+	for _, k := range scalars {
+		result.Add(result, k)
+	}
+	return mod(result)
+}
+
+func innerProduct(a, b []*big.Int) *big.Int {
+	result := new(big.Int)
+	for i := range a {
+		result.Add(result, new(big.Int).Mul(a[i], b[i]))
+	}
+	return mod(result)
+}
+
+// scaledHs 返回 [y⁰·H₀, y⁻¹·H₁, ..., y^-(n-1)·H(n-1)]。
+func scaledHs(y *big.Int, n int) []point {
+	yInvn := powers(new(big.Int).ModInverse(y, order), n)
+	result := make([]point, n)
+	for i := range result {
+		result[i] = hs[i].mul(yInvn[i])
+	}
+	return result
+}
+
+// foldPoints 返回 lo·P[:n/2] + hi·P[n/2:]。
+func foldPoints(P []point, lo, hi *big.Int) []point {
+	n := len(P) / 2
+	result := make([]point, n)
+	for i := range result {
+		result[i] = P[i].mul(lo).add(P[n+i].mul(hi))
+	}
+	return result
+}
+
+// foldScalars 返回 lo·a[:n/2] + hi·a[n/2:]。
+func foldScalars(a []*big.Int, lo, hi *big.Int) []*big.Int {
+	n := len(a) / 2
+	result := make([]*big.Int, n)
+	for i := range result {
+		result[i] = mod(new(big.Int).Add(new(big.Int).Mul(a[i], lo), new(big.Int).Mul(a[n+i], hi)))
+	}
+	return result
+}
@@ -0,0 +1,134 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package remote
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const defaultTimeout = 10 * time.Second
+
+// Client 是签名服务的客户端，它把 GetKey、Sign 和 Verify 请求转发给远程的签名服务。
+type Client struct {
+	conn    *grpc.ClientConn
+	client  SignerClient
+	timeout time.Duration
+}
+
+// Dial 通过双向 TLS 认证连接到 address 处的签名服务。
+func Dial(ctx context.Context, address string, tlsConfig *tls.Config, opts ...grpc.DialOption) (*Client, error) {
+	if tlsConfig == nil {
+		return nil, errors.New("invalid tls config, it must be different from nil")
+	}
+	if len(tlsConfig.Certificates) == 0 && tlsConfig.GetClientCertificate == nil {
+		return nil, errors.New("invalid tls config, a client certificate is required")
+	}
+
+	opts = append([]grpc.DialOption{
+		grpc.WithTransportCredentials(credentials.NewTLS(tlsConfig)),
+		grpc.WithBlock(),
+	}, opts...)
+
+	conn, err := grpc.DialContext(ctx, address, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to remote signer [%s] [%v]", address, err)
+	}
+
+	return &Client{
+		conn:    conn,
+		client:  NewSignerClient(conn),
+		timeout: defaultTimeout,
+	}, nil
+}
+
+// Close 关闭与签名服务之间的连接。
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+// GetKey 返回 SKI 对应的公钥。
+func (c *Client) GetKey(ctx context.Context, ski []byte) (*ecdsa.PublicKey, error) {
+	resp, err := c.client.GetKey(ctx, &GetKeyRequest{Ski: ski})
+	if err != nil {
+		return nil, err
+	}
+
+	pub, err := x509.ParsePKIXPublicKey(resp.PublicKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key [%v]", err)
+	}
+
+	ecdsaPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type [%T]", pub)
+	}
+
+	return ecdsaPub, nil
+}
+
+// Sign 请求签名服务用 SKI 对应的私钥对 digest 签名，返回的签名是 low-S 形式的。
+func (c *Client) Sign(ctx context.Context, ski, digest []byte) ([]byte, error) {
+	resp, err := c.client.Sign(ctx, &SignRequest{Ski: ski, Digest: digest})
+	if err != nil {
+		return nil, err
+	}
+
+	return resp.Signature, nil
+}
+
+// Verify 请求签名服务用 SKI 对应的公钥验证签名。
+func (c *Client) Verify(ctx context.Context, ski, signature, digest []byte) (bool, error) {
+	resp, err := c.client.Verify(ctx, &VerifyRequest{Ski: ski, Signature: signature, Digest: digest})
+	if err != nil {
+		return false, err
+	}
+
+	return resp.Valid, nil
+}
+
+// Signer 返回一个由签名服务中 SKI 对应的密钥支撑的 crypto.Signer，可以直接交给只
+// 接受 crypto.Signer 的代码使用。
+func (c *Client) Signer(ctx context.Context, ski []byte) (crypto.Signer, error) {
+	pub, err := c.GetKey(ctx, ski)
+	if err != nil {
+		return nil, err
+	}
+
+	return &remoteSigner{
+		client: c,
+		ski:    append([]byte(nil), ski...),
+		pub:    pub,
+	}, nil
+}
+
+type remoteSigner struct {
+	client *Client
+	ski    []byte
+	pub    *ecdsa.PublicKey
+}
+
+func (rs *remoteSigner) Public() crypto.PublicKey {
+	return rs.pub
+}
+
+// Sign 忽略 rand 参数，签名所用的随机数由签名服务生成。
+func (rs *remoteSigner) Sign(_ io.Reader, digest []byte, _ crypto.SignerOpts) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), rs.client.timeout)
+	defer cancel()
+
+	return rs.client.Sign(ctx, rs.ski, digest)
+}
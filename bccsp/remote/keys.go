@@ -0,0 +1,304 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package remote
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+	"github.com/geistwelt/quarkx/bccsp/keyaudit"
+	"github.com/geistwelt/quarkx/bccsp/utils"
+)
+
+// ErrKeyNotFound 表示签名服务中不存在给定 SKI 对应的密钥。
+var ErrKeyNotFound = errors.New("key not found")
+
+// KeySource 为签名服务提供密钥，任何能够按照 SKI 取出 crypto.Signer 的本地密钥
+// 提供者都可以作为签名服务的后端。
+type KeySource interface {
+	GetSigner(ski []byte) (crypto.Signer, error)
+}
+
+type keyEntry struct {
+	signer   crypto.Signer
+	metadata KeyMetadata
+}
+
+// Keys 是 KeySource 的实现，密钥按照公钥的 SKI 进行索引，每个密钥都带有生命周期信息。
+// *ecdsa.PrivateKey 会被包装成 utils.ECDSAPrivateKey，Keys 接管它的所有权，并在密钥被
+// 吊销时将其清零。NewKeys 创建的 Keys 只保存在内存中，OpenKeys 创建的 Keys 会把密钥与
+// 生命周期信息保存在目录中。
+type Keys struct {
+	mutex   sync.RWMutex
+	entries map[string]*keyEntry
+	dir     string
+	rand    io.Reader
+	auditor *keyaudit.Auditor
+}
+
+// NewKeys 创建一个只保存在内存中、包含给定密钥的 Keys，目前只支持 ECDSA 密钥。
+func NewKeys(signers ...crypto.Signer) (*Keys, error) {
+	keys := &Keys{entries: make(map[string]*keyEntry)}
+	for _, signer := range signers {
+		if _, err := keys.Add(signer); err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
+
+// Add 添加一个可以签名与验签、永不过期的 active 密钥，并返回该密钥的 SKI。如果
+// 密钥已经存在，包括保存在目录中的密钥，则保留它原有的生命周期信息；已经吊销的密钥
+// 不会被重新载入，传入的私钥会被清零。
+func (k *Keys) Add(signer crypto.Signer) ([]byte, error) {
+	ski, err := signerSKI(signer)
+	if err != nil {
+		return nil, err
+	}
+	if signer, err = wrapSigner(signer); err != nil {
+		return nil, err
+	}
+	id := hex.EncodeToString(ski)
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if entry, ok := k.entries[id]; ok {
+		if entry.metadata.Status == StatusRevoked {
+			destroy(signer)
+			return ski, nil
+		}
+		if err := k.persist(id, signer, entry.metadata); err != nil {
+			return nil, err
+		}
+		entry.signer = signer
+		return ski, nil
+	}
+
+	metadata := KeyMetadata{
+		Created: time.Now(),
+		Usage:   UsageSign | UsageVerify,
+		Status:  StatusActive,
+	}
+	if err := k.persist(id, signer, metadata); err != nil {
+		return nil, err
+	}
+	k.entries[id] = &keyEntry{signer: signer, metadata: metadata}
+
+	return ski, nil
+}
+
+// AddWithMetadata 添加一个密钥并使用给定的生命周期信息，已经存在的密钥会被覆盖。
+// 如果 metadata.Created 为零值，则使用当前时间。
+func (k *Keys) AddWithMetadata(signer crypto.Signer, metadata KeyMetadata) ([]byte, error) {
+	ski, err := signerSKI(signer)
+	if err != nil {
+		return nil, err
+	}
+	if metadata.Status > StatusRevoked {
+		return nil, fmt.Errorf("invalid key status [%s]", metadata.Status)
+	}
+	if metadata.Created.IsZero() {
+		metadata.Created = time.Now()
+	}
+	if signer, err = wrapSigner(signer); err != nil {
+		return nil, err
+	}
+
+	id := hex.EncodeToString(ski)
+
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if err := k.persist(id, signer, metadata); err != nil {
+		return nil, err
+	}
+	k.entries[id] = &keyEntry{signer: signer, metadata: cloneMetadata(metadata)}
+
+	return ski, nil
+}
+
+// GetSigner 返回 SKI 对应的密钥，如果不存在则返回 ErrKeyNotFound。GetSigner 不检查
+// 密钥的生命周期，调用方需要结合 GetMetadata 判断密钥能否用于签名。
+func (k *Keys) GetSigner(ski []byte) (crypto.Signer, error) {
+	k.mutex.RLock()
+	entry, ok := k.entries[hex.EncodeToString(ski)]
+	k.mutex.RUnlock()
+
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+
+	return entry.signer, nil
+}
+
+// GetMetadata 返回 SKI 对应密钥的生命周期信息的副本，如果不存在则返回 ErrKeyNotFound。
+func (k *Keys) GetMetadata(ski []byte) (*KeyMetadata, error) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	entry, ok := k.entries[hex.EncodeToString(ski)]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+
+	metadata := cloneMetadata(entry.metadata)
+	return &metadata, nil
+}
+
+// Retire 让密钥退役，退役后的密钥只能验签。只有 active 状态的密钥可以退役。
+func (k *Keys) Retire(ski []byte) error {
+	return k.setStatus(ski, StatusRetired)
+}
+
+// Revoke 吊销密钥并清零私钥，吊销后的密钥不能再用于签名与验签，只能查询公钥。已经
+// 吊销的密钥不能再次吊销。
+func (k *Keys) Revoke(ski []byte) error {
+	return k.setStatus(ski, StatusRevoked)
+}
+
+func (k *Keys) setStatus(ski []byte, status KeyStatus) error {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	id := hex.EncodeToString(ski)
+	entry, ok := k.entries[id]
+	if !ok {
+		return ErrKeyNotFound
+	}
+	if entry.metadata.Status >= status {
+		return fmt.Errorf("%w: from %s to %s", ErrInvalidTransition, entry.metadata.Status, status)
+	}
+
+	metadata := cloneMetadata(entry.metadata)
+	metadata.Status = status
+	if err := k.persist(id, entry.signer, metadata); err != nil {
+		return err
+	}
+	entry.metadata = metadata
+	if status == StatusRevoked {
+		destroy(entry.signer)
+	}
+
+	return nil
+}
+
+// SetAuditor 替换记录私钥导出的 Auditor，OpenKeys 创建的 Keys 把私钥写入目录时会以
+// keysComponent 的名义记录一次 OpExport 操作。为 nil 时只写入 bccsp.keyaudit 日志器。
+func (k *Keys) SetAuditor(auditor *keyaudit.Auditor) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	k.auditor = auditor
+}
+
+// SetEntropy 替换 Rotate 生成后继密钥时使用的随机源，传入 nil 时使用 entropy.Reader。
+func (k *Keys) SetEntropy(rand io.Reader) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	k.rand = rand
+}
+
+// Rotate 为 SKI 对应的 active 密钥生成一个使用相同曲线的后继密钥，后继密钥继承原密钥的
+// 用途、标签与有效时长，原密钥随之退役。两代密钥通过 Predecessor 与 Successor 相互
+// 关联，返回后继密钥的 SKI。
+func (k *Keys) Rotate(ski []byte) ([]byte, error) {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	entry, ok := k.entries[hex.EncodeToString(ski)]
+	if !ok {
+		return nil, ErrKeyNotFound
+	}
+	if entry.metadata.Status != StatusActive {
+		return nil, fmt.Errorf("%w: cannot rotate a %s key", ErrInvalidTransition, entry.metadata.Status)
+	}
+
+	pub := entry.signer.Public().(*ecdsa.PublicKey)
+	successor, err := ecdsa.GenerateKey(pub.Curve, entropy.Or(k.rand))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate successor key [%v]", err)
+	}
+	successorSKI := utils.SKI(&successor.PublicKey)
+	signer, err := utils.NewECDSAPrivateKey(successor)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	metadata := KeyMetadata{
+		Created:     now,
+		Usage:       entry.metadata.Usage,
+		Status:      StatusActive,
+		Label:       entry.metadata.Label,
+		Predecessor: bytes.Clone(ski),
+	}
+	if !entry.metadata.Expiry.IsZero() {
+		metadata.Expiry = now.Add(entry.metadata.Expiry.Sub(entry.metadata.Created))
+	}
+
+	retired := cloneMetadata(entry.metadata)
+	retired.Status = StatusRetired
+	retired.Successor = bytes.Clone(successorSKI)
+
+	// 先保存后继密钥，保证前一代密钥记录的 Successor 总能在目录中找到。
+	successorID := hex.EncodeToString(successorSKI)
+	if err := k.persist(successorID, signer, metadata); err != nil {
+		return nil, err
+	}
+	if err := k.persist(hex.EncodeToString(ski), entry.signer, retired); err != nil {
+		return nil, err
+	}
+	k.entries[successorID] = &keyEntry{signer: signer, metadata: metadata}
+	entry.metadata = retired
+
+	return successorSKI, nil
+}
+
+func signerSKI(signer crypto.Signer) ([]byte, error) {
+	if signer == nil {
+		return nil, errors.New("invalid signer, it must be different from nil")
+	}
+
+	pub, ok := signer.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type [%T]", signer.Public())
+	}
+
+	return utils.SKI(pub), nil
+}
+
+// wrapSigner 将 *ecdsa.PrivateKey 包装成 utils.ECDSAPrivateKey，其他类型的 signer 保持
+// 不变。
+func wrapSigner(signer crypto.Signer) (crypto.Signer, error) {
+	if sk, ok := signer.(*ecdsa.PrivateKey); ok {
+		return utils.NewECDSAPrivateKey(sk)
+	}
+	return signer, nil
+}
+
+// destroy 清零 signer 持有的私钥，不支持清零的 signer 保持不变。
+func destroy(signer crypto.Signer) {
+	if destroyer, ok := signer.(interface{ Destroy() }); ok {
+		destroyer.Destroy()
+	}
+}
+
+func cloneMetadata(metadata KeyMetadata) KeyMetadata {
+	metadata.Predecessor = bytes.Clone(metadata.Predecessor)
+	metadata.Successor = bytes.Clone(metadata.Successor)
+	return metadata
+}
@@ -0,0 +1,121 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package remote
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// KeyUsage 描述密钥允许的用途，多个用途可以按位组合。
+type KeyUsage uint8
+
+const (
+	UsageSign KeyUsage = 1 << iota
+	UsageVerify
+	UsageEncrypt
+)
+
+func (u KeyUsage) String() string {
+	var usages []string
+	if u&UsageSign != 0 {
+		usages = append(usages, "sign")
+	}
+	if u&UsageVerify != 0 {
+		usages = append(usages, "verify")
+	}
+	if u&UsageEncrypt != 0 {
+		usages = append(usages, "encrypt")
+	}
+
+	return strings.Join(usages, "|")
+}
+
+// KeyStatus 是密钥在生命周期中所处的状态。
+type KeyStatus uint8
+
+const (
+	// StatusActive 表示密钥可以按照 Usage 正常使用。
+	StatusActive KeyStatus = iota
+	// StatusRetired 表示密钥已经退役，只能用来验证它过去生成的签名，不能再签名。
+	StatusRetired
+	// StatusRevoked 表示密钥已经被吊销，不能再用于任何操作。
+	StatusRevoked
+)
+
+func (s KeyStatus) String() string {
+	switch s {
+	case StatusActive:
+		return "active"
+	case StatusRetired:
+		return "retired"
+	case StatusRevoked:
+		return "revoked"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint8(s))
+	}
+}
+
+var (
+	// ErrKeyNotUsable 表示密钥的状态、有效期或用途不允许执行请求的操作。
+	ErrKeyNotUsable = errors.New("key not usable")
+	// ErrInvalidTransition 表示密钥的状态不能从当前状态变更为目标状态。
+	ErrInvalidTransition = errors.New("invalid key status transition")
+)
+
+// KeyMetadata 是与密钥一同保存的生命周期信息。Predecessor 与 Successor 记录密钥
+// 轮换时前后两代密钥的 SKI。
+type KeyMetadata struct {
+	Created     time.Time
+	Expiry      time.Time // 零值表示永不过期
+	Usage       KeyUsage
+	Status      KeyStatus
+	Label       string
+	Predecessor []byte
+	Successor   []byte
+}
+
+// Expired 判断密钥在 now 时刻是否已经过期。
+func (m *KeyMetadata) Expired(now time.Time) bool {
+	return !m.Expiry.IsZero() && !now.Before(m.Expiry)
+}
+
+// CanSign 判断密钥在 now 时刻是否可以用来签名，只有处于 active 状态、未过期并且允许
+// 签名用途的密钥才能签名。
+func (m *KeyMetadata) CanSign(now time.Time) error {
+	if m.Usage&UsageSign == 0 {
+		return fmt.Errorf("%w: usage [%s] does not permit signing", ErrKeyNotUsable, m.Usage)
+	}
+	if m.Status != StatusActive {
+		return fmt.Errorf("%w: key is %s", ErrKeyNotUsable, m.Status)
+	}
+	if m.Expired(now) {
+		return fmt.Errorf("%w: key expired at %s", ErrKeyNotUsable, m.Expiry.Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// CanVerify 判断密钥是否可以用来验签。退役和过期的密钥仍然可以验证历史签名，只有被
+// 吊销的密钥不能再验签。
+func (m *KeyMetadata) CanVerify() error {
+	if m.Usage&UsageVerify == 0 {
+		return fmt.Errorf("%w: usage [%s] does not permit verification", ErrKeyNotUsable, m.Usage)
+	}
+	if m.Status == StatusRevoked {
+		return fmt.Errorf("%w: key is %s", ErrKeyNotUsable, m.Status)
+	}
+
+	return nil
+}
+
+// MetadataSource 是 KeySource 的可选扩展，实现了该接口的 KeySource 会由签名服务
+// 根据密钥的生命周期信息决定是否允许签名与验签。
+type MetadataSource interface {
+	GetMetadata(ski []byte) (*KeyMetadata, error)
+}
@@ -0,0 +1,52 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package remote
+
+import "github.com/geistwelt/quarkx/common/metrics"
+
+var (
+	requestsReceivedOpts = metrics.CounterOpts{
+		Namespace:    "bccsp",
+		Subsystem:    "remote",
+		Name:         "requests_received",
+		Help:         "The number of requests received by the remote signer.",
+		LabelNames:   []string{"method"},
+		StatsdFormat: "%{#fqname}.%{method}",
+	}
+
+	requestsCompletedOpts = metrics.CounterOpts{
+		Namespace:    "bccsp",
+		Subsystem:    "remote",
+		Name:         "requests_completed",
+		Help:         "The number of requests completed by the remote signer.",
+		LabelNames:   []string{"method", "code"},
+		StatsdFormat: "%{#fqname}.%{method}.%{code}",
+	}
+
+	requestDurationOpts = metrics.HistogramOpts{
+		Namespace:    "bccsp",
+		Subsystem:    "remote",
+		Name:         "request_duration",
+		Help:         "The time to complete a request to the remote signer, in seconds.",
+		LabelNames:   []string{"method", "code"},
+		StatsdFormat: "%{#fqname}.%{method}.%{code}",
+	}
+)
+
+// Metrics 记录签名服务每个方法的请求数量与耗时。
+type Metrics struct {
+	RequestsReceived  metrics.Counter
+	RequestsCompleted metrics.Counter
+	RequestDuration   metrics.Histogram
+}
+
+func NewMetrics(provider metrics.Provider) *Metrics {
+	return &Metrics{
+		RequestsReceived:  provider.NewCounter(requestsReceivedOpts),
+		RequestsCompleted: provider.NewCounter(requestsCompletedOpts),
+		RequestDuration:   provider.NewHistogram(requestDurationOpts),
+	}
+}
@@ -0,0 +1,459 @@
+package remote
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/geistwelt/quarkx/bccsp/keyaudit"
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"github.com/geistwelt/quarkx/common/metrics/metricsfakes"
+	"github.com/geistwelt/quarkx/common/qlogging/audit"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+func newCertificate(t *testing.T, template *x509.Certificate, parent *x509.Certificate, parentKey *ecdsa.PrivateKey) (*x509.Certificate, *ecdsa.PrivateKey) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	if parent == nil {
+		parent, parentKey = template, sk
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent, &sk.PublicKey, parentKey)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	return cert, sk
+}
+
+func newTLSConfigs(t *testing.T) (*tls.Config, *tls.Config) {
+	now := time.Now()
+	ca, caKey := newCertificate(t, &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "ca"},
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}, nil, nil)
+	serverCert, serverKey := newCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: "signer"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}, ca, caKey)
+	clientCert, clientKey := newCertificate(t, &x509.Certificate{
+		SerialNumber: big.NewInt(3),
+		Subject:      pkix.Name{CommonName: "peer0"},
+		NotBefore:    now.Add(-time.Hour),
+		NotAfter:     now.Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth},
+	}, ca, caKey)
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ca)
+
+	serverConfig := &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{serverCert.Raw}, PrivateKey: serverKey}},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+	}
+	clientConfig := &tls.Config{
+		Certificates: []tls.Certificate{{Certificate: [][]byte{clientCert.Raw}, PrivateKey: clientKey}},
+		RootCAs:      pool,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	return serverConfig, clientConfig
+}
+
+func newFakeProvider() (*metricsfakes.Provider, *metricsfakes.Counter) {
+	counter := &metricsfakes.Counter{}
+	counter.WithReturns(counter)
+	histogram := &metricsfakes.Histogram{}
+	histogram.WithReturns(histogram)
+
+	provider := &metricsfakes.Provider{}
+	provider.NewCounterReturns(counter)
+	provider.NewHistogramReturns(histogram)
+
+	return provider, counter
+}
+
+func TestRemoteSigner(t *testing.T) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keys, err := NewKeys(sk)
+	require.NoError(t, err)
+	ski := utils.SKI(&sk.PublicKey)
+
+	provider, counter := newFakeProvider()
+	serverConfig, clientConfig := newTLSConfigs(t)
+
+	server := NewServer(keys, provider)
+	_, err = server.NewGRPCServer(&tls.Config{})
+	require.EqualError(t, err, "invalid tls config, client certificates must be required and verified")
+
+	gs, err := server.NewGRPCServer(serverConfig)
+	require.NoError(t, err)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go gs.Serve(lis)
+	defer gs.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	client, err := Dial(ctx, lis.Addr().String(), clientConfig)
+	require.NoError(t, err)
+	defer client.Close()
+
+	pub, err := client.GetKey(ctx, ski)
+	require.NoError(t, err)
+	require.True(t, sk.PublicKey.Equal(pub))
+
+	digest := sha256.Sum256([]byte("hello, quarkx"))
+	for i := 0; i < 10; i++ {
+		signature, err := client.Sign(ctx, ski, digest[:])
+		require.NoError(t, err)
+
+		_, s, err := utils.UnmarshalECDSASignature(signature)
+		require.NoError(t, err)
+		lowS, err := utils.IsLowS(pub, s)
+		require.NoError(t, err)
+		require.True(t, lowS)
+
+		valid, err := client.Verify(ctx, ski, signature, digest[:])
+		require.NoError(t, err)
+		require.True(t, valid)
+		require.True(t, ecdsa.VerifyASN1(pub, digest[:], signature))
+	}
+
+	signer, err := client.Signer(ctx, ski)
+	require.NoError(t, err)
+	signature, err := signer.Sign(nil, digest[:], nil)
+	require.NoError(t, err)
+	require.True(t, ecdsa.VerifyASN1(&sk.PublicKey, digest[:], signature))
+
+	other := sha256.Sum256([]byte("another message"))
+	valid, err := client.Verify(ctx, ski, signature, other[:])
+	require.NoError(t, err)
+	require.False(t, valid)
+
+	_, err = client.Sign(ctx, []byte("unknown"), digest[:])
+	require.Equal(t, codes.NotFound, status.Code(err))
+
+	_, err = client.Sign(ctx, ski, nil)
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	require.True(t, counter.AddCallCount() > 0)
+}
+
+func TestRemoteSignerRejectsClientWithoutCertificate(t *testing.T) {
+	keys, err := NewKeys()
+	require.NoError(t, err)
+	provider, _ := newFakeProvider()
+	serverConfig, clientConfig := newTLSConfigs(t)
+
+	_, err = Dial(context.Background(), "127.0.0.1:0", &tls.Config{RootCAs: clientConfig.RootCAs})
+	require.EqualError(t, err, "invalid tls config, a client certificate is required")
+
+	gs, err := NewServer(keys, provider).NewGRPCServer(serverConfig)
+	require.NoError(t, err)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go gs.Serve(lis)
+	defer gs.Stop()
+
+	conn, err := tls.Dial("tcp", lis.Addr().String(), &tls.Config{RootCAs: clientConfig.RootCAs, NextProtos: []string{"h2"}})
+	if err == nil {
+		// TLS 1.3 下服务端在握手完成后才会校验客户端证书，读取时会得到错误。
+		_, err = conn.Read(make([]byte, 1))
+		conn.Close()
+	}
+	require.Error(t, err)
+}
+
+func TestKeysLifecycle(t *testing.T) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keys, err := NewKeys()
+	require.NoError(t, err)
+	ski, err := keys.AddWithMetadata(sk, KeyMetadata{
+		Created: time.Now().Add(-time.Hour),
+		Expiry:  time.Now().Add(time.Hour),
+		Usage:   UsageSign | UsageVerify,
+		Label:   "orderer",
+	})
+	require.NoError(t, err)
+
+	metadata, err := keys.GetMetadata(ski)
+	require.NoError(t, err)
+	require.Equal(t, StatusActive, metadata.Status)
+	require.Equal(t, "sign|verify", metadata.Usage.String())
+	require.NoError(t, metadata.CanSign(time.Now()))
+	require.ErrorIs(t, metadata.CanSign(time.Now().Add(2*time.Hour)), ErrKeyNotUsable)
+
+	successorSKI, err := keys.Rotate(ski)
+	require.NoError(t, err)
+	successor, err := keys.GetMetadata(successorSKI)
+	require.NoError(t, err)
+	require.Equal(t, StatusActive, successor.Status)
+	require.Equal(t, "orderer", successor.Label)
+	require.Equal(t, ski, successor.Predecessor)
+	require.WithinDuration(t, time.Now().Add(2*time.Hour), successor.Expiry, time.Minute)
+
+	signer, err := keys.GetSigner(successorSKI)
+	require.NoError(t, err)
+	require.Equal(t, elliptic.P256(), signer.Public().(*ecdsa.PublicKey).Curve)
+
+	metadata, err = keys.GetMetadata(ski)
+	require.NoError(t, err)
+	require.Equal(t, StatusRetired, metadata.Status)
+	require.Equal(t, successorSKI, metadata.Successor)
+	require.NoError(t, metadata.CanVerify())
+
+	_, err = keys.Rotate(ski)
+	require.ErrorIs(t, err, ErrInvalidTransition)
+	require.ErrorIs(t, keys.Retire(ski), ErrInvalidTransition)
+
+	// 私钥不会出现在格式化输出中，吊销之后被清零。
+	revoked, err := keys.GetSigner(ski)
+	require.NoError(t, err)
+	require.Equal(t, "[REDACTED]", fmt.Sprintf("%+v", revoked))
+	require.NotContains(t, fmt.Sprintf("%+v", *keys.entries[hex.EncodeToString(ski)]), sk.D.String())
+	require.NoError(t, keys.Revoke(ski))
+	require.Zero(t, sk.D.Sign())
+	_, err = revoked.Sign(rand.Reader, make([]byte, 32), nil)
+	require.ErrorIs(t, err, utils.ErrKeyDestroyed)
+	require.True(t, sk.PublicKey.Equal(revoked.Public()))
+	require.ErrorIs(t, keys.Revoke(ski), ErrInvalidTransition)
+	require.ErrorIs(t, keys.Retire([]byte("unknown")), ErrKeyNotFound)
+
+	// 重新添加同一个密钥不会重置它的生命周期信息。
+	_, err = keys.Add(sk)
+	require.NoError(t, err)
+	metadata, err = keys.GetMetadata(ski)
+	require.NoError(t, err)
+	require.Equal(t, StatusRevoked, metadata.Status)
+	require.ErrorIs(t, metadata.CanVerify(), ErrKeyNotUsable)
+}
+
+func TestOpenKeys(t *testing.T) {
+	dir := t.TempDir()
+	keys, err := OpenKeys(dir)
+	require.NoError(t, err)
+
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ski, err := keys.AddWithMetadata(sk, KeyMetadata{Usage: UsageSign | UsageVerify, Label: "orderer"})
+	require.NoError(t, err)
+	successorSKI, err := keys.Rotate(ski)
+	require.NoError(t, err)
+	other, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	otherSKI, err := keys.Add(other)
+	require.NoError(t, err)
+	require.NoError(t, keys.Revoke(otherSKI))
+	_, err = os.Stat(filepath.Join(dir, hex.EncodeToString(otherSKI)+"_sk"))
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	// 重启之后状态与轮换的前后关联保持不变，后继密钥依然可以签名。
+	reopened, err := OpenKeys(dir)
+	require.NoError(t, err)
+	metadata, err := reopened.GetMetadata(ski)
+	require.NoError(t, err)
+	require.Equal(t, StatusRetired, metadata.Status)
+	require.Equal(t, successorSKI, metadata.Successor)
+	successor, err := reopened.GetMetadata(successorSKI)
+	require.NoError(t, err)
+	require.Equal(t, StatusActive, successor.Status)
+	require.Equal(t, ski, successor.Predecessor)
+	require.Equal(t, "orderer", successor.Label)
+
+	provider, _ := newFakeProvider()
+	digest := sha256.Sum256([]byte("hello, quarkx"))
+	resp, err := NewServer(reopened, provider).Sign(context.Background(), &SignRequest{Ski: successorSKI, Digest: digest[:]})
+	require.NoError(t, err)
+	signer, err := reopened.GetSigner(successorSKI)
+	require.NoError(t, err)
+	require.True(t, ecdsa.VerifyASN1(signer.Public().(*ecdsa.PublicKey), digest[:], resp.Signature))
+
+	// 吊销的密钥重启之后依然是吊销状态，再次添加也不会恢复。
+	_, err = reopened.Add(other)
+	require.NoError(t, err)
+	metadata, err = reopened.GetMetadata(otherSKI)
+	require.NoError(t, err)
+	require.Equal(t, StatusRevoked, metadata.Status)
+	revoked, err := reopened.GetSigner(otherSKI)
+	require.NoError(t, err)
+	require.True(t, other.PublicKey.Equal(revoked.Public()))
+	_, err = revoked.Sign(rand.Reader, digest[:], nil)
+	require.ErrorIs(t, err, utils.ErrKeyDestroyed)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, hex.EncodeToString(ski)+"_meta.json"), []byte("{"), 0o600))
+	_, err = OpenKeys(dir)
+	require.Error(t, err)
+}
+
+func TestOpenKeysAuditsExport(t *testing.T) {
+	keys, err := OpenKeys(t.TempDir())
+	require.NoError(t, err)
+	provider, counter := newFakeProvider()
+	keys.SetAuditor(keyaudit.NewAuditor(provider, nil))
+
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	ski, err := keys.Add(sk)
+	require.NoError(t, err)
+	require.NoError(t, keys.Retire(ski))
+
+	// 私钥只在第一次写入目录时被导出，之后只更新生命周期信息。
+	require.Equal(t, 1, counter.AddCallCount())
+	require.Equal(t, []string{"ski", hex.EncodeToString(ski), "component", "remote.keys", "operation", "export", "outcome", "success"}, counter.WithArgsForCall(0))
+}
+
+// revokingSource 在返回生命周期信息之后立即吊销密钥，模拟生命周期检查与签名之间的
+// 并发吊销。
+type revokingSource struct {
+	*Keys
+}
+
+func (r *revokingSource) GetMetadata(ski []byte) (*KeyMetadata, error) {
+	metadata, err := r.Keys.GetMetadata(ski)
+	if err == nil && metadata.Status == StatusActive {
+		err = r.Keys.Revoke(ski)
+	}
+	return metadata, err
+}
+
+func TestServerSignConcurrentRevoke(t *testing.T) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keys, err := NewKeys(sk)
+	require.NoError(t, err)
+	ski := utils.SKI(&sk.PublicKey)
+	provider, _ := newFakeProvider()
+
+	digest := sha256.Sum256([]byte("hello, quarkx"))
+	_, err = NewServer(&revokingSource{Keys: keys}, provider).Sign(context.Background(), &SignRequest{Ski: ski, Digest: digest[:]})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	require.Contains(t, status.Convert(err).Message(), "key is revoked")
+}
+
+func TestServerEnforcesKeyLifecycle(t *testing.T) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keys, err := NewKeys(sk)
+	require.NoError(t, err)
+	ski := utils.SKI(&sk.PublicKey)
+	provider, _ := newFakeProvider()
+	server := NewServer(keys, provider)
+
+	ctx := context.Background()
+	digest := sha256.Sum256([]byte("hello, quarkx"))
+	resp, err := server.Sign(ctx, &SignRequest{Ski: ski, Digest: digest[:]})
+	require.NoError(t, err)
+	signature := resp.Signature
+
+	// high-S 签名的错误与本地验签一致。
+	r, s, err := utils.UnmarshalECDSASignature(signature)
+	require.NoError(t, err)
+	highS, err := utils.MarshalECDSASignature(r, new(big.Int).Sub(elliptic.P256().Params().N, s))
+	require.NoError(t, err)
+	_, err = server.Verify(ctx, &VerifyRequest{Ski: ski, Signature: highS, Digest: digest[:]})
+	require.Equal(t, codes.InvalidArgument, status.Code(err))
+	require.Contains(t, status.Convert(err).Message(), utils.ErrHighS.Error())
+
+	require.NoError(t, keys.Retire(ski))
+	_, err = server.Sign(ctx, &SignRequest{Ski: ski, Digest: digest[:]})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+	verified, err := server.Verify(ctx, &VerifyRequest{Ski: ski, Signature: signature, Digest: digest[:]})
+	require.NoError(t, err)
+	require.True(t, verified.Valid)
+
+	require.NoError(t, keys.Revoke(ski))
+	_, err = server.Verify(ctx, &VerifyRequest{Ski: ski, Signature: signature, Digest: digest[:]})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	verifyOnly, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	verifyOnlySKI, err := keys.AddWithMetadata(verifyOnly, KeyMetadata{Usage: UsageVerify})
+	require.NoError(t, err)
+	_, err = server.Sign(ctx, &SignRequest{Ski: verifyOnlySKI, Digest: digest[:]})
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+}
+
+func TestServerAuditsSign(t *testing.T) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keys, err := NewKeys(sk)
+	require.NoError(t, err)
+	ski := utils.SKI(&sk.PublicKey)
+
+	provider, _ := newFakeProvider()
+	auditProvider, auditCounter := newFakeProvider()
+	auditKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	buf := new(bytes.Buffer)
+	sink, err := audit.NewSink(buf, auditKey, 0)
+	require.NoError(t, err)
+	logging, err := audit.NewLogging(sink, "info")
+	require.NoError(t, err)
+
+	server := NewServer(keys, provider)
+	server.SetAuditor(keyaudit.NewAuditor(auditProvider, logging.Logger("keyaudit")))
+	serverConfig, clientConfig := newTLSConfigs(t)
+	gs, err := server.NewGRPCServer(serverConfig)
+	require.NoError(t, err)
+	lis, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go gs.Serve(lis)
+	defer gs.Stop()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	client, err := Dial(ctx, lis.Addr().String(), clientConfig)
+	require.NoError(t, err)
+	defer client.Close()
+
+	digest := sha256.Sum256([]byte("hello, quarkx"))
+	_, err = client.Sign(ctx, ski, digest[:])
+	require.NoError(t, err)
+	require.NoError(t, keys.Revoke(ski))
+	_, err = client.Sign(ctx, ski, digest[:])
+	require.Equal(t, codes.FailedPrecondition, status.Code(err))
+
+	// 调用方的名称取自它的 TLS 客户端证书。
+	encodedSKI := hex.EncodeToString(ski)
+	require.Equal(t, 2, auditCounter.AddCallCount())
+	require.Equal(t, []string{"ski", encodedSKI, "component", "peer0", "operation", "sign", "outcome", "success"}, auditCounter.WithArgsForCall(0))
+	require.Equal(t, []string{"ski", encodedSKI, "component", "peer0", "operation", "sign", "outcome", "failure"}, auditCounter.WithArgsForCall(1))
+
+	require.NoError(t, logging.Sync())
+	report, err := audit.Verify(bytes.NewReader(buf.Bytes()), &auditKey.PublicKey, 0)
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), report.Entries)
+	require.Contains(t, buf.String(), `\"component\":\"peer0\"`)
+}
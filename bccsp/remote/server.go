@@ -0,0 +1,265 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package remote
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"path"
+	"time"
+
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+	"github.com/geistwelt/quarkx/bccsp/keyaudit"
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"github.com/geistwelt/quarkx/common/metrics"
+	"github.com/geistwelt/quarkx/common/qlogging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+var logger = qlogging.MustGetLogger("bccsp.remote")
+
+// Server 是签名服务的服务端实现，它把收到的请求转交给本地的 KeySource 处理。
+// 私钥始终留在签名服务所在的主机上。
+type Server struct {
+	UnimplementedSignerServer
+
+	keys    KeySource
+	metrics *Metrics
+	auditor *keyaudit.Auditor
+	rand    io.Reader
+}
+
+// NewServer 创建一个签名服务，keys 提供密钥，provider 用来创建每个方法的指标以及私钥
+// 操作的审计计数器。
+func NewServer(keys KeySource, provider metrics.Provider) *Server {
+	return &Server{
+		keys:    keys,
+		metrics: NewMetrics(provider),
+		auditor: keyaudit.NewAuditor(provider, nil),
+		rand:    entropy.Reader,
+	}
+}
+
+// SetAuditor 替换记录私钥操作的 Auditor，通常用来把审计记录额外写入防篡改的审计文件。
+func (s *Server) SetAuditor(auditor *keyaudit.Auditor) {
+	s.auditor = auditor
+}
+
+// SetEntropy 替换签名时使用的随机源，传入 nil 时恢复为 entropy.Reader。它与 SetAuditor
+// 一样需要在开始提供服务之前调用。
+func (s *Server) SetEntropy(rand io.Reader) {
+	s.rand = entropy.Or(rand)
+}
+
+// NewGRPCServer 创建一个只接受双向 TLS 认证连接的 gRPC 服务端，注册签名服务，并
+// 安装记录请求日志与指标的拦截器。
+func (s *Server) NewGRPCServer(tlsConfig *tls.Config, opts ...grpc.ServerOption) (*grpc.Server, error) {
+	if tlsConfig == nil {
+		return nil, errors.New("invalid tls config, it must be different from nil")
+	}
+	if tlsConfig.ClientAuth != tls.RequireAndVerifyClientCert {
+		return nil, errors.New("invalid tls config, client certificates must be required and verified")
+	}
+
+	opts = append([]grpc.ServerOption{
+		grpc.Creds(credentials.NewTLS(tlsConfig)),
+		grpc.UnaryInterceptor(s.UnaryServerInterceptor),
+	}, opts...)
+
+	gs := grpc.NewServer(opts...)
+	RegisterSignerServer(gs, s)
+
+	return gs, nil
+}
+
+// UnaryServerInterceptor 记录每个请求的日志，并更新每个方法的请求数量与耗时指标。
+func (s *Server) UnaryServerInterceptor(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+	method := path.Base(info.FullMethod)
+	s.metrics.RequestsReceived.With("method", method).Add(1)
+
+	start := time.Now()
+	resp, err := handler(ctx, req)
+	duration := time.Since(start)
+
+	code := status.Code(err).String()
+	s.metrics.RequestsCompleted.With("method", method, "code", code).Add(1)
+	s.metrics.RequestDuration.With("method", method, "code", code).Observe(duration.Seconds())
+
+	remote := "unknown"
+	if p, ok := peer.FromContext(ctx); ok {
+		remote = p.Addr.String()
+	}
+	if err != nil {
+		logger.Warnw("remote signer request failed", "method", method, "remote", remote, "code", code, "duration", duration, "error", err)
+	} else {
+		logger.Debugw("remote signer request completed", "method", method, "remote", remote, "code", code, "duration", duration)
+	}
+
+	return resp, err
+}
+
+func (s *Server) GetKey(ctx context.Context, req *GetKeyRequest) (*GetKeyResponse, error) {
+	pub, err := s.publicKey(req.Ski)
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to marshal public key [%v]", err)
+	}
+
+	return &GetKeyResponse{PublicKey: raw}, nil
+}
+
+func (s *Server) Sign(ctx context.Context, req *SignRequest) (*SignResponse, error) {
+	if len(req.Digest) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid digest, it must not be empty")
+	}
+
+	signer, err := s.signer(req.Ski)
+	if err != nil {
+		return nil, err
+	}
+
+	// 找到密钥之后的每一次签名，包括被生命周期拒绝的签名，都以调用方的名义记录下来。
+	signature, err := s.sign(req.Ski, signer, req.Digest)
+	s.auditor.Record(req.Ski, peerName(ctx), keyaudit.OpSign, err)
+	if err != nil {
+		return nil, err
+	}
+
+	return &SignResponse{Signature: signature}, nil
+}
+
+func (s *Server) sign(ski []byte, signer crypto.Signer, digest []byte) ([]byte, error) {
+	if err := s.checkLifecycle(ski, func(m *KeyMetadata) error { return m.CanSign(time.Now()) }); err != nil {
+		return nil, err
+	}
+
+	signature, err := signer.Sign(s.rand, digest, nil)
+	if errors.Is(err, utils.ErrKeyDestroyed) {
+		// 密钥在生命周期检查之后、签名之前被并发地吊销，再次检查以返回吊销的错误。
+		if err := s.checkLifecycle(ski, func(m *KeyMetadata) error { return m.CanSign(time.Now()) }); err != nil {
+			return nil, err
+		}
+		return nil, status.Errorf(codes.FailedPrecondition, "key with ski [%x] cannot be used [%v]", ski, err)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to sign [%v]", err)
+	}
+
+	// 与本地签名保持一致，签名服务只返回 low-S 形式的签名。
+	signature, err = utils.SignatureToLowS(signer.Public().(*ecdsa.PublicKey), signature)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to normalize signature [%v]", err)
+	}
+
+	return signature, nil
+}
+
+func (s *Server) Verify(ctx context.Context, req *VerifyRequest) (*VerifyResponse, error) {
+	if len(req.Digest) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid digest, it must not be empty")
+	}
+
+	pub, err := s.publicKey(req.Ski)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.checkLifecycle(req.Ski, (*KeyMetadata).CanVerify); err != nil {
+		return nil, err
+	}
+
+	r, sv, err := utils.UnmarshalECDSASignature(req.Signature)
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "failed to unmarshal signature [%v]", err)
+	}
+
+	if err := utils.CheckLowS(pub, sv); err != nil {
+		if errors.Is(err, utils.ErrUnknownCurve) {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	return &VerifyResponse{Valid: ecdsa.Verify(pub, req.Digest, r, sv)}, nil
+}
+
+func (s *Server) signer(ski []byte) (crypto.Signer, error) {
+	if len(ski) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "invalid ski, it must not be empty")
+	}
+
+	signer, err := s.keys.GetSigner(ski)
+	if errors.Is(err, ErrKeyNotFound) {
+		return nil, status.Errorf(codes.NotFound, "key with ski [%x] not found", ski)
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get key with ski [%x] [%v]", ski, err)
+	}
+
+	if _, ok := signer.Public().(*ecdsa.PublicKey); !ok {
+		return nil, status.Errorf(codes.Unimplemented, "unsupported public key type [%T]", signer.Public())
+	}
+
+	return signer, nil
+}
+
+func (s *Server) publicKey(ski []byte) (*ecdsa.PublicKey, error) {
+	signer, err := s.signer(ski)
+	if err != nil {
+		return nil, err
+	}
+
+	return signer.Public().(*ecdsa.PublicKey), nil
+}
+
+// peerName 返回调用方 TLS 客户端证书的 CommonName，作为审计记录中的组件名称。
+func peerName(ctx context.Context) string {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return "unknown"
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.PeerCertificates) == 0 {
+		return "unknown"
+	}
+
+	return info.State.PeerCertificates[0].Subject.CommonName
+}
+
+// checkLifecycle 在 KeySource 实现了 MetadataSource 时，使用 check 检查密钥的生命
+// 周期是否允许当前操作。
+func (s *Server) checkLifecycle(ski []byte, check func(*KeyMetadata) error) error {
+	source, ok := s.keys.(MetadataSource)
+	if !ok {
+		return nil
+	}
+
+	metadata, err := source.GetMetadata(ski)
+	if errors.Is(err, ErrKeyNotFound) {
+		return status.Errorf(codes.NotFound, "metadata of key with ski [%x] not found", ski)
+	}
+	if err != nil {
+		return status.Errorf(codes.Internal, "failed to get metadata of key with ski [%x] [%v]", ski, err)
+	}
+
+	if err := check(metadata); err != nil {
+		return status.Errorf(codes.FailedPrecondition, "key with ski [%x] cannot be used [%v]", ski, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,274 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package remote
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/geistwelt/quarkx/bccsp/keyaudit"
+	"github.com/geistwelt/quarkx/bccsp/utils"
+)
+
+const (
+	keyFileSuffix      = "_sk"
+	metadataFileSuffix = "_meta.json"
+
+	// keysComponent 是 Keys 在私钥操作审计记录中的组件名称。
+	keysComponent = "remote.keys"
+)
+
+// errKeyNotLoaded 表示目录中只有密钥的生命周期信息，私钥还没有通过 Add 载入。
+var errKeyNotLoaded = errors.New("private key has not been loaded")
+
+// metadataFile 是 <ski>_meta.json 文件的内容。
+type metadataFile struct {
+	PublicKey   string    `json:"public_key"`
+	Created     time.Time `json:"created"`
+	Expiry      time.Time `json:"expiry"`
+	Usage       KeyUsage  `json:"usage"`
+	Status      KeyStatus `json:"status"`
+	Label       string    `json:"label,omitempty"`
+	Predecessor string    `json:"predecessor,omitempty"`
+	Successor   string    `json:"successor,omitempty"`
+}
+
+// publicKeyOnly 代替私钥不在内存中的密钥，例如已经吊销的密钥，它只能提供公钥。
+type publicKeyOnly struct {
+	pub *ecdsa.PublicKey
+	err error
+}
+
+func (k *publicKeyOnly) Public() crypto.PublicKey {
+	return k.pub
+}
+
+func (k *publicKeyOnly) Sign(io.Reader, []byte, crypto.SignerOpts) ([]byte, error) {
+	return nil, k.err
+}
+
+// OpenKeys 打开保存在目录 dir 中的密钥，目录不存在时会被创建。每个密钥在目录中对应
+// 两个文件：<ski>_sk 是 PEM 格式的私钥，<ski>_meta.json 是密钥的公钥与生命周期信息。
+// 之后添加、轮换、退役与吊销密钥都会同步写入目录，重启之后密钥的状态以及轮换的前后
+// 关联保持不变。吊销密钥时它的私钥文件会被删除，只保留生命周期信息。
+//
+// 不能导出私钥的 signer（例如 HSM 中的密钥）只会保存生命周期信息，重启之后需要再次
+// 通过 Add 载入私钥，载入时沿用目录中保存的生命周期信息。
+func OpenKeys(dir string) (*Keys, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return nil, fmt.Errorf("failed to create key directory [%v]", err)
+	}
+
+	keys := &Keys{entries: make(map[string]*keyEntry), dir: dir}
+
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read key directory [%v]", err)
+	}
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, metadataFileSuffix) {
+			continue
+		}
+		id := strings.TrimSuffix(name, metadataFileSuffix)
+		entry, err := keys.load(id)
+		if err != nil {
+			return nil, fmt.Errorf("failed to load key [%s] [%v]", id, err)
+		}
+		keys.entries[id] = entry
+	}
+
+	// 只有私钥文件、没有生命周期信息的密钥作为新的 active 密钥添加。
+	for _, file := range files {
+		name := file.Name()
+		if file.IsDir() || !strings.HasSuffix(name, keyFileSuffix) {
+			continue
+		}
+		if _, ok := keys.entries[strings.TrimSuffix(name, keyFileSuffix)]; ok {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("failed to read private key [%s] [%v]", name, err)
+		}
+		sk, err := utils.PEMToPrivateKey(raw)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse private key [%s] [%v]", name, err)
+		}
+		if _, err := keys.Add(sk); err != nil {
+			return nil, err
+		}
+	}
+
+	return keys, nil
+}
+
+// load 从目录中读取 id 对应的生命周期信息与私钥。
+func (k *Keys) load(id string) (*keyEntry, error) {
+	raw, err := os.ReadFile(filepath.Join(k.dir, id+metadataFileSuffix))
+	if err != nil {
+		return nil, err
+	}
+	var file metadataFile
+	if err := json.Unmarshal(raw, &file); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal metadata [%v]", err)
+	}
+	pub, err := utils.PEMToPublicKey([]byte(file.PublicKey))
+	if err != nil {
+		return nil, err
+	}
+	if hex.EncodeToString(utils.SKI(pub)) != id {
+		return nil, errors.New("the public key does not match the ski")
+	}
+
+	metadata := KeyMetadata{
+		Created: file.Created,
+		Expiry:  file.Expiry,
+		Usage:   file.Usage,
+		Status:  file.Status,
+		Label:   file.Label,
+	}
+	if metadata.Predecessor, err = hex.DecodeString(file.Predecessor); err != nil {
+		return nil, fmt.Errorf("invalid predecessor [%v]", err)
+	}
+	if metadata.Successor, err = hex.DecodeString(file.Successor); err != nil {
+		return nil, fmt.Errorf("invalid successor [%v]", err)
+	}
+	if len(metadata.Predecessor) == 0 {
+		metadata.Predecessor = nil
+	}
+	if len(metadata.Successor) == 0 {
+		metadata.Successor = nil
+	}
+
+	entry := &keyEntry{metadata: metadata, signer: &publicKeyOnly{pub: pub, err: errKeyNotLoaded}}
+	if metadata.Status == StatusRevoked {
+		entry.signer = &publicKeyOnly{pub: pub, err: utils.ErrKeyDestroyed}
+		return entry, nil
+	}
+
+	raw, err = os.ReadFile(filepath.Join(k.dir, id+keyFileSuffix))
+	if errors.Is(err, os.ErrNotExist) {
+		return entry, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	sk, err := utils.PEMToPrivateKey(raw)
+	if err != nil {
+		return nil, err
+	}
+	if !sk.PublicKey.Equal(pub) {
+		return nil, errors.New("the private key does not match the public key")
+	}
+	if entry.signer, err = utils.NewECDSAPrivateKey(sk); err != nil {
+		return nil, err
+	}
+
+	return entry, nil
+}
+
+// persist 将 id 对应密钥的生命周期信息写入目录。密钥没有被吊销并且私钥可以导出时，
+// 私钥文件不存在则一并写入；密钥被吊销时删除私钥文件。没有目录时什么也不做，调用方
+// 需要持有写锁。
+func (k *Keys) persist(id string, signer crypto.Signer, metadata KeyMetadata) error {
+	if k.dir == "" {
+		return nil
+	}
+
+	pubPEM, err := utils.PublicKeyToPEM(signer.Public().(*ecdsa.PublicKey))
+	if err != nil {
+		return err
+	}
+	raw, err := json.MarshalIndent(&metadataFile{
+		PublicKey:   string(pubPEM),
+		Created:     metadata.Created,
+		Expiry:      metadata.Expiry,
+		Usage:       metadata.Usage,
+		Status:      metadata.Status,
+		Label:       metadata.Label,
+		Predecessor: hex.EncodeToString(metadata.Predecessor),
+		Successor:   hex.EncodeToString(metadata.Successor),
+	}, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal metadata of key [%s] [%v]", id, err)
+	}
+
+	keyFile := filepath.Join(k.dir, id+keyFileSuffix)
+	if metadata.Status == StatusRevoked {
+		if err := os.Remove(keyFile); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove private key [%s] [%v]", id, err)
+		}
+	} else if sk, ok := signer.(*utils.ECDSAPrivateKey); ok {
+		if _, err := os.Stat(keyFile); errors.Is(err, os.ErrNotExist) {
+			err = sk.Export(func(sk *ecdsa.PrivateKey) error {
+				keyPEM, err := utils.PrivateKeyToPEM(sk)
+				if err != nil {
+					return err
+				}
+				return writeFileAtomic(keyFile, keyPEM, 0o600)
+			})
+			k.audit(sk.Public(), keyaudit.OpExport, err)
+			if err != nil {
+				return fmt.Errorf("failed to store private key [%s] [%v]", id, err)
+			}
+		}
+	}
+
+	if err := writeFileAtomic(filepath.Join(k.dir, id+metadataFileSuffix), raw, 0o600); err != nil {
+		return fmt.Errorf("failed to store metadata of key [%s] [%v]", id, err)
+	}
+
+	return nil
+}
+
+// audit 以 keysComponent 的名义记录一次私钥操作，调用方需要持有锁。
+func (k *Keys) audit(pub crypto.PublicKey, op keyaudit.Operation, err error) {
+	auditor := k.auditor
+	if auditor == nil {
+		auditor = keyaudit.NewAuditor(nil, nil)
+	}
+	ski, skiErr := keyaudit.SKI(pub)
+	if skiErr != nil {
+		return
+	}
+	auditor.Record(ski, keysComponent, op, err)
+}
+
+// writeFileAtomic 先写入临时文件再重命名，避免崩溃时留下不完整的文件。
+func writeFileAtomic(name string, data []byte, perm os.FileMode) error {
+	tmp, err := os.CreateTemp(filepath.Dir(name), "."+filepath.Base(name)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), perm); err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), name)
+}
@@ -0,0 +1,452 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package schnorr
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+
+	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+	"github.com/geistwelt/quarkx/bccsp/secret"
+)
+
+// MuSig2 按照 BIP-327 实现，委员会的成员各自持有私钥，通过两轮交互生成一个普通的 BIP-340
+// 签名，使用聚合公钥即可验证。流程如下所示：
+//  1. 所有成员调用 AggregateKeys 得到相同的聚合公钥；
+//  2. 每个成员调用 GenerateNonce 生成一次性的随机数，并广播 PublicNonce；
+//  3. 任意一方调用 AggregateNonces 聚合所有的 PublicNonce；
+//  4. 每个成员使用 NewSession 创建会话，调用 Session.Sign 生成部分签名；
+//  5. 任意一方调用 Session.VerifyPartial 检查每个部分签名，再调用 Session.Aggregate 得到最终签名。
+//
+// 这里没有实现 BIP-327 中的公钥调整（tweak）。
+
+// 公开随机数与聚合随机数的字节长度，两者都是两个压缩编码的点。
+const (
+	PublicNonceSize    = 66
+	AggregateNonceSize = 66
+	PartialSigSize     = 32
+)
+
+// ContributionError 表示某个成员提供的公钥、随机数或部分签名无效。
+type ContributionError struct {
+	Signer       int
+	Contribution string
+	Err          error
+}
+
+func (e *ContributionError) Error() string {
+	return fmt.Sprintf("invalid %s from signer %d [%v]", e.Contribution, e.Signer, e.Err)
+}
+
+func (e *ContributionError) Unwrap() error {
+	return e.Err
+}
+
+// SortKeys 按照压缩编码的字典序对公钥排序，对应 BIP-327 中的 KeySort。聚合公钥与公钥的
+// 顺序有关，成员之间可以先排序以免协商顺序。
+func SortKeys(pubs []*secp.PublicKey) []*secp.PublicKey {
+	sorted := append([]*secp.PublicKey{}, pubs...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i].SerializeCompressed(), sorted[j].SerializeCompressed()) < 0
+	})
+	return sorted
+}
+
+// KeyAggContext 是公钥聚合的结果。
+type KeyAggContext struct {
+	q         secp.JacobianPoint
+	keys      [][]byte
+	listHash  [32]byte
+	secondKey []byte
+}
+
+// AggregateKeys 按照给定的顺序聚合公钥，对应 BIP-327 中的 KeyAgg。
+func AggregateKeys(pubs []*secp.PublicKey) (*KeyAggContext, error) {
+	if len(pubs) == 0 {
+		return nil, errors.New("at least one public key is required")
+	}
+
+	keys := make([][]byte, len(pubs))
+	for i, pub := range pubs {
+		if pub == nil {
+			return nil, &ContributionError{Signer: i, Contribution: "pubkey", Err: errors.New("public key must be different from nil")}
+		}
+		keys[i] = pub.SerializeCompressed()
+	}
+	return newKeyAggContext(keys)
+}
+
+// AggregateSerializedKeys 与 AggregateKeys 相同，但是接收 33 字节压缩编码的公钥。
+func AggregateSerializedKeys(keys [][]byte) (*KeyAggContext, error) {
+	if len(keys) == 0 {
+		return nil, errors.New("at least one public key is required")
+	}
+	for i, key := range keys {
+		if _, err := parseCompressedPoint(key); err != nil {
+			return nil, &ContributionError{Signer: i, Contribution: "pubkey", Err: err}
+		}
+	}
+	return newKeyAggContext(keys)
+}
+
+func newKeyAggContext(keys [][]byte) (*KeyAggContext, error) {
+	ctx := &KeyAggContext{keys: keys}
+	ctx.listHash = taggedHash("KeyAgg list", keys...)
+	for _, key := range keys[1:] {
+		if !bytes.Equal(key, keys[0]) {
+			ctx.secondKey = key
+			break
+		}
+	}
+
+	// Q = Σ aᵢ·Pᵢ
+	for _, key := range keys {
+		P, _ := parseCompressedPoint(key)
+		a := ctx.coefficient(key)
+		var aP, sum secp.JacobianPoint
+		secp.ScalarMultNonConst(&a, &P, &aP)
+		secp.AddNonConst(&ctx.q, &aP, &sum)
+		ctx.q = sum
+	}
+	if isInfinity(&ctx.q) {
+		return nil, errors.New("the aggregate public key is the point at infinity")
+	}
+	ctx.q.ToAffine()
+
+	return ctx, nil
+}
+
+// coefficient 返回公钥的聚合系数，第二个不同的公钥的系数为 1。
+func (c *KeyAggContext) coefficient(key []byte) secp.ModNScalar {
+	if c.secondKey != nil && bytes.Equal(key, c.secondKey) {
+		var one secp.ModNScalar
+		one.SetInt(1)
+		return one
+	}
+	return hashToScalar("KeyAgg coefficient", c.listHash[:], key)
+}
+
+func (c *KeyAggContext) contains(key []byte) bool {
+	for _, k := range c.keys {
+		if bytes.Equal(k, key) {
+			return true
+		}
+	}
+	return false
+}
+
+// PublicKey 返回聚合公钥，它可以直接用于验证 BIP-340 签名。
+func (c *KeyAggContext) PublicKey() *secp.PublicKey {
+	return secp.NewPublicKey(&c.q.X, &c.q.Y)
+}
+
+// XOnly 返回聚合公钥的 32 字节 x-only 编码。
+func (c *KeyAggContext) XOnly() []byte {
+	x := c.q.X.Bytes()
+	return x[:]
+}
+
+// SecretNonce 是成员保存的一次性随机数，使用一次之后即被清零，在 fmt 与日志中只显示
+// 为 [REDACTED]。
+type SecretNonce struct {
+	secret.Redacted
+
+	k1, k2 secp.ModNScalar
+	pk     []byte
+}
+
+// Destroy 将没有使用的随机数清零，例如签名会话被放弃时。
+func (n *SecretNonce) Destroy() {
+	n.k1.Zero()
+	n.k2.Zero()
+}
+
+// PublicNonce 是成员广播的公开随机数 R1 || R2。
+type PublicNonce [PublicNonceSize]byte
+
+// AggregateNonce 是聚合之后的随机数，其中的点可以是无穷远点，以 33 个 0 表示。
+type AggregateNonce [AggregateNonceSize]byte
+
+// NonceOptions 是生成随机数时可选的额外输入，它们与随机数混合在一起，在随机数源出现
+// 问题时提供额外的保护。
+type NonceOptions struct {
+	PrivateKey *secp.PrivateKey
+	Keys       *KeyAggContext
+	Message    []byte
+	Extra      []byte
+
+	// Rand 是随机数的来源，为 nil 时使用 entropy.Reader。
+	Rand io.Reader
+}
+
+// GenerateNonce 为公钥 pub 的所有者生成一次性的随机数，对应 BIP-327 中的 NonceGen。每个
+// SecretNonce 只能用于一次签名。
+func GenerateNonce(pub *secp.PublicKey, opts NonceOptions) (*SecretNonce, PublicNonce, error) {
+	randPrime := make([]byte, 32)
+	if _, err := io.ReadFull(entropy.Or(opts.Rand), randPrime); err != nil {
+		return nil, PublicNonce{}, fmt.Errorf("failed to generate nonce [%v]", err)
+	}
+
+	var aggpk []byte
+	if opts.Keys != nil {
+		aggpk = opts.Keys.XOnly()
+	}
+	return generateNonce(randPrime, pub, opts.PrivateKey, aggpk, opts.Message, opts.Extra)
+}
+
+func generateNonce(randPrime []byte, pub *secp.PublicKey, sk *secp.PrivateKey, aggpk, msg, extra []byte) (*SecretNonce, PublicNonce, error) {
+	if pub == nil {
+		return nil, PublicNonce{}, errors.New("invalid public key, it must be different from nil")
+	}
+
+	random := randPrime
+	if sk != nil {
+		d := sk.Key.Bytes()
+		auxHash := taggedHash("MuSig/aux", randPrime)
+		random = make([]byte, 32)
+		for i := range random {
+			random[i] = d[i] ^ auxHash[i]
+		}
+	}
+
+	pk := pub.SerializeCompressed()
+	msgPrefixed := []byte{0}
+	if msg != nil {
+		msgPrefixed = binary.BigEndian.AppendUint64([]byte{1}, uint64(len(msg)))
+		msgPrefixed = append(msgPrefixed, msg...)
+	}
+	extraLen := binary.BigEndian.AppendUint32(nil, uint32(len(extra)))
+
+	nonce := &SecretNonce{pk: pk}
+	var pubNonce PublicNonce
+	for i, k := range []*secp.ModNScalar{&nonce.k1, &nonce.k2} {
+		*k = hashToScalar("MuSig/nonce", random, []byte{byte(len(pk))}, pk, []byte{byte(len(aggpk))}, aggpk, msgPrefixed, extraLen, extra, []byte{byte(i)})
+		if k.IsZero() {
+			return nil, PublicNonce{}, errors.New("failed to generate nonce, the nonce is zero")
+		}
+		var R secp.JacobianPoint
+		secp.ScalarBaseMultNonConst(k, &R)
+		R.ToAffine()
+		copy(pubNonce[33*i:], secp.NewPublicKey(&R.X, &R.Y).SerializeCompressed())
+	}
+
+	return nonce, pubNonce, nil
+}
+
+// AggregateNonces 聚合所有成员的公开随机数，对应 BIP-327 中的 NonceAgg。
+func AggregateNonces(nonces []PublicNonce) (AggregateNonce, error) {
+	var agg AggregateNonce
+	for j := 0; j < 2; j++ {
+		var R secp.JacobianPoint
+		for i, nonce := range nonces {
+			P, err := parseCompressedPoint(nonce[33*j : 33*(j+1)])
+			if err != nil {
+				return AggregateNonce{}, &ContributionError{Signer: i, Contribution: "pubnonce", Err: err}
+			}
+			var sum secp.JacobianPoint
+			secp.AddNonConst(&R, &P, &sum)
+			R = sum
+		}
+		copy(agg[33*j:], serializePointExt(&R))
+	}
+
+	return agg, nil
+}
+
+// Session 是对一条消息进行 MuSig2 签名的会话，所有成员使用相同的聚合公钥、聚合随机数与
+// 消息创建会话。
+type Session struct {
+	keys *KeyAggContext
+	msg  []byte
+	b, e secp.ModNScalar
+	r    secp.JacobianPoint
+}
+
+// NewSession 创建签名会话，计算随机数系数 b、最终的随机点 R 与挑战值 e。
+func NewSession(keys *KeyAggContext, aggNonce AggregateNonce, msg []byte) (*Session, error) {
+	if keys == nil {
+		return nil, errors.New("invalid key aggregation context, it must be different from nil")
+	}
+
+	R1, err := parsePointExt(aggNonce[:33])
+	if err != nil {
+		return nil, fmt.Errorf("invalid aggregate nonce [%v]", err)
+	}
+	R2, err := parsePointExt(aggNonce[33:])
+	if err != nil {
+		return nil, fmt.Errorf("invalid aggregate nonce [%v]", err)
+	}
+
+	s := &Session{keys: keys, msg: append([]byte{}, msg...)}
+	s.b = hashToScalar("MuSig/noncecoef", aggNonce[:], keys.XOnly(), msg)
+
+	// R = R1 + b·R2，如果 R 是无穷远点，则使用基点 G。
+	var bR2 secp.JacobianPoint
+	secp.ScalarMultNonConst(&s.b, &R2, &bR2)
+	secp.AddNonConst(&R1, &bR2, &s.r)
+	if isInfinity(&s.r) {
+		var one secp.ModNScalar
+		one.SetInt(1)
+		secp.ScalarBaseMultNonConst(&one, &s.r)
+	}
+	s.r.ToAffine()
+
+	r := s.r.X.Bytes()
+	s.e = hashToScalar("BIP0340/challenge", r[:], keys.XOnly(), msg)
+
+	return s, nil
+}
+
+// Sign 使用私钥 sk 与一次性随机数 nonce 生成部分签名，nonce 在调用之后被清零，不能再次
+// 使用。
+func (s *Session) Sign(nonce *SecretNonce, sk *secp.PrivateKey) ([]byte, error) {
+	if nonce == nil || nonce.k1.IsZero() || nonce.k2.IsZero() {
+		return nil, errors.New("invalid secret nonce, it has already been used")
+	}
+	k1, k2 := nonce.k1, nonce.k2
+	nonce.k1.Zero()
+	nonce.k2.Zero()
+
+	if sk == nil || sk.Key.IsZero() {
+		return nil, errors.New("invalid private key, it must be different from zero")
+	}
+	pk := sk.PubKey().SerializeCompressed()
+	if !bytes.Equal(pk, nonce.pk) {
+		return nil, errors.New("the secret nonce was generated for a different public key")
+	}
+	if !s.keys.contains(pk) {
+		return nil, errors.New("the public key is not part of the aggregate public key")
+	}
+
+	if s.r.Y.IsOdd() {
+		k1.Negate()
+		k2.Negate()
+	}
+
+	// d = g·d'，其中 g = -1 当且仅当聚合公钥的 y 坐标为奇数。
+	var d secp.ModNScalar
+	d.Set(&sk.Key)
+	if s.keys.q.Y.IsOdd() {
+		d.Negate()
+	}
+
+	// s = k1 + b·k2 + e·a·d
+	a := s.keys.coefficient(pk)
+	var sig secp.ModNScalar
+	sig.Mul2(&s.b, &k2).Add(&k1)
+	sig.Add(new(secp.ModNScalar).Mul2(&s.e, &a).Mul(&d))
+	d.Zero()
+	k1.Zero()
+	k2.Zero()
+
+	raw := sig.Bytes()
+	return raw[:], nil
+}
+
+// VerifyPartial 验证公钥为 pub、公开随机数为 nonce 的成员给出的部分签名。
+func (s *Session) VerifyPartial(psig []byte, nonce PublicNonce, pub *secp.PublicKey) error {
+	if len(psig) != PartialSigSize {
+		return fmt.Errorf("invalid partial signature length %d, must be %d", len(psig), PartialSigSize)
+	}
+	var sig secp.ModNScalar
+	if overflow := sig.SetByteSlice(psig); overflow {
+		return errors.New("invalid partial signature, it is not smaller than the group order")
+	}
+	if pub == nil {
+		return errors.New("invalid public key, it must be different from nil")
+	}
+	pk := pub.SerializeCompressed()
+	if !s.keys.contains(pk) {
+		return errors.New("the public key is not part of the aggregate public key")
+	}
+
+	R1, err := parseCompressedPoint(nonce[:33])
+	if err != nil {
+		return fmt.Errorf("invalid public nonce [%v]", err)
+	}
+	R2, err := parseCompressedPoint(nonce[33:])
+	if err != nil {
+		return fmt.Errorf("invalid public nonce [%v]", err)
+	}
+
+	// Re = R1 + b·R2，如果 R 的 y 坐标为奇数则取反。
+	var bR2, Re secp.JacobianPoint
+	secp.ScalarMultNonConst(&s.b, &R2, &bR2)
+	secp.AddNonConst(&R1, &bR2, &Re)
+	if s.r.Y.IsOdd() {
+		Re.ToAffine()
+		Re.Y.Negate(1).Normalize()
+	}
+
+	// s·G = Re + e·a·g·P
+	a := s.keys.coefficient(pk)
+	ea := new(secp.ModNScalar).Mul2(&s.e, &a)
+	if s.keys.q.Y.IsOdd() {
+		ea.Negate()
+	}
+	var P, eaP, rhs, lhs secp.JacobianPoint
+	pub.AsJacobian(&P)
+	secp.ScalarMultNonConst(ea, &P, &eaP)
+	secp.AddNonConst(&Re, &eaP, &rhs)
+	secp.ScalarBaseMultNonConst(&sig, &lhs)
+
+	if !lhs.EquivalentNonConst(&rhs) {
+		return errors.New("invalid partial signature")
+	}
+	return nil
+}
+
+// Aggregate 将所有成员的部分签名聚合成 64 字节的 BIP-340 签名。
+func (s *Session) Aggregate(psigs [][]byte) ([]byte, error) {
+	var sum secp.ModNScalar
+	for i, psig := range psigs {
+		var k secp.ModNScalar
+		if len(psig) != PartialSigSize || k.SetByteSlice(psig) {
+			return nil, &ContributionError{Signer: i, Contribution: "psig", Err: errors.New("partial signature is malformed")}
+		}
+		sum.Add(&k)
+	}
+
+	r := s.r.X.Bytes()
+	sBytes := sum.Bytes()
+	return append(r[:], sBytes[:]...), nil
+}
+
+// parseCompressedPoint 解析 33 字节压缩编码的点。
+func parseCompressedPoint(raw []byte) (secp.JacobianPoint, error) {
+	var P secp.JacobianPoint
+	if len(raw) != 33 || (raw[0] != 2 && raw[0] != 3) {
+		return P, errors.New("point must be 33 bytes in compressed format")
+	}
+	pub, err := secp.ParsePubKey(raw)
+	if err != nil {
+		return P, err
+	}
+	pub.AsJacobian(&P)
+	return P, nil
+}
+
+// parsePointExt 与 parseCompressedPoint 相同，但是将 33 个 0 解析为无穷远点。
+func parsePointExt(raw []byte) (secp.JacobianPoint, error) {
+	if bytes.Equal(raw, make([]byte, 33)) {
+		return secp.JacobianPoint{}, nil
+	}
+	return parseCompressedPoint(raw)
+}
+
+// serializePointExt 返回点的压缩编码，无穷远点编码成 33 个 0。
+func serializePointExt(P *secp.JacobianPoint) []byte {
+	if isInfinity(P) {
+		return make([]byte, 33)
+	}
+	P.ToAffine()
+	return secp.NewPublicKey(&P.X, &P.Y).SerializeCompressed()
+}
@@ -0,0 +1,205 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package schnorr
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"io"
+
+	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+)
+
+// BIP-340 中 x-only 公钥与签名的字节长度。
+const (
+	PublicKeySize = 32
+	SignatureSize = 64
+)
+
+// ErrInvalidSignature 表示 Schnorr 签名没有通过验证。
+var ErrInvalidSignature = errors.New("invalid schnorr signature")
+
+// taggedHash 计算 BIP-340 定义的带标签的哈希 SHA256(SHA256(tag) || SHA256(tag) || msg)。
+func taggedHash(tag string, msgs ...[]byte) [32]byte {
+	tagHash := sha256.Sum256([]byte(tag))
+	h := sha256.New()
+	h.Write(tagHash[:])
+	h.Write(tagHash[:])
+	for _, msg := range msgs {
+		h.Write(msg)
+	}
+
+	var sum [32]byte
+	h.Sum(sum[:0])
+	return sum
+}
+
+// hashToScalar 将带标签的哈希按曲线的阶取模。
+func hashToScalar(tag string, msgs ...[]byte) secp.ModNScalar {
+	sum := taggedHash(tag, msgs...)
+	var k secp.ModNScalar
+	k.SetBytes(&sum)
+	return k
+}
+
+// GenerateKey 使用 entropy.Reader 生成 secp256k1 私钥。
+func GenerateKey() (*secp.PrivateKey, error) {
+	return GenerateKeyWithRand(entropy.Reader)
+}
+
+// GenerateKeyWithRand 使用随机源 rand 生成 secp256k1 私钥。
+func GenerateKeyWithRand(rand io.Reader) (*secp.PrivateKey, error) {
+	sk, err := secp.GeneratePrivateKeyFromRand(rand)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate secp256k1 key [%v]", err)
+	}
+	return sk, nil
+}
+
+// SerializePublicKey 返回公钥的 32 字节 x-only 编码，y 坐标的奇偶性被丢弃。
+func SerializePublicKey(pub *secp.PublicKey) []byte {
+	return pub.SerializeCompressed()[1:]
+}
+
+// ParsePublicKey 解析 32 字节的 x-only 公钥，返回 y 坐标为偶数的那个点。
+func ParsePublicKey(raw []byte) (*secp.PublicKey, error) {
+	if len(raw) != PublicKeySize {
+		return nil, fmt.Errorf("invalid x-only public key length %d, must be %d", len(raw), PublicKeySize)
+	}
+
+	var x, y secp.FieldVal
+	if overflow := x.SetByteSlice(raw); overflow {
+		return nil, errors.New("invalid x-only public key, x is not smaller than the field prime")
+	}
+	if !secp.DecompressY(&x, false, &y) {
+		return nil, errors.New("invalid x-only public key, it is not on the curve")
+	}
+
+	return secp.NewPublicKey(&x, &y), nil
+}
+
+// Sign 对 msg 生成 BIP-340 签名，辅助随机数取自 entropy.Reader。
+func Sign(sk *secp.PrivateKey, msg []byte) ([]byte, error) {
+	return SignWithRand(entropy.Reader, sk, msg)
+}
+
+// SignWithRand 对 msg 生成 BIP-340 签名，辅助随机数取自随机源 rand。
+func SignWithRand(rand io.Reader, sk *secp.PrivateKey, msg []byte) ([]byte, error) {
+	aux := make([]byte, 32)
+	if _, err := io.ReadFull(rand, aux); err != nil {
+		return nil, fmt.Errorf("failed to read auxiliary randomness [%v]", err)
+	}
+
+	return SignWithAuxRand(sk, msg, aux)
+}
+
+// SignWithAuxRand 使用给定的 32 字节辅助随机数对 msg 生成 BIP-340 签名，相同的输入总是
+// 得到相同的签名。
+func SignWithAuxRand(sk *secp.PrivateKey, msg, aux []byte) ([]byte, error) {
+	if len(aux) != 32 {
+		return nil, fmt.Errorf("invalid auxiliary randomness length %d, must be 32", len(aux))
+	}
+	if sk == nil || sk.Key.IsZero() {
+		return nil, errors.New("invalid private key, it must be different from zero")
+	}
+
+	// d = d' 或者 n - d'，使得 d·G 的 y 坐标为偶数。
+	var d secp.ModNScalar
+	d.Set(&sk.Key)
+	var P secp.JacobianPoint
+	secp.ScalarBaseMultNonConst(&d, &P)
+	P.ToAffine()
+	if P.Y.IsOdd() {
+		d.Negate()
+	}
+	pk := P.X.Bytes()
+
+	t := d.Bytes()
+	auxHash := taggedHash("BIP0340/aux", aux)
+	for i := range t {
+		t[i] ^= auxHash[i]
+	}
+
+	k := hashToScalar("BIP0340/nonce", t[:], pk[:], msg)
+	if k.IsZero() {
+		return nil, errors.New("failed to sign, the nonce is zero")
+	}
+
+	var R secp.JacobianPoint
+	secp.ScalarBaseMultNonConst(&k, &R)
+	R.ToAffine()
+	if R.Y.IsOdd() {
+		k.Negate()
+	}
+	r := R.X.Bytes()
+
+	e := hashToScalar("BIP0340/challenge", r[:], pk[:], msg)
+	s := new(secp.ModNScalar).Mul2(&e, &d).Add(&k)
+	sBytes := s.Bytes()
+
+	sig := make([]byte, 0, SignatureSize)
+	sig = append(append(sig, r[:]...), sBytes[:]...)
+	if err := verify(pk[:], msg, sig); err != nil {
+		return nil, fmt.Errorf("failed to sign, the signature does not verify [%v]", err)
+	}
+
+	return sig, nil
+}
+
+// Verify 验证 sig 是否为公钥 pub 对 msg 的 BIP-340 签名，验证时只使用公钥的 x 坐标。
+func Verify(pub *secp.PublicKey, msg, sig []byte) bool {
+	return pub != nil && verify(SerializePublicKey(pub), msg, sig) == nil
+}
+
+// VerifyXOnly 使用 32 字节的 x-only 公钥验证 BIP-340 签名。
+func VerifyXOnly(pub []byte, msg, sig []byte) error {
+	return verify(pub, msg, sig)
+}
+
+func verify(pk, msg, sig []byte) error {
+	if len(sig) != SignatureSize {
+		return fmt.Errorf("invalid schnorr signature length %d, must be %d", len(sig), SignatureSize)
+	}
+
+	pub, err := ParsePublicKey(pk)
+	if err != nil {
+		return err
+	}
+
+	var r secp.FieldVal
+	if overflow := r.SetByteSlice(sig[:32]); overflow {
+		return errors.New("invalid schnorr signature, r is not smaller than the field prime")
+	}
+	var s secp.ModNScalar
+	if overflow := s.SetByteSlice(sig[32:]); overflow {
+		return errors.New("invalid schnorr signature, s is not smaller than the group order")
+	}
+
+	// R = s·G - e·P
+	e := hashToScalar("BIP0340/challenge", sig[:32], pk, msg)
+	e.Negate()
+	var P, sG, eP, R secp.JacobianPoint
+	pub.AsJacobian(&P)
+	secp.ScalarBaseMultNonConst(&s, &sG)
+	secp.ScalarMultNonConst(&e, &P, &eP)
+	secp.AddNonConst(&sG, &eP, &R)
+
+	if isInfinity(&R) {
+		return ErrInvalidSignature
+	}
+	R.ToAffine()
+	if R.Y.IsOdd() || !R.X.Equals(&r) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func isInfinity(p *secp.JacobianPoint) bool {
+	return (p.X.IsZero() && p.Y.IsZero()) || p.Z.IsZero()
+}
@@ -0,0 +1,250 @@
+package schnorr
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"testing"
+
+	secp "github.com/decred/dcrd/dcrec/secp256k1/v4"
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+	"github.com/geistwelt/quarkx/bccsp/secret"
+	"github.com/stretchr/testify/require"
+)
+
+func decodeHex(t *testing.T, s string) []byte {
+	raw, err := hex.DecodeString(s)
+	require.NoError(t, err)
+	return raw
+}
+
+// BIP-340 的测试向量。
+func TestBIP340Vectors(t *testing.T) {
+	for _, v := range []struct {
+		secretKey, publicKey, auxRand, message, signature string
+	}{
+		{
+			secretKey: "B7E151628AED2A6ABF7158809CF4F3C762E7160F38B4DA56A784D9045190CFEF",
+			publicKey: "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659",
+			auxRand:   "0000000000000000000000000000000000000000000000000000000000000001",
+			message:   "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89",
+			signature: "6896BD60EEAE296DB48A229FF71DFE071BDE413E6D43F917DC8DCF8C78DE33418906D11AC976ABCCB20B091292BFF4EA897EFCB639EA871CFA95F6DE339E4B0A",
+		},
+		{
+			secretKey: "C90FDAA22168C234C4C6628B80DC1CD129024E088A67CC74020BBEA63B14E5C9",
+			publicKey: "DD308AFEC5777E13121FA72B9CC1B7CC0139715309B086C960E18FD969774EB8",
+			auxRand:   "C87AA53824B4D7AE2EB035A2B5BBBCCC080E76CDC6D1692C4B0B62D798E6D906",
+			message:   "7E2D58D8B3BCDF1ABADEC7829054F90DDA9805AAB56C77333024B9D0A508B75C",
+			signature: "5831AAEED7B44BB74E5EAB94BA9D4294C49BCF2A60728D8B4C200F50DD313C1BAB745879A5AD954A72C45A91C3A51D3C7ADEA98D82F8481E0E1E03674A6F3FB7",
+		},
+		{
+			secretKey: "0B432B2677937381AEF05BB02A66ECD012773062CF3FA2549E44F58ED2401710",
+			publicKey: "25D1DFF95105F5253C4022F628A996AD3A0D95FBF21D468A1B33F8C160D8F517",
+			auxRand:   "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF",
+			message:   "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFF",
+			signature: "7EB0509757E246F19449885651611CB965ECC1A187DD51B64FDA1EDC9637D5EC97582B9CB13DB3933705B32BA982AF5AF25FD78881EBB32771FC5922EFC66EA3",
+		},
+	} {
+		sk := secp.PrivKeyFromBytes(decodeHex(t, v.secretKey))
+		require.Equal(t, decodeHex(t, v.publicKey), SerializePublicKey(sk.PubKey()))
+
+		sig, err := SignWithAuxRand(sk, decodeHex(t, v.message), decodeHex(t, v.auxRand))
+		require.NoError(t, err)
+		require.Equal(t, decodeHex(t, v.signature), sig)
+		require.NoError(t, VerifyXOnly(decodeHex(t, v.publicKey), decodeHex(t, v.message), sig))
+	}
+
+	pub := decodeHex(t, "DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659")
+	msg := decodeHex(t, "243F6A8885A308D313198A2E03707344A4093822299F31D0082EFA98EC4E6C89")
+
+	// R 的 y 坐标为奇数。
+	err := VerifyXOnly(pub, msg, decodeHex(t, "FFF97BD5755EEEA420453A14355235D382F6472F8568A18B2F057A14602975563CC27944640AC607CD107AE10923D9EF7A73C643E166BE5EBEAFA34B1AC553E2"))
+	require.ErrorIs(t, err, ErrInvalidSignature)
+
+	// 公钥不在曲线上。
+	err = VerifyXOnly(decodeHex(t, "EEFDEA4CDB677750A420FEE807EACF21EB9898AE79B9768766E4FAA04A2D4A34"), msg, decodeHex(t, "6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E17776969E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B"))
+	require.EqualError(t, err, "invalid x-only public key, it is not on the curve")
+
+	// 公钥的 x 坐标超过了域的大小。
+	err = VerifyXOnly(decodeHex(t, "FFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFFEFFFFFC30"), msg, decodeHex(t, "6CFF5C3BA86C69EA4B7376F31A9BCB4F74C1976089B2D9963DA2E5543E17776969E89B4C5564D00349106B8497785DD7D1D713A8AE82B32FA79D5F7FC407D39B"))
+	require.EqualError(t, err, "invalid x-only public key, x is not smaller than the field prime")
+}
+
+func TestSignAndVerify(t *testing.T) {
+	sk, err := GenerateKey()
+	require.NoError(t, err)
+	msg := []byte("hello, quarkx")
+
+	sig, err := Sign(sk, msg)
+	require.NoError(t, err)
+	require.Len(t, sig, SignatureSize)
+	require.True(t, Verify(sk.PubKey(), msg, sig))
+	require.False(t, Verify(sk.PubKey(), []byte("hello, fabric"), sig))
+	require.False(t, Verify(sk.PubKey(), msg, sig[1:]))
+
+	pub, err := ParsePublicKey(SerializePublicKey(sk.PubKey()))
+	require.NoError(t, err)
+	require.True(t, Verify(pub, msg, sig))
+
+	_, err = SignWithAuxRand(sk, msg, []byte("short"))
+	require.EqualError(t, err, "invalid auxiliary randomness length 5, must be 32")
+}
+
+func TestDeterministicEntropy(t *testing.T) {
+	generate := func() ([]byte, []byte) {
+		rand := entropy.NewDeterministic([]byte("seed"))
+		sk, err := GenerateKeyWithRand(rand)
+		require.NoError(t, err)
+		sig, err := SignWithRand(rand, sk, []byte("hello, quarkx"))
+		require.NoError(t, err)
+		return sk.Serialize(), sig
+	}
+
+	// 密钥与辅助随机数都取自注入的随机源，相同的种子得到相同的密钥和签名。
+	sk1, sig1 := generate()
+	sk2, sig2 := generate()
+	require.Equal(t, sk1, sk2)
+	require.Equal(t, sig1, sig2)
+}
+
+// BIP-327 的公钥聚合与部分签名测试向量。
+func TestMuSig2Vectors(t *testing.T) {
+	pubkeys := [][]byte{
+		decodeHex(t, "02F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9"),
+		decodeHex(t, "03DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA659"),
+		decodeHex(t, "023590A94E768F8E1815C2F24B4D80A8E3149316C3518CE7B7AD338368D038CA66"),
+	}
+	ctx, err := AggregateSerializedKeys(pubkeys)
+	require.NoError(t, err)
+	require.Equal(t, decodeHex(t, "90539EEDE565F5D054F32CC0C220126889ED1E5D193BAF15AEF344FE59D4610C"), ctx.XOnly())
+
+	ctx, err = AggregateSerializedKeys([][]byte{pubkeys[0], pubkeys[0], pubkeys[1], pubkeys[1]})
+	require.NoError(t, err)
+	require.Equal(t, decodeHex(t, "69BC22BFA5D106306E48A20679DE1D7389386124D07571D0D872686028C26A3E"), ctx.XOnly())
+
+	_, err = AggregateSerializedKeys([][]byte{pubkeys[0], decodeHex(t, "020000000000000000000000000000000000000000000000000000000000000005")})
+	var contribErr *ContributionError
+	require.True(t, errors.As(err, &contribErr))
+	require.Equal(t, 1, contribErr.Signer)
+	require.Equal(t, "pubkey", contribErr.Contribution)
+
+	sk := secp.PrivKeyFromBytes(decodeHex(t, "7FB9E0E687ADA1EEBF7ECFE2F21E73EBDB51A7D450948DFE8D76D7F2D1007671"))
+	ctx, err = AggregateSerializedKeys([][]byte{
+		decodeHex(t, "03935F972DA013F80AE011890FA89B67A27B7BE6CCB24D3274D18B2D4067F261A9"),
+		decodeHex(t, "02F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9"),
+		decodeHex(t, "02DFF1D77F2A671C5F36183726DB2341BE58FEAE1DA2DECED843240F7B502BA661"),
+	})
+	require.NoError(t, err)
+
+	var pubNonces [3]PublicNonce
+	copy(pubNonces[0][:], decodeHex(t, "0337C87821AFD50A8644D820A8F3E02E499C931865C2360FB43D0A0D20DAFE07EA0287BF891D2A6DEAEBADC909352AA9405D1428C15F4B75F04DAE642A95C2548480"))
+	copy(pubNonces[1][:], decodeHex(t, "0279BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F817980279BE667EF9DCBBAC55A06295CE870B07029BFCDB2DCE28D959F2815B16F81798"))
+	copy(pubNonces[2][:], decodeHex(t, "032DE2662628C90B03F5E720284EB52FF7D71F4284F627B68A853D78C78E1FFE9303E4C5524E83FFE1493B9077CF1CA6BEB2090C93D930321071AD40B2F44E599046"))
+	aggNonce, err := AggregateNonces(pubNonces[:])
+	require.NoError(t, err)
+	require.Equal(t, decodeHex(t, "028465FCF0BBDBCF443AABCCE533D42B4B5A10966AC09A49655E8C42DAAB8FCD61037496A3CC86926D452CAFCFD55D25972CA1675D549310DE296BFF42F72EEEA8C9"), aggNonce[:])
+
+	session, err := NewSession(ctx, aggNonce, decodeHex(t, "F95466D086770E689964664219266FE5ED215C92AE20BAB5C9D79ADDDDF3C0CF"))
+	require.NoError(t, err)
+
+	secnonce := decodeHex(t, "508B81A611F100A6B2B6B29656590898AF488BCF2E1F55CF22E5CFB84421FE61FA27FD49B1D50085B481285E1CA205D55C82CC1B31FF5CD54A489829355901F7")
+	nonce := &SecretNonce{pk: sk.PubKey().SerializeCompressed()}
+	nonce.k1.SetByteSlice(secnonce[:32])
+	nonce.k2.SetByteSlice(secnonce[32:])
+	psig, err := session.Sign(nonce, sk)
+	require.NoError(t, err)
+	require.Equal(t, decodeHex(t, "012ABBCB52B3016AC03AD82395A1A415C48B93DEF78718E62A7A90052FE224FB"), psig)
+	require.NoError(t, session.VerifyPartial(psig, pubNonces[0], sk.PubKey()))
+
+	// 一次性随机数不能被再次使用。
+	_, err = session.Sign(nonce, sk)
+	require.EqualError(t, err, "invalid secret nonce, it has already been used")
+}
+
+// BIP-327 的随机数生成测试向量。
+func TestNonceGenVectors(t *testing.T) {
+	randPrime := make([]byte, 32)
+	sk := secp.PrivKeyFromBytes(decodeHex(t, "0202020202020202020202020202020202020202020202020202020202020202"))
+	nonce, _, err := generateNonce(randPrime, sk.PubKey(), sk, decodeHex(t, "0707070707070707070707070707070707070707070707070707070707070707"), decodeHex(t, ""), decodeHex(t, "0808080808080808080808080808080808080808080808080808080808080808"))
+	require.NoError(t, err)
+	k1, k2 := nonce.k1.Bytes(), nonce.k2.Bytes()
+	require.Equal(t, decodeHex(t, "CD0F47FE471D6788FF3243F47345EA0A179AEF69476BE8348322EF39C2723318870C2065AFB52DEDF02BF4FDBF6D2F442E608692F50C2374C08FFFE57042A61C024D4B6CD1361032CA9BD2AEB9D900AA4D45D9EAD80AC9423374C451A7254D0766"), append(append(k1[:], k2[:]...), nonce.pk...))
+
+	pub, err := secp.ParsePubKey(decodeHex(t, "02F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9"))
+	require.NoError(t, err)
+	nonce, _, err = generateNonce(randPrime, pub, nil, nil, nil, nil)
+	require.NoError(t, err)
+	k1, k2 = nonce.k1.Bytes(), nonce.k2.Bytes()
+	require.Equal(t, decodeHex(t, "890E83616A3BC4640AB9B6374F21C81FF89CDDDBAFAA7475AE2A102A92E3EDB29FD7E874E23342813A60D9646948242646B7951CA046B4B36D7D6078506D3C9402F9308A019258C31049344F85F89D5229B531C845836F99B08601F113BCE036F9"), append(append(k1[:], k2[:]...), nonce.pk...))
+}
+
+func TestMuSig2(t *testing.T) {
+	const n = 3
+	msg := []byte("block 42")
+
+	sks := make([]*secp.PrivateKey, n)
+	pubs := make([]*secp.PublicKey, n)
+	for i := range sks {
+		sk, err := GenerateKey()
+		require.NoError(t, err)
+		sks[i], pubs[i] = sk, sk.PubKey()
+	}
+	keys, err := AggregateKeys(SortKeys(pubs))
+	require.NoError(t, err)
+
+	nonces := make([]*SecretNonce, n)
+	pubNonces := make([]PublicNonce, n)
+	for i := range sks {
+		nonces[i], pubNonces[i], err = GenerateNonce(pubs[i], NonceOptions{PrivateKey: sks[i], Keys: keys, Message: msg})
+		require.NoError(t, err)
+	}
+	aggNonce, err := AggregateNonces(pubNonces)
+	require.NoError(t, err)
+
+	psigs := make([][]byte, n)
+	for i := range sks {
+		session, err := NewSession(keys, aggNonce, msg)
+		require.NoError(t, err)
+		psigs[i], err = session.Sign(nonces[i], sks[i])
+		require.NoError(t, err)
+	}
+
+	session, err := NewSession(keys, aggNonce, msg)
+	require.NoError(t, err)
+	for i := range psigs {
+		require.NoError(t, session.VerifyPartial(psigs[i], pubNonces[i], pubs[i]))
+	}
+	require.EqualError(t, session.VerifyPartial(psigs[0], pubNonces[1], pubs[1]), "invalid partial signature")
+
+	sig, err := session.Aggregate(psigs)
+	require.NoError(t, err)
+	require.True(t, Verify(keys.PublicKey(), msg, sig))
+	require.NoError(t, VerifyXOnly(keys.XOnly(), msg, sig))
+	require.False(t, Verify(keys.PublicKey(), []byte("block 43"), sig))
+
+	// 缺少任意一个成员的部分签名，聚合之后的签名都无效。
+	sig, err = session.Aggregate(psigs[1:])
+	require.NoError(t, err)
+	require.False(t, Verify(keys.PublicKey(), msg, sig))
+
+	// 不在聚合公钥中的成员不能参与签名。
+	outsider, err := GenerateKey()
+	require.NoError(t, err)
+	nonce, _, err := GenerateNonce(outsider.PubKey(), NonceOptions{})
+	require.NoError(t, err)
+	_, err = session.Sign(nonce, outsider)
+	require.EqualError(t, err, "the public key is not part of the aggregate public key")
+
+	nonce, _, err = GenerateNonce(pubs[0], NonceOptions{})
+	require.NoError(t, err)
+	_, err = session.Sign(nonce, sks[1])
+	require.EqualError(t, err, "the secret nonce was generated for a different public key")
+
+	// 被放弃的随机数销毁之后不能再用于签名。
+	nonce, _, err = GenerateNonce(pubs[0], NonceOptions{})
+	require.NoError(t, err)
+	require.Equal(t, secret.Placeholder, fmt.Sprint(nonce))
+	nonce.Destroy()
+	_, err = session.Sign(nonce, sks[0])
+	require.EqualError(t, err, "invalid secret nonce, it has already been used")
+}
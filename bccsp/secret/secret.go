@@ -0,0 +1,41 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package secret
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"io"
+)
+
+// Placeholder 是秘密在日志与调试输出中显示的内容。
+const Placeholder = "[REDACTED]"
+
+// Redacted 嵌入到保存私钥等秘密的结构体中，使其无论以何种格式传给 fmt、日志器或
+// encoding/json，都只输出 Placeholder，避免私钥出现在调试日志中。
+type Redacted struct{}
+
+func (Redacted) String() string {
+	return Placeholder
+}
+
+func (Redacted) GoString() string {
+	return Placeholder
+}
+
+// Format 覆盖 fmt 的所有格式化动词，包括 %x 与 %d 等不会调用 String 的动词。
+func (Redacted) Format(f fmt.State, verb rune) {
+	io.WriteString(f, Placeholder)
+}
+
+func (Redacted) MarshalJSON() ([]byte, error) {
+	return []byte(`"` + Placeholder + `"`), nil
+}
+
+// Equal 以常数时间比较两个 MAC 或其他秘密值是否相同，比较所用的时间只与长度有关。
+func Equal(a, b []byte) bool {
+	return subtle.ConstantTimeCompare(a, b) == 1
+}
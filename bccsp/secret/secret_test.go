@@ -0,0 +1,53 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package secret
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/geistwelt/quarkx/common/qlogging"
+	"github.com/stretchr/testify/require"
+)
+
+type privateKey struct {
+	Redacted
+	d []byte
+}
+
+func TestRedacted(t *testing.T) {
+	k := &privateKey{d: []byte("private key bytes")}
+
+	for _, format := range []string{"%v", "%+v", "%#v", "%s", "%q", "%x", "%X", "%d"} {
+		require.Equal(t, Placeholder, fmt.Sprintf(format, k), format)
+		require.Equal(t, Placeholder, fmt.Sprintf(format, *k), format)
+	}
+	require.Equal(t, "["+Placeholder+"]", fmt.Sprint([]*privateKey{k}))
+
+	raw, err := json.Marshal(map[string]interface{}{"key": k})
+	require.NoError(t, err)
+	require.JSONEq(t, `{"key":"[REDACTED]"}`, string(raw))
+
+	for _, format := range []string{"json", "logfmt"} {
+		buf := new(bytes.Buffer)
+		logging, err := qlogging.New(qlogging.Config{Format: format, LogSpec: "debug", Writer: buf})
+		require.NoError(t, err)
+		logger := logging.Logger("secret")
+		logger.Debugw("loaded key", "key", k)
+		logger.Debugf("loaded key %v", k)
+		require.NotContains(t, buf.String(), "private key bytes", format)
+		require.Equal(t, 2, strings.Count(buf.String(), Placeholder), format)
+	}
+}
+
+func TestEqual(t *testing.T) {
+	require.True(t, Equal([]byte("mac"), []byte("mac")))
+	require.False(t, Equal([]byte("mac"), []byte("maC")))
+	require.False(t, Equal([]byte("mac"), []byte("mac1")))
+}
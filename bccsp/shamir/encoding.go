@@ -0,0 +1,121 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package shamir
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/binary"
+	"encoding/pem"
+	"errors"
+	"fmt"
+)
+
+const (
+	shareVersion   = 1
+	checksumLength = 4
+	sharePEMType   = "QUARKX SECRET SHARE"
+)
+
+var shareMagic = []byte("QXS")
+
+// MarshalShare 将分片编码成如下格式的字节序列，末尾附带 SHA-256 校验和的前 4 个字节，
+// 用来发现分片在保管或传输过程中出现的损坏：
+//
+//	magic(3) | version(1) | set id(8) | threshold(1) | index(1) | length(2) | value | checksum(4)
+func MarshalShare(share *Share) ([]byte, error) {
+	if share == nil {
+		return nil, errors.New("invalid share, it must be different from nil")
+	}
+	if share.Index == 0 {
+		return nil, errors.New("invalid share, index must be larger than 0")
+	}
+	if len(share.Value) == 0 || len(share.Value) > 0xffff {
+		return nil, fmt.Errorf("invalid share, value length [%d] is out of range", len(share.Value))
+	}
+
+	buf := new(bytes.Buffer)
+	buf.Write(shareMagic)
+	buf.WriteByte(shareVersion)
+	buf.Write(share.SetID[:])
+	buf.WriteByte(share.Threshold)
+	buf.WriteByte(share.Index)
+	binary.Write(buf, binary.BigEndian, uint16(len(share.Value)))
+	buf.Write(share.Value)
+
+	sum := sha256.Sum256(buf.Bytes())
+	buf.Write(sum[:checksumLength])
+
+	return buf.Bytes(), nil
+}
+
+// UnmarshalShare 解析由 MarshalShare 编码的分片，并校验其校验和。
+func UnmarshalShare(raw []byte) (*Share, error) {
+	headerLength := len(shareMagic) + 1 + 8 + 1 + 1 + 2
+	if len(raw) < headerLength+checksumLength {
+		return nil, fmt.Errorf("invalid share, length [%d] is too short", len(raw))
+	}
+
+	body, checksum := raw[:len(raw)-checksumLength], raw[len(raw)-checksumLength:]
+	sum := sha256.Sum256(body)
+	if subtle.ConstantTimeCompare(sum[:checksumLength], checksum) != 1 {
+		return nil, errors.New("invalid share, checksum mismatch")
+	}
+
+	if !bytes.Equal(body[:len(shareMagic)], shareMagic) {
+		return nil, errors.New("invalid share, unrecognized magic")
+	}
+	body = body[len(shareMagic):]
+
+	if body[0] != shareVersion {
+		return nil, fmt.Errorf("invalid share, unsupported version [%d]", body[0])
+	}
+	body = body[1:]
+
+	share := &Share{}
+	copy(share.SetID[:], body[:8])
+	share.Threshold = body[8]
+	share.Index = body[9]
+	length := int(binary.BigEndian.Uint16(body[10:12]))
+	body = body[12:]
+
+	if share.Index == 0 {
+		return nil, errors.New("invalid share, index must be larger than 0")
+	}
+	if share.Threshold < 2 {
+		return nil, fmt.Errorf("invalid share, threshold [%d] must be at least 2", share.Threshold)
+	}
+	if length == 0 || length != len(body) {
+		return nil, fmt.Errorf("invalid share, declared length [%d] does not match actual length [%d]", length, len(body))
+	}
+	share.Value = append([]byte(nil), body...)
+
+	return share, nil
+}
+
+// ShareToPEM 将分片编码成 PEM 格式，便于打印或交给不同的保管人离线保存。
+func ShareToPEM(share *Share) ([]byte, error) {
+	raw, err := MarshalShare(share)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: sharePEMType, Bytes: raw}), nil
+}
+
+// PEMToShare 解析由 ShareToPEM 编码的分片。
+func PEMToShare(raw []byte) (*Share, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("failed decoding PEM, block must be different from nil")
+	}
+	if block.Type != sharePEMType {
+		return nil, fmt.Errorf("invalid PEM block type [%s], expected [%s]", block.Type, sharePEMType)
+	}
+
+	return UnmarshalShare(block.Bytes)
+}
@@ -0,0 +1,45 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package shamir
+
+import (
+	"crypto/ecdsa"
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+// SplitECDSAPrivateKey 将椭圆曲线私钥以 SEC 1 DER 格式编码后拆分成 n 个分片，任意 t 个
+// 分片即可恢复出私钥。曲线信息包含在 DER 编码中，恢复时不需要额外指定。
+func SplitECDSAPrivateKey(k *ecdsa.PrivateKey, n, t int) ([]*Share, error) {
+	if k == nil {
+		return nil, errors.New("invalid private key, it must be different from nil")
+	}
+
+	der, err := x509.MarshalECPrivateKey(k)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal private key [%v]", err)
+	}
+	defer zero(der)
+
+	return Split(der, n, t)
+}
+
+// CombineECDSAPrivateKey 从分片中恢复出由 SplitECDSAPrivateKey 拆分的椭圆曲线私钥。
+func CombineECDSAPrivateKey(shares []*Share) (*ecdsa.PrivateKey, error) {
+	der, err := Combine(shares)
+	if err != nil {
+		return nil, err
+	}
+	defer zero(der)
+
+	k, err := x509.ParseECPrivateKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse recovered private key [%v]", err)
+	}
+
+	return k, nil
+}
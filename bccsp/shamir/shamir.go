@@ -0,0 +1,195 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package shamir
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+	"github.com/geistwelt/quarkx/bccsp/secret"
+)
+
+// Share 是秘密被拆分后得到的一个分片。Index 是分片在多项式上的横坐标，取值范围
+// 为 [1, 255]；Threshold 是恢复秘密至少需要的分片个数；SetID 用来标识同一次拆分
+// 产生的所有分片，避免把不同批次的分片混在一起恢复。分片在 fmt 与日志中只显示为
+// [REDACTED]。
+type Share struct {
+	secret.Redacted
+
+	SetID     [8]byte
+	Threshold byte
+	Index     byte
+	Value     []byte
+}
+
+// Destroy 将分片的取值清零，分片不再需要时应该调用。
+func (s *Share) Destroy() {
+	zero(s.Value)
+	s.Value = nil
+}
+
+// Split 在 GF(256) 上把 secret 拆分成 n 个分片，任意 t 个分片即可恢复出 secret，
+// 少于 t 个分片则无法获得关于 secret 的任何信息。要求 2 <= t <= n <= 255。
+// 多项式的系数取自 entropy.Reader。
+func Split(secret []byte, n, t int) ([]*Share, error) {
+	return SplitWithRand(entropy.Reader, secret, n, t)
+}
+
+// SplitWithRand 与 Split 相同，多项式的系数取自随机源 rand。
+func SplitWithRand(reader io.Reader, secret []byte, n, t int) ([]*Share, error) {
+	if len(secret) == 0 {
+		return nil, errors.New("invalid secret, it must not be empty")
+	}
+	if t < 2 {
+		return nil, fmt.Errorf("invalid threshold [%d], it must be at least 2", t)
+	}
+	if n < t {
+		return nil, fmt.Errorf("invalid number of shares [%d], it must not be less than threshold [%d]", n, t)
+	}
+	if n > 255 {
+		return nil, fmt.Errorf("invalid number of shares [%d], it must not be larger than 255", n)
+	}
+
+	var setID [8]byte
+	if _, err := io.ReadFull(reader, setID[:]); err != nil {
+		return nil, fmt.Errorf("failed to generate share set id [%v]", err)
+	}
+
+	shares := make([]*Share, n)
+	for i := range shares {
+		shares[i] = &Share{
+			SetID:     setID,
+			Threshold: byte(t),
+			Index:     byte(i + 1),
+			Value:     make([]byte, len(secret)),
+		}
+	}
+
+	// 对 secret 的每个字节单独构造一个 t-1 次多项式，常数项即为该字节。
+	coefficients := make([]byte, t)
+	defer zero(coefficients)
+	for j, b := range secret {
+		coefficients[0] = b
+		if _, err := io.ReadFull(reader, coefficients[1:]); err != nil {
+			return nil, fmt.Errorf("failed to generate polynomial coefficients [%v]", err)
+		}
+		for _, share := range shares {
+			share.Value[j] = evaluate(coefficients, share.Index)
+		}
+	}
+
+	return shares, nil
+}
+
+// Combine 利用拉格朗日插值法从给定的分片中恢复出秘密。分片数量不能少于拆分时设定
+// 的门限值，并且所有分片必须来自同一次拆分。
+func Combine(shares []*Share) ([]byte, error) {
+	if len(shares) == 0 {
+		return nil, errors.New("no shares provided")
+	}
+
+	first := shares[0]
+	if first == nil {
+		return nil, errors.New("invalid share, it must be different from nil")
+	}
+	if int(first.Threshold) < 2 {
+		return nil, fmt.Errorf("invalid share, threshold [%d] must be at least 2", first.Threshold)
+	}
+	if len(shares) < int(first.Threshold) {
+		return nil, fmt.Errorf("not enough shares, got [%d], but threshold is [%d]", len(shares), first.Threshold)
+	}
+
+	seen := make(map[byte]struct{}, len(shares))
+	for _, share := range shares {
+		if share == nil {
+			return nil, errors.New("invalid share, it must be different from nil")
+		}
+		if share.SetID != first.SetID {
+			return nil, errors.New("shares come from different splits")
+		}
+		if share.Threshold != first.Threshold {
+			return nil, errors.New("shares have inconsistent thresholds")
+		}
+		if len(share.Value) != len(first.Value) || len(share.Value) == 0 {
+			return nil, errors.New("shares have inconsistent lengths")
+		}
+		if share.Index == 0 {
+			return nil, errors.New("invalid share, index must be larger than 0")
+		}
+		if _, ok := seen[share.Index]; ok {
+			return nil, fmt.Errorf("duplicate share with index [%d]", share.Index)
+		}
+		seen[share.Index] = struct{}{}
+	}
+
+	// 只需要门限值个分片即可完成插值。
+	used := shares[:first.Threshold]
+	secret := make([]byte, len(first.Value))
+	for i, si := range used {
+		// 计算拉格朗日基多项式在 x = 0 处的取值：∏ xj / (xj - xi)，GF(256) 上减法即异或。
+		basis := byte(1)
+		for j, sj := range used {
+			if i == j {
+				continue
+			}
+			basis = mul(basis, div(sj.Index, sj.Index^si.Index))
+		}
+		for k := range secret {
+			secret[k] ^= mul(si.Value[k], basis)
+		}
+	}
+
+	return secret, nil
+}
+
+// evaluate 采用秦九韶算法计算多项式在 x 处的取值。
+func evaluate(coefficients []byte, x byte) byte {
+	result := byte(0)
+	for i := len(coefficients) - 1; i >= 0; i-- {
+		result = mul(result, x) ^ coefficients[i]
+	}
+	return result
+}
+
+// mul 计算 GF(256) 上的乘法，不可约多项式为 x^8 + x^4 + x^3 + x + 1（与 AES 相同）。
+// 实现中没有使用查表和分支，以避免通过时间侧信道泄露秘密。
+func mul(a, b byte) byte {
+	var p byte
+	for i := 0; i < 8; i++ {
+		p ^= -(b & 1) & a
+		carry := -(a >> 7)
+		a = (a << 1) ^ (carry & 0x1b)
+		b >>= 1
+	}
+	return p
+}
+
+// inverse 计算 a 在 GF(256) 上的乘法逆元，即 a^254。
+func inverse(a byte) byte {
+	b := mul(a, a)   // a^2
+	c := mul(a, b)   // a^3
+	b = mul(c, c)    // a^6
+	b = mul(b, b)    // a^12
+	c = mul(b, c)    // a^15
+	b = mul(b, b)    // a^24
+	b = mul(b, b)    // a^48
+	b = mul(b, c)    // a^63
+	b = mul(b, b)    // a^126
+	b = mul(a, b)    // a^127
+	return mul(b, b) // a^254
+}
+
+func div(a, b byte) byte {
+	return mul(a, inverse(b))
+}
+
+func zero(b []byte) {
+	for i := range b {
+		b[i] = 0
+	}
+}
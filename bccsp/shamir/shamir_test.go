@@ -0,0 +1,107 @@
+package shamir
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"fmt"
+	"testing"
+
+	"github.com/geistwelt/quarkx/bccsp/secret"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGF256(t *testing.T) {
+	for a := 1; a < 256; a++ {
+		require.Equal(t, byte(1), mul(byte(a), inverse(byte(a))))
+	}
+	// AES 标准中的例子：{57} • {83} = {c1}
+	require.Equal(t, byte(0xc1), mul(0x57, 0x83))
+}
+
+func TestSplitAndCombine(t *testing.T) {
+	secret := []byte("org1 admin signing key material")
+
+	shares, err := Split(secret, 5, 3)
+	require.NoError(t, err)
+	require.Len(t, shares, 5)
+
+	for _, subset := range [][]int{{0, 1, 2}, {4, 2, 0}, {1, 3, 4}, {0, 1, 2, 3, 4}} {
+		var picked []*Share
+		for _, i := range subset {
+			picked = append(picked, shares[i])
+		}
+		recovered, err := Combine(picked)
+		require.NoError(t, err)
+		require.Equal(t, secret, recovered)
+	}
+
+	_, err = Combine(shares[:2])
+	require.EqualError(t, err, "not enough shares, got [2], but threshold is [3]")
+
+	_, err = Combine([]*Share{shares[0], shares[0], shares[1]})
+	require.EqualError(t, err, "duplicate share with index [1]")
+
+	others, err := Split(secret, 5, 3)
+	require.NoError(t, err)
+	_, err = Combine([]*Share{shares[0], shares[1], others[2]})
+	require.EqualError(t, err, "shares come from different splits")
+}
+
+func TestSplitInvalidParameters(t *testing.T) {
+	_, err := Split(nil, 3, 2)
+	require.EqualError(t, err, "invalid secret, it must not be empty")
+
+	_, err = Split([]byte{1}, 3, 1)
+	require.EqualError(t, err, "invalid threshold [1], it must be at least 2")
+
+	_, err = Split([]byte{1}, 2, 3)
+	require.EqualError(t, err, "invalid number of shares [2], it must not be less than threshold [3]")
+
+	_, err = Split([]byte{1}, 256, 3)
+	require.EqualError(t, err, "invalid number of shares [256], it must not be larger than 255")
+}
+
+func TestShareEncoding(t *testing.T) {
+	shares, err := Split([]byte("secret"), 3, 2)
+	require.NoError(t, err)
+
+	raw, err := MarshalShare(shares[1])
+	require.NoError(t, err)
+	share, err := UnmarshalShare(raw)
+	require.NoError(t, err)
+	require.Equal(t, shares[1], share)
+
+	raw[len(raw)-5] ^= 0x01
+	_, err = UnmarshalShare(raw)
+	require.EqualError(t, err, "invalid share, checksum mismatch")
+
+	_, err = UnmarshalShare([]byte{0})
+	require.EqualError(t, err, "invalid share, length [1] is too short")
+
+	pemBytes, err := ShareToPEM(shares[2])
+	require.NoError(t, err)
+	share, err = PEMToShare(pemBytes)
+	require.NoError(t, err)
+	require.Equal(t, shares[2], share)
+
+	recovered, err := Combine([]*Share{share, shares[0]})
+	require.NoError(t, err)
+	require.Equal(t, []byte("secret"), recovered)
+
+	require.Equal(t, secret.Placeholder, fmt.Sprintf("%v", share))
+	share.Destroy()
+	require.Nil(t, share.Value)
+}
+
+func TestSplitECDSAPrivateKey(t *testing.T) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	shares, err := SplitECDSAPrivateKey(sk, 4, 3)
+	require.NoError(t, err)
+
+	recovered, err := CombineECDSAPrivateKey(shares[1:])
+	require.NoError(t, err)
+	require.True(t, sk.Equal(recovered))
+}
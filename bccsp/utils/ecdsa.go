@@ -7,6 +7,7 @@
 package utils
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"encoding/asn1"
@@ -45,32 +46,59 @@ func GetCurveHalfOrdersAt(c elliptic.Curve) *big.Int {
 	return new(big.Int).Set(curveHalfOrders[c])
 }
 
+// 签名解析与 low-S 检查返回的错误都包装了下面的哨兵错误，调用方可以用 errors.Is 区分
+// 构造异常、可能来自攻击者的签名与曲线不受支持之类的配置错误。
+var (
+	// ErrMalformedSignature 表示签名不是合法的 DER 编码的 ECDSASignature。
+	ErrMalformedSignature = errors.New("malformed signature")
+	// ErrNonPositiveR 表示签名的 R 不大于 0。
+	ErrNonPositiveR = errors.New("invalid signature, R must be larger than 0")
+	// ErrNonPositiveS 表示签名的 S 不大于 0。
+	ErrNonPositiveS = errors.New("invalid signature, S must be larger than 0")
+	// ErrHighS 表示签名的 S 大于 base point 的阶的一半，验证方要求 low-S 时应拒绝该签名。
+	ErrHighS = errors.New("invalid S, must be smaller than half the order")
+	// ErrUnknownCurve 表示公钥所在的曲线不在 curveHalfOrders 中。
+	ErrUnknownCurve = errors.New("curve not recognized")
+)
+
 // MarshalECDSASignature 将椭圆曲线签名转化为用于描述数据结构的标记语言。
 func MarshalECDSASignature(r, s *big.Int) ([]byte, error) {
 	return asn1.Marshal(ECDSASignature{R: r, S: s})
 }
 
-// UnmarshalECDSASignature 反序列化椭圆曲线签名，先后返回签名的 R 和 S。
+// UnmarshalECDSASignature 反序列化椭圆曲线签名，先后返回签名的 R 和 S。签名必须是规范
+// 的 DER 编码，之后不能带有多余的字节。
 func UnmarshalECDSASignature(raw []byte) (*big.Int, *big.Int, error) {
 	sig := new(ECDSASignature)
-	if _, err := asn1.Unmarshal(raw, sig); err != nil {
-		return nil, nil, fmt.Errorf("failed to unmarshal signature [%v]", err)
+	rest, err := asn1.Unmarshal(raw, sig)
+	if err != nil {
+		return nil, nil, fmt.Errorf("%w [%v]", ErrMalformedSignature, err)
+	}
+
+	if len(rest) != 0 {
+		return nil, nil, fmt.Errorf("%w, %d trailing bytes after the signature", ErrMalformedSignature, len(rest))
 	}
 
 	if sig.R == nil {
-		return nil, nil, errors.New("invalid signature, R must be different from nil")
+		return nil, nil, fmt.Errorf("%w, R must be different from nil", ErrMalformedSignature)
 	}
 
 	if sig.S == nil {
-		return nil, nil, errors.New("invalid signature, S must be different from nil")
+		return nil, nil, fmt.Errorf("%w, S must be different from nil", ErrMalformedSignature)
 	}
 
 	if sig.R.Sign() != 1 {
-		return nil, nil, errors.New("invalid signature, R must be larger than 0")
+		return nil, nil, ErrNonPositiveR
 	}
 
 	if sig.S.Sign() != 1 {
-		return nil, nil, errors.New("invalid signature, S must be larger than 0")
+		return nil, nil, ErrNonPositiveS
+	}
+
+	// encoding/asn1 会忽略 SEQUENCE 内部多余的元素，重新编码后比较可以拒绝所有非规范的
+	// 编码，保证同一个签名只有一种合法的字节表示。
+	if canonical, err := MarshalECDSASignature(sig.R, sig.S); err != nil || !bytes.Equal(canonical, raw) {
+		return nil, nil, fmt.Errorf("%w, non-canonical DER encoding", ErrMalformedSignature)
 	}
 
 	return sig.R, sig.S, nil
@@ -91,24 +119,50 @@ func SignatureToLowS(k *ecdsa.PublicKey, signature []byte) ([]byte, error) {
 	return MarshalECDSASignature(r, s)
 }
 
-// IsLowS 签名 s 大于椭圆曲线 base point 的阶的一半时返回 true。
+// IsLowS 签名 s 不大于椭圆曲线 base point 的阶的一半时返回 true。
 func IsLowS(k *ecdsa.PublicKey, s *big.Int) (bool, error) {
+	if k == nil || k.Curve == nil {
+		return false, fmt.Errorf("%w, public key must be different from nil", ErrUnknownCurve)
+	}
+
 	halfOrder, ok := curveHalfOrders[k.Curve]
 	if !ok {
-		return false, fmt.Errorf("curve not recognized [%s]", k.Curve)
+		return false, fmt.Errorf("%w [%s]", ErrUnknownCurve, k.Curve.Params().Name)
+	}
+
+	if s == nil || s.Sign() != 1 {
+		return false, ErrNonPositiveS
 	}
 
 	return s.Cmp(halfOrder) != 1, nil
 }
 
+// CheckLowS 检查签名 s 是否为 low-S 形式，high-S 签名返回包装了 ErrHighS 的错误，错误中
+// 带有 s 与阶的一半。验证方要求 low-S 时应统一使用它，而不是自行拼接错误信息。
+func CheckLowS(k *ecdsa.PublicKey, s *big.Int) error {
+	lowS, err := IsLowS(k, s)
+	if err != nil {
+		return err
+	}
+	if !lowS {
+		return fmt.Errorf("%w [%s][%s]", ErrHighS, s, GetCurveHalfOrdersAt(k.Curve))
+	}
+
+	return nil
+}
+
 // ToLowS 如果签名 s 大于椭圆曲线 base point 的阶的一半，那么就用 base point 的阶减去 s，
-// 并让 s 等于它。这样的转化不会破坏签名的正确性。
+// 并让 s 等于它。这样的转化不会破坏签名的正确性。s 不小于阶时返回 ErrMalformedSignature。
 func ToLowS(k *ecdsa.PublicKey, s *big.Int) (*big.Int, error) {
 	lowS, err := IsLowS(k, s)
 	if err != nil {
 		return nil, err
 	}
 
+	if s.Cmp(k.Params().N) >= 0 {
+		return nil, fmt.Errorf("%w, S must be smaller than the order", ErrMalformedSignature)
+	}
+
 	if !lowS {
 		s.Sub(k.Params().N, s)
 		return s, nil
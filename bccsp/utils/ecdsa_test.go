@@ -1,10 +1,12 @@
 package utils
 
 import (
+	"bytes"
 	"crypto/ecdsa"
 	"crypto/elliptic"
 	"crypto/rand"
 	"crypto/sha256"
+	"errors"
 	"fmt"
 	"math/big"
 	"testing"
@@ -58,43 +60,43 @@ func TestHalfCurveSignature(t *testing.T) {
 
 func TestUnmarshalECDSASignature(t *testing.T) {
 	_, _, err := UnmarshalECDSASignature(nil)
-	require.Contains(t, err.Error(), "failed to unmarshal signature [")
+	require.ErrorIs(t, err, ErrMalformedSignature)
 
 	_, _, err = UnmarshalECDSASignature([]byte{})
-	require.Contains(t, err.Error(), "failed to unmarshal signature [")
+	require.ErrorIs(t, err, ErrMalformedSignature)
 
 	_, _, err = UnmarshalECDSASignature([]byte{0})
-	require.Contains(t, err.Error(), "failed to unmarshal signature [")
+	require.ErrorIs(t, err, ErrMalformedSignature)
 
 	sig, err := MarshalECDSASignature(big.NewInt(-1), big.NewInt(1))
 	require.NoError(t, err)
 	_, _, err = UnmarshalECDSASignature(sig)
-	require.Equal(t, err.Error(), "invalid signature, R must be larger than 0")
+	require.ErrorIs(t, err, ErrNonPositiveR)
 
 	sig, err = MarshalECDSASignature(big.NewInt(1), big.NewInt(-1))
 	require.NoError(t, err)
 	_, _, err = UnmarshalECDSASignature(sig)
-	require.Equal(t, err.Error(), "invalid signature, S must be larger than 0")
+	require.ErrorIs(t, err, ErrNonPositiveS)
 
 	sig, err = MarshalECDSASignature(big.NewInt(-1), big.NewInt(-1))
 	require.NoError(t, err)
 	_, _, err = UnmarshalECDSASignature(sig)
-	require.Equal(t, err.Error(), "invalid signature, R must be larger than 0")
+	require.ErrorIs(t, err, ErrNonPositiveR)
 
 	sig, err = MarshalECDSASignature(big.NewInt(0), big.NewInt(1))
 	require.NoError(t, err)
 	_, _, err = UnmarshalECDSASignature(sig)
-	require.Equal(t, err.Error(), "invalid signature, R must be larger than 0")
+	require.ErrorIs(t, err, ErrNonPositiveR)
 
 	sig, err = MarshalECDSASignature(big.NewInt(1), big.NewInt(0))
 	require.NoError(t, err)
 	_, _, err = UnmarshalECDSASignature(sig)
-	require.Equal(t, err.Error(), "invalid signature, S must be larger than 0")
+	require.ErrorIs(t, err, ErrNonPositiveS)
 
 	sig, err = MarshalECDSASignature(big.NewInt(0), big.NewInt(0))
 	require.NoError(t, err)
 	_, _, err = UnmarshalECDSASignature(sig)
-	require.Equal(t, err.Error(), "invalid signature, R must be larger than 0")
+	require.ErrorIs(t, err, ErrNonPositiveR)
 
 	sig, err = MarshalECDSASignature(big.NewInt(1), big.NewInt(1))
 	require.NoError(t, err)
@@ -102,4 +104,120 @@ func TestUnmarshalECDSASignature(t *testing.T) {
 	require.NoError(t, err)
 	require.Equal(t, int64(1), r.Int64())
 	require.Equal(t, int64(1), s.Int64())
+
+	_, _, err = UnmarshalECDSASignature(append(sig, 0))
+	require.ErrorIs(t, err, ErrMalformedSignature)
+	require.EqualError(t, err, "malformed signature, 1 trailing bytes after the signature")
+
+	// SEQUENCE 内部多余的元素。
+	_, _, err = UnmarshalECDSASignature([]byte{0x30, 0x09, 0x02, 0x01, 0x01, 0x02, 0x01, 0x01, 0x02, 0x01, 0x01})
+	require.EqualError(t, err, "malformed signature, non-canonical DER encoding")
+}
+
+func TestLowSErrors(t *testing.T) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	_, err = IsLowS(nil, big.NewInt(1))
+	require.ErrorIs(t, err, ErrUnknownCurve)
+	_, err = IsLowS(&sk.PublicKey, big.NewInt(0))
+	require.ErrorIs(t, err, ErrNonPositiveS)
+	_, err = ToLowS(&sk.PublicKey, nil)
+	require.ErrorIs(t, err, ErrNonPositiveS)
+
+	unknown := &ecdsa.PublicKey{Curve: elliptic.P256().Params(), X: sk.X, Y: sk.Y}
+	_, err = IsLowS(unknown, big.NewInt(1))
+	require.ErrorIs(t, err, ErrUnknownCurve)
+	require.EqualError(t, err, "curve not recognized [P-256]")
+
+	sig, err := MarshalECDSASignature(big.NewInt(1), big.NewInt(1))
+	require.NoError(t, err)
+	_, err = SignatureToLowS(unknown, sig)
+	require.ErrorIs(t, err, ErrUnknownCurve)
+	_, err = SignatureToLowS(&sk.PublicKey, sig[:len(sig)-1])
+	require.ErrorIs(t, err, ErrMalformedSignature)
+
+	halfOrder := GetCurveHalfOrdersAt(elliptic.P256())
+	require.NoError(t, CheckLowS(&sk.PublicKey, halfOrder))
+	err = CheckLowS(&sk.PublicKey, new(big.Int).Add(halfOrder, big.NewInt(1)))
+	require.ErrorIs(t, err, ErrHighS)
+	require.EqualError(t, err, fmt.Sprintf("invalid S, must be smaller than half the order [%s][%s]", new(big.Int).Add(halfOrder, big.NewInt(1)), halfOrder))
+	require.ErrorIs(t, CheckLowS(unknown, big.NewInt(1)), ErrUnknownCurve)
+
+	// S 不小于阶的签名无法转换成 low-S 形式。
+	_, err = ToLowS(&sk.PublicKey, new(big.Int).Set(sk.Params().N))
+	require.ErrorIs(t, err, ErrMalformedSignature)
+	require.EqualError(t, err, "malformed signature, S must be smaller than the order")
+}
+
+func FuzzUnmarshalECDSASignature(f *testing.F) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(f, err)
+	digest := sha256.Sum256([]byte("hello, quarkx"))
+	sig, err := ecdsa.SignASN1(rand.Reader, sk, digest[:])
+	require.NoError(f, err)
+	f.Add(sig)
+	f.Add([]byte{})
+	f.Add([]byte{0x30, 0x06, 0x02, 0x01, 0x00, 0x02, 0x01, 0x01})
+	f.Add([]byte{0x30, 0x06, 0x02, 0x01, 0x01, 0x02, 0x01, 0x01, 0x00})
+
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		r, s, err := UnmarshalECDSASignature(raw)
+		if err != nil {
+			if !errors.Is(err, ErrMalformedSignature) && !errors.Is(err, ErrNonPositiveR) && !errors.Is(err, ErrNonPositiveS) {
+				t.Fatalf("unexpected error type [%v]", err)
+			}
+			return
+		}
+
+		if r.Sign() != 1 || s.Sign() != 1 {
+			t.Fatalf("accepted non-positive R or S [%s][%s]", r, s)
+		}
+		// 能够解析的签名一定是规范的 DER 编码。
+		encoded, err := MarshalECDSASignature(r, s)
+		if err != nil {
+			t.Fatalf("failed to marshal parsed signature [%v]", err)
+		}
+		if !bytes.Equal(encoded, raw) {
+			t.Fatalf("non-canonical encoding accepted [%x]", raw)
+		}
+	})
+}
+
+func FuzzSignatureToLowS(f *testing.F) {
+	sk, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(f, err)
+	digest := sha256.Sum256([]byte("hello, quarkx"))
+	sig, err := ecdsa.SignASN1(rand.Reader, sk, digest[:])
+	require.NoError(f, err)
+	f.Add(sig)
+	f.Add([]byte{0x30, 0x06, 0x02, 0x01, 0x01, 0x02, 0x01, 0x01})
+
+	f.Fuzz(func(t *testing.T, raw []byte) {
+		lowS, err := SignatureToLowS(&sk.PublicKey, raw)
+		if err != nil {
+			if !errors.Is(err, ErrMalformedSignature) && !errors.Is(err, ErrNonPositiveR) && !errors.Is(err, ErrNonPositiveS) {
+				t.Fatalf("unexpected error type [%v]", err)
+			}
+			return
+		}
+
+		r, s, err := UnmarshalECDSASignature(raw)
+		if err != nil {
+			t.Fatalf("failed to parse accepted signature [%v]", err)
+		}
+		if s.Cmp(sk.Params().N) >= 0 {
+			t.Fatalf("accepted S larger than the order [%s]", s)
+		}
+		normalizedR, normalizedS, err := UnmarshalECDSASignature(lowS)
+		if err != nil {
+			t.Fatalf("failed to parse normalized signature [%v]", err)
+		}
+		if normalizedR.Cmp(r) != 0 {
+			t.Fatalf("R changed after normalization")
+		}
+		if ok, err := IsLowS(&sk.PublicKey, normalizedS); err != nil || !ok {
+			t.Fatalf("normalized S is not low-S [%s]", normalizedS)
+		}
+	})
 }
@@ -0,0 +1,41 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package utils
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"fmt"
+	"hash"
+	"strings"
+
+	"golang.org/x/crypto/sha3"
+)
+
+// GetHashFunc 根据节点安全配置中的哈希族（SHA2 或 SHA3）和安全级别（256 或 384）返回
+// 对应的哈希函数，与 Fabric 软件 BCCSP 的安全级别配置一致。
+func GetHashFunc(family string, level int) (func() hash.Hash, error) {
+	switch strings.ToUpper(family) {
+	case "SHA2":
+		switch level {
+		case 256:
+			return sha256.New, nil
+		case 384:
+			return sha512.New384, nil
+		}
+	case "SHA3":
+		switch level {
+		case 256:
+			return sha3.New256, nil
+		case 384:
+			return sha3.New384, nil
+		}
+	default:
+		return nil, fmt.Errorf("hash family not supported [%s]", family)
+	}
+
+	return nil, fmt.Errorf("security level not supported [%d]", level)
+}
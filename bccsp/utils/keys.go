@@ -0,0 +1,165 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package utils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+)
+
+// SKI 计算椭圆曲线公钥的 Subject Key Identifier，即公钥未压缩编码的 SHA-256 哈希值，
+// 与 Fabric 中 ECDSA 密钥的 SKI 定义保持一致。无论公钥最初以压缩还是未压缩的形式
+// 导入，同一个公钥的 SKI 都相同。
+func SKI(k *ecdsa.PublicKey) []byte {
+	if k == nil {
+		return nil
+	}
+
+	raw := elliptic.Marshal(k.Curve, k.X, k.Y)
+	hash := sha256.Sum256(raw)
+	return hash[:]
+}
+
+// PrivateKeyToDER 将椭圆曲线私钥编码成 PKCS#8 DER 格式。
+func PrivateKeyToDER(k *ecdsa.PrivateKey) ([]byte, error) {
+	if k == nil {
+		return nil, errors.New("invalid ecdsa private key, it must be different from nil")
+	}
+
+	return x509.MarshalPKCS8PrivateKey(k)
+}
+
+// DERToPrivateKey 解析 DER 格式的椭圆曲线私钥，支持 PKCS#8 与 SEC 1 两种编码。
+func DERToPrivateKey(der []byte) (*ecdsa.PrivateKey, error) {
+	if key, err := x509.ParsePKCS8PrivateKey(der); err == nil {
+		k, ok := key.(*ecdsa.PrivateKey)
+		if !ok {
+			return nil, fmt.Errorf("unsupported private key type [%T]", key)
+		}
+		return k, nil
+	}
+
+	if k, err := x509.ParseECPrivateKey(der); err == nil {
+		return k, nil
+	}
+
+	return nil, errors.New("invalid key type, the DER must contain an ecdsa private key")
+}
+
+// PrivateKeyToPEM 将椭圆曲线私钥编码成 PKCS#8 PEM 格式。
+func PrivateKeyToPEM(k *ecdsa.PrivateKey) ([]byte, error) {
+	der, err := PrivateKeyToDER(k)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), nil
+}
+
+// PEMToPrivateKey 解析 PEM 格式的椭圆曲线私钥。
+func PEMToPrivateKey(raw []byte) (*ecdsa.PrivateKey, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("failed decoding PEM, block must be different from nil")
+	}
+
+	return DERToPrivateKey(block.Bytes)
+}
+
+// PublicKeyToDER 将椭圆曲线公钥编码成 PKIX DER 格式。
+func PublicKeyToDER(k *ecdsa.PublicKey) ([]byte, error) {
+	if k == nil {
+		return nil, errors.New("invalid ecdsa public key, it must be different from nil")
+	}
+
+	return x509.MarshalPKIXPublicKey(k)
+}
+
+// DERToPublicKey 解析 PKIX DER 格式的椭圆曲线公钥。
+func DERToPublicKey(der []byte) (*ecdsa.PublicKey, error) {
+	key, err := x509.ParsePKIXPublicKey(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse public key [%v]", err)
+	}
+
+	k, ok := key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type [%T]", key)
+	}
+
+	return k, nil
+}
+
+// PublicKeyToPEM 将椭圆曲线公钥编码成 PKIX PEM 格式。
+func PublicKeyToPEM(k *ecdsa.PublicKey) ([]byte, error) {
+	der, err := PublicKeyToDER(k)
+	if err != nil {
+		return nil, err
+	}
+
+	return pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der}), nil
+}
+
+// PEMToPublicKey 解析 PEM 格式的椭圆曲线公钥。
+func PEMToPublicKey(raw []byte) (*ecdsa.PublicKey, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("failed decoding PEM, block must be different from nil")
+	}
+
+	return DERToPublicKey(block.Bytes)
+}
+
+// PublicKeyToSEC1 将椭圆曲线公钥编码成 SEC 1 格式的点，compressed 为 true 时使用
+// 压缩编码（0x02/0x03 || X），否则使用未压缩编码（0x04 || X || Y）。
+func PublicKeyToSEC1(k *ecdsa.PublicKey, compressed bool) ([]byte, error) {
+	if k == nil {
+		return nil, errors.New("invalid ecdsa public key, it must be different from nil")
+	}
+	if _, ok := curveHalfOrders[k.Curve]; !ok {
+		return nil, fmt.Errorf("%w [%s]", ErrUnknownCurve, k.Curve.Params().Name)
+	}
+	if k.X == nil || k.Y == nil || !k.Curve.IsOnCurve(k.X, k.Y) {
+		return nil, fmt.Errorf("invalid ecdsa public key, it is not a valid point on %s", k.Curve.Params().Name)
+	}
+
+	if compressed {
+		return elliptic.MarshalCompressed(k.Curve, k.X, k.Y), nil
+	}
+	return elliptic.Marshal(k.Curve, k.X, k.Y), nil
+}
+
+// SEC1ToPublicKey 解析 curve 上 SEC 1 格式的点，同时支持压缩与未压缩编码，并检查点
+// 是否位于曲线上。
+func SEC1ToPublicKey(curve elliptic.Curve, raw []byte) (*ecdsa.PublicKey, error) {
+	if _, ok := curveHalfOrders[curve]; !ok {
+		return nil, fmt.Errorf("%w [%s]", ErrUnknownCurve, curve.Params().Name)
+	}
+	if len(raw) == 0 {
+		return nil, errors.New("invalid SEC 1 point, it must not be empty")
+	}
+
+	var x, y *big.Int
+	switch raw[0] {
+	case 2, 3:
+		x, y = elliptic.UnmarshalCompressed(curve, raw)
+	case 4:
+		x, y = elliptic.Unmarshal(curve, raw)
+	default:
+		return nil, fmt.Errorf("invalid SEC 1 point, unsupported prefix [0x%02x]", raw[0])
+	}
+	if x == nil {
+		return nil, fmt.Errorf("invalid SEC 1 point, it is not a valid point on %s", curve.Params().Name)
+	}
+
+	return &ecdsa.PublicKey{Curve: curve, X: x, Y: y}, nil
+}
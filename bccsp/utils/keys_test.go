@@ -0,0 +1,242 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestSKI(t *testing.T) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	expected := sha256.Sum256(elliptic.Marshal(elliptic.P256(), sk.X, sk.Y))
+	require.Equal(t, expected[:], SKI(&sk.PublicKey))
+	require.Nil(t, SKI(nil))
+}
+
+func TestSKIVectors(t *testing.T) {
+	// point 是私钥为 d 时公钥的压缩编码，私钥为 1 时公钥即曲线的基点 G。P-224 的
+	// p ≡ 1 (mod 4)，解压缩不能像其他曲线一样计算 (p+1)/4 次幂开方，因此同时覆盖 y 为偶数
+	// 与奇数两种情况。向量由曲线参数独立计算得到。
+	for _, tc := range []struct {
+		curve elliptic.Curve
+		d     int64
+		point string
+		ski   string
+	}{
+		{elliptic.P224(), 1, "02b70e0cbd6bb4bf7f321390b94a03c1d356c21122343280d6115c1d21", "53cf68c06e477d12c9fb95e1c4e0cf426d329568fead019ff00289af5b2834bf"},
+		{elliptic.P224(), 2, "03706a46dc76dcb76798e60e6d89474788d16dc18032d268fd1a704fa6", "767526c54375902256c6d4a75877faa7583ed9684cdc80deb4362891d44b63ed"},
+		{elliptic.P256(), 1, "036b17d1f2e12c4247f8bce6e563a440f277037d812deb33a0f4a13945d898c296", "698bea63dc44a344663ff1429aea10842df27b6b991ef25866b2c6c02cdcc5be"},
+		{elliptic.P384(), 1, "03aa87ca22be8b05378eb1c71ef320ad746e1d3b628ba79b9859f741e082542a385502f25dbf55296c3a545e3872760ab7", "8c2eb3e0b8d6cc2a197a52c92860f7b1ba71c966e3c88ec6c81900a7308e6266"},
+		{elliptic.P521(), 1, "0200c6858e06b70404e9cd9e3ecb662395b4429c648139053fb521f828af606b4d3dbaa14b5e77efe75928fe1dc127a2ffa8de3348b3c1856a429bf97e7e31c2e5bd66", "866323b2e29c6eddaba0acbdb76df7b5bf56518399b1b4b0833768297e0d68f0"},
+	} {
+		compressed, err := hex.DecodeString(tc.point)
+		require.NoError(t, err)
+
+		pub, err := SEC1ToPublicKey(tc.curve, compressed)
+		require.NoError(t, err)
+		x, y := tc.curve.ScalarBaseMult(big.NewInt(tc.d).Bytes())
+		require.True(t, pub.Equal(&ecdsa.PublicKey{Curve: tc.curve, X: x, Y: y}))
+		require.Equal(t, tc.ski, hex.EncodeToString(SKI(pub)))
+
+		uncompressed, err := PublicKeyToSEC1(pub, false)
+		require.NoError(t, err)
+		pub, err = SEC1ToPublicKey(tc.curve, uncompressed)
+		require.NoError(t, err)
+		require.Equal(t, tc.ski, hex.EncodeToString(SKI(pub)))
+
+		raw, err := PublicKeyToSEC1(pub, true)
+		require.NoError(t, err)
+		require.Equal(t, tc.point, hex.EncodeToString(raw))
+	}
+}
+
+func TestSEC1(t *testing.T) {
+	for _, curve := range []elliptic.Curve{elliptic.P224(), elliptic.P256(), elliptic.P384(), elliptic.P521()} {
+		sk, err := ecdsa.GenerateKey(curve, rand.Reader)
+		require.NoError(t, err)
+
+		for _, compressed := range []bool{true, false} {
+			raw, err := PublicKeyToSEC1(&sk.PublicKey, compressed)
+			require.NoError(t, err)
+			pub, err := SEC1ToPublicKey(curve, raw)
+			require.NoError(t, err)
+			require.True(t, sk.PublicKey.Equal(pub))
+			require.Equal(t, SKI(&sk.PublicKey), SKI(pub))
+		}
+	}
+
+	_, err := PublicKeyToSEC1(&ecdsa.PublicKey{Curve: elliptic.P256(), X: big.NewInt(1), Y: big.NewInt(1)}, true)
+	require.EqualError(t, err, "invalid ecdsa public key, it is not a valid point on P-256")
+
+	// P-256 上不存在 X 坐标为 1 的点。
+	raw := append([]byte{0x02}, big.NewInt(1).FillBytes(make([]byte, 32))...)
+	_, err = SEC1ToPublicKey(elliptic.P256(), raw)
+	require.EqualError(t, err, "invalid SEC 1 point, it is not a valid point on P-256")
+	_, err = SEC1ToPublicKey(elliptic.P256(), raw[:20])
+	require.EqualError(t, err, "invalid SEC 1 point, it is not a valid point on P-256")
+
+	_, err = SEC1ToPublicKey(elliptic.P256(), []byte{0x05, 0x01})
+	require.EqualError(t, err, "invalid SEC 1 point, unsupported prefix [0x05]")
+
+	_, err = SEC1ToPublicKey(elliptic.P256(), nil)
+	require.EqualError(t, err, "invalid SEC 1 point, it must not be empty")
+
+	_, err = PublicKeyToSEC1(nil, true)
+	require.EqualError(t, err, "invalid ecdsa public key, it must be different from nil")
+}
+
+func TestKeyEncoding(t *testing.T) {
+	sk, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+
+	raw, err := PrivateKeyToPEM(sk)
+	require.NoError(t, err)
+	parsedSK, err := PEMToPrivateKey(raw)
+	require.NoError(t, err)
+	require.True(t, sk.Equal(parsedSK))
+
+	sec1, err := x509.MarshalECPrivateKey(sk)
+	require.NoError(t, err)
+	parsedSK, err = PEMToPrivateKey(pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: sec1}))
+	require.NoError(t, err)
+	require.True(t, sk.Equal(parsedSK))
+
+	raw, err = PublicKeyToPEM(&sk.PublicKey)
+	require.NoError(t, err)
+	parsedPK, err := PEMToPublicKey(raw)
+	require.NoError(t, err)
+	require.True(t, sk.PublicKey.Equal(parsedPK))
+
+	_, err = PEMToPrivateKey([]byte("not a pem"))
+	require.EqualError(t, err, "failed decoding PEM, block must be different from nil")
+
+	_, err = DERToPrivateKey([]byte{0})
+	require.EqualError(t, err, "invalid key type, the DER must contain an ecdsa private key")
+
+	_, err = PublicKeyToPEM(nil)
+	require.EqualError(t, err, "invalid ecdsa public key, it must be different from nil")
+}
+
+func TestOpenSSH(t *testing.T) {
+	ecKey, err := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		key     crypto.Signer
+		keyType string
+	}{
+		{ecKey, "ecdsa-sha2-nistp384"},
+		{edKey, "ssh-ed25519"},
+	} {
+		line, err := PublicKeyToAuthorizedKey(tc.key.Public(), "node@org1")
+		require.NoError(t, err)
+		pub, comment, _, rest, err := ssh.ParseAuthorizedKey(line)
+		require.NoError(t, err)
+		require.Empty(t, rest)
+		require.Equal(t, "node@org1", comment)
+		require.Equal(t, tc.keyType, pub.Type())
+
+		raw, err := PrivateKeyToOpenSSH(tc.key, "node@org1", nil)
+		require.NoError(t, err)
+		parsed, err := ssh.ParseRawPrivateKey(raw)
+		require.NoError(t, err)
+		signer, err := ssh.NewSignerFromKey(parsed)
+		require.NoError(t, err)
+		require.Equal(t, pub.Marshal(), signer.PublicKey().Marshal())
+
+		raw, err = PrivateKeyToOpenSSH(tc.key, "", []byte("passphrase"))
+		require.NoError(t, err)
+		_, err = ssh.ParseRawPrivateKey(raw)
+		require.Error(t, err)
+		parsed, err = ssh.ParseRawPrivateKeyWithPassphrase(raw, []byte("passphrase"))
+		require.NoError(t, err)
+		signer, err = ssh.NewSignerFromKey(parsed)
+		require.NoError(t, err)
+		require.Equal(t, pub.Marshal(), signer.PublicKey().Marshal())
+	}
+
+	// OpenSSH 不支持 P-224。
+	p224Key, err := ecdsa.GenerateKey(elliptic.P224(), rand.Reader)
+	require.NoError(t, err)
+	_, err = PublicKeyToAuthorizedKey(&p224Key.PublicKey, "")
+	require.EqualError(t, err, "failed to convert public key to ssh format [ssh: only P-256, P-384 and P-521 EC keys are supported]")
+	_, err = PrivateKeyToOpenSSH(p224Key, "", nil)
+	require.ErrorContains(t, err, "failed to convert private key to ssh format")
+}
+
+func TestGetHashFunc(t *testing.T) {
+	for _, tc := range []struct {
+		family string
+		level  int
+		size   int
+	}{
+		{"SHA2", 256, 32},
+		{"sha2", 384, 48},
+		{"SHA3", 256, 32},
+		{"SHA3", 384, 48},
+	} {
+		hashFunc, err := GetHashFunc(tc.family, tc.level)
+		require.NoError(t, err)
+		require.Equal(t, tc.size, hashFunc().Size())
+	}
+
+	_, err := GetHashFunc("MD5", 256)
+	require.EqualError(t, err, "hash family not supported [MD5]")
+	_, err = GetHashFunc("SHA2", 512)
+	require.EqualError(t, err, "security level not supported [512]")
+}
+
+func TestECDSAPrivateKey(t *testing.T) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	d := new(big.Int).Set(sk.D)
+	require.Contains(t, fmt.Sprintf("%+v", sk), d.String())
+
+	k, err := NewECDSAPrivateKey(sk)
+	require.NoError(t, err)
+	for _, format := range []string{"%v", "%+v", "%#v", "%x", "%d"} {
+		require.Equal(t, "[REDACTED]", fmt.Sprintf(format, k), format)
+	}
+	require.Equal(t, "{Signer:[REDACTED]}", fmt.Sprintf("%+v", struct{ Signer crypto.Signer }{k}))
+
+	digest := sha256.Sum256([]byte("hello, quarkx"))
+	sig, err := k.Sign(rand.Reader, digest[:], crypto.SHA256)
+	require.NoError(t, err)
+	require.True(t, ecdsa.VerifyASN1(&sk.PublicKey, digest[:], sig))
+	require.NoError(t, k.Export(func(exported *ecdsa.PrivateKey) error {
+		require.Equal(t, d, exported.D)
+		return nil
+	}))
+
+	// Destroy 清零的是被包装的私钥本身，公钥依然可用。
+	words := sk.D.Bits()
+	k.Destroy()
+	k.Destroy()
+	require.Zero(t, sk.D.Sign())
+	for _, w := range words {
+		require.Zero(t, w)
+	}
+	_, err = k.Sign(rand.Reader, digest[:], crypto.SHA256)
+	require.ErrorIs(t, err, ErrKeyDestroyed)
+	require.ErrorIs(t, k.Export(func(*ecdsa.PrivateKey) error { return nil }), ErrKeyDestroyed)
+	require.True(t, sk.PublicKey.Equal(k.Public()))
+
+	_, err = NewECDSAPrivateKey(nil)
+	require.EqualError(t, err, "invalid private key, it must be different from nil")
+}
@@ -0,0 +1,86 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package utils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"errors"
+	"io"
+	"sync"
+
+	"github.com/geistwelt/quarkx/bccsp/secret"
+)
+
+// ErrKeyDestroyed 表示私钥已经被 Destroy 清零，不能再使用。
+var ErrKeyDestroyed = errors.New("private key has been destroyed")
+
+// ECDSAPrivateKey 包装常驻内存的椭圆曲线私钥，实现了 crypto.Signer。与 *ecdsa.PrivateKey
+// 不同，它在 fmt、日志与 JSON 中只显示为 [REDACTED]，不会在 %v 或 %+v 中输出 D；不再
+// 需要时调用 Destroy 将私钥清零。
+type ECDSAPrivateKey struct {
+	secret.Redacted
+
+	mutex sync.RWMutex
+	key   *ecdsa.PrivateKey
+	pub   *ecdsa.PublicKey
+}
+
+// NewECDSAPrivateKey 包装 k 并接管它的所有权，Destroy 会清零 k 本身，调用方不应再
+// 使用 k。
+func NewECDSAPrivateKey(k *ecdsa.PrivateKey) (*ECDSAPrivateKey, error) {
+	if k == nil || k.D == nil {
+		return nil, errors.New("invalid private key, it must be different from nil")
+	}
+
+	pub := k.PublicKey
+	return &ECDSAPrivateKey{key: k, pub: &pub}, nil
+}
+
+// Public 返回私钥对应的公钥，私钥被清零之后依然可用。
+func (k *ECDSAPrivateKey) Public() crypto.PublicKey {
+	return k.pub
+}
+
+// Sign 与 (*ecdsa.PrivateKey).Sign 相同，私钥被清零之后返回 ErrKeyDestroyed。
+func (k *ECDSAPrivateKey) Sign(rand io.Reader, digest []byte, opts crypto.SignerOpts) ([]byte, error) {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	if k.key == nil {
+		return nil, ErrKeyDestroyed
+	}
+	return k.key.Sign(rand, digest, opts)
+}
+
+// Export 将私钥交给 f 处理，例如编码成 PEM 格式，私钥被清零之后返回 ErrKeyDestroyed。
+// f 不应保留私钥的引用。
+func (k *ECDSAPrivateKey) Export(f func(*ecdsa.PrivateKey) error) error {
+	k.mutex.RLock()
+	defer k.mutex.RUnlock()
+
+	if k.key == nil {
+		return ErrKeyDestroyed
+	}
+	return f(k.key)
+}
+
+// Destroy 将私钥的标量 D 所在的内存清零，之后私钥不能再用于签名或导出。重复调用
+// Destroy 没有影响。
+func (k *ECDSAPrivateKey) Destroy() {
+	k.mutex.Lock()
+	defer k.mutex.Unlock()
+
+	if k.key == nil {
+		return
+	}
+	words := k.key.D.Bits()
+	for i := range words {
+		words[i] = 0
+	}
+	k.key.D.SetInt64(0)
+	k.key = nil
+}
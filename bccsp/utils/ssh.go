@@ -0,0 +1,54 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package utils
+
+import (
+	"bytes"
+	"crypto"
+	"encoding/pem"
+	"fmt"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// PublicKeyToAuthorizedKey 将 ECDSA（P-256、P-384、P-521）或 Ed25519 公钥编码成 OpenSSH
+// authorized_keys 文件中的一行，comment 非空时附加在行尾。
+func PublicKeyToAuthorizedKey(k crypto.PublicKey, comment string) ([]byte, error) {
+	pub, err := ssh.NewPublicKey(k)
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert public key to ssh format [%v]", err)
+	}
+
+	line := bytes.TrimSuffix(ssh.MarshalAuthorizedKey(pub), []byte("\n"))
+	if comment != "" {
+		line = append(append(line, ' '), comment...)
+	}
+
+	return append(line, '\n'), nil
+}
+
+// PrivateKeyToOpenSSH 将 ECDSA 或 Ed25519 私钥编码成 OpenSSH 私钥格式（PEM 类型为
+// OPENSSH PRIVATE KEY），passphrase 非空时使用 bcrypt KDF 与 AES-256-CTR 加密私钥。
+func PrivateKeyToOpenSSH(k crypto.PrivateKey, comment string, passphrase []byte) ([]byte, error) {
+	if signer, ok := k.(crypto.Signer); ok {
+		if _, err := ssh.NewPublicKey(signer.Public()); err != nil {
+			return nil, fmt.Errorf("failed to convert private key to ssh format [%v]", err)
+		}
+	}
+
+	var block *pem.Block
+	var err error
+	if len(passphrase) == 0 {
+		block, err = ssh.MarshalPrivateKey(k, comment)
+	} else {
+		block, err = ssh.MarshalPrivateKeyWithPassphrase(k, comment, passphrase)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to convert private key to ssh format [%v]", err)
+	}
+
+	return pem.EncodeToMemory(block), nil
+}
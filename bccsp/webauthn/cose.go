@@ -0,0 +1,365 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/geistwelt/quarkx/bccsp/utils"
+)
+
+// COSE 算法标识，见 RFC 9053。
+const (
+	AlgES256 = -7
+	AlgEdDSA = -8
+)
+
+// COSE 密钥的参数标签与取值，见 RFC 9052 与 RFC 9053。
+const (
+	coseKeyType  = 1
+	coseKeyAlg   = 3
+	coseKeyCurve = -1
+	coseKeyX     = -2
+	coseKeyY     = -3
+
+	coseKeyTypeOKP = 1
+	coseKeyTypeEC2 = 2
+
+	coseCurveP256    = 1
+	coseCurveEd25519 = 6
+)
+
+// PublicKey 是从 COSE 密钥导入的凭证公钥，Key 是 *ecdsa.PublicKey（ES256）或
+// ed25519.PublicKey（EdDSA）。
+type PublicKey struct {
+	Algorithm int
+	Key       crypto.PublicKey
+}
+
+// ParseCOSEKey 解析注册凭证时认证器返回的 COSE_Key，目前支持 P-256 上的 ES256 与
+// Ed25519 上的 EdDSA。
+func ParseCOSEKey(raw []byte) (*PublicKey, error) {
+	d := &cborDecoder{data: raw}
+	v, err := d.decode(0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse COSE key [%v]", err)
+	}
+	if d.pos != len(raw) {
+		return nil, fmt.Errorf("invalid COSE key, %d trailing bytes", len(raw)-d.pos)
+	}
+	params, ok := v.(map[int64]interface{})
+	if !ok {
+		return nil, errors.New("invalid COSE key, it must be a map with integer labels")
+	}
+
+	kty, err := intParam(params, coseKeyType)
+	if err != nil {
+		return nil, err
+	}
+	alg, err := intParam(params, coseKeyAlg)
+	if err != nil {
+		return nil, err
+	}
+	crv, err := intParam(params, coseKeyCurve)
+	if err != nil {
+		return nil, err
+	}
+	x, err := bytesParam(params, coseKeyX)
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case alg == AlgES256 && kty == coseKeyTypeEC2 && crv == coseCurveP256:
+		y, err := bytesParam(params, coseKeyY)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != 32 || len(y) != 32 {
+			return nil, fmt.Errorf("invalid COSE key, P-256 coordinates must be 32 bytes, got %d and %d", len(x), len(y))
+		}
+		pub := &ecdsa.PublicKey{Curve: elliptic.P256(), X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+		if !pub.Curve.IsOnCurve(pub.X, pub.Y) {
+			return nil, errors.New("invalid COSE key, the point is not on the curve")
+		}
+		return &PublicKey{Algorithm: AlgES256, Key: pub}, nil
+	case alg == AlgEdDSA && kty == coseKeyTypeOKP && crv == coseCurveEd25519:
+		if len(x) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("invalid COSE key, Ed25519 public key must be %d bytes, got %d", ed25519.PublicKeySize, len(x))
+		}
+		return &PublicKey{Algorithm: AlgEdDSA, Key: ed25519.PublicKey(append([]byte{}, x...))}, nil
+	default:
+		return nil, fmt.Errorf("unsupported COSE key, kty [%d], alg [%d], crv [%d]", kty, alg, crv)
+	}
+}
+
+// MarshalCOSEKey 将公钥编码成 CTAP2 规范形式的 COSE_Key。
+func MarshalCOSEKey(k *PublicKey) ([]byte, error) {
+	if k == nil {
+		return nil, errors.New("invalid public key, it must be different from nil")
+	}
+
+	switch pub := k.Key.(type) {
+	case *ecdsa.PublicKey:
+		if k.Algorithm != AlgES256 || pub.Curve != elliptic.P256() {
+			return nil, errors.New("invalid public key, ECDSA keys must use ES256 on P-256")
+		}
+		x, y := make([]byte, 32), make([]byte, 32)
+		pub.X.FillBytes(x)
+		pub.Y.FillBytes(y)
+
+		raw := appendCBORHead(nil, cborMap, 5)
+		raw = appendCBORInt(raw, coseKeyType)
+		raw = appendCBORInt(raw, coseKeyTypeEC2)
+		raw = appendCBORInt(raw, coseKeyAlg)
+		raw = appendCBORInt(raw, AlgES256)
+		raw = appendCBORInt(raw, coseKeyCurve)
+		raw = appendCBORInt(raw, coseCurveP256)
+		raw = appendCBORInt(raw, coseKeyX)
+		raw = appendCBORBytes(raw, x)
+		raw = appendCBORInt(raw, coseKeyY)
+		raw = appendCBORBytes(raw, y)
+		return raw, nil
+	case ed25519.PublicKey:
+		if k.Algorithm != AlgEdDSA || len(pub) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid public key, Ed25519 keys must use EdDSA")
+		}
+
+		raw := appendCBORHead(nil, cborMap, 4)
+		raw = appendCBORInt(raw, coseKeyType)
+		raw = appendCBORInt(raw, coseKeyTypeOKP)
+		raw = appendCBORInt(raw, coseKeyAlg)
+		raw = appendCBORInt(raw, AlgEdDSA)
+		raw = appendCBORInt(raw, coseKeyCurve)
+		raw = appendCBORInt(raw, coseCurveEd25519)
+		raw = appendCBORInt(raw, coseKeyX)
+		raw = appendCBORBytes(raw, pub)
+		return raw, nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type [%T]", k.Key)
+	}
+}
+
+// SKI 返回公钥的标识符。ECDSA 公钥与 bccsp/utils 中的 SKI 一致，Ed25519 公钥使用
+// SubjectPublicKeyInfo 的 SHA-256 哈希。
+func (k *PublicKey) SKI() ([]byte, error) {
+	switch pub := k.Key.(type) {
+	case *ecdsa.PublicKey:
+		return utils.SKI(pub), nil
+	case ed25519.PublicKey:
+		der, err := x509.MarshalPKIXPublicKey(pub)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal Ed25519 public key [%v]", err)
+		}
+		ski := sha256.Sum256(der)
+		return ski[:], nil
+	default:
+		return nil, fmt.Errorf("unsupported public key type [%T]", k.Key)
+	}
+}
+
+func intParam(params map[int64]interface{}, label int64) (int64, error) {
+	v, ok := params[label].(int64)
+	if !ok {
+		return 0, fmt.Errorf("invalid COSE key, parameter [%d] is missing or not an integer", label)
+	}
+	return v, nil
+}
+
+func bytesParam(params map[int64]interface{}, label int64) ([]byte, error) {
+	v, ok := params[label].([]byte)
+	if !ok {
+		return nil, fmt.Errorf("invalid COSE key, parameter [%d] is missing or not a byte string", label)
+	}
+	return v, nil
+}
+
+// CBOR 的主类型，见 RFC 8949。
+const (
+	cborUint   = 0
+	cborNegInt = 1
+	cborBytes  = 2
+	cborText   = 3
+	cborArray  = 4
+	cborMap    = 5
+	cborSimple = 7
+)
+
+// cborMaxDepth 限制嵌套的深度，避免恶意构造的输入耗尽栈空间。
+const cborMaxDepth = 16
+
+// cborDecoder 是只覆盖 COSE 密钥所需子集的 CBOR 解码器：整数、字节串、文本串、数组、
+// 以整数或文本为键的映射，以及 false、true 与 null。不定长编码、浮点数与标签都会被拒绝。
+type cborDecoder struct {
+	data []byte
+	pos  int
+}
+
+func (d *cborDecoder) head() (byte, uint64, error) {
+	if d.pos >= len(d.data) {
+		return 0, 0, errors.New("unexpected end of CBOR data")
+	}
+	b := d.data[d.pos]
+	d.pos++
+	major, info := b>>5, b&0x1f
+
+	var size int
+	switch {
+	case info < 24:
+		return major, uint64(info), nil
+	case info == 24:
+		size = 1
+	case info == 25:
+		size = 2
+	case info == 26:
+		size = 4
+	case info == 27:
+		size = 8
+	default:
+		return 0, 0, fmt.Errorf("unsupported CBOR additional information [%d]", info)
+	}
+	if len(d.data)-d.pos < size {
+		return 0, 0, errors.New("unexpected end of CBOR data")
+	}
+	var arg uint64
+	for _, c := range d.data[d.pos : d.pos+size] {
+		arg = arg<<8 | uint64(c)
+	}
+	d.pos += size
+	return major, arg, nil
+}
+
+func (d *cborDecoder) decode(depth int) (interface{}, error) {
+	if depth > cborMaxDepth {
+		return nil, errors.New("CBOR data is nested too deeply")
+	}
+
+	major, arg, err := d.head()
+	if err != nil {
+		return nil, err
+	}
+
+	switch major {
+	case cborUint:
+		if arg > 1<<63-1 {
+			return nil, errors.New("CBOR integer overflows int64")
+		}
+		return int64(arg), nil
+	case cborNegInt:
+		if arg > 1<<63-1 {
+			return nil, errors.New("CBOR integer overflows int64")
+		}
+		return -1 - int64(arg), nil
+	case cborBytes, cborText:
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, errors.New("unexpected end of CBOR data")
+		}
+		b := d.data[d.pos : d.pos+int(arg)]
+		d.pos += int(arg)
+		if major == cborText {
+			return string(b), nil
+		}
+		return append([]byte{}, b...), nil
+	case cborArray:
+		// 每个元素至少占 1 字节，超过剩余长度的个数一定是无效的。
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, errors.New("unexpected end of CBOR data")
+		}
+		items := make([]interface{}, 0, arg)
+		for i := uint64(0); i < arg; i++ {
+			item, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			items = append(items, item)
+		}
+		return items, nil
+	case cborMap:
+		if arg > uint64(len(d.data)-d.pos) {
+			return nil, errors.New("unexpected end of CBOR data")
+		}
+		intKeys := make(map[int64]interface{}, arg)
+		textKeys := make(map[string]interface{})
+		for i := uint64(0); i < arg; i++ {
+			key, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			value, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			switch k := key.(type) {
+			case int64:
+				if _, ok := intKeys[k]; ok {
+					return nil, fmt.Errorf("duplicate CBOR map key [%d]", k)
+				}
+				intKeys[k] = value
+			case string:
+				if _, ok := textKeys[k]; ok {
+					return nil, fmt.Errorf("duplicate CBOR map key [%s]", k)
+				}
+				textKeys[k] = value
+			default:
+				return nil, fmt.Errorf("unsupported CBOR map key type [%T]", key)
+			}
+		}
+		if len(textKeys) != 0 {
+			if len(intKeys) != 0 {
+				return nil, errors.New("CBOR map mixes integer and text keys")
+			}
+			return textKeys, nil
+		}
+		return intKeys, nil
+	case cborSimple:
+		switch arg {
+		case 20:
+			return false, nil
+		case 21:
+			return true, nil
+		case 22:
+			return nil, nil
+		}
+		return nil, fmt.Errorf("unsupported CBOR simple value [%d]", arg)
+	default:
+		return nil, fmt.Errorf("unsupported CBOR major type [%d]", major)
+	}
+}
+
+func appendCBORHead(b []byte, major byte, arg uint64) []byte {
+	switch {
+	case arg < 24:
+		return append(b, major<<5|byte(arg))
+	case arg <= 0xff:
+		return append(b, major<<5|24, byte(arg))
+	case arg <= 0xffff:
+		return binary.BigEndian.AppendUint16(append(b, major<<5|25), uint16(arg))
+	case arg <= 0xffffffff:
+		return binary.BigEndian.AppendUint32(append(b, major<<5|26), uint32(arg))
+	default:
+		return binary.BigEndian.AppendUint64(append(b, major<<5|27), arg)
+	}
+}
+
+func appendCBORInt(b []byte, v int64) []byte {
+	if v < 0 {
+		return appendCBORHead(b, cborNegInt, uint64(-1-v))
+	}
+	return appendCBORHead(b, cborUint, uint64(v))
+}
+
+func appendCBORBytes(b []byte, v []byte) []byte {
+	return append(appendCBORHead(b, cborBytes, uint64(len(v))), v...)
+}
@@ -0,0 +1,230 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package webauthn
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/geistwelt/quarkx/bccsp/secret"
+	"github.com/geistwelt/quarkx/bccsp/utils"
+)
+
+// 认证器数据中的标志位，见 WebAuthn Level 3 §6.1。
+const (
+	FlagUserPresent            = 0x01
+	FlagUserVerified           = 0x04
+	FlagBackupEligible         = 0x08
+	FlagBackedUp               = 0x10
+	FlagAttestedCredentialData = 0x40
+	FlagExtensionData          = 0x80
+)
+
+// authenticatorDataMinSize 是 rpIdHash、flags 与 signCount 的总长度。
+const authenticatorDataMinSize = 32 + 1 + 4
+
+// clientDataTypeGet 是断言的 clientDataJSON 中 type 字段的取值。
+const clientDataTypeGet = "webauthn.get"
+
+var (
+	// ErrInvalidAssertion 表示断言的签名无效。
+	ErrInvalidAssertion = errors.New("invalid WebAuthn assertion")
+	// ErrSignCountRollback 表示签名计数器没有增加，凭证可能被复制到了其他认证器上。
+	ErrSignCountRollback = errors.New("authenticator sign count did not increase")
+)
+
+// AuthenticatorData 是认证器数据中固定长度的部分，扩展数据不做解析。
+type AuthenticatorData struct {
+	RPIDHash  [32]byte
+	Flags     byte
+	SignCount uint32
+}
+
+// ParseAuthenticatorData 解析认证器数据。
+func ParseAuthenticatorData(raw []byte) (*AuthenticatorData, error) {
+	if len(raw) < authenticatorDataMinSize {
+		return nil, fmt.Errorf("invalid authenticator data length %d, must be at least %d", len(raw), authenticatorDataMinSize)
+	}
+
+	ad := &AuthenticatorData{Flags: raw[32], SignCount: binary.BigEndian.Uint32(raw[33:37])}
+	copy(ad.RPIDHash[:], raw[:32])
+	return ad, nil
+}
+
+// ClientData 是浏览器生成的 clientDataJSON 中需要校验的字段。
+type ClientData struct {
+	Type        string `json:"type"`
+	Challenge   string `json:"challenge"`
+	Origin      string `json:"origin"`
+	CrossOrigin bool   `json:"crossOrigin"`
+}
+
+// Assertion 是浏览器调用 navigator.credentials.get() 得到的断言。
+type Assertion struct {
+	AuthenticatorData []byte
+	ClientDataJSON    []byte
+	Signature         []byte
+}
+
+// Bytes 将断言编码成 ASN.1 DER，以便作为交易的签名携带。
+func (a *Assertion) Bytes() ([]byte, error) {
+	return asn1.Marshal(*a)
+}
+
+// ParseAssertion 解析 Bytes 返回的编码。
+func ParseAssertion(raw []byte) (*Assertion, error) {
+	a := new(Assertion)
+	rest, err := asn1.Unmarshal(raw, a)
+	if err != nil {
+		return nil, fmt.Errorf("failed to unmarshal WebAuthn assertion [%v]", err)
+	}
+	if len(rest) != 0 {
+		return nil, errors.New("trailing data after WebAuthn assertion")
+	}
+	return a, nil
+}
+
+// VerifyOptions 是验证断言时依赖方（relying party）的配置。
+type VerifyOptions struct {
+	// RPID 是依赖方的标识，通常是网站的域名。
+	RPID string
+	// Origins 是允许的来源，例如 https://wallet.example.com。
+	Origins []string
+	// Challenge 是依赖方发出的挑战。对交易签名时，它就是交易的摘要。
+	Challenge []byte
+	// RequireUserVerification 为 true 时，要求认证器验证过用户（PIN、生物识别等）。
+	RequireUserVerification bool
+	// SignCount 是上一次验证通过时记录的签名计数器，为 0 时不检查。
+	SignCount uint32
+}
+
+// Verify 按照 WebAuthn Level 3 §7.2 验证断言，返回的认证器数据中的 SignCount 需要由调用
+// 方保存，用于下一次验证。ES256 签名必须是规范的 DER 编码且为 low-S 形式，与其他身份的
+// ECDSA 签名一样不允许延展；认证器生成的 high-S 签名需要先经过 NormalizeSignature 处理。
+func (k *PublicKey) Verify(a *Assertion, opts VerifyOptions) (*AuthenticatorData, error) {
+	if a == nil {
+		return nil, errors.New("invalid assertion, it must be different from nil")
+	}
+	if len(opts.Challenge) == 0 {
+		return nil, errors.New("invalid options, challenge must not be empty")
+	}
+
+	var cd ClientData
+	if err := json.Unmarshal(a.ClientDataJSON, &cd); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal client data [%v]", err)
+	}
+	if cd.Type != clientDataTypeGet {
+		return nil, fmt.Errorf("invalid client data type [%s], expected [%s]", cd.Type, clientDataTypeGet)
+	}
+	challenge, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(cd.Challenge, "="))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode challenge [%v]", err)
+	}
+	if !secret.Equal(challenge, opts.Challenge) {
+		return nil, errors.New("challenge mismatch")
+	}
+	if !originAllowed(cd.Origin, opts.Origins) {
+		return nil, fmt.Errorf("origin [%s] is not allowed", cd.Origin)
+	}
+	if cd.CrossOrigin {
+		return nil, errors.New("cross-origin assertions are not allowed")
+	}
+
+	ad, err := ParseAuthenticatorData(a.AuthenticatorData)
+	if err != nil {
+		return nil, err
+	}
+	rpIDHash := sha256.Sum256([]byte(opts.RPID))
+	if ad.RPIDHash != rpIDHash {
+		return nil, fmt.Errorf("RP ID hash mismatch, expected the hash of [%s]", opts.RPID)
+	}
+	if ad.Flags&FlagUserPresent == 0 {
+		return nil, errors.New("user presence flag is not set")
+	}
+	if opts.RequireUserVerification && ad.Flags&FlagUserVerified == 0 {
+		return nil, errors.New("user verification flag is not set")
+	}
+
+	if err := k.verifySignature(signedData(a), a.Signature); err != nil {
+		return nil, err
+	}
+
+	// 计数器只在签名验证通过之后检查，认证器不支持计数器时两次都为 0。
+	if (ad.SignCount != 0 || opts.SignCount != 0) && ad.SignCount <= opts.SignCount {
+		return nil, fmt.Errorf("%w, got %d, last seen %d", ErrSignCountRollback, ad.SignCount, opts.SignCount)
+	}
+
+	return ad, nil
+}
+
+// NormalizeSignature 将断言中 high-S 形式的 ES256 签名转换成 low-S 形式，转换之后的签名
+// 对同一份数据依然有效。EdDSA 签名没有延展性，不做处理。
+func (k *PublicKey) NormalizeSignature(a *Assertion) error {
+	pub, ok := k.Key.(*ecdsa.PublicKey)
+	if !ok {
+		return nil
+	}
+	sig, err := utils.SignatureToLowS(pub, a.Signature)
+	if err != nil {
+		return err
+	}
+	a.Signature = sig
+	return nil
+}
+
+func (k *PublicKey) verifySignature(data, sig []byte) error {
+	switch pub := k.Key.(type) {
+	case *ecdsa.PublicKey:
+		if k.Algorithm != AlgES256 {
+			return fmt.Errorf("unsupported algorithm [%d] for ECDSA keys", k.Algorithm)
+		}
+		r, s, err := utils.UnmarshalECDSASignature(sig)
+		if err != nil {
+			return err
+		}
+		if err := utils.CheckLowS(pub, s); err != nil {
+			return err
+		}
+		digest := sha256.Sum256(data)
+		if !ecdsa.Verify(pub, digest[:], r, s) {
+			return ErrInvalidAssertion
+		}
+		return nil
+	case ed25519.PublicKey:
+		if k.Algorithm != AlgEdDSA {
+			return fmt.Errorf("unsupported algorithm [%d] for Ed25519 keys", k.Algorithm)
+		}
+		if !ed25519.Verify(pub, data, sig) {
+			return ErrInvalidAssertion
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported public key type [%T]", k.Key)
+	}
+}
+
+// signedData 返回认证器签名的数据 authenticatorData || SHA-256(clientDataJSON)。
+func signedData(a *Assertion) []byte {
+	clientDataHash := sha256.Sum256(a.ClientDataJSON)
+	return append(append([]byte{}, a.AuthenticatorData...), clientDataHash[:]...)
+}
+
+func originAllowed(origin string, allowed []string) bool {
+	for _, o := range allowed {
+		if o == origin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,198 @@
+package webauthn
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"testing"
+
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	rpID   = "wallet.example.com"
+	origin = "https://wallet.example.com"
+)
+
+// authenticator 模拟持有通行密钥的认证器。
+type authenticator struct {
+	signer    crypto.Signer
+	signCount uint32
+}
+
+func (au *authenticator) assert(t *testing.T, challenge []byte, flags byte) *Assertion {
+	au.signCount++
+	rpIDHash := sha256.Sum256([]byte(rpID))
+	authData := append(rpIDHash[:], flags)
+	authData = binary.BigEndian.AppendUint32(authData, au.signCount)
+	clientData := fmt.Sprintf(`{"type":"webauthn.get","challenge":"%s","origin":"%s","crossOrigin":false}`,
+		base64.RawURLEncoding.EncodeToString(challenge), origin)
+
+	a := &Assertion{AuthenticatorData: authData, ClientDataJSON: []byte(clientData)}
+	data := signedData(a)
+	var err error
+	switch au.signer.(type) {
+	case ed25519.PrivateKey:
+		a.Signature, err = au.signer.Sign(rand.Reader, data, crypto.Hash(0))
+	default:
+		digest := sha256.Sum256(data)
+		a.Signature, err = au.signer.Sign(rand.Reader, digest[:], crypto.SHA256)
+	}
+	require.NoError(t, err)
+	return a
+}
+
+func TestCOSEKey(t *testing.T) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	raw, err := MarshalCOSEKey(&PublicKey{Algorithm: AlgES256, Key: &sk.PublicKey})
+	require.NoError(t, err)
+	// {1: 2, 3: -7, -1: 1, -2: x, -3: y}
+	require.Equal(t, "a5010203262001215820", hex.EncodeToString(raw[:10]))
+	require.Len(t, raw, 77)
+
+	pub, err := ParseCOSEKey(raw)
+	require.NoError(t, err)
+	require.Equal(t, AlgES256, pub.Algorithm)
+	require.True(t, sk.PublicKey.Equal(pub.Key))
+	ski, err := pub.SKI()
+	require.NoError(t, err)
+	require.Equal(t, utils.SKI(&sk.PublicKey), ski)
+
+	edPub, _, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	raw, err = MarshalCOSEKey(&PublicKey{Algorithm: AlgEdDSA, Key: edPub})
+	require.NoError(t, err)
+	// {1: 1, 3: -8, -1: 6, -2: x}
+	require.Equal(t, "a4010103272006215820", hex.EncodeToString(raw[:10]))
+	pub, err = ParseCOSEKey(raw)
+	require.NoError(t, err)
+	require.Equal(t, AlgEdDSA, pub.Algorithm)
+	require.True(t, edPub.Equal(pub.Key))
+
+	_, err = ParseCOSEKey(append(raw, 0))
+	require.EqualError(t, err, "invalid COSE key, 1 trailing bytes")
+	_, err = ParseCOSEKey(raw[:len(raw)-1])
+	require.EqualError(t, err, "failed to parse COSE key [unexpected end of CBOR data]")
+	// RS256 (-257) 的 RSA 密钥。
+	_, err = ParseCOSEKey([]byte{0xa4, 0x01, 0x03, 0x03, 0x39, 0x01, 0x00, 0x20, 0x01, 0x21, 0x41, 0x00})
+	require.EqualError(t, err, "unsupported COSE key, kty [3], alg [-257], crv [1]")
+	_, err = ParseCOSEKey([]byte{0xa2, 0x01, 0x01, 0x01, 0x02})
+	require.EqualError(t, err, "failed to parse COSE key [duplicate CBOR map key [1]]")
+	_, err = ParseCOSEKey([]byte{0x9f})
+	require.EqualError(t, err, "failed to parse COSE key [unsupported CBOR additional information [31]]")
+	_, err = ParseCOSEKey([]byte{0x80})
+	require.EqualError(t, err, "invalid COSE key, it must be a map with integer labels")
+
+	// 不在曲线上的点。
+	offCurve := &ecdsa.PublicKey{Curve: elliptic.P256(), X: sk.X, Y: sk.X}
+	raw, err = MarshalCOSEKey(&PublicKey{Algorithm: AlgES256, Key: offCurve})
+	require.NoError(t, err)
+	_, err = ParseCOSEKey(raw)
+	require.EqualError(t, err, "invalid COSE key, the point is not on the curve")
+}
+
+func TestVerifyAssertion(t *testing.T) {
+	ecdsaKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+
+	for name, au := range map[string]*authenticator{
+		"ES256": {signer: ecdsaKey},
+		"EdDSA": {signer: edKey},
+	} {
+		t.Run(name, func(t *testing.T) {
+			alg := AlgES256
+			if name == "EdDSA" {
+				alg = AlgEdDSA
+			}
+			raw, err := MarshalCOSEKey(&PublicKey{Algorithm: alg, Key: au.signer.Public()})
+			require.NoError(t, err)
+			pub, err := ParseCOSEKey(raw)
+			require.NoError(t, err)
+
+			txDigest := sha256.Sum256([]byte("tx 42"))
+			opts := VerifyOptions{RPID: rpID, Origins: []string{origin}, Challenge: txDigest[:], RequireUserVerification: true}
+
+			a := au.assert(t, txDigest[:], FlagUserPresent|FlagUserVerified)
+			require.NoError(t, pub.NormalizeSignature(a))
+			encoded, err := a.Bytes()
+			require.NoError(t, err)
+			a, err = ParseAssertion(encoded)
+			require.NoError(t, err)
+			ad, err := pub.Verify(a, opts)
+			require.NoError(t, err)
+			require.Equal(t, uint32(1), ad.SignCount)
+
+			// 计数器没有增加的断言会被拒绝。
+			opts.SignCount = ad.SignCount
+			_, err = pub.Verify(a, opts)
+			require.ErrorIs(t, err, ErrSignCountRollback)
+
+			a = au.assert(t, txDigest[:], FlagUserPresent|FlagUserVerified)
+			require.NoError(t, pub.NormalizeSignature(a))
+			_, err = pub.Verify(a, opts)
+			require.NoError(t, err)
+
+			other := sha256.Sum256([]byte("tx 43"))
+			_, err = pub.Verify(a, VerifyOptions{RPID: rpID, Origins: []string{origin}, Challenge: other[:]})
+			require.EqualError(t, err, "challenge mismatch")
+			_, err = pub.Verify(a, VerifyOptions{RPID: "evil.example.com", Origins: []string{origin}, Challenge: txDigest[:]})
+			require.EqualError(t, err, "RP ID hash mismatch, expected the hash of [evil.example.com]")
+			_, err = pub.Verify(a, VerifyOptions{RPID: rpID, Origins: []string{"https://evil.example.com"}, Challenge: txDigest[:]})
+			require.EqualError(t, err, "origin [https://wallet.example.com] is not allowed")
+
+			tampered := *a
+			tampered.AuthenticatorData = append([]byte{}, a.AuthenticatorData...)
+			tampered.AuthenticatorData[36]++
+			_, err = pub.Verify(&tampered, opts)
+			require.ErrorIs(t, err, ErrInvalidAssertion)
+
+			a = au.assert(t, txDigest[:], FlagUserPresent)
+			require.NoError(t, pub.NormalizeSignature(a))
+			_, err = pub.Verify(a, opts)
+			require.EqualError(t, err, "user verification flag is not set")
+			opts.RequireUserVerification = false
+			opts.SignCount = 0
+			_, err = pub.Verify(a, opts)
+			require.NoError(t, err)
+
+			_, err = pub.Verify(au.assert(t, txDigest[:], 0), opts)
+			require.EqualError(t, err, "user presence flag is not set")
+		})
+	}
+}
+
+func TestHighSSignatureRejected(t *testing.T) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	pub := &PublicKey{Algorithm: AlgES256, Key: &sk.PublicKey}
+	challenge := []byte("challenge")
+	opts := VerifyOptions{RPID: rpID, Origins: []string{origin}, Challenge: challenge}
+
+	a := (&authenticator{signer: sk}).assert(t, challenge, FlagUserPresent)
+	r, s, err := utils.UnmarshalECDSASignature(a.Signature)
+	require.NoError(t, err)
+	if lowS, _ := utils.IsLowS(&sk.PublicKey, s); lowS {
+		s.Sub(elliptic.P256().Params().N, s)
+	}
+	a.Signature, err = utils.MarshalECDSASignature(r, s)
+	require.NoError(t, err)
+
+	// 认证器不保证生成 low-S 签名，未经处理的 high-S 签名会被拒绝。
+	_, err = pub.Verify(a, opts)
+	require.ErrorIs(t, err, utils.ErrHighS)
+
+	require.NoError(t, pub.NormalizeSignature(a))
+	_, err = pub.Verify(a, opts)
+	require.NoError(t, err)
+}
@@ -0,0 +1,54 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/geistwelt/quarkx/common/qlogging/audit"
+)
+
+func verifyAudit(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("verify-audit", flag.ContinueOnError)
+	keyPath := fs.String("key", "", "file containing the public key, private key or certificate of the audit log signer")
+	in := fs.String("in", "-", "audit log to verify, - for stdin")
+	signEvery := fs.Int("sign-every", audit.DefaultSignEvery, "maximum number of entries between two checkpoints, as configured on the signer")
+	strict := fs.Bool("strict", true, "fail if the log ends with entries that are not covered by a checkpoint, -strict=false only warns")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	k, err := loadKey(*keyPath)
+	if err != nil {
+		return err
+	}
+	k.destroy()
+
+	raw, err := readInput(*in)
+	if err != nil {
+		return err
+	}
+
+	report, err := audit.Verify(bytes.NewReader(raw), k.public, *signEvery)
+	if err != nil {
+		return fmt.Errorf("audit log has been tampered with: %v", err)
+	}
+
+	fmt.Fprintf(stdout, "entries:     %d\n", report.Entries)
+	fmt.Fprintf(stdout, "checkpoints: %d\n", report.Checkpoints)
+	fmt.Fprintf(stdout, "unsigned:    %d\n", report.Unsigned)
+	if report.Unsigned != 0 {
+		if *strict {
+			return fmt.Errorf("the last %d entries are not covered by a checkpoint", report.Unsigned)
+		}
+		fmt.Fprintf(stdout, "warning: the last %d entries are not covered by a checkpoint, a truncated tail cannot be detected\n", report.Unsigned)
+	}
+
+	return nil
+}
@@ -0,0 +1,125 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/geistwelt/quarkx/bccsp/backup"
+	"github.com/geistwelt/quarkx/bccsp/keyaudit"
+)
+
+// stringsFlag 是可以重复指定的字符串参数。
+type stringsFlag []string
+
+func (s *stringsFlag) String() string { return strings.Join(*s, ",") }
+
+func (s *stringsFlag) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
+
+func encryptBackup(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("encrypt-backup", flag.ContinueOnError)
+	var recipients stringsFlag
+	fs.Var(&recipients, "recipient", "age X25519 recipient (age1...), can be repeated")
+	recipientsFile := fs.String("recipients-file", "", "file containing age recipients, one per line")
+	in := fs.String("in", "-", "file to encrypt, - for stdin")
+	out := fs.String("out", "", "file to write the encrypted backup to, stdout if empty")
+	armored := fs.Bool("armor", false, "encode the output in PEM-style ASCII armor")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *recipientsFile != "" {
+		raw, err := readInput(*recipientsFile)
+		if err != nil {
+			return err
+		}
+		recipients = append(recipients, strings.Split(string(raw), "\n")...)
+	}
+
+	raw, err := readInput(*in)
+	if err != nil {
+		return err
+	}
+
+	encrypted := new(bytes.Buffer)
+	w, err := backup.Encrypt(encrypted, *armored, recipients...)
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(raw); err != nil {
+		return fmt.Errorf("failed to encrypt backup [%v]", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("failed to encrypt backup [%v]", err)
+	}
+
+	return writeOutput(*out, encrypted.Bytes(), 0o644, stdout)
+}
+
+func decryptBackup(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("decrypt-backup", flag.ContinueOnError)
+	identityPath := fs.String("identity", "", "file containing the age identity (AGE-SECRET-KEY-1...)")
+	in := fs.String("in", "-", "file to decrypt, - for stdin")
+	out := fs.String("out", "", "file to write the decrypted backup to, stdout if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if *identityPath == "" {
+		return errors.New("an identity file must be specified with -identity")
+	}
+	raw, err := readInput(*identityPath)
+	if err != nil {
+		return err
+	}
+	var identities []string
+	for _, line := range strings.Split(string(raw), "\n") {
+		if line = strings.TrimSpace(line); line != "" && !strings.HasPrefix(line, "#") {
+			identities = append(identities, line)
+		}
+	}
+
+	raw, err = readInput(*in)
+	if err != nil {
+		return err
+	}
+
+	plaintext, err := decrypt(raw, identities)
+	for _, identity := range identities {
+		if ski, skiErr := backup.IdentitySKI(identity); skiErr == nil {
+			auditor.Record(ski, component, keyaudit.OpDecrypt, err)
+		}
+	}
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(*out, plaintext, os.FileMode(0o600), stdout)
+}
+
+// decrypt 用 identities 解密 raw 中的备份，age 在读完数据之前不会校验最后一个数据块，
+// 因此只有读完之后才能确定解密是否成功。
+func decrypt(raw []byte, identities []string) ([]byte, error) {
+	r, err := backup.Decrypt(bytes.NewReader(raw), identities...)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt backup [%v]", err)
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,438 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+	"github.com/geistwelt/quarkx/bccsp/keyaudit"
+	"github.com/geistwelt/quarkx/bccsp/utils"
+)
+
+// component 是命令行工具在私钥操作审计记录中的组件名称。
+const component = "quarkx-crypto"
+
+// auditor 记录命令行工具中的每一次签名、私钥导出与备份解密，记录写入 bccsp.keyaudit 日志器。
+var auditor = keyaudit.NewAuditor(nil, nil)
+
+func keygen(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	curveName := fs.String("curve", "P-256", "curve of the key: P-224, P-256, P-384 or P-521")
+	format := fs.String("format", "pem", "encoding of the key: pem, der, jwk or ssh")
+	out := fs.String("out", "", "file to write the private key to, stdout if empty")
+	pubOut := fs.String("pubout", "", "file to write the public key to, in the same encoding")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	curve, err := curveByName(*curveName)
+	if err != nil {
+		return err
+	}
+
+	sk, err := ecdsa.GenerateKey(curve, entropy.Reader)
+	if err != nil {
+		return fmt.Errorf("failed to generate key [%v]", err)
+	}
+
+	k, err := newPrivateKey(sk)
+	if err != nil {
+		return err
+	}
+	defer k.destroy()
+
+	raw, err := encodeKey(k, *format)
+	if err != nil {
+		return err
+	}
+	if err := writeOutput(*out, raw, 0o600, stdout); err != nil {
+		return err
+	}
+
+	if *pubOut != "" {
+		raw, err = encodeKey(&key{public: k.public}, *format)
+		if err != nil {
+			return err
+		}
+		if err := writeOutput(*pubOut, raw, 0o644, stdout); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func sign(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("sign", flag.ContinueOnError)
+	keyPath := fs.String("key", "", "file containing the private key")
+	in := fs.String("in", "-", "file to sign, - for stdin")
+	hashName := fs.String("hash", "sha256", "hash applied to the input: sha256, sha384, sha512 or none if the input is already a digest")
+	sigFormat := fs.String("sigformat", "hex", "encoding of the signature: der, hex or base64")
+	out := fs.String("out", "", "file to write the signature to, stdout if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	k, err := loadKey(*keyPath)
+	if err != nil {
+		return err
+	}
+	defer k.destroy()
+	if k.private == nil {
+		return fmt.Errorf("file [%s] does not contain a private key", *keyPath)
+	}
+
+	digest, err := readDigest(*in, *hashName)
+	if err != nil {
+		return err
+	}
+
+	signature, err := k.private.Sign(entropy.Reader, digest, nil)
+	auditor.Record(utils.SKI(k.public), component, keyaudit.OpSign, err)
+	if err != nil {
+		return fmt.Errorf("failed to sign [%v]", err)
+	}
+	signature, err = utils.SignatureToLowS(k.public, signature)
+	if err != nil {
+		return err
+	}
+
+	raw, err := encodeSignature(signature, *sigFormat)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(*out, raw, 0o644, stdout)
+}
+
+func verify(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("verify", flag.ContinueOnError)
+	keyPath := fs.String("key", "", "file containing the public key, private key or certificate")
+	in := fs.String("in", "-", "signed file, - for stdin")
+	hashName := fs.String("hash", "sha256", "hash applied to the input: sha256, sha384, sha512 or none if the input is already a digest")
+	sigPath := fs.String("sig", "", "file containing the signature")
+	sigFormat := fs.String("sigformat", "hex", "encoding of the signature: der, hex or base64")
+	allowHighS := fs.Bool("allow-high-s", false, "accept signatures whose s is larger than half the curve order")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	k, err := loadKey(*keyPath)
+	if err != nil {
+		return err
+	}
+	defer k.destroy()
+
+	signature, err := loadSignature(*sigPath, *sigFormat)
+	if err != nil {
+		return err
+	}
+
+	digest, err := readDigest(*in, *hashName)
+	if err != nil {
+		return err
+	}
+
+	r, s, err := utils.UnmarshalECDSASignature(signature)
+	if err != nil {
+		return err
+	}
+
+	if err := utils.CheckLowS(k.public, s); err != nil && !(*allowHighS && errors.Is(err, utils.ErrHighS)) {
+		return err
+	}
+
+	if !ecdsa.Verify(k.public, digest, r, s) {
+		return errors.New("signature is not valid")
+	}
+
+	fmt.Fprintln(stdout, "signature is valid")
+	return nil
+}
+
+func inspectSig(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("inspect-sig", flag.ContinueOnError)
+	sigPath := fs.String("sig", "-", "file containing the signature, - for stdin")
+	sigFormat := fs.String("sigformat", "hex", "encoding of the signature: der, hex or base64")
+	keyPath := fs.String("key", "", "file containing the public key, private key or certificate of the signer")
+	curveName := fs.String("curve", "P-256", "curve of the signer if -key is not given")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	pub, err := signerPublicKey(*keyPath, *curveName)
+	if err != nil {
+		return err
+	}
+
+	signature, err := loadSignature(*sigPath, *sigFormat)
+	if err != nil {
+		return err
+	}
+
+	r, s, err := utils.UnmarshalECDSASignature(signature)
+	if err != nil {
+		return err
+	}
+
+	lowS, err := utils.IsLowS(pub, s)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "curve:      %s\n", pub.Curve.Params().Name)
+	fmt.Fprintf(stdout, "r:          %s\n", r)
+	fmt.Fprintf(stdout, "s:          %s\n", s)
+	fmt.Fprintf(stdout, "half order: %s\n", utils.GetCurveHalfOrdersAt(pub.Curve))
+	fmt.Fprintf(stdout, "low-S:      %t\n", lowS)
+	if !lowS {
+		lowSValue, err := utils.ToLowS(pub, s)
+		if err != nil {
+			return err
+		}
+		fmt.Fprintf(stdout, "low-S s:    %s\n", lowSValue)
+	}
+
+	return nil
+}
+
+func normalizeSig(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("normalize-sig", flag.ContinueOnError)
+	sigPath := fs.String("sig", "-", "file containing the signature, - for stdin")
+	sigFormat := fs.String("sigformat", "hex", "encoding of the signature: der, hex or base64")
+	keyPath := fs.String("key", "", "file containing the public key, private key or certificate of the signer")
+	curveName := fs.String("curve", "P-256", "curve of the signer if -key is not given")
+	out := fs.String("out", "", "file to write the signature to, stdout if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	pub, err := signerPublicKey(*keyPath, *curveName)
+	if err != nil {
+		return err
+	}
+
+	signature, err := loadSignature(*sigPath, *sigFormat)
+	if err != nil {
+		return err
+	}
+
+	signature, err = utils.SignatureToLowS(pub, signature)
+	if err != nil {
+		return err
+	}
+
+	raw, err := encodeSignature(signature, *sigFormat)
+	if err != nil {
+		return err
+	}
+
+	return writeOutput(*out, raw, 0o644, stdout)
+}
+
+func ski(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("ski", flag.ContinueOnError)
+	keyPath := fs.String("key", "", "file containing the public key, private key or certificate")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	k, err := loadKey(*keyPath)
+	if err != nil {
+		return err
+	}
+	k.destroy()
+
+	fmt.Fprintln(stdout, hex.EncodeToString(utils.SKI(k.public)))
+	return nil
+}
+
+func convert(args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("convert", flag.ContinueOnError)
+	in := fs.String("in", "-", "file containing the key, - for stdin")
+	from := fs.String("from", "auto", "encoding of the input: auto, pem, der or jwk")
+	to := fs.String("to", "pem", "encoding of the output: pem, der, jwk or ssh (OpenSSH private key or authorized_keys line)")
+	public := fs.Bool("public", false, "only output the public part of a private key")
+	comment := fs.String("comment", "", "comment of the key when the output encoding is ssh")
+	out := fs.String("out", "", "file to write the key to, stdout if empty")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	raw, err := readInput(*in)
+	if err != nil {
+		return err
+	}
+
+	k, err := parseKey(raw, *from)
+	if err != nil {
+		return err
+	}
+	defer k.destroy()
+	if *public {
+		k.destroy()
+		k.private = nil
+	}
+
+	if *to == "ssh" {
+		raw, err = encodeSSH(k, *comment)
+	} else {
+		raw, err = encodeKey(k, *to)
+	}
+	if k.private != nil {
+		auditor.Record(utils.SKI(k.public), component, keyaudit.OpExport, err)
+	}
+	if err != nil {
+		return err
+	}
+
+	perm := os.FileMode(0o644)
+	if k.private != nil {
+		perm = 0o600
+	}
+
+	return writeOutput(*out, raw, perm, stdout)
+}
+
+func loadKey(path string) (*key, error) {
+	if path == "" {
+		return nil, errors.New("a key file must be specified with -key")
+	}
+
+	raw, err := readInput(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return parseKey(raw, "auto")
+}
+
+// signerPublicKey 返回签名者的公钥，如果没有给出密钥文件，则返回一个只包含曲线信息的
+// 公钥，足以判断签名是否为 low-S 形式。
+func signerPublicKey(keyPath, curveName string) (*ecdsa.PublicKey, error) {
+	if keyPath != "" {
+		k, err := loadKey(keyPath)
+		if err != nil {
+			return nil, err
+		}
+		k.destroy()
+		return k.public, nil
+	}
+
+	curve, err := curveByName(curveName)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ecdsa.PublicKey{Curve: curve}, nil
+}
+
+func loadSignature(path, format string) ([]byte, error) {
+	if path == "" {
+		return nil, errors.New("a signature file must be specified with -sig")
+	}
+
+	raw, err := readInput(path)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeSignature(raw, format)
+}
+
+func decodeSignature(raw []byte, format string) ([]byte, error) {
+	switch format {
+	case "der":
+		return raw, nil
+	case "hex":
+		signature, err := hex.DecodeString(string(bytes.TrimSpace(raw)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode hex signature [%v]", err)
+		}
+		return signature, nil
+	case "base64":
+		signature, err := base64.StdEncoding.DecodeString(string(bytes.TrimSpace(raw)))
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode base64 signature [%v]", err)
+		}
+		return signature, nil
+	default:
+		return nil, fmt.Errorf("unsupported signature format [%s], expected one of der, hex, base64", format)
+	}
+}
+
+func encodeSignature(signature []byte, format string) ([]byte, error) {
+	switch format {
+	case "der":
+		return signature, nil
+	case "hex":
+		return []byte(hex.EncodeToString(signature) + "\n"), nil
+	case "base64":
+		return []byte(base64.StdEncoding.EncodeToString(signature) + "\n"), nil
+	default:
+		return nil, fmt.Errorf("unsupported signature format [%s], expected one of der, hex, base64", format)
+	}
+}
+
+func readDigest(path, hashName string) ([]byte, error) {
+	raw, err := readInput(path)
+	if err != nil {
+		return nil, err
+	}
+
+	switch hashName {
+	case "sha256":
+		digest := sha256.Sum256(raw)
+		return digest[:], nil
+	case "sha384":
+		digest := sha512.Sum384(raw)
+		return digest[:], nil
+	case "sha512":
+		digest := sha512.Sum512(raw)
+		return digest[:], nil
+	case "none":
+		return raw, nil
+	default:
+		return nil, fmt.Errorf("unsupported hash [%s], expected one of sha256, sha384, sha512, none", hashName)
+	}
+}
+
+func readInput(path string) ([]byte, error) {
+	if path == "-" {
+		return io.ReadAll(os.Stdin)
+	}
+
+	raw, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read [%s] [%v]", path, err)
+	}
+
+	return raw, nil
+}
+
+func writeOutput(path string, raw []byte, perm os.FileMode, stdout io.Writer) error {
+	if path == "" {
+		_, err := stdout.Write(raw)
+		return err
+	}
+
+	if err := os.WriteFile(path, raw, perm); err != nil {
+		return fmt.Errorf("failed to write [%s] [%v]", path, err)
+	}
+
+	return nil
+}
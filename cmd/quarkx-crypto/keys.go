@@ -0,0 +1,235 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/geistwelt/quarkx/bccsp/utils"
+)
+
+var curves = map[string]elliptic.Curve{
+	"P-224": elliptic.P224(),
+	"P-256": elliptic.P256(),
+	"P-384": elliptic.P384(),
+	"P-521": elliptic.P521(),
+}
+
+func curveByName(name string) (elliptic.Curve, error) {
+	curve, ok := curves[strings.ToUpper(name)]
+	if !ok {
+		return nil, fmt.Errorf("unsupported curve [%s], expected one of P-224, P-256, P-384, P-521", name)
+	}
+	return curve, nil
+}
+
+// key 是从文件中读取出来的密钥，如果文件中是私钥，那么 private 不为 nil。私钥用完之后
+// 需要调用 destroy 清零。
+type key struct {
+	private *utils.ECDSAPrivateKey
+	public  *ecdsa.PublicKey
+}
+
+// newPrivateKey 包装私钥 sk，返回的 key 接管 sk 的所有权。
+func newPrivateKey(sk *ecdsa.PrivateKey) (*key, error) {
+	private, err := utils.NewECDSAPrivateKey(sk)
+	if err != nil {
+		return nil, err
+	}
+	return &key{private: private, public: private.Public().(*ecdsa.PublicKey)}, nil
+}
+
+// destroy 将 k 中的私钥清零。
+func (k *key) destroy() {
+	if k.private != nil {
+		k.private.Destroy()
+	}
+}
+
+// parseKey 解析 PEM、DER 或 JWK 格式的椭圆曲线私钥、公钥或证书，format 为 auto 时
+// 根据内容自动判断格式。
+func parseKey(raw []byte, format string) (*key, error) {
+	if format == "auto" {
+		trimmed := bytes.TrimSpace(raw)
+		switch {
+		case bytes.HasPrefix(trimmed, []byte("-----BEGIN")):
+			format = "pem"
+		case bytes.HasPrefix(trimmed, []byte("{")):
+			format = "jwk"
+		default:
+			format = "der"
+		}
+	}
+
+	switch format {
+	case "pem":
+		block, _ := pem.Decode(raw)
+		if block == nil {
+			return nil, errors.New("failed decoding PEM, block must be different from nil")
+		}
+		return parseDERKey(block.Bytes)
+	case "der":
+		return parseDERKey(raw)
+	case "jwk":
+		return parseJWK(raw)
+	default:
+		return nil, fmt.Errorf("unsupported key format [%s], expected one of pem, der, jwk", format)
+	}
+}
+
+func parseDERKey(der []byte) (*key, error) {
+	if sk, err := utils.DERToPrivateKey(der); err == nil {
+		return newPrivateKey(sk)
+	}
+
+	if pk, err := utils.DERToPublicKey(der); err == nil {
+		return &key{public: pk}, nil
+	}
+
+	if cert, err := x509.ParseCertificate(der); err == nil {
+		pk, ok := cert.PublicKey.(*ecdsa.PublicKey)
+		if !ok {
+			return nil, fmt.Errorf("unsupported certificate public key type [%T]", cert.PublicKey)
+		}
+		return &key{public: pk}, nil
+	}
+
+	return nil, errors.New("failed to parse key, expected an ecdsa private key, public key or certificate")
+}
+
+// encodeKey 将密钥编码成 PEM、DER 或 JWK 格式，如果 k 中包含私钥，则编码私钥。
+func encodeKey(k *key, format string) ([]byte, error) {
+	switch format {
+	case "pem":
+		if k.private != nil {
+			return exportPrivateKey(k, utils.PrivateKeyToPEM)
+		}
+		return utils.PublicKeyToPEM(k.public)
+	case "der":
+		if k.private != nil {
+			return exportPrivateKey(k, utils.PrivateKeyToDER)
+		}
+		return utils.PublicKeyToDER(k.public)
+	case "jwk":
+		return encodeJWK(k)
+	case "ssh":
+		return encodeSSH(k, "")
+	default:
+		return nil, fmt.Errorf("unsupported key format [%s], expected one of pem, der, jwk, ssh", format)
+	}
+}
+
+// encodeSSH 将私钥编码成 OpenSSH 私钥格式，将公钥编码成 authorized_keys 中的一行。
+func encodeSSH(k *key, comment string) ([]byte, error) {
+	if k.private != nil {
+		return exportPrivateKey(k, func(sk *ecdsa.PrivateKey) ([]byte, error) {
+			return utils.PrivateKeyToOpenSSH(sk, comment, nil)
+		})
+	}
+	return utils.PublicKeyToAuthorizedKey(k.public, comment)
+}
+
+// exportPrivateKey 用 encode 编码 k 中的私钥。
+func exportPrivateKey(k *key, encode func(*ecdsa.PrivateKey) ([]byte, error)) ([]byte, error) {
+	var raw []byte
+	err := k.private.Export(func(sk *ecdsa.PrivateKey) error {
+		var err error
+		raw, err = encode(sk)
+		return err
+	})
+	return raw, err
+}
+
+// jwk 是 RFC 7517 中定义的 JSON Web Key，这里只支持椭圆曲线密钥。
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+	D   string `json:"d,omitempty"`
+}
+
+func encodeJWK(k *key) ([]byte, error) {
+	size := (k.public.Curve.Params().BitSize + 7) / 8
+	j := jwk{
+		Kty: "EC",
+		Crv: k.public.Curve.Params().Name,
+		X:   base64.RawURLEncoding.EncodeToString(k.public.X.FillBytes(make([]byte, size))),
+		Y:   base64.RawURLEncoding.EncodeToString(k.public.Y.FillBytes(make([]byte, size))),
+	}
+	if k.private != nil {
+		err := k.private.Export(func(sk *ecdsa.PrivateKey) error {
+			j.D = base64.RawURLEncoding.EncodeToString(sk.D.FillBytes(make([]byte, size)))
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	raw, err := json.MarshalIndent(j, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	return append(raw, '\n'), nil
+}
+
+func parseJWK(raw []byte) (*key, error) {
+	var j jwk
+	if err := json.Unmarshal(raw, &j); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal JWK [%v]", err)
+	}
+	if j.Kty != "EC" {
+		return nil, fmt.Errorf("unsupported JWK key type [%s], expected EC", j.Kty)
+	}
+
+	curve, err := curveByName(j.Crv)
+	if err != nil {
+		return nil, err
+	}
+
+	x, err := base64.RawURLEncoding.DecodeString(j.X)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWK x coordinate [%v]", err)
+	}
+	y, err := base64.RawURLEncoding.DecodeString(j.Y)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWK y coordinate [%v]", err)
+	}
+
+	pk := &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	if !curve.IsOnCurve(pk.X, pk.Y) {
+		return nil, errors.New("invalid JWK, point is not on curve")
+	}
+
+	if j.D == "" {
+		return &key{public: pk}, nil
+	}
+
+	d, err := base64.RawURLEncoding.DecodeString(j.D)
+	if err != nil {
+		return nil, fmt.Errorf("invalid JWK private scalar [%v]", err)
+	}
+	sk := &ecdsa.PrivateKey{PublicKey: *pk, D: new(big.Int).SetBytes(d)}
+
+	x1, y1 := curve.ScalarBaseMult(d)
+	if x1.Cmp(pk.X) != 0 || y1.Cmp(pk.Y) != 0 {
+		return nil, errors.New("invalid JWK, private scalar does not match public point")
+	}
+
+	return newPrivateKey(sk)
+}
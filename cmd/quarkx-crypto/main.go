@@ -0,0 +1,76 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+)
+
+// command 是 quarkx-crypto 的一个子命令。
+type command struct {
+	usage string
+	run   func(args []string, stdout io.Writer) error
+}
+
+var commands = map[string]command{
+	"keygen":         {usage: "generate an ECDSA private key", run: keygen},
+	"sign":           {usage: "sign a file or a digest, the signature is always low-S", run: sign},
+	"verify":         {usage: "verify a signature", run: verify},
+	"inspect-sig":    {usage: "print r, s, the curve half order and whether s is low-S", run: inspectSig},
+	"normalize-sig":  {usage: "convert a signature to its low-S form", run: normalizeSig},
+	"ski":            {usage: "print the subject key identifier of a key or certificate", run: ski},
+	"convert":        {usage: "convert a key between PEM, DER, JWK and OpenSSH", run: convert},
+	"verify-audit":   {usage: "verify the hash chain and checkpoints of an audit log", run: verifyAudit},
+	"encrypt-backup": {usage: "encrypt a file to age X25519 recipients", run: encryptBackup},
+	"decrypt-backup": {usage: "decrypt a file encrypted to an age X25519 identity", run: decryptBackup},
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintf(os.Stderr, "Error: %s\n", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(args []string, stdout, stderr io.Writer) error {
+	if len(args) == 0 || args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+		usage(stderr)
+		return flag.ErrHelp
+	}
+
+	cmd, ok := commands[args[0]]
+	if !ok {
+		usage(stderr)
+		return fmt.Errorf("unknown command [%s]", args[0])
+	}
+
+	return cmd.run(args[1:], stdout)
+}
+
+func usage(w io.Writer) {
+	fmt.Fprintln(w, "Usage: quarkx-crypto <command> [flags]")
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Commands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintf(w, "  %-14s %s\n", name, commands[name].usage)
+	}
+
+	fmt.Fprintln(w)
+	fmt.Fprintln(w, "Run 'quarkx-crypto <command> -h' for the flags of a command.")
+}
@@ -0,0 +1,237 @@
+package main
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/geistwelt/quarkx/bccsp/backup"
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"github.com/geistwelt/quarkx/common/qlogging/audit"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func execute(t *testing.T, args ...string) (string, error) {
+	stdout := new(bytes.Buffer)
+	err := run(args, stdout, new(bytes.Buffer))
+	return stdout.String(), err
+}
+
+func TestSignAndVerify(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "key.pem")
+	pubPath := filepath.Join(dir, "pub.pem")
+	msgPath := filepath.Join(dir, "msg")
+	sigPath := filepath.Join(dir, "sig")
+
+	_, err := execute(t, "keygen", "-curve", "P-384", "-out", keyPath, "-pubout", pubPath)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(msgPath, []byte("hello, quarkx"), 0o644))
+
+	_, err = execute(t, "sign", "-key", keyPath, "-in", msgPath, "-hash", "sha384", "-out", sigPath)
+	require.NoError(t, err)
+
+	out, err := execute(t, "verify", "-key", pubPath, "-in", msgPath, "-hash", "sha384", "-sig", sigPath)
+	require.NoError(t, err)
+	require.Equal(t, "signature is valid\n", out)
+
+	out, err = execute(t, "inspect-sig", "-key", pubPath, "-sig", sigPath)
+	require.NoError(t, err)
+	require.Contains(t, out, "curve:      P-384")
+	require.Contains(t, out, "low-S:      true")
+
+	_, err = execute(t, "verify", "-key", pubPath, "-in", keyPath, "-hash", "sha384", "-sig", sigPath)
+	require.EqualError(t, err, "signature is not valid")
+
+	_, err = execute(t, "sign", "-key", pubPath, "-in", msgPath)
+	require.EqualError(t, err, "file ["+pubPath+"] does not contain a private key")
+}
+
+func TestKeyIsRedacted(t *testing.T) {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	d := sk.D.String()
+	raw, err := utils.PrivateKeyToPEM(sk)
+	require.NoError(t, err)
+
+	k, err := parseKey(raw, "auto")
+	require.NoError(t, err)
+	require.NotContains(t, fmt.Sprintf("%+v", *k), d)
+	require.Equal(t, "[REDACTED]", fmt.Sprintf("%+v", k.private))
+
+	k.destroy()
+	_, err = encodeKey(k, "pem")
+	require.ErrorIs(t, err, utils.ErrKeyDestroyed)
+}
+
+func TestNormalizeSig(t *testing.T) {
+	dir := t.TempDir()
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	raw, err := utils.PublicKeyToPEM(&sk.PublicKey)
+	require.NoError(t, err)
+	pubPath := filepath.Join(dir, "pub.pem")
+	require.NoError(t, os.WriteFile(pubPath, raw, 0o644))
+
+	digest := sha256.Sum256([]byte("hello, quarkx"))
+	r, s, err := ecdsa.Sign(rand.Reader, sk, digest[:])
+	require.NoError(t, err)
+	lowS, err := utils.IsLowS(&sk.PublicKey, s)
+	require.NoError(t, err)
+	if lowS {
+		s = new(big.Int).Sub(elliptic.P256().Params().N, s)
+	}
+	highS, err := utils.MarshalECDSASignature(r, s)
+	require.NoError(t, err)
+	sigPath := filepath.Join(dir, "sig")
+	require.NoError(t, os.WriteFile(sigPath, []byte(hex.EncodeToString(highS)), 0o644))
+	digestPath := filepath.Join(dir, "digest")
+	require.NoError(t, os.WriteFile(digestPath, digest[:], 0o644))
+
+	out, err := execute(t, "inspect-sig", "-sig", sigPath)
+	require.NoError(t, err)
+	require.Contains(t, out, "low-S:      false")
+	require.Contains(t, out, "half order: "+utils.GetCurveHalfOrdersAt(elliptic.P256()).String())
+
+	_, err = execute(t, "verify", "-key", pubPath, "-in", digestPath, "-hash", "none", "-sig", sigPath)
+	require.Error(t, err)
+	require.True(t, strings.HasPrefix(err.Error(), "invalid S, must be smaller than half the order"))
+
+	_, err = execute(t, "verify", "-key", pubPath, "-in", digestPath, "-hash", "none", "-sig", sigPath, "-allow-high-s")
+	require.NoError(t, err)
+
+	normalizedPath := filepath.Join(dir, "normalized")
+	_, err = execute(t, "normalize-sig", "-sig", sigPath, "-out", normalizedPath)
+	require.NoError(t, err)
+
+	out, err = execute(t, "verify", "-key", pubPath, "-in", digestPath, "-hash", "none", "-sig", normalizedPath)
+	require.NoError(t, err)
+	require.Equal(t, "signature is valid\n", out)
+}
+
+func TestSKIAndConvert(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "key.pem")
+	_, err := execute(t, "keygen", "-out", keyPath)
+	require.NoError(t, err)
+
+	raw, err := os.ReadFile(keyPath)
+	require.NoError(t, err)
+	sk, err := utils.PEMToPrivateKey(raw)
+	require.NoError(t, err)
+
+	out, err := execute(t, "ski", "-key", keyPath)
+	require.NoError(t, err)
+	require.Equal(t, hex.EncodeToString(utils.SKI(&sk.PublicKey))+"\n", out)
+
+	jwkPath := filepath.Join(dir, "key.jwk")
+	_, err = execute(t, "convert", "-in", keyPath, "-to", "jwk", "-out", jwkPath)
+	require.NoError(t, err)
+	derPath := filepath.Join(dir, "key.der")
+	_, err = execute(t, "convert", "-in", jwkPath, "-to", "der", "-out", derPath)
+	require.NoError(t, err)
+	out, err = execute(t, "convert", "-in", derPath, "-from", "der", "-to", "pem")
+	require.NoError(t, err)
+	require.Equal(t, string(raw), out)
+
+	out, err = execute(t, "convert", "-in", jwkPath, "-to", "jwk", "-public")
+	require.NoError(t, err)
+	require.NotContains(t, out, `"d"`)
+
+	out, err = execute(t, "convert", "-in", keyPath, "-to", "ssh", "-public", "-comment", "peer0@org1")
+	require.NoError(t, err)
+	sshPub, comment, _, _, err := ssh.ParseAuthorizedKey([]byte(out))
+	require.NoError(t, err)
+	require.Equal(t, "peer0@org1", comment)
+	require.Equal(t, "ecdsa-sha2-nistp256", sshPub.Type())
+
+	out, err = execute(t, "convert", "-in", keyPath, "-to", "ssh")
+	require.NoError(t, err)
+	parsed, err := ssh.ParseRawPrivateKey([]byte(out))
+	require.NoError(t, err)
+	require.True(t, sk.Equal(parsed))
+
+	_, err = execute(t, "convert", "-in", keyPath, "-to", "xml")
+	require.EqualError(t, err, "unsupported key format [xml], expected one of pem, der, jwk, ssh")
+
+	_, err = execute(t, "unknown")
+	require.EqualError(t, err, "unknown command [unknown]")
+}
+
+func TestVerifyAudit(t *testing.T) {
+	dir := t.TempDir()
+	keyPath := filepath.Join(dir, "key.pem")
+	logPath := filepath.Join(dir, "audit.log")
+	_, err := execute(t, "keygen", "-out", keyPath)
+	require.NoError(t, err)
+
+	raw, err := os.ReadFile(keyPath)
+	require.NoError(t, err)
+	sk, err := utils.PEMToPrivateKey(raw)
+	require.NoError(t, err)
+
+	sink, err := audit.OpenFile(logPath, sk, 2)
+	require.NoError(t, err)
+	_, err = sink.Write([]byte("add admin user1\nremove admin user2\nrotate key\n"))
+	require.NoError(t, err)
+
+	_, err = execute(t, "verify-audit", "-key", keyPath, "-in", logPath, "-sign-every", "2")
+	require.EqualError(t, err, "the last 1 entries are not covered by a checkpoint")
+	out, err := execute(t, "verify-audit", "-key", keyPath, "-in", logPath, "-sign-every", "2", "-strict=false")
+	require.NoError(t, err)
+	require.Contains(t, out, "entries:     3\n")
+	require.Contains(t, out, "warning: the last 1 entries are not covered by a checkpoint")
+
+	require.NoError(t, sink.Close())
+	out, err = execute(t, "verify-audit", "-key", keyPath, "-in", logPath, "-sign-every", "2")
+	require.NoError(t, err)
+	require.Contains(t, out, "checkpoints: 2\n")
+
+	// 签名间隔比日志写入时更小，说明检查点被删除了。
+	_, err = execute(t, "verify-audit", "-key", keyPath, "-in", logPath, "-sign-every", "1")
+	require.EqualError(t, err, "audit log has been tampered with: line 2: entry 2 is more than 1 entries after the last checkpoint, checkpoints have been removed")
+
+	raw, err = os.ReadFile(logPath)
+	require.NoError(t, err)
+	lines := strings.Split(string(raw), "\n")
+	lines[0], lines[1] = lines[1], lines[0]
+	require.NoError(t, os.WriteFile(logPath, []byte(strings.Join(lines, "\n")), 0o600))
+	_, err = execute(t, "verify-audit", "-key", keyPath, "-in", logPath)
+	require.EqualError(t, err, "audit log has been tampered with: line 1: entry 2 is out of sequence, expected entry 1, entries have been deleted or reordered")
+}
+
+func TestBackup(t *testing.T) {
+	dir := t.TempDir()
+	identity, recipient, err := backup.GenerateIdentity()
+	require.NoError(t, err)
+	identityPath := filepath.Join(dir, "identity.txt")
+	require.NoError(t, os.WriteFile(identityPath, []byte("# created for test\n"+identity+"\n"), 0o600))
+	plainPath := filepath.Join(dir, "ledger.tar")
+	require.NoError(t, os.WriteFile(plainPath, []byte("ledger snapshot"), 0o600))
+
+	encryptedPath := filepath.Join(dir, "ledger.tar.age")
+	_, err = execute(t, "encrypt-backup", "-recipient", recipient, "-in", plainPath, "-out", encryptedPath, "-armor")
+	require.NoError(t, err)
+	raw, err := os.ReadFile(encryptedPath)
+	require.NoError(t, err)
+	require.True(t, strings.HasPrefix(string(raw), "-----BEGIN AGE ENCRYPTED FILE-----"))
+
+	out, err := execute(t, "decrypt-backup", "-identity", identityPath, "-in", encryptedPath)
+	require.NoError(t, err)
+	require.Equal(t, "ledger snapshot", out)
+
+	_, err = execute(t, "encrypt-backup", "-in", plainPath)
+	require.EqualError(t, err, "at least one age recipient is required")
+	_, err = execute(t, "decrypt-backup", "-in", encryptedPath)
+	require.EqualError(t, err, "an identity file must be specified with -identity")
+}
@@ -0,0 +1,254 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package ca
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"time"
+
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+	"github.com/geistwelt/quarkx/bccsp/utils"
+)
+
+const (
+	// DefaultValidity 是签发证书默认的有效期，与 cryptogen 保持一致，为 10 年。
+	DefaultValidity = 10 * 365 * 24 * time.Hour
+
+	// 证书的生效时间会往前调整一点，以容忍节点之间的时钟偏差。
+	clockSkew = 5 * time.Minute
+)
+
+// NodeOU 是写在证书 OU 字段中的节点类型，MSP 根据它区分不同角色的身份。
+const (
+	ClientOU  = "client"
+	PeerOU    = "peer"
+	AdminOU   = "admin"
+	OrdererOU = "orderer"
+)
+
+// CA 是一个证书颁发机构，Signer 是 CA 的私钥，SignCert 是 CA 自己的证书。传入的
+// *ecdsa.PrivateKey 会被包装成 utils.ECDSAPrivateKey，CA 接管它的所有权。Rand 是签发
+// 证书时生成序列号与签名所用的随机源，为 nil 时使用 entropy.Reader，由它创建的中间 CA
+// 沿用同一个随机源。
+type CA struct {
+	Name     string
+	Signer   crypto.Signer
+	SignCert *x509.Certificate
+	Rand     io.Reader
+}
+
+// Destroy 将 CA 的私钥清零，之后 CA 不能再签发证书。
+func (ca *CA) Destroy() {
+	if destroyer, ok := ca.Signer.(interface{ Destroy() }); ok {
+		destroyer.Destroy()
+	}
+}
+
+// NewRootCA 用 signer 创建一个自签名的根 CA，subject 中的 CommonName 会被设置为 name。
+func NewRootCA(name string, subject pkix.Name, signer crypto.Signer) (*CA, error) {
+	if signer == nil {
+		return nil, errors.New("invalid signer, it must be different from nil")
+	}
+
+	template, err := caTemplate(entropy.Reader, name, subject, signer.Public())
+	if err != nil {
+		return nil, err
+	}
+
+	cert, err := createCertificate(entropy.Reader, template, template, signer.Public(), signer)
+	if err != nil {
+		return nil, err
+	}
+
+	return newCA(name, signer, cert)
+}
+
+// NewIntermediateCA 创建一个由当前 CA 签发的中间 CA。
+func (ca *CA) NewIntermediateCA(name string, subject pkix.Name, signer crypto.Signer) (*CA, error) {
+	if signer == nil {
+		return nil, errors.New("invalid signer, it must be different from nil")
+	}
+
+	// 没有指定的地址信息沿用上级 CA 的设置。
+	parent := ca.SignCert.Subject
+	if len(subject.Country) == 0 {
+		subject.Country = parent.Country
+	}
+	if len(subject.Province) == 0 {
+		subject.Province = parent.Province
+	}
+	if len(subject.Locality) == 0 {
+		subject.Locality = parent.Locality
+	}
+	if len(subject.StreetAddress) == 0 {
+		subject.StreetAddress = parent.StreetAddress
+	}
+	if len(subject.PostalCode) == 0 {
+		subject.PostalCode = parent.PostalCode
+	}
+
+	template, err := caTemplate(ca.rand(), name, subject, signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	// 中间 CA 不能再继续签发下一级 CA。
+	template.MaxPathLenZero = true
+
+	cert, err := createCertificate(ca.rand(), template, ca.SignCert, signer.Public(), ca.Signer)
+	if err != nil {
+		return nil, err
+	}
+
+	intermediate, err := newCA(name, signer, cert)
+	if err != nil {
+		return nil, err
+	}
+	intermediate.Rand = ca.Rand
+
+	return intermediate, nil
+}
+
+// SignCertificate 为公钥 pub 签发一个证书，orgUnits 会被写入证书的 OU 字段，
+// alternateNames 会根据其格式被写入 IP 或 DNS 类型的 SAN。
+func (ca *CA) SignCertificate(name string, orgUnits []string, alternateNames []string, pub crypto.PublicKey, ku x509.KeyUsage, eku []x509.ExtKeyUsage) (*x509.Certificate, error) {
+	template, err := x509Template(ca.rand(), pub)
+	if err != nil {
+		return nil, err
+	}
+
+	template.Subject = ca.subject(name, orgUnits)
+	template.KeyUsage = ku
+	template.ExtKeyUsage = eku
+	for _, san := range alternateNames {
+		if ip := net.ParseIP(san); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, san)
+		}
+	}
+
+	return createCertificate(ca.rand(), template, ca.SignCert, pub, ca.Signer)
+}
+
+// NewSigningCertificate 签发一个用于签名的身份证书，nodeOU 是身份的角色，例如
+// ClientOU、PeerOU、AdminOU 或 OrdererOU，为空时不写入 OU。
+func (ca *CA) NewSigningCertificate(name string, nodeOU string, pub crypto.PublicKey) (*x509.Certificate, error) {
+	var orgUnits []string
+	if nodeOU != "" {
+		orgUnits = []string{nodeOU}
+	}
+
+	return ca.SignCertificate(name, orgUnits, nil, pub, x509.KeyUsageDigitalSignature, nil)
+}
+
+// NewTLSCertificate 签发一个既可以用作服务端也可以用作客户端的 TLS 证书，name
+// 会同时被写入 SAN。
+func (ca *CA) NewTLSCertificate(name string, alternateNames []string, pub crypto.PublicKey) (*x509.Certificate, error) {
+	sans := append([]string{name}, alternateNames...)
+
+	return ca.SignCertificate(
+		name,
+		nil,
+		sans,
+		pub,
+		x509.KeyUsageDigitalSignature|x509.KeyUsageKeyEncipherment,
+		[]x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	)
+}
+
+func (ca *CA) rand() io.Reader {
+	return entropy.Or(ca.Rand)
+}
+
+func (ca *CA) subject(name string, orgUnits []string) pkix.Name {
+	subject := pkix.Name{
+		Country:       ca.SignCert.Subject.Country,
+		Province:      ca.SignCert.Subject.Province,
+		Locality:      ca.SignCert.Subject.Locality,
+		StreetAddress: ca.SignCert.Subject.StreetAddress,
+		PostalCode:    ca.SignCert.Subject.PostalCode,
+		CommonName:    name,
+	}
+	subject.OrganizationalUnit = append(subject.OrganizationalUnit, orgUnits...)
+
+	return subject
+}
+
+func newCA(name string, signer crypto.Signer, cert *x509.Certificate) (*CA, error) {
+	if sk, ok := signer.(*ecdsa.PrivateKey); ok {
+		wrapped, err := utils.NewECDSAPrivateKey(sk)
+		if err != nil {
+			return nil, err
+		}
+		signer = wrapped
+	}
+
+	return &CA{Name: name, Signer: signer, SignCert: cert}, nil
+}
+
+func caTemplate(rand io.Reader, name string, subject pkix.Name, pub crypto.PublicKey) (*x509.Certificate, error) {
+	template, err := x509Template(rand, pub)
+	if err != nil {
+		return nil, err
+	}
+
+	subject.CommonName = name
+	if len(subject.Organization) == 0 {
+		subject.Organization = []string{name}
+	}
+	template.Subject = subject
+	template.IsCA = true
+	template.KeyUsage = x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign | x509.KeyUsageCRLSign
+	template.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth}
+
+	return template, nil
+}
+
+// x509Template 返回一个填充好序列号、有效期与 SubjectKeyId 的证书模板，其中
+// SubjectKeyId 使用与 bccsp 相同的 SKI 定义。
+func x509Template(random io.Reader, pub crypto.PublicKey) (*x509.Certificate, error) {
+	ecdsaPub, ok := pub.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type [%T]", pub)
+	}
+
+	serialNumber, err := rand.Int(random, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate serial number [%v]", err)
+	}
+
+	now := time.Now()
+	return &x509.Certificate{
+		SerialNumber:          serialNumber,
+		NotBefore:             now.Add(-clockSkew).UTC(),
+		NotAfter:              now.Add(DefaultValidity).UTC(),
+		BasicConstraintsValid: true,
+		SubjectKeyId:          utils.SKI(ecdsaPub),
+	}, nil
+}
+
+func createCertificate(rand io.Reader, template, parent *x509.Certificate, pub crypto.PublicKey, signer crypto.Signer) (*x509.Certificate, error) {
+	der, err := x509.CreateCertificate(rand, template, parent, pub, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate [%v]", err)
+	}
+
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate [%v]", err)
+	}
+
+	return cert, nil
+}
@@ -0,0 +1,143 @@
+package ca
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return sk
+}
+
+func TestCertificateChain(t *testing.T) {
+	rootKey := newKey(t)
+	root, err := NewRootCA("ca.org1.example.com", pkix.Name{Country: []string{"CN"}, Locality: []string{"Hefei"}}, rootKey)
+	require.NoError(t, err)
+	require.True(t, root.SignCert.IsCA)
+	require.Equal(t, utils.SKI(&rootKey.PublicKey), root.SignCert.SubjectKeyId)
+	require.Equal(t, []string{"ca.org1.example.com"}, root.SignCert.Subject.Organization)
+	require.NotZero(t, root.SignCert.KeyUsage&x509.KeyUsageCertSign)
+
+	intermediateKey := newKey(t)
+	intermediate, err := root.NewIntermediateCA("ica.org1.example.com", pkix.Name{}, intermediateKey)
+	require.NoError(t, err)
+	require.True(t, intermediate.SignCert.IsCA)
+	require.True(t, intermediate.SignCert.MaxPathLenZero)
+	require.Equal(t, root.SignCert.SubjectKeyId, intermediate.SignCert.AuthorityKeyId)
+
+	peerKey := newKey(t)
+	peer, err := intermediate.NewSigningCertificate("peer0.org1.example.com", PeerOU, &peerKey.PublicKey)
+	require.NoError(t, err)
+	require.Equal(t, []string{PeerOU}, peer.Subject.OrganizationalUnit)
+	require.Equal(t, []string{"CN"}, peer.Subject.Country)
+	require.Equal(t, x509.KeyUsageDigitalSignature, peer.KeyUsage)
+	require.Equal(t, utils.SKI(&peerKey.PublicKey), peer.SubjectKeyId)
+	require.Equal(t, intermediate.SignCert.SubjectKeyId, peer.AuthorityKeyId)
+
+	roots := x509.NewCertPool()
+	roots.AddCert(root.SignCert)
+	intermediates := x509.NewCertPool()
+	intermediates.AddCert(intermediate.SignCert)
+	_, err = peer.Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}})
+	require.NoError(t, err)
+
+	tlsKey := newKey(t)
+	tlsCert, err := root.NewTLSCertificate("peer0.org1.example.com", []string{"localhost", "127.0.0.1"}, &tlsKey.PublicKey)
+	require.NoError(t, err)
+	require.Equal(t, []string{"peer0.org1.example.com", "localhost"}, tlsCert.DNSNames)
+	require.True(t, tlsCert.IPAddresses[0].Equal(net.ParseIP("127.0.0.1")))
+	require.Equal(t, []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth}, tlsCert.ExtKeyUsage)
+	_, err = tlsCert.Verify(x509.VerifyOptions{Roots: roots, DNSName: "localhost"})
+	require.NoError(t, err)
+
+	parsed, err := PEMToCert(CertToPEM(tlsCert))
+	require.NoError(t, err)
+	require.True(t, parsed.Equal(tlsCert))
+}
+
+func TestDestroy(t *testing.T) {
+	rootKey := newKey(t)
+	root, err := NewRootCA("ca.org1.example.com", pkix.Name{}, rootKey)
+	require.NoError(t, err)
+	require.Equal(t, "[REDACTED]", fmt.Sprintf("%+v", root.Signer))
+	require.NotContains(t, fmt.Sprintf("%+v", *root), rootKey.D.String())
+
+	root.Destroy()
+	require.Zero(t, rootKey.D.Sign())
+	_, err = root.NewSigningCertificate("peer0.org1.example.com", PeerOU, &newKey(t).PublicKey)
+	require.EqualError(t, err, "failed to create certificate [private key has been destroyed]")
+}
+
+func TestUnsupportedKeys(t *testing.T) {
+	_, err := NewRootCA("ca", pkix.Name{}, nil)
+	require.EqualError(t, err, "invalid signer, it must be different from nil")
+
+	_, edKey, err := ed25519.GenerateKey(rand.Reader)
+	require.NoError(t, err)
+	_, err = NewRootCA("ca", pkix.Name{}, edKey)
+	require.EqualError(t, err, "unsupported public key type [ed25519.PublicKey]")
+}
+
+func TestCSR(t *testing.T) {
+	root, err := NewRootCA("ca.org1.example.com", pkix.Name{}, newKey(t))
+	require.NoError(t, err)
+
+	userKey := newKey(t)
+	der, err := NewCSR(pkix.Name{CommonName: "user1@org1.example.com", OrganizationalUnit: []string{ClientOU}}, []string{"user1.org1.example.com"}, userKey)
+	require.NoError(t, err)
+
+	decoded, err := PEMToCSR(CSRToPEM(der))
+	require.NoError(t, err)
+	require.Equal(t, der, decoded)
+
+	csr, err := VerifyCSR(der)
+	require.NoError(t, err)
+	require.Equal(t, "user1@org1.example.com", csr.Subject.CommonName)
+
+	cert, err := root.SignCSR(der, []string{ClientOU}, x509.KeyUsageDigitalSignature, nil)
+	require.NoError(t, err)
+	require.Equal(t, "user1@org1.example.com", cert.Subject.CommonName)
+	require.Equal(t, []string{ClientOU}, cert.Subject.OrganizationalUnit)
+	require.Equal(t, []string{"user1.org1.example.com"}, cert.DNSNames)
+	require.Equal(t, utils.SKI(&userKey.PublicKey), cert.SubjectKeyId)
+	require.NoError(t, cert.CheckSignatureFrom(root.SignCert))
+
+	// 请求者不能通过 CSR 中的 OU 为自己申请 admin 角色。
+	der, err = NewCSR(pkix.Name{CommonName: "user2@org1.example.com", OrganizationalUnit: []string{AdminOU}}, nil, userKey)
+	require.NoError(t, err)
+	cert, err = root.SignCSR(der, []string{ClientOU}, x509.KeyUsageDigitalSignature, nil)
+	require.NoError(t, err)
+	require.Equal(t, []string{ClientOU}, cert.Subject.OrganizationalUnit)
+
+	// 篡改请求中的签名。
+	der[len(der)-1] ^= 0x01
+	_, err = VerifyCSR(der)
+	require.Error(t, err)
+}
+
+func TestRand(t *testing.T) {
+	issue := func() *x509.Certificate {
+		root, err := NewRootCA("ca.org1.example.com", pkix.Name{}, newKey(t))
+		require.NoError(t, err)
+		root.Rand = entropy.NewDeterministic([]byte("seed"))
+		intermediate, err := root.NewIntermediateCA("ica.org1.example.com", pkix.Name{}, newKey(t))
+		require.NoError(t, err)
+		return intermediate.SignCert
+	}
+
+	// 序列号取自注入的随机源，相同的种子得到相同的序列号。
+	require.Equal(t, issue().SerialNumber, issue().SerialNumber)
+}
@@ -0,0 +1,121 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package ca
+
+import (
+	"crypto"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+)
+
+// NewCSR 用 signer 生成一个证书签名请求，返回 DER 编码的结果。alternateNames 会根据
+// 其格式被写入 IP 或 DNS 类型的 SAN。签名所需的随机数取自 entropy.Reader。
+func NewCSR(subject pkix.Name, alternateNames []string, signer crypto.Signer) ([]byte, error) {
+	return NewCSRWithRand(entropy.Reader, subject, alternateNames, signer)
+}
+
+// NewCSRWithRand 与 NewCSR 相同，签名所需的随机数取自 rand。
+func NewCSRWithRand(rand io.Reader, subject pkix.Name, alternateNames []string, signer crypto.Signer) ([]byte, error) {
+	if signer == nil {
+		return nil, errors.New("invalid signer, it must be different from nil")
+	}
+
+	template := &x509.CertificateRequest{Subject: subject}
+	for _, san := range alternateNames {
+		if ip := net.ParseIP(san); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, san)
+		}
+	}
+
+	der, err := x509.CreateCertificateRequest(rand, template, signer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create certificate request [%v]", err)
+	}
+
+	return der, nil
+}
+
+// VerifyCSR 解析 DER 编码的证书签名请求，并检查请求上的签名是否由请求中的公钥生成。
+func VerifyCSR(der []byte) (*x509.CertificateRequest, error) {
+	csr, err := x509.ParseCertificateRequest(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate request [%v]", err)
+	}
+
+	if err := csr.CheckSignature(); err != nil {
+		return nil, fmt.Errorf("invalid certificate request signature [%v]", err)
+	}
+
+	return csr, nil
+}
+
+// SignCSR 校验证书签名请求，并根据请求中的 CommonName 与 SAN 签发证书。证书的 OU 决定
+// 了身份在 MSP 中的角色（client、peer、admin 或 orderer），因此只使用 CA 运营方给出的
+// orgUnits，请求中自带的 OU 会被忽略。
+func (ca *CA) SignCSR(der []byte, orgUnits []string, ku x509.KeyUsage, eku []x509.ExtKeyUsage) (*x509.Certificate, error) {
+	csr, err := VerifyCSR(der)
+	if err != nil {
+		return nil, err
+	}
+
+	template, err := x509Template(ca.rand(), csr.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+
+	template.Subject = ca.subject(csr.Subject.CommonName, orgUnits)
+	template.KeyUsage = ku
+	template.ExtKeyUsage = eku
+	template.DNSNames = csr.DNSNames
+	template.IPAddresses = csr.IPAddresses
+
+	return createCertificate(ca.rand(), template, ca.SignCert, csr.PublicKey, ca.Signer)
+}
+
+// CertToPEM 将证书编码成 PEM 格式。
+func CertToPEM(cert *x509.Certificate) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})
+}
+
+// PEMToCert 解析 PEM 格式的证书。
+func PEMToCert(raw []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("failed decoding PEM, block must be different from nil")
+	}
+	if block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("invalid PEM block type [%s], expected [CERTIFICATE]", block.Type)
+	}
+
+	return x509.ParseCertificate(block.Bytes)
+}
+
+// CSRToPEM 将 DER 编码的证书签名请求编码成 PEM 格式。
+func CSRToPEM(der []byte) []byte {
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE REQUEST", Bytes: der})
+}
+
+// PEMToCSR 解析 PEM 格式的证书签名请求，返回 DER 编码的结果。
+func PEMToCSR(raw []byte) ([]byte, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("failed decoding PEM, block must be different from nil")
+	}
+	if block.Type != "CERTIFICATE REQUEST" {
+		return nil, fmt.Errorf("invalid PEM block type [%s], expected [CERTIFICATE REQUEST]", block.Type)
+	}
+
+	return block.Bytes, nil
+}
@@ -0,0 +1,148 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package comm
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// CASupport 按组织维护受信任的 CA 证书，组织的 CA 可以在运行时增加、替换或移除，
+// 服务端在每次握手时使用最新的证书池校验客户端证书。
+type CASupport struct {
+	mutex sync.RWMutex
+	orgs  map[string][]*x509.Certificate
+	pool  *x509.CertPool
+}
+
+// NewCASupport 创建一个不包含任何 CA 的 CASupport。
+func NewCASupport() *CASupport {
+	return &CASupport{orgs: make(map[string][]*x509.Certificate), pool: x509.NewCertPool()}
+}
+
+// TLSCAs 提供一个组织的 TLS CA 证书，msp.MSP 满足该接口。
+type TLSCAs interface {
+	GetIdentifier() string
+	GetTLSRootCerts() []*x509.Certificate
+	GetTLSIntermediateCerts() []*x509.Certificate
+}
+
+// SetOrgTLSCAs 用 MSP 目录中 tlscacerts 与 tlsintermediatecerts 下的证书替换组织的 CA
+// 证书，组织的名称为 MSP 的标识。与 Fabric 一样，TLS 中间证书也被直接信任，节点握手时
+// 不需要发送中间证书。
+func (cas *CASupport) SetOrgTLSCAs(m TLSCAs) error {
+	certs := append(append([]*x509.Certificate{}, m.GetTLSRootCerts()...), m.GetTLSIntermediateCerts()...)
+	return cas.setOrg(m.GetIdentifier(), certs)
+}
+
+// SetOrgRootCAs 用 PEM 格式的 certs 替换组织 org 的 CA 证书。
+func (cas *CASupport) SetOrgRootCAs(org string, certs ...[]byte) error {
+	var parsed []*x509.Certificate
+	for _, raw := range certs {
+		for {
+			var block *pem.Block
+			block, raw = pem.Decode(raw)
+			if block == nil {
+				break
+			}
+			if block.Type != "CERTIFICATE" {
+				continue
+			}
+			cert, err := x509.ParseCertificate(block.Bytes)
+			if err != nil {
+				return fmt.Errorf("failed to parse CA certificate of organization %s [%v]", org, err)
+			}
+			parsed = append(parsed, cert)
+		}
+	}
+
+	return cas.setOrg(org, parsed)
+}
+
+func (cas *CASupport) setOrg(org string, certs []*x509.Certificate) error {
+	if org == "" {
+		return errors.New("organization name must not be empty")
+	}
+	if len(certs) == 0 {
+		return fmt.Errorf("no CA certificate found for organization %s", org)
+	}
+	for _, cert := range certs {
+		if !cert.IsCA {
+			return fmt.Errorf("certificate [%s] of organization %s is not a CA certificate", cert.Subject, org)
+		}
+	}
+
+	cas.mutex.Lock()
+	cas.orgs[org] = certs
+	cas.rebuild()
+	cas.mutex.Unlock()
+
+	return nil
+}
+
+// RemoveOrg 移除组织 org 的 CA 证书，之后由这些 CA 签发的证书不再被信任。
+func (cas *CASupport) RemoveOrg(org string) {
+	cas.mutex.Lock()
+	delete(cas.orgs, org)
+	cas.rebuild()
+	cas.mutex.Unlock()
+}
+
+// Orgs 返回所有组织的名称。
+func (cas *CASupport) Orgs() []string {
+	cas.mutex.RLock()
+	defer cas.mutex.RUnlock()
+	return cas.sortedOrgs()
+}
+
+// CertPool 返回包含所有组织 CA 证书的证书池。返回的证书池不会再被修改。
+func (cas *CASupport) CertPool() *x509.CertPool {
+	cas.mutex.RLock()
+	defer cas.mutex.RUnlock()
+	return cas.pool
+}
+
+// OrgOf 返回签发了 cert 的组织，cert 的证书链必须能够追溯到该组织的某个 CA。
+func (cas *CASupport) OrgOf(cert *x509.Certificate, intermediates *x509.CertPool) (string, error) {
+	cas.mutex.RLock()
+	defer cas.mutex.RUnlock()
+
+	for _, org := range cas.sortedOrgs() {
+		pool := x509.NewCertPool()
+		for _, ca := range cas.orgs[org] {
+			pool.AddCert(ca)
+		}
+		opts := x509.VerifyOptions{Roots: pool, Intermediates: intermediates, KeyUsages: []x509.ExtKeyUsage{x509.ExtKeyUsageAny}}
+		if _, err := cert.Verify(opts); err == nil {
+			return org, nil
+		}
+	}
+
+	return "", fmt.Errorf("certificate [%s] is not issued by any known organization", cert.Subject)
+}
+
+func (cas *CASupport) rebuild() {
+	pool := x509.NewCertPool()
+	for _, certs := range cas.orgs {
+		for _, cert := range certs {
+			pool.AddCert(cert)
+		}
+	}
+	cas.pool = pool
+}
+
+func (cas *CASupport) sortedOrgs() []string {
+	orgs := make([]string, 0, len(cas.orgs))
+	for org := range cas.orgs {
+		orgs = append(orgs, org)
+	}
+	sort.Strings(orgs)
+	return orgs
+}
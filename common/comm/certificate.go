@@ -0,0 +1,215 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package comm
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"github.com/geistwelt/quarkx/common/qlogging"
+)
+
+var commLogger = qlogging.MustGetLogger("comm")
+
+// DefaultReloadInterval 是检查证书文件是否发生变化的默认间隔。
+const DefaultReloadInterval = 30 * time.Second
+
+// Certificate 是可以热更新的 TLS 证书。证书来自 PEM 格式的证书文件（可以包含中间证书），
+// 私钥可以来自 PEM 格式的私钥文件，也可以是密钥库中的 crypto.Signer。Watch 会定期检查
+// 文件是否发生变化并重新加载，已经建立的连接不受影响，新的握手使用新的证书。从文件加载
+// 的私钥以 utils.ECDSAPrivateKey 的形式保存，轮换之后原来的私钥会被清零。
+type Certificate struct {
+	certFile string
+	keyFile  string
+	signer   crypto.Signer
+
+	// reloadMutex 保证同一时刻只有一个 Reload，避免并发的 Reload 清零对方刚加载的私钥。
+	reloadMutex sync.Mutex
+
+	mutex  sync.RWMutex
+	cert   *tls.Certificate
+	digest [sha256.Size]byte
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewCertificate 从 certFile 加载证书。keyFile 为空时使用 signer 作为私钥，证书轮换时
+// 新证书的公钥必须与 signer 对应；否则从 keyFile 加载私钥，证书与私钥可以同时轮换。
+func NewCertificate(certFile, keyFile string, signer crypto.Signer) (*Certificate, error) {
+	if certFile == "" {
+		return nil, errors.New("certificate file must be specified")
+	}
+	if keyFile == "" && signer == nil {
+		return nil, errors.New("either a key file or a signer must be specified")
+	}
+
+	c := &Certificate{certFile: certFile, keyFile: keyFile, signer: signer, stop: make(chan struct{})}
+	if _, err := c.Reload(); err != nil {
+		return nil, err
+	}
+
+	return c, nil
+}
+
+// Reload 重新读取证书与私钥文件，文件内容没有变化时返回 false。新的证书与私钥不匹配时
+// 返回错误，并继续使用原来的证书。私钥文件中的私钥被替换之后，原来的私钥会被清零，此时
+// 还没有完成签名的握手会失败，由对端重试。
+func (c *Certificate) Reload() (bool, error) {
+	c.reloadMutex.Lock()
+	defer c.reloadMutex.Unlock()
+
+	certPEM, err := os.ReadFile(c.certFile)
+	if err != nil {
+		return false, fmt.Errorf("failed to read certificate file %s [%v]", c.certFile, err)
+	}
+	var keyPEM []byte
+	if c.keyFile != "" {
+		if keyPEM, err = os.ReadFile(c.keyFile); err != nil {
+			return false, fmt.Errorf("failed to read key file %s [%v]", c.keyFile, err)
+		}
+	}
+
+	digest := sha256.Sum256(append(append([]byte{}, certPEM...), keyPEM...))
+	c.mutex.RLock()
+	unchanged := c.cert != nil && digest == c.digest
+	c.mutex.RUnlock()
+	if unchanged {
+		return false, nil
+	}
+
+	signer := c.signer
+	if c.keyFile != "" {
+		sk, err := utils.PEMToPrivateKey(keyPEM)
+		if err != nil {
+			return false, fmt.Errorf("failed to load private key from %s [%v]", c.keyFile, err)
+		}
+		if signer, err = utils.NewECDSAPrivateKey(sk); err != nil {
+			return false, fmt.Errorf("failed to load private key from %s [%v]", c.keyFile, err)
+		}
+	}
+
+	cert, err := newTLSCertificate(certPEM, signer)
+	if err != nil {
+		if c.keyFile != "" {
+			signer.(*utils.ECDSAPrivateKey).Destroy()
+		}
+		return false, fmt.Errorf("failed to load certificate from %s [%v]", c.certFile, err)
+	}
+
+	c.mutex.Lock()
+	previous := c.cert
+	c.cert, c.digest = cert, digest
+	c.mutex.Unlock()
+
+	// 私钥来自文件时，Certificate 持有它的所有权。密钥库中的 signer 由调用方管理。
+	if previous != nil && c.keyFile != "" {
+		if sk, ok := previous.PrivateKey.(*utils.ECDSAPrivateKey); ok && sk != signer {
+			sk.Destroy()
+		}
+	}
+
+	return true, nil
+}
+
+// Watch 每隔 interval 检查一次证书文件，直到 Stop 被调用，interval 不大于 0 时使用
+// DefaultReloadInterval。
+func (c *Certificate) Watch(interval time.Duration) {
+	if interval <= 0 {
+		interval = DefaultReloadInterval
+	}
+
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				reloaded, err := c.Reload()
+				if err != nil {
+					commLogger.Warnf("failed reloading TLS certificate, keep using the current one: %s", err)
+					continue
+				}
+				if reloaded {
+					leaf := c.Leaf()
+					commLogger.Infof("reloaded TLS certificate [%s], serial number [%s], expires at %s", leaf.Subject, leaf.SerialNumber, leaf.NotAfter)
+				}
+			case <-c.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止 Watch。
+func (c *Certificate) Stop() {
+	c.stopOnce.Do(func() { close(c.stop) })
+}
+
+// Leaf 返回当前使用的证书。
+func (c *Certificate) Leaf() *x509.Certificate {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.cert.Leaf
+}
+
+// GetCertificate 可以作为 tls.Config.GetCertificate 使用。
+func (c *Certificate) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.cert, nil
+}
+
+// GetClientCertificate 可以作为 tls.Config.GetClientCertificate 使用。
+func (c *Certificate) GetClientCertificate(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+	c.mutex.RLock()
+	defer c.mutex.RUnlock()
+	return c.cert, nil
+}
+
+// newTLSCertificate 由 PEM 格式的证书链与私钥创建 tls.Certificate，第一张证书的公钥
+// 必须与私钥对应。
+func newTLSCertificate(certPEM []byte, signer crypto.Signer) (*tls.Certificate, error) {
+	cert := &tls.Certificate{PrivateKey: signer}
+	for {
+		var block *pem.Block
+		block, certPEM = pem.Decode(certPEM)
+		if block == nil {
+			break
+		}
+		if block.Type == "CERTIFICATE" {
+			cert.Certificate = append(cert.Certificate, block.Bytes)
+		}
+	}
+	if len(cert.Certificate) == 0 {
+		return nil, errors.New("no certificate found in PEM")
+	}
+
+	leaf, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate [%v]", err)
+	}
+	if !publicKeyEqual(leaf.PublicKey, signer.Public()) {
+		return nil, errors.New("the private key does not match the certificate")
+	}
+	cert.Leaf = leaf
+
+	return cert, nil
+}
+
+func publicKeyEqual(a, b crypto.PublicKey) bool {
+	pub, ok := a.(interface{ Equal(crypto.PublicKey) bool })
+	return ok && pub.Equal(b)
+}
@@ -0,0 +1,228 @@
+package comm
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"github.com/geistwelt/quarkx/common/ca"
+	"github.com/geistwelt/quarkx/msp"
+	"github.com/geistwelt/quarkx/msp/msptest"
+	"github.com/stretchr/testify/require"
+)
+
+func newTLSCert(t *testing.T, issuer *ca.CA, sk *ecdsa.PrivateKey) *x509.Certificate {
+	cert, err := issuer.NewTLSCertificate("localhost", []string{"127.0.0.1"}, &sk.PublicKey)
+	require.NoError(t, err)
+	return cert
+}
+
+// serve 启动一个 TLS 服务，每个连接完成握手后返回服务端看到的连接状态。
+func serve(t *testing.T, config *tls.Config) (string, <-chan tls.ConnectionState) {
+	lis, err := tls.Listen("tcp", "127.0.0.1:0", config)
+	require.NoError(t, err)
+	t.Cleanup(func() { lis.Close() })
+
+	states := make(chan tls.ConnectionState, 16)
+	go func() {
+		for {
+			conn, err := lis.Accept()
+			if err != nil {
+				return
+			}
+			tlsConn := conn.(*tls.Conn)
+			if err := tlsConn.Handshake(); err == nil {
+				states <- tlsConn.ConnectionState()
+			}
+			tlsConn.Close()
+		}
+	}()
+
+	return lis.Addr().String(), states
+}
+
+func dial(addr string, config *tls.Config) (tls.ConnectionState, error) {
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 5 * time.Second}, "tcp", addr, config)
+	if err != nil {
+		return tls.ConnectionState{}, err
+	}
+	defer conn.Close()
+
+	// TLS 1.3 中客户端证书被拒绝的错误要在读取时才会出现。
+	conn.SetReadDeadline(time.Now().Add(100 * time.Millisecond))
+	if _, err := conn.Read(make([]byte, 1)); err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, os.ErrDeadlineExceeded) {
+		return tls.ConnectionState{}, err
+	}
+
+	return conn.ConnectionState(), nil
+}
+
+func TestMutualTLSWithOrgCAs(t *testing.T) {
+	dir := t.TempDir()
+	serverCA, org1CA, org2CA := msptest.NewOrg(t, "server.example.com").Root, msptest.NewOrg(t, "org1.example.com").Root, msptest.NewOrg(t, "org2.example.com").Root
+
+	// 服务端的私钥来自密钥库，只有证书文件会被轮换。
+	serverKey := msptest.NewKey(t)
+	serverCertFile := filepath.Join(dir, "server.crt")
+	require.NoError(t, os.WriteFile(serverCertFile, ca.CertToPEM(newTLSCert(t, serverCA, serverKey)), 0o644))
+	serverCert, err := NewCertificate(serverCertFile, "", serverKey)
+	require.NoError(t, err)
+
+	clientCAs := NewCASupport()
+	require.NoError(t, clientCAs.SetOrgRootCAs("Org1", ca.CertToPEM(org1CA.SignCert)))
+	require.NoError(t, clientCAs.SetOrgRootCAs("Org2", ca.CertToPEM(org2CA.SignCert)))
+	require.Equal(t, []string{"Org1", "Org2"}, clientCAs.Orgs())
+
+	serverConfig, err := ServerTLSConfig(SecureOptions{Certificate: serverCert, RootCAs: clientCAs, RequireClientCert: true})
+	require.NoError(t, err)
+	addr, states := serve(t, serverConfig)
+
+	// 客户端的证书与私钥都来自文件。
+	clientKey := msptest.NewKey(t)
+	clientCertFile, clientKeyFile := filepath.Join(dir, "client.crt"), filepath.Join(dir, "client.key")
+	require.NoError(t, os.WriteFile(clientCertFile, ca.CertToPEM(newTLSCert(t, org2CA, clientKey)), 0o644))
+	keyPEM, err := utils.PrivateKeyToPEM(clientKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(clientKeyFile, keyPEM, 0o600))
+	clientCert, err := NewCertificate(clientCertFile, clientKeyFile, nil)
+	require.NoError(t, err)
+
+	serverCAs := NewCASupport()
+	require.NoError(t, serverCAs.SetOrgRootCAs("Server", ca.CertToPEM(serverCA.SignCert)))
+	clientConfig, err := ClientTLSConfig(SecureOptions{Certificate: clientCert, RootCAs: serverCAs})
+	require.NoError(t, err)
+
+	clientState, err := dial(addr, clientConfig)
+	require.NoError(t, err)
+	require.Equal(t, serverCert.Leaf().Raw, clientState.PeerCertificates[0].Raw)
+	org, err := ClientOrg(<-states, clientCAs)
+	require.NoError(t, err)
+	require.Equal(t, "Org2", org)
+
+	// 没有客户端证书的连接被拒绝。
+	noCertConfig, err := ClientTLSConfig(SecureOptions{RootCAs: serverCAs})
+	require.NoError(t, err)
+	_, err = dial(addr, noCertConfig)
+	require.Error(t, err)
+
+	// 移除组织之后，该组织的客户端无法再建立连接，服务端无需重启。
+	clientCAs.RemoveOrg("Org2")
+	_, err = dial(addr, clientConfig)
+	require.Error(t, err)
+	require.NoError(t, clientCAs.SetOrgRootCAs("Org2", ca.CertToPEM(org2CA.SignCert)))
+	_, err = dial(addr, clientConfig)
+	require.NoError(t, err)
+	<-states
+
+	// 轮换服务端证书。
+	rotated := newTLSCert(t, serverCA, serverKey)
+	require.NoError(t, os.WriteFile(serverCertFile, ca.CertToPEM(rotated), 0o644))
+	serverCert.Watch(10 * time.Millisecond)
+	defer serverCert.Stop()
+	require.Eventually(t, func() bool { return serverCert.Leaf().Equal(rotated) }, 5*time.Second, 10*time.Millisecond)
+	clientState, err = dial(addr, clientConfig)
+	require.NoError(t, err)
+	require.Equal(t, rotated.Raw, clientState.PeerCertificates[0].Raw)
+	<-states
+
+	// 新证书与密钥库中的私钥不匹配时继续使用原来的证书。
+	require.NoError(t, os.WriteFile(serverCertFile, ca.CertToPEM(newTLSCert(t, serverCA, msptest.NewKey(t))), 0o644))
+	reloaded, err := serverCert.Reload()
+	require.False(t, reloaded)
+	require.EqualError(t, err, "failed to load certificate from "+serverCertFile+" [the private key does not match the certificate]")
+	require.True(t, serverCert.Leaf().Equal(rotated))
+
+	// 客户端的证书与私钥同时轮换。
+	newClientKey := msptest.NewKey(t)
+	newClientCert := newTLSCert(t, org1CA, newClientKey)
+	keyPEM, err = utils.PrivateKeyToPEM(newClientKey)
+	require.NoError(t, err)
+	require.NoError(t, os.WriteFile(clientCertFile, ca.CertToPEM(newClientCert), 0o644))
+	require.NoError(t, os.WriteFile(clientKeyFile, keyPEM, 0o600))
+	previous, err := clientCert.GetClientCertificate(nil)
+	require.NoError(t, err)
+	reloaded, err = clientCert.Reload()
+	require.NoError(t, err)
+	require.True(t, reloaded)
+
+	// 文件中的私钥以 utils.ECDSAPrivateKey 的形式保存，轮换之后原来的私钥被清零。
+	current, err := clientCert.GetClientCertificate(nil)
+	require.NoError(t, err)
+	require.IsType(t, &utils.ECDSAPrivateKey{}, current.PrivateKey)
+	digest := sha256.Sum256([]byte("hello, quarkx"))
+	_, err = previous.PrivateKey.(crypto.Signer).Sign(rand.Reader, digest[:], nil)
+	require.ErrorIs(t, err, utils.ErrKeyDestroyed)
+	_, err = dial(addr, clientConfig)
+	require.NoError(t, err)
+	org, err = ClientOrg(<-states, clientCAs)
+	require.NoError(t, err)
+	require.Equal(t, "Org1", org)
+
+	reloaded, err = clientCert.Reload()
+	require.NoError(t, err)
+	require.False(t, reloaded)
+}
+
+func TestOrgTLSCAsFromMSP(t *testing.T) {
+	root := msptest.NewOrg(t, "org1.example.com").Root
+	tlsOrg := msptest.NewOrg(t, "tls.org1.example.com")
+	tlsRoot, tlsIntermediate := tlsOrg.Root, tlsOrg.Intermediate
+
+	m, err := msp.New(&msp.Config{
+		Name:                 "Org1MSP",
+		RootCerts:            [][]byte{ca.CertToPEM(root.SignCert)},
+		TLSRootCerts:         [][]byte{ca.CertToPEM(tlsRoot.SignCert)},
+		TLSIntermediateCerts: [][]byte{ca.CertToPEM(tlsIntermediate.SignCert)},
+	})
+	require.NoError(t, err)
+
+	cas := NewCASupport()
+	require.NoError(t, cas.SetOrgTLSCAs(m))
+	require.Equal(t, []string{"Org1MSP"}, cas.Orgs())
+
+	// TLS 中间证书被直接信任，节点不发送中间证书也能通过校验。
+	org, err := cas.OrgOf(newTLSCert(t, tlsIntermediate, msptest.NewKey(t)), nil)
+	require.NoError(t, err)
+	require.Equal(t, "Org1MSP", org)
+	org, err = cas.OrgOf(newTLSCert(t, tlsRoot, msptest.NewKey(t)), nil)
+	require.NoError(t, err)
+	require.Equal(t, "Org1MSP", org)
+
+	// 身份证书的 CA 不能用来校验 TLS 证书。
+	_, err = cas.OrgOf(newTLSCert(t, root, msptest.NewKey(t)), nil)
+	require.Error(t, err)
+
+	m, err = msp.New(&msp.Config{Name: "Org2MSP", RootCerts: [][]byte{ca.CertToPEM(root.SignCert)}})
+	require.NoError(t, err)
+	require.EqualError(t, cas.SetOrgTLSCAs(m), "no CA certificate found for organization Org2MSP")
+}
+
+func TestConfigErrors(t *testing.T) {
+	_, err := ServerTLSConfig(SecureOptions{})
+	require.EqualError(t, err, "server TLS requires a certificate")
+	_, err = ClientTLSConfig(SecureOptions{RootCAs: NewCASupport()})
+	require.EqualError(t, err, "client TLS requires at least one root CA")
+
+	_, err = NewCertificate("", "", nil)
+	require.EqualError(t, err, "certificate file must be specified")
+	_, err = NewCertificate("cert.pem", "", nil)
+	require.EqualError(t, err, "either a key file or a signer must be specified")
+
+	cas := NewCASupport()
+	require.EqualError(t, cas.SetOrgRootCAs("Org1", []byte("not a pem")), "no CA certificate found for organization Org1")
+	sk := msptest.NewKey(t)
+	leaf := newTLSCert(t, msptest.NewOrg(t, "org1.example.com").Root, sk)
+	require.EqualError(t, cas.SetOrgRootCAs("Org1", ca.CertToPEM(leaf)), "certificate [CN=localhost,C=CN] of organization Org1 is not a CA certificate")
+	require.EqualError(t, cas.SetOrgRootCAs("", nil), "organization name must not be empty")
+}
@@ -0,0 +1,120 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+* From: hyperledger/fabric/internal/pkg/comm/config.go
+ */
+
+package comm
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+)
+
+// DefaultTLSCipherSuites 是 TLS 1.2 下允许使用的密码套件，TLS 1.3 的密码套件不可配置。
+var DefaultTLSCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305,
+}
+
+// SecureOptions 是构建 TLS 配置所需的选项。
+type SecureOptions struct {
+	// Certificate 是本端的证书。服务端必须提供，客户端只在双向 TLS 时需要。
+	Certificate *Certificate
+
+	// RootCAs 是按组织划分的受信任 CA。服务端用它校验客户端证书，并且每次握手都使用
+	// 最新的 CA；客户端用它校验服务端证书，使用构建配置时的 CA。
+	RootCAs *CASupport
+
+	// RequireClientCert 为 true 时服务端要求并校验客户端证书。
+	RequireClientCert bool
+
+	// ServerNameOverride 用来覆盖客户端校验服务端证书时使用的主机名。
+	ServerNameOverride string
+
+	// CipherSuites 为空时使用 DefaultTLSCipherSuites。
+	CipherSuites []uint16
+}
+
+// ServerTLSConfig 构建服务端的 TLS 配置。证书通过 GetCertificate 获取，客户端 CA 通过
+// GetConfigForClient 在每次握手时获取，因此证书轮换与组织 CA 的变化都不需要重启服务。
+func ServerTLSConfig(opts SecureOptions) (*tls.Config, error) {
+	if opts.Certificate == nil {
+		return nil, errors.New("server TLS requires a certificate")
+	}
+	if opts.RequireClientCert && opts.RootCAs == nil {
+		return nil, errors.New("client authentication requires root CAs")
+	}
+
+	base := &tls.Config{
+		MinVersion:     tls.VersionTLS12,
+		CipherSuites:   cipherSuites(opts.CipherSuites),
+		GetCertificate: opts.Certificate.GetCertificate,
+	}
+	if !opts.RequireClientCert {
+		return base, nil
+	}
+
+	base.ClientAuth = tls.RequireAndVerifyClientCert
+	base.ClientCAs = opts.RootCAs.CertPool()
+	base.GetConfigForClient = func(*tls.ClientHelloInfo) (*tls.Config, error) {
+		config := base.Clone()
+		config.GetConfigForClient = nil
+		config.ClientCAs = opts.RootCAs.CertPool()
+		return config, nil
+	}
+
+	return base, nil
+}
+
+// ClientTLSConfig 构建客户端的 TLS 配置。提供了 Certificate 时会在服务端要求时出示
+// 客户端证书，证书同样可以热更新。
+func ClientTLSConfig(opts SecureOptions) (*tls.Config, error) {
+	if opts.RootCAs == nil || len(opts.RootCAs.Orgs()) == 0 {
+		return nil, errors.New("client TLS requires at least one root CA")
+	}
+
+	config := &tls.Config{
+		MinVersion:   tls.VersionTLS12,
+		CipherSuites: cipherSuites(opts.CipherSuites),
+		RootCAs:      opts.RootCAs.CertPool(),
+		ServerName:   opts.ServerNameOverride,
+	}
+	if opts.Certificate != nil {
+		config.GetClientCertificate = opts.Certificate.GetClientCertificate
+	}
+
+	return config, nil
+}
+
+// ClientOrg 返回已经完成握手的连接中客户端证书所属的组织。
+func ClientOrg(state tls.ConnectionState, cas *CASupport) (string, error) {
+	if len(state.PeerCertificates) == 0 {
+		return "", errors.New("no client certificate presented")
+	}
+
+	intermediates := x509.NewCertPool()
+	for _, cert := range state.PeerCertificates[1:] {
+		intermediates.AddCert(cert)
+	}
+
+	org, err := cas.OrgOf(state.PeerCertificates[0], intermediates)
+	if err != nil {
+		return "", fmt.Errorf("failed to identify client organization [%v]", err)
+	}
+
+	return org, nil
+}
+
+func cipherSuites(suites []uint16) []uint16 {
+	if len(suites) == 0 {
+		return DefaultTLSCipherSuites
+	}
+	return suites
+}
@@ -0,0 +1,201 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package merkle
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"hash"
+)
+
+// DefaultWidth 是默认的树宽，即每个内部节点最多拥有的子节点数量。
+const DefaultWidth = 2
+
+const (
+	// leafPrefix 与 nodePrefix 用来区分叶子节点与内部节点的哈希，防止把内部节点伪装成叶子。
+	leafPrefix byte = 0x00
+	nodePrefix byte = 0x01
+)
+
+// Tree 是一棵宽度可配置的 Merkle 树。每一层从左到右每 width 个节点合并成上一层的一个
+// 节点，最后一组不足 width 个节点时只合并现有的节点，只剩一个节点时直接提升到上一层。
+type Tree struct {
+	width    int
+	hashFunc func() hash.Hash
+
+	// levels[0] 是叶子节点的哈希，levels[len(levels)-1] 只有根节点。
+	levels [][][]byte
+}
+
+// ProofStep 是包含证明中的一层：当前节点在所属分组中的位置，以及同组的其他节点。
+type ProofStep struct {
+	Position int
+	Siblings [][]byte
+}
+
+// Proof 证明第 Index 个叶子包含在一棵拥有 LeafCount 个叶子的树中。
+type Proof struct {
+	Index     int
+	LeafCount int
+	Path      []ProofStep
+}
+
+// New 用 hashFunc 构建一棵宽度为 width 的 Merkle 树，hashFunc 应当来自节点的安全配置，
+// 见 utils.GetHashFunc。
+func New(width int, hashFunc func() hash.Hash, leaves [][]byte) (*Tree, error) {
+	if width < 2 {
+		return nil, fmt.Errorf("invalid tree width %d, it must be at least 2", width)
+	}
+	if hashFunc == nil {
+		return nil, errors.New("nil hash function")
+	}
+	if len(leaves) == 0 {
+		return nil, errors.New("cannot build a merkle tree without leaves")
+	}
+
+	level := make([][]byte, len(leaves))
+	for i, leaf := range leaves {
+		level[i] = hashLeaf(hashFunc, leaf)
+	}
+
+	tree := &Tree{width: width, hashFunc: hashFunc, levels: [][][]byte{level}}
+	for len(level) > 1 {
+		next := make([][]byte, 0, (len(level)+width-1)/width)
+		for start := 0; start < len(level); start += width {
+			end := start + width
+			if end > len(level) {
+				end = len(level)
+			}
+			next = append(next, hashNode(hashFunc, level[start:end]))
+		}
+		tree.levels = append(tree.levels, next)
+		level = next
+	}
+
+	return tree, nil
+}
+
+// Root 返回树根的哈希值。
+func (t *Tree) Root() []byte {
+	return t.levels[len(t.levels)-1][0]
+}
+
+// Width 返回树宽。
+func (t *Tree) Width() int {
+	return t.width
+}
+
+// LeafCount 返回叶子节点的数量。
+func (t *Tree) LeafCount() int {
+	return len(t.levels[0])
+}
+
+// Proof 生成第 index 个叶子的包含证明。
+func (t *Tree) Proof(index int) (*Proof, error) {
+	if index < 0 || index >= t.LeafCount() {
+		return nil, fmt.Errorf("leaf index %d out of range [0, %d)", index, t.LeafCount())
+	}
+
+	proof := &Proof{Index: index, LeafCount: t.LeafCount()}
+	for _, level := range t.levels[:len(t.levels)-1] {
+		start := index / t.width * t.width
+		end := start + t.width
+		if end > len(level) {
+			end = len(level)
+		}
+
+		step := ProofStep{Position: index - start}
+		for i := start; i < end; i++ {
+			if i != index {
+				step.Siblings = append(step.Siblings, level[i])
+			}
+		}
+		proof.Path = append(proof.Path, step)
+		index /= t.width
+	}
+
+	return proof, nil
+}
+
+// Verify 检查 proof 是否证明 leaf 包含在根为 root、宽度为 width 的树中。证明的形状必须与
+// Index 和 LeafCount 所确定的形状一致。
+func Verify(width int, hashFunc func() hash.Hash, root []byte, leaf []byte, proof *Proof) error {
+	if width < 2 {
+		return fmt.Errorf("invalid tree width %d, it must be at least 2", width)
+	}
+	if proof == nil {
+		return errors.New("nil proof")
+	}
+	if proof.Index < 0 || proof.Index >= proof.LeafCount {
+		return fmt.Errorf("leaf index %d out of range [0, %d)", proof.Index, proof.LeafCount)
+	}
+
+	current := hashLeaf(hashFunc, leaf)
+	index, count := proof.Index, proof.LeafCount
+	for _, step := range proof.Path {
+		if count == 1 {
+			return errors.New("proof is longer than the tree height")
+		}
+
+		start := index / width * width
+		size := width
+		if start+size > count {
+			size = count - start
+		}
+		if step.Position != index-start || len(step.Siblings) != size-1 {
+			return fmt.Errorf("malformed proof step at leaf index %d", index)
+		}
+
+		group := make([][]byte, 0, size)
+		group = append(group, step.Siblings[:step.Position]...)
+		group = append(group, current)
+		group = append(group, step.Siblings[step.Position:]...)
+		current = hashNode(hashFunc, group)
+
+		index /= width
+		count = (count + width - 1) / width
+	}
+	if count != 1 {
+		return errors.New("proof is shorter than the tree height")
+	}
+
+	if !bytes.Equal(current, root) {
+		return errors.New("merkle root mismatch")
+	}
+
+	return nil
+}
+
+// Root 直接计算 leaves 的 Merkle 树根。
+func Root(width int, hashFunc func() hash.Hash, leaves [][]byte) ([]byte, error) {
+	tree, err := New(width, hashFunc, leaves)
+	if err != nil {
+		return nil, err
+	}
+	return tree.Root(), nil
+}
+
+func hashLeaf(hashFunc func() hash.Hash, leaf []byte) []byte {
+	h := hashFunc()
+	h.Write([]byte{leafPrefix})
+	h.Write(leaf)
+	return h.Sum(nil)
+}
+
+// hashNode 合并一组子节点，只有一个子节点时直接将它提升到上一层。
+func hashNode(hashFunc func() hash.Hash, children [][]byte) []byte {
+	if len(children) == 1 {
+		return children[0]
+	}
+
+	h := hashFunc()
+	h.Write([]byte{nodePrefix})
+	for _, child := range children {
+		h.Write(child)
+	}
+	return h.Sum(nil)
+}
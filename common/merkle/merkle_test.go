@@ -0,0 +1,118 @@
+package merkle
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"testing"
+
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func leaves(n int) [][]byte {
+	res := make([][]byte, n)
+	for i := range res {
+		res[i] = []byte(fmt.Sprintf("tx-%d", i))
+	}
+	return res
+}
+
+func TestProofs(t *testing.T) {
+	for _, family := range []string{"SHA2", "SHA3"} {
+		hashFunc, err := utils.GetHashFunc(family, 256)
+		require.NoError(t, err)
+
+		for _, width := range []int{2, 3, 4, 16} {
+			for _, n := range []int{1, 2, 3, 5, 7, 16, 17, 33} {
+				data := leaves(n)
+				tree, err := New(width, hashFunc, data)
+				require.NoError(t, err)
+				require.Equal(t, n, tree.LeafCount())
+
+				root, err := Root(width, hashFunc, data)
+				require.NoError(t, err)
+				require.Equal(t, tree.Root(), root)
+
+				for i := 0; i < n; i++ {
+					proof, err := tree.Proof(i)
+					require.NoError(t, err)
+					require.NoError(t, Verify(width, hashFunc, root, data[i], proof), "width %d, leaves %d, index %d", width, n, i)
+
+					if n > 1 {
+						require.EqualError(t, Verify(width, hashFunc, root, data[(i+1)%n], proof), "merkle root mismatch")
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestTree(t *testing.T) {
+	data := leaves(3)
+	tree, err := New(2, sha256.New, data)
+	require.NoError(t, err)
+
+	// 第三个叶子在第一层没有兄弟节点，直接被提升。
+	h := func(prefix byte, parts ...[]byte) []byte {
+		hash := sha256.New()
+		hash.Write([]byte{prefix})
+		for _, part := range parts {
+			hash.Write(part)
+		}
+		return hash.Sum(nil)
+	}
+	l0, l1, l2 := h(leafPrefix, data[0]), h(leafPrefix, data[1]), h(leafPrefix, data[2])
+	require.Equal(t, h(nodePrefix, h(nodePrefix, l0, l1), l2), tree.Root())
+
+	single, err := New(4, sha256.New, data[:1])
+	require.NoError(t, err)
+	require.Equal(t, l0, single.Root())
+
+	// 不同的树宽得到不同的根。
+	wide, err := New(3, sha256.New, data)
+	require.NoError(t, err)
+	require.Equal(t, h(nodePrefix, l0, l1, l2), wide.Root())
+	require.NotEqual(t, tree.Root(), wide.Root())
+
+	_, err = New(1, sha256.New, data)
+	require.EqualError(t, err, "invalid tree width 1, it must be at least 2")
+	_, err = New(2, nil, data)
+	require.EqualError(t, err, "nil hash function")
+	_, err = New(2, sha256.New, nil)
+	require.EqualError(t, err, "cannot build a merkle tree without leaves")
+	_, err = tree.Proof(3)
+	require.EqualError(t, err, "leaf index 3 out of range [0, 3)")
+}
+
+func TestMalformedProofs(t *testing.T) {
+	data := leaves(5)
+	tree, err := New(2, sha256.New, data)
+	require.NoError(t, err)
+	root := tree.Root()
+
+	proof, err := tree.Proof(2)
+	require.NoError(t, err)
+
+	// 内部节点不能被当作叶子。
+	inner := &Proof{Index: 0, LeafCount: 3, Path: proof.Path[1:]}
+	require.Error(t, Verify(2, sha256.New, root, tree.levels[1][1], inner))
+
+	forged := *proof
+	forged.Index = 3
+	require.EqualError(t, Verify(2, sha256.New, root, data[2], &forged), "malformed proof step at leaf index 3")
+
+	forged = *proof
+	forged.LeafCount = 9
+	require.Error(t, Verify(2, sha256.New, root, data[2], &forged))
+
+	forged = *proof
+	forged.Path = forged.Path[:len(forged.Path)-1]
+	require.EqualError(t, Verify(2, sha256.New, root, data[2], &forged), "proof is shorter than the tree height")
+
+	forged = *proof
+	forged.Path = append(append([]ProofStep{}, forged.Path...), ProofStep{})
+	require.EqualError(t, Verify(2, sha256.New, root, data[2], &forged), "proof is longer than the tree height")
+
+	require.EqualError(t, Verify(2, sha256.New, root, data[2], nil), "nil proof")
+	require.EqualError(t, Verify(2, sha256.New, root, data[2], &Proof{Index: 5, LeafCount: 5}), "leaf index 5 out of range [0, 5)")
+}
@@ -0,0 +1,172 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+* From: hyperledger/fabric/common/cauthdsl/cauthdsl.go
+ */
+
+package policies
+
+import (
+	"errors"
+
+	"github.com/geistwelt/quarkx/common/qlogging"
+	"github.com/geistwelt/quarkx/msp"
+	"github.com/geistwelt/quarkx/protoutil"
+)
+
+var policyLogger = qlogging.MustGetLogger("policies")
+
+// ErrPolicyNotSatisfied 表示签名集合没有满足策略。
+var ErrPolicyNotSatisfied = errors.New("signature set did not satisfy policy")
+
+// Match 记录策略中的一个主体由哪个签名者满足，SignedDataIndex 是该签名在签名集合中的下标。
+type Match struct {
+	Principal       *msp.MSPRole
+	Identity        msp.Identity
+	SignedDataIndex int
+}
+
+// Policy 是编译之后的签名策略。
+type Policy struct {
+	envelope     *SignaturePolicyEnvelope
+	deserializer msp.IdentityDeserializer
+	evaluator    evaluator
+}
+
+// signer 是通过了签名验证的签名者。
+type signer struct {
+	identity msp.Identity
+	index    int
+}
+
+// evaluator 判断签名者是否满足策略的一个节点，used[i] 不为 nil 表示 signers[i] 已经
+// 被用来满足了该主体，同一个签名者只能满足策略中的一个主体。
+type evaluator func(signers []*signer, used []*msp.MSPRole) bool
+
+// NewPolicy 编译签名策略，deserializer 用来还原签名者的身份，通常是 MSPManager。
+func NewPolicy(envelope *SignaturePolicyEnvelope, deserializer msp.IdentityDeserializer) (*Policy, error) {
+	if envelope == nil {
+		return nil, errors.New("empty policy element")
+	}
+	if deserializer == nil {
+		return nil, errors.New("nil identity deserializer")
+	}
+	if err := envelope.validate(); err != nil {
+		return nil, err
+	}
+
+	return &Policy{
+		envelope:     envelope,
+		deserializer: deserializer,
+		evaluator:    compile(envelope.Rule, envelope.Identities),
+	}, nil
+}
+
+// NewPolicyFromString 解析并编译策略语言描述的签名策略。
+func NewPolicyFromString(policy string, deserializer msp.IdentityDeserializer) (*Policy, error) {
+	envelope, err := FromString(policy)
+	if err != nil {
+		return nil, err
+	}
+
+	return NewPolicy(envelope, deserializer)
+}
+
+// Envelope 返回策略的原始定义。
+func (p *Policy) Envelope() *SignaturePolicyEnvelope {
+	return p.envelope
+}
+
+// EvaluateSignedData 判断签名集合是否满足策略。无法还原身份、签名无效的条目会被忽略，
+// 同一个身份的多个签名只有第一个会被计入。策略被满足时返回每个被满足的主体以及满足
+// 它的签名者，否则返回 ErrPolicyNotSatisfied。
+func (p *Policy) EvaluateSignedData(signatureSet []*protoutil.SignedData) ([]*Match, error) {
+	signers := p.signers(signatureSet)
+	used := make([]*msp.MSPRole, len(signers))
+
+	if !p.evaluator(signers, used) {
+		return nil, ErrPolicyNotSatisfied
+	}
+
+	var matches []*Match
+	for i, principal := range used {
+		if principal == nil {
+			continue
+		}
+		matches = append(matches, &Match{Principal: principal, Identity: signers[i].identity, SignedDataIndex: signers[i].index})
+	}
+
+	return matches, nil
+}
+
+// signers 还原签名集合中的身份并验证签名，返回去重之后的有效签名者。
+func (p *Policy) signers(signatureSet []*protoutil.SignedData) []*signer {
+	seen := make(map[msp.IdentityIdentifier]struct{}, len(signatureSet))
+	signers := make([]*signer, 0, len(signatureSet))
+	for i, sd := range signatureSet {
+		if sd == nil {
+			continue
+		}
+
+		identity, err := p.deserializer.DeserializeIdentity(sd.Identity)
+		if err != nil {
+			policyLogger.Warnf("principal deserialization failure (%s) for identity %d", err, i)
+			continue
+		}
+
+		key := *identity.GetIdentifier()
+		if _, ok := seen[key]; ok {
+			policyLogger.Warnf("de-duplicating identity [%s] at index %d in signature set", key.Id, i)
+			continue
+		}
+
+		if err := identity.Verify(sd.Data, sd.Signature); err != nil {
+			policyLogger.Warnf("signature for identity %d is invalid: %s", i, err)
+			continue
+		}
+
+		seen[key] = struct{}{}
+		signers = append(signers, &signer{identity: identity, index: i})
+	}
+
+	return signers
+}
+
+func compile(rule *SignaturePolicy, identities []*msp.MSPRole) evaluator {
+	if rule.NOutOf == nil {
+		principal := identities[rule.SignedBy]
+		return func(signers []*signer, used []*msp.MSPRole) bool {
+			for i, s := range signers {
+				if used[i] != nil {
+					continue
+				}
+				if err := s.identity.SatisfiesPrincipal(principal); err != nil {
+					policyLogger.Debugf("identity %d does not satisfy principal %s: %s", s.index, principal, err)
+					continue
+				}
+				used[i] = principal
+				return true
+			}
+			return false
+		}
+	}
+
+	n := rule.NOutOf.N
+	policies := make([]evaluator, len(rule.NOutOf.Rules))
+	for i, sub := range rule.NOutOf.Rules {
+		policies[i] = compile(sub, identities)
+	}
+
+	return func(signers []*signer, used []*msp.MSPRole) bool {
+		verified := 0
+		tmp := make([]*msp.MSPRole, len(used))
+		for _, policy := range policies {
+			copy(tmp, used)
+			if policy(signers, tmp) {
+				verified++
+				copy(used, tmp)
+			}
+		}
+		return verified >= n
+	}
+}
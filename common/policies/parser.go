@@ -0,0 +1,229 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package policies
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/geistwelt/quarkx/msp"
+)
+
+// roles 是策略语言中可以使用的角色名称。
+var roles = map[string]msp.MSPRoleType{
+	"member":  msp.MSPRoleMember,
+	"admin":   msp.MSPRoleAdmin,
+	"client":  msp.MSPRoleClient,
+	"peer":    msp.MSPRolePeer,
+	"orderer": msp.MSPRoleOrderer,
+}
+
+// FromString 解析策略语言，例如：
+//
+//	OutOf(2, 'Org1MSP.member', 'Org2MSP.peer')
+//	AND('Org1MSP.admin', OR('Org2MSP.peer', 'Org3MSP.peer'))
+//
+// AND、OR 与 OutOf 不区分大小写，主体的形式为 'MSP标识.角色'，角色可以是 member、
+// admin、client、peer 或 orderer，主体可以使用单引号或双引号。相同的主体在
+// Identities 中只会出现一次。
+func FromString(policy string) (*SignaturePolicyEnvelope, error) {
+	p := &parser{input: policy, principals: make(map[msp.MSPRole]int)}
+
+	rule, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.next(); tok.kind != tokenEOF {
+		return nil, p.unexpected(tok)
+	}
+
+	envelope := &SignaturePolicyEnvelope{Rule: rule, Identities: p.identities}
+	if err := envelope.validate(); err != nil {
+		return nil, err
+	}
+
+	return envelope, nil
+}
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenLParen
+	tokenRParen
+	tokenComma
+	tokenInvalid
+)
+
+type token struct {
+	kind  tokenKind
+	value string
+	pos   int
+}
+
+type parser struct {
+	input string
+	pos   int
+
+	identities []*msp.MSPRole
+	principals map[msp.MSPRole]int
+}
+
+func (p *parser) parseExpression() (*SignaturePolicy, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenString:
+		return p.parsePrincipal(tok)
+	case tokenIdent:
+	default:
+		return nil, p.unexpected(tok)
+	}
+
+	var n int
+	name := strings.ToLower(tok.value)
+	switch name {
+	case "and", "or", "outof":
+	default:
+		return nil, fmt.Errorf("unrecognized function %s at position %d", tok.value, tok.pos)
+	}
+
+	if tok := p.next(); tok.kind != tokenLParen {
+		return nil, p.unexpected(tok)
+	}
+
+	if name == "outof" {
+		tok := p.next()
+		if tok.kind != tokenNumber {
+			return nil, fmt.Errorf("the first argument of OutOf must be an integer, got %s at position %d", tok, tok.pos)
+		}
+		var err error
+		if n, err = strconv.Atoi(tok.value); err != nil {
+			return nil, fmt.Errorf("invalid threshold %s at position %d [%v]", tok.value, tok.pos, err)
+		}
+		if tok := p.next(); tok.kind != tokenComma {
+			return nil, p.unexpected(tok)
+		}
+	}
+
+	var rules []*SignaturePolicy
+	for {
+		rule, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+
+		tok := p.next()
+		if tok.kind == tokenRParen {
+			break
+		}
+		if tok.kind != tokenComma {
+			return nil, p.unexpected(tok)
+		}
+	}
+
+	switch name {
+	case "and":
+		n = len(rules)
+	case "or":
+		n = 1
+	}
+	if n < 1 || n > len(rules) {
+		return nil, fmt.Errorf("invalid threshold %d in OutOf at position %d, must be between 1 and the number of rules %d", n, tok.pos, len(rules))
+	}
+
+	return NOutOfPolicy(n, rules...), nil
+}
+
+func (p *parser) parsePrincipal(tok token) (*SignaturePolicy, error) {
+	dot := strings.LastIndexByte(tok.value, '.')
+	if dot <= 0 {
+		return nil, fmt.Errorf("invalid principal %s at position %d, expected MSPID.role", tok, tok.pos)
+	}
+
+	role, ok := roles[tok.value[dot+1:]]
+	if !ok {
+		return nil, fmt.Errorf("invalid role %s in principal %s at position %d", tok.value[dot+1:], tok, tok.pos)
+	}
+
+	principal := msp.MSPRole{MSPIdentifier: tok.value[:dot], Role: role}
+	index, ok := p.principals[principal]
+	if !ok {
+		index = len(p.identities)
+		p.principals[principal] = index
+		p.identities = append(p.identities, &msp.MSPRole{MSPIdentifier: principal.MSPIdentifier, Role: principal.Role})
+	}
+
+	return SignedBy(index), nil
+}
+
+func (p *parser) unexpected(tok token) error {
+	if tok.kind == tokenEOF {
+		return fmt.Errorf("unexpected end of policy %q", p.input)
+	}
+	return fmt.Errorf("unexpected %s at position %d in policy %q", tok, tok.pos, p.input)
+}
+
+// next 返回下一个词法单元，无法识别的字符与未闭合的字符串会作为 tokenInvalid 返回。
+func (p *parser) next() token {
+	for p.pos < len(p.input) && unicode.IsSpace(rune(p.input[p.pos])) {
+		p.pos++
+	}
+	if p.pos >= len(p.input) {
+		return token{kind: tokenEOF, pos: p.pos}
+	}
+
+	start := p.pos
+	c := p.input[p.pos]
+	switch {
+	case c == '(':
+		p.pos++
+		return token{kind: tokenLParen, value: "(", pos: start}
+	case c == ')':
+		p.pos++
+		return token{kind: tokenRParen, value: ")", pos: start}
+	case c == ',':
+		p.pos++
+		return token{kind: tokenComma, value: ",", pos: start}
+	case c == '\'' || c == '"':
+		end := strings.IndexByte(p.input[start+1:], c)
+		if end < 0 {
+			p.pos = len(p.input)
+			return token{kind: tokenInvalid, value: p.input[start:], pos: start}
+		}
+		p.pos = start + end + 2
+		return token{kind: tokenString, value: p.input[start+1 : start+1+end], pos: start}
+	case c >= '0' && c <= '9':
+		for p.pos < len(p.input) && p.input[p.pos] >= '0' && p.input[p.pos] <= '9' {
+			p.pos++
+		}
+		return token{kind: tokenNumber, value: p.input[start:p.pos], pos: start}
+	case isLetter(c):
+		for p.pos < len(p.input) && isLetter(p.input[p.pos]) {
+			p.pos++
+		}
+		return token{kind: tokenIdent, value: p.input[start:p.pos], pos: start}
+	default:
+		p.pos++
+		return token{kind: tokenInvalid, value: string(c), pos: start}
+	}
+}
+
+func isLetter(c byte) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func (t token) String() string {
+	if t.kind == tokenString {
+		return "'" + t.value + "'"
+	}
+	return t.value
+}
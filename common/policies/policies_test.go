@@ -0,0 +1,231 @@
+package policies
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"github.com/geistwelt/quarkx/common/ca"
+	"github.com/geistwelt/quarkx/msp"
+	"github.com/geistwelt/quarkx/msp/msptest"
+	"github.com/geistwelt/quarkx/protos/common"
+	"github.com/geistwelt/quarkx/protoutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+type testSigner struct {
+	mspID string
+	cert  []byte
+	key   *ecdsa.PrivateKey
+}
+
+func (s *testSigner) sign(t *testing.T, data []byte) *protoutil.SignedData {
+	sig, err := s.Sign(data)
+	require.NoError(t, err)
+	identity, err := s.Serialize()
+	require.NoError(t, err)
+
+	return &protoutil.SignedData{Data: data, Identity: identity, Signature: sig}
+}
+
+// Sign 与 Serialize 使 testSigner 满足 protoutil.Signer。
+func (s *testSigner) Sign(msg []byte) ([]byte, error) {
+	digest := sha256.Sum256(msg)
+	sig, err := ecdsa.SignASN1(rand.Reader, s.key, digest[:])
+	if err != nil {
+		return nil, err
+	}
+	return utils.SignatureToLowS(&s.key.PublicKey, sig)
+}
+
+func (s *testSigner) Serialize() ([]byte, error) {
+	return proto.Marshal(&msp.SerializedIdentity{Mspid: s.mspID, IdBytes: s.cert})
+}
+
+type testOrg struct {
+	*msptest.Org
+	mspID string
+	msp   msp.MSP
+}
+
+func newTestOrg(t *testing.T, mspID, domain string) *testOrg {
+	org := msptest.NewOrg(t, domain)
+
+	intermediatePEM := ca.CertToPEM(org.Intermediate.SignCert)
+	ou := func(name string) *msp.OUIdentifierConfig {
+		return &msp.OUIdentifierConfig{Certificate: intermediatePEM, OrganizationalUnitIdentifier: name}
+	}
+	m, err := msp.New(&msp.Config{
+		Name:              mspID,
+		RootCerts:         [][]byte{ca.CertToPEM(org.Root.SignCert)},
+		IntermediateCerts: [][]byte{intermediatePEM},
+		NodeOUs: &msp.NodeOUsConfig{
+			Enable:              true,
+			ClientOUIdentifier:  ou(ca.ClientOU),
+			PeerOUIdentifier:    ou(ca.PeerOU),
+			AdminOUIdentifier:   ou(ca.AdminOU),
+			OrdererOUIdentifier: ou(ca.OrdererOU),
+		},
+	})
+	require.NoError(t, err)
+
+	return &testOrg{Org: org, mspID: mspID, msp: m}
+}
+
+func (org *testOrg) newSigner(t *testing.T, name, nodeOU string) *testSigner {
+	cert, sk := org.NewIdentity(t, name, nodeOU)
+	return &testSigner{mspID: org.mspID, cert: ca.CertToPEM(cert), key: sk}
+}
+
+func TestFromString(t *testing.T) {
+	envelope, err := FromString("OutOf(2, 'Org1MSP.member', 'Org2MSP.peer')")
+	require.NoError(t, err)
+	require.Equal(t, &SignaturePolicyEnvelope{
+		Rule: NOutOfPolicy(2, SignedBy(0), SignedBy(1)),
+		Identities: []*msp.MSPRole{
+			{MSPIdentifier: "Org1MSP", Role: msp.MSPRoleMember},
+			{MSPIdentifier: "Org2MSP", Role: msp.MSPRolePeer},
+		},
+	}, envelope)
+
+	envelope, err = FromString(` and ( "org.example.com.admin", or('Org2MSP.peer', 'Org3MSP.orderer', 'org.example.com.admin'), outof(1, 'Org2MSP.client') ) `)
+	require.NoError(t, err)
+	require.Equal(t, And(SignedBy(0), Or(SignedBy(1), SignedBy(2), SignedBy(0)), NOutOfPolicy(1, SignedBy(3))), envelope.Rule)
+	require.Len(t, envelope.Identities, 4)
+	require.Equal(t, &msp.MSPRole{MSPIdentifier: "org.example.com", Role: msp.MSPRoleAdmin}, envelope.Identities[0])
+	require.Equal(t, "AND('org.example.com.admin', OR('Org2MSP.peer', 'Org3MSP.orderer', 'org.example.com.admin'), AND('Org2MSP.client'))", envelope.String())
+
+	reparsed, err := FromString(envelope.String())
+	require.NoError(t, err)
+	require.Equal(t, envelope, reparsed)
+
+	for policy, expected := range map[string]string{
+		"":                                  `unexpected end of policy ""`,
+		"'Org1MSP.member' 'Org2MSP.member'": `unexpected 'Org2MSP.member' at position 17 in policy "'Org1MSP.member' 'Org2MSP.member'"`,
+		"NOT('Org1MSP.member')":             "unrecognized function NOT at position 0",
+		"AND('Org1MSP.member'":              `unexpected end of policy "AND('Org1MSP.member'"`,
+		"AND()":                             `unexpected ) at position 4 in policy "AND()"`,
+		"OutOf('Org1MSP.member')":           "the first argument of OutOf must be an integer, got 'Org1MSP.member' at position 6",
+		"OutOf(3, 'Org1MSP.member', 'Org2MSP.member')": "invalid threshold 3 in OutOf at position 0, must be between 1 and the number of rules 2",
+		"OutOf(0, 'Org1MSP.member')":                   "invalid threshold 0 in OutOf at position 0, must be between 1 and the number of rules 1",
+		"'Org1MSP'":                                    "invalid principal 'Org1MSP' at position 0, expected MSPID.role",
+		"'Org1MSP.auditor'":                            "invalid role auditor in principal 'Org1MSP.auditor' at position 0",
+		"OR('Org1MSP.member)":                          `unexpected 'Org1MSP.member) at position 3 in policy "OR('Org1MSP.member)"`,
+		"OR('Org1MSP.member'; 'Org2MSP.member')":       `unexpected ; at position 19 in policy "OR('Org1MSP.member'; 'Org2MSP.member')"`,
+	} {
+		_, err := FromString(policy)
+		require.EqualError(t, err, expected, policy)
+	}
+}
+
+func TestNewPolicy(t *testing.T) {
+	mgr, err := msp.NewMSPManager()
+	require.NoError(t, err)
+
+	_, err = NewPolicy(nil, mgr)
+	require.EqualError(t, err, "empty policy element")
+	_, err = NewPolicy(&SignaturePolicyEnvelope{Rule: SignedBy(0)}, nil)
+	require.EqualError(t, err, "nil identity deserializer")
+	_, err = NewPolicy(&SignaturePolicyEnvelope{Rule: SignedBy(1), Identities: []*msp.MSPRole{{MSPIdentifier: "Org1MSP"}}}, mgr)
+	require.EqualError(t, err, "identity index out of range, requested 1, but identities length is 1")
+	_, err = NewPolicy(&SignaturePolicyEnvelope{Rule: NOutOfPolicy(2, SignedBy(0)), Identities: []*msp.MSPRole{{MSPIdentifier: "Org1MSP"}}}, mgr)
+	require.EqualError(t, err, "invalid threshold 2, must be between 1 and the number of rules 1")
+}
+
+func TestEvaluateSignedData(t *testing.T) {
+	org1 := newTestOrg(t, "Org1MSP", "org1.example.com")
+	org2 := newTestOrg(t, "Org2MSP", "org2.example.com")
+	mgr, err := msp.NewMSPManager(org1.msp, org2.msp)
+	require.NoError(t, err)
+
+	client1 := org1.newSigner(t, "user1@org1.example.com", ca.ClientOU)
+	admin1 := org1.newSigner(t, "admin@org1.example.com", ca.AdminOU)
+	peer2 := org2.newSigner(t, "peer0.org2.example.com", ca.PeerOU)
+	client2 := org2.newSigner(t, "user1@org2.example.com", ca.ClientOU)
+
+	data := []byte("config update")
+	policy, err := NewPolicyFromString("OutOf(2, 'Org1MSP.member', 'Org2MSP.peer')", mgr)
+	require.NoError(t, err)
+
+	matches, err := policy.EvaluateSignedData([]*protoutil.SignedData{client2.sign(t, data), peer2.sign(t, data), client1.sign(t, data)})
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	require.Equal(t, "Org2MSP.peer", matches[0].Principal.String())
+	require.Equal(t, 1, matches[0].SignedDataIndex)
+	require.Equal(t, "Org1MSP.member", matches[1].Principal.String())
+	require.Equal(t, 2, matches[1].SignedDataIndex)
+
+	_, err = policy.EvaluateSignedData([]*protoutil.SignedData{client1.sign(t, data), client2.sign(t, data)})
+	require.Equal(t, ErrPolicyNotSatisfied, err)
+
+	// 同一个签名者的多个签名只计入一次。
+	policy, err = NewPolicyFromString("OutOf(2, 'Org1MSP.member', 'Org1MSP.member')", mgr)
+	require.NoError(t, err)
+	require.Len(t, policy.Envelope().Identities, 1)
+	_, err = policy.EvaluateSignedData([]*protoutil.SignedData{client1.sign(t, data), client1.sign(t, data)})
+	require.Equal(t, ErrPolicyNotSatisfied, err)
+	matches, err = policy.EvaluateSignedData([]*protoutil.SignedData{client1.sign(t, data), client1.sign(t, data), admin1.sign(t, data)})
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+
+	// 嵌套的 AND 与 OR。
+	policy, err = NewPolicyFromString("AND('Org1MSP.admin', OR('Org2MSP.peer', 'Org2MSP.admin'))", mgr)
+	require.NoError(t, err)
+	_, err = policy.EvaluateSignedData([]*protoutil.SignedData{admin1.sign(t, data), client2.sign(t, data)})
+	require.Equal(t, ErrPolicyNotSatisfied, err)
+	matches, err = policy.EvaluateSignedData([]*protoutil.SignedData{peer2.sign(t, data), admin1.sign(t, data)})
+	require.NoError(t, err)
+	require.Len(t, matches, 2)
+	require.Equal(t, "Org2MSP.peer", matches[0].Principal.String())
+	require.Equal(t, "Org1MSP.admin", matches[1].Principal.String())
+
+	// 签名无效、high-S 形式的签名以及未知 MSP 的身份都会被忽略。
+	policy, err = NewPolicyFromString("OR('Org1MSP.member')", mgr)
+	require.NoError(t, err)
+
+	wrongData := client1.sign(t, []byte("another message"))
+	wrongData.Data = data
+
+	highS := client1.sign(t, data)
+	r, s, err := utils.UnmarshalECDSASignature(highS.Signature)
+	require.NoError(t, err)
+	highS.Signature, err = utils.MarshalECDSASignature(r, new(big.Int).Sub(elliptic.P256().Params().N, s))
+	require.NoError(t, err)
+
+	stranger := newTestOrg(t, "Org3MSP", "org3.example.com").newSigner(t, "user1@org3.example.com", ca.ClientOU)
+	_, err = policy.EvaluateSignedData([]*protoutil.SignedData{wrongData, highS, stranger.sign(t, data), nil, {Identity: []byte("garbage")}})
+	require.Equal(t, ErrPolicyNotSatisfied, err)
+
+	// 身份的 MSP 标识与证书的签发者不一致时不满足主体。
+	forged := org2.newSigner(t, "user2@org2.example.com", ca.ClientOU)
+	forged.mspID = "Org1MSP"
+	_, err = policy.EvaluateSignedData([]*protoutil.SignedData{forged.sign(t, data)})
+	require.Equal(t, ErrPolicyNotSatisfied, err)
+}
+
+func TestEvaluateEnvelope(t *testing.T) {
+	org1 := newTestOrg(t, "Org1MSP", "org1.example.com")
+	mgr, err := msp.NewMSPManager(org1.msp)
+	require.NoError(t, err)
+
+	client1 := org1.newSigner(t, "user1@org1.example.com", ca.ClientOU)
+	env, err := protoutil.CreateSignedEnvelope(common.HeaderType_ENDORSER_TRANSACTION, "mychannel", client1, &common.SignatureHeader{Nonce: []byte("data")}, 1, 2)
+	require.NoError(t, err)
+	sd, err := protoutil.EnvelopeAsSignedData(env)
+	require.NoError(t, err)
+
+	policy, err := NewPolicyFromString("OR('Org1MSP.member')", mgr)
+	require.NoError(t, err)
+	_, err = policy.EvaluateSignedData(sd)
+	require.NoError(t, err)
+
+	policy, err = NewPolicyFromString("OR('Org1MSP.admin')", mgr)
+	require.NoError(t, err)
+	_, err = policy.EvaluateSignedData(sd)
+	require.Equal(t, ErrPolicyNotSatisfied, err)
+}
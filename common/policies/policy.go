@@ -0,0 +1,123 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+* From: hyperledger/fabric/common/policydsl/policydsl_builder.go
+ */
+
+package policies
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/geistwelt/quarkx/msp"
+)
+
+// SignaturePolicyEnvelope 是一个完整的签名策略，Rule 中的叶子节点通过下标引用
+// Identities 中的主体。
+type SignaturePolicyEnvelope struct {
+	Rule       *SignaturePolicy
+	Identities []*msp.MSPRole
+}
+
+// SignaturePolicy 是签名策略中的一个节点，NOutOf 为 nil 时它是一个叶子节点，要求
+// Identities[SignedBy] 所描述的主体签名。
+type SignaturePolicy struct {
+	SignedBy int
+	NOutOf   *NOutOf
+}
+
+// NOutOf 要求 Rules 中至少有 N 条规则被满足。
+type NOutOf struct {
+	N     int
+	Rules []*SignaturePolicy
+}
+
+// SignedBy 创建一个要求 Identities[index] 签名的叶子节点。
+func SignedBy(index int) *SignaturePolicy {
+	return &SignaturePolicy{SignedBy: index}
+}
+
+// NOutOfPolicy 创建一个要求 rules 中至少有 n 条规则被满足的节点。
+func NOutOfPolicy(n int, rules ...*SignaturePolicy) *SignaturePolicy {
+	return &SignaturePolicy{NOutOf: &NOutOf{N: n, Rules: rules}}
+}
+
+// And 要求 rules 全部被满足。
+func And(rules ...*SignaturePolicy) *SignaturePolicy {
+	return NOutOfPolicy(len(rules), rules...)
+}
+
+// Or 要求 rules 中至少有一条被满足。
+func Or(rules ...*SignaturePolicy) *SignaturePolicy {
+	return NOutOfPolicy(1, rules...)
+}
+
+// String 将策略还原成策略语言的形式，结果可以被 FromString 重新解析。
+func (e *SignaturePolicyEnvelope) String() string {
+	var sb strings.Builder
+	e.write(&sb, e.Rule)
+	return sb.String()
+}
+
+func (e *SignaturePolicyEnvelope) write(sb *strings.Builder, rule *SignaturePolicy) {
+	if rule.NOutOf == nil {
+		if rule.SignedBy < 0 || rule.SignedBy >= len(e.Identities) {
+			fmt.Fprintf(sb, "<invalid principal %d>", rule.SignedBy)
+			return
+		}
+		fmt.Fprintf(sb, "'%s'", e.Identities[rule.SignedBy])
+		return
+	}
+
+	switch {
+	case rule.NOutOf.N == len(rule.NOutOf.Rules):
+		sb.WriteString("AND(")
+	case rule.NOutOf.N == 1:
+		sb.WriteString("OR(")
+	default:
+		fmt.Fprintf(sb, "OutOf(%d, ", rule.NOutOf.N)
+	}
+	for i, sub := range rule.NOutOf.Rules {
+		if i > 0 {
+			sb.WriteString(", ")
+		}
+		e.write(sb, sub)
+	}
+	sb.WriteString(")")
+}
+
+// validate 检查策略中的每个节点：叶子节点引用的主体必须存在，N 必须在 1 到规则数量之间。
+func (e *SignaturePolicyEnvelope) validate() error {
+	if e.Rule == nil {
+		return errors.New("empty policy rule")
+	}
+
+	var check func(rule *SignaturePolicy) error
+	check = func(rule *SignaturePolicy) error {
+		if rule == nil {
+			return errors.New("nil policy rule")
+		}
+		if rule.NOutOf == nil {
+			if rule.SignedBy < 0 || rule.SignedBy >= len(e.Identities) {
+				return fmt.Errorf("identity index out of range, requested %d, but identities length is %d", rule.SignedBy, len(e.Identities))
+			}
+			if e.Identities[rule.SignedBy] == nil {
+				return fmt.Errorf("nil identity at index %d", rule.SignedBy)
+			}
+			return nil
+		}
+		if rule.NOutOf.N < 1 || rule.NOutOf.N > len(rule.NOutOf.Rules) {
+			return fmt.Errorf("invalid threshold %d, must be between 1 and the number of rules %d", rule.NOutOf.N, len(rule.NOutOf.Rules))
+		}
+		for _, sub := range rule.NOutOf.Rules {
+			if err := check(sub); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	return check(e.Rule)
+}
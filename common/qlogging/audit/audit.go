@@ -0,0 +1,351 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package audit
+
+import (
+	"bufio"
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"github.com/geistwelt/quarkx/common/qlogging"
+)
+
+// DefaultSignEvery 是默认的签名间隔，每写入这么多条日志生成一个签名检查点。
+const DefaultSignEvery = 100
+
+// Record 是审计日志中的一行。普通记录保存一条日志，Hash 是
+// SHA-256(Prev || 大端序的 Seq || Entry)，Prev 是上一条记录的 Hash，第一条记录的 Prev
+// 全为零。检查点记录的 Signature 不为空，它是对第 Seq 条记录的 Hash 的签名，由于哈希链
+// 的存在，一个检查点可以证明它之前的所有记录都没有被修改。
+type Record struct {
+	Seq       uint64 `json:"seq"`
+	Prev      string `json:"prev,omitempty"`
+	Entry     string `json:"entry,omitempty"`
+	Hash      string `json:"hash"`
+	Signature string `json:"sig,omitempty"`
+	SKI       string `json:"ski,omitempty"`
+}
+
+// IsCheckpoint 判断记录是否为检查点。
+func (r *Record) IsCheckpoint() bool {
+	return r.Signature != ""
+}
+
+// Sink 是一个防篡改的审计日志输出，它实现了 zapcore.WriteSyncer，可以作为 qlogging
+// 的 Writer 使用。每条日志都带有上一条日志的哈希值，每 signEvery 条日志使用 signer 签名
+// 一次，Sync 与 Close 也会为尚未签名的日志生成检查点。
+type Sink struct {
+	mutex     sync.Mutex
+	w         io.Writer
+	closer    io.Closer
+	signer    crypto.Signer
+	pub       *ecdsa.PublicKey
+	signEvery int
+	rand      io.Reader
+
+	seq      uint64
+	prev     []byte
+	unsigned int
+}
+
+// NewSink 创建一个从头开始的审计日志，signer 必须是 ECDSA 私钥，signEvery 不大于 0 时
+// 使用 DefaultSignEvery。
+func NewSink(w io.Writer, signer crypto.Signer, signEvery int) (*Sink, error) {
+	if signer == nil {
+		return nil, errors.New("nil signer")
+	}
+	pub, ok := signer.Public().(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported signer public key type [%T]", signer.Public())
+	}
+	if signEvery <= 0 {
+		signEvery = DefaultSignEvery
+	}
+
+	return &Sink{
+		w:         w,
+		signer:    signer,
+		pub:       pub,
+		signEvery: signEvery,
+		rand:      entropy.Reader,
+		prev:      make([]byte, sha256.Size),
+	}, nil
+}
+
+// SetEntropy 替换对检查点签名时使用的随机源，传入 nil 时恢复为 entropy.Reader。
+func (s *Sink) SetEntropy(rand io.Reader) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	s.rand = entropy.Or(rand)
+}
+
+// OpenFile 打开或创建审计日志文件并在末尾继续追加。已有的内容会先用 signer 的公钥和
+// signEvery 校验，被篡改或者检查点间隔超过 signEvery 的文件不会被继续写入，否则下一个
+// 检查点会对被篡改的日志签名。
+func OpenFile(path string, signer crypto.Signer, signEvery int) (*Sink, error) {
+	sink, err := NewSink(nil, signer, signEvery)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open audit log %s [%v]", path, err)
+	}
+
+	report, err := Verify(file, sink.pub, sink.signEvery)
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("refusing to append to audit log %s [%w]", path, err)
+	}
+	if report.Entries != 0 {
+		sink.seq = report.Entries
+		sink.prev = report.LastHash
+		sink.unsigned = int(report.Unsigned)
+	}
+	sink.w, sink.closer = file, file
+
+	return sink, nil
+}
+
+// NewLogging 创建一个以 JSON 格式写入 sink 的 qlogging.Logging，通过它的 Logger 方法
+// 获得记录审计事件的日志器。
+func NewLogging(sink *Sink, spec string) (*qlogging.Logging, error) {
+	return qlogging.New(qlogging.Config{Format: "json", LogSpec: spec, Writer: sink})
+}
+
+// Write 将 p 中的每一行作为一条日志写入审计日志。
+func (s *Sink) Write(p []byte) (int, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	for _, line := range bytes.Split(bytes.TrimRight(p, "\n"), []byte("\n")) {
+		if len(line) == 0 {
+			continue
+		}
+		if err := s.writeEntry(string(line)); err != nil {
+			return 0, err
+		}
+		if s.unsigned >= s.signEvery {
+			if err := s.checkpoint(); err != nil {
+				return 0, err
+			}
+		}
+	}
+
+	return len(p), nil
+}
+
+// Sync 为尚未签名的日志生成检查点，并同步底层的输出。
+func (s *Sink) Sync() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	if err := s.checkpoint(); err != nil {
+		return err
+	}
+	if syncer, ok := s.w.(interface{ Sync() error }); ok {
+		return syncer.Sync()
+	}
+
+	return nil
+}
+
+// Close 生成最后一个检查点，并关闭由 OpenFile 打开的文件。
+func (s *Sink) Close() error {
+	err := s.Sync()
+
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+	if s.closer != nil {
+		if cerr := s.closer.Close(); err == nil {
+			err = cerr
+		}
+		s.closer = nil
+	}
+
+	return err
+}
+
+func (s *Sink) writeEntry(entry string) error {
+	seq := s.seq + 1
+	hash := entryHash(s.prev, seq, entry)
+	record := &Record{
+		Seq:   seq,
+		Prev:  hex.EncodeToString(s.prev),
+		Entry: entry,
+		Hash:  hex.EncodeToString(hash),
+	}
+	if err := s.writeRecord(record); err != nil {
+		return err
+	}
+
+	s.seq, s.prev = seq, hash
+	s.unsigned++
+	return nil
+}
+
+func (s *Sink) checkpoint() error {
+	if s.unsigned == 0 {
+		return nil
+	}
+
+	sig, err := s.signer.Sign(s.rand, s.prev, crypto.SHA256)
+	if err != nil {
+		return fmt.Errorf("failed to sign audit checkpoint [%v]", err)
+	}
+	if sig, err = utils.SignatureToLowS(s.pub, sig); err != nil {
+		return err
+	}
+
+	record := &Record{
+		Seq:       s.seq,
+		Hash:      hex.EncodeToString(s.prev),
+		Signature: hex.EncodeToString(sig),
+		SKI:       hex.EncodeToString(utils.SKI(s.pub)),
+	}
+	if err := s.writeRecord(record); err != nil {
+		return err
+	}
+
+	s.unsigned = 0
+	return nil
+}
+
+func (s *Sink) writeRecord(record *Record) error {
+	raw, err := json.Marshal(record)
+	if err != nil {
+		return fmt.Errorf("failed to marshal audit record [%v]", err)
+	}
+	if _, err := s.w.Write(append(raw, '\n')); err != nil {
+		return fmt.Errorf("failed to write audit record [%v]", err)
+	}
+	return nil
+}
+
+func entryHash(prev []byte, seq uint64, entry string) []byte {
+	var buf [8]byte
+	binary.BigEndian.PutUint64(buf[:], seq)
+
+	h := sha256.New()
+	h.Write(prev)
+	h.Write(buf[:])
+	h.Write([]byte(entry))
+	return h.Sum(nil)
+}
+
+// Report 是校验审计日志的结果。
+type Report struct {
+	// Entries 是日志的条数。
+	Entries uint64
+	// Checkpoints 是有效检查点的个数。
+	Checkpoints int
+	// Unsigned 是最后一个检查点之后的日志条数，这些日志可能在末尾被截断而无法察觉，它
+	// 不会超过 signEvery。
+	Unsigned uint64
+	// LastHash 是最后一条日志的哈希值。
+	LastHash []byte
+}
+
+// Verify 逐行校验审计日志：序号必须连续，Prev 必须等于上一条日志的 Hash，Hash 必须与
+// 内容一致，检查点的签名必须能用 pub 验证，并且两个检查点之间的日志不能超过 signEvery
+// 条。哈希链本身不带密钥，删除检查点之后可以重新计算哈希链，因此检查点的间隔也必须校验。
+// 任何一行被删除、调换顺序或修改都会导致校验失败，错误中包含出现问题的行号。signEvery
+// 不大于 0 时使用 DefaultSignEvery。
+func Verify(r io.Reader, pub *ecdsa.PublicKey, signEvery int) (*Report, error) {
+	if pub == nil {
+		return nil, errors.New("nil public key")
+	}
+	if signEvery <= 0 {
+		signEvery = DefaultSignEvery
+	}
+
+	report := &Report{}
+	prev := make([]byte, sha256.Size)
+	var signed uint64
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 16<<20)
+	for line := 1; scanner.Scan(); line++ {
+		record := &Record{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			return nil, fmt.Errorf("line %d: malformed audit record [%v]", line, err)
+		}
+
+		if record.IsCheckpoint() {
+			if record.Seq != report.Entries || record.Hash != hex.EncodeToString(prev) {
+				return nil, fmt.Errorf("line %d: checkpoint for entry %d does not match the hash chain", line, record.Seq)
+			}
+			if err := verifyCheckpoint(pub, prev, record); err != nil {
+				return nil, fmt.Errorf("line %d: %w", line, err)
+			}
+			signed = record.Seq
+			report.Checkpoints++
+			continue
+		}
+
+		if record.Seq != report.Entries+1 {
+			return nil, fmt.Errorf("line %d: entry %d is out of sequence, expected entry %d, entries have been deleted or reordered", line, record.Seq, report.Entries+1)
+		}
+		if record.Seq-signed > uint64(signEvery) {
+			return nil, fmt.Errorf("line %d: entry %d is more than %d entries after the last checkpoint, checkpoints have been removed", line, record.Seq, signEvery)
+		}
+		if record.Prev != hex.EncodeToString(prev) {
+			return nil, fmt.Errorf("line %d: entry %d does not link to the previous entry", line, record.Seq)
+		}
+		hash := entryHash(prev, record.Seq, record.Entry)
+		if record.Hash != hex.EncodeToString(hash) {
+			return nil, fmt.Errorf("line %d: entry %d has been modified", line, record.Seq)
+		}
+
+		prev = hash
+		report.Entries = record.Seq
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read audit log [%v]", err)
+	}
+
+	report.Unsigned = report.Entries - signed
+	report.LastHash = prev
+
+	return report, nil
+}
+
+func verifyCheckpoint(pub *ecdsa.PublicKey, hash []byte, record *Record) error {
+	if record.SKI != "" && record.SKI != hex.EncodeToString(utils.SKI(pub)) {
+		return fmt.Errorf("checkpoint for entry %d is signed by another key [%s]", record.Seq, record.SKI)
+	}
+
+	sig, err := hex.DecodeString(record.Signature)
+	if err != nil {
+		return fmt.Errorf("malformed checkpoint signature [%v]", err)
+	}
+	r, s, err := utils.UnmarshalECDSASignature(sig)
+	if err != nil {
+		return fmt.Errorf("malformed checkpoint signature [%w]", err)
+	}
+	if err := utils.CheckLowS(pub, s); err != nil {
+		return fmt.Errorf("invalid checkpoint signature for entry %d [%w]", record.Seq, err)
+	}
+	if !ecdsa.Verify(pub, hash, r, s) {
+		return fmt.Errorf("invalid checkpoint signature for entry %d", record.Seq)
+	}
+
+	return nil
+}
@@ -0,0 +1,226 @@
+package audit
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"github.com/stretchr/testify/require"
+)
+
+func newKey(t *testing.T) *ecdsa.PrivateKey {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return sk
+}
+
+func writeLog(t *testing.T, sk *ecdsa.PrivateKey, entries, signEvery int) []string {
+	buf := &bytes.Buffer{}
+	sink, err := NewSink(buf, sk, signEvery)
+	require.NoError(t, err)
+
+	logging, err := NewLogging(sink, "info")
+	require.NoError(t, err)
+	logger := logging.Logger("audit")
+	for i := 0; i < entries; i++ {
+		logger.Infow("admin action", "action", "update-config", "index", i)
+	}
+	logger.Debugf("debug entries are not recorded")
+	require.NoError(t, logging.Sync())
+
+	return strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n")
+}
+
+// verifyLines 校验由 writeLog(t, sk, entries, 4) 生成的日志。
+func verifyLines(sk *ecdsa.PrivateKey, lines []string) (*Report, error) {
+	return Verify(strings.NewReader(strings.Join(lines, "\n")+"\n"), &sk.PublicKey, 4)
+}
+
+func TestSink(t *testing.T) {
+	sk := newKey(t)
+	lines := writeLog(t, sk, 10, 4)
+	// 10 条日志，在第 4、8 条之后以及 Sync 时各有一个检查点。
+	require.Len(t, lines, 13)
+
+	record := &Record{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), record))
+	require.Equal(t, uint64(1), record.Seq)
+	require.Contains(t, record.Entry, `"msg":"admin action"`)
+	require.Contains(t, record.Entry, `"index":0`)
+	require.NoError(t, json.Unmarshal([]byte(lines[4]), record))
+	require.True(t, record.IsCheckpoint())
+	require.Equal(t, uint64(4), record.Seq)
+
+	report, err := verifyLines(sk, lines)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), report.Entries)
+	require.Equal(t, 3, report.Checkpoints)
+	require.Zero(t, report.Unsigned)
+
+	// 末尾没有检查点的日志会被报告出来。
+	report, err = verifyLines(sk, lines[:len(lines)-1])
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), report.Unsigned)
+}
+
+func TestTamperDetection(t *testing.T) {
+	sk := newKey(t)
+	lines := writeLog(t, sk, 10, 4)
+
+	modify := func(f func(lines []string) []string) []string {
+		return f(append([]string{}, lines...))
+	}
+
+	_, err := verifyLines(sk, modify(func(l []string) []string { return append(l[:2], l[3:]...) }))
+	require.EqualError(t, err, "line 3: entry 4 is out of sequence, expected entry 3, entries have been deleted or reordered")
+
+	_, err = verifyLines(sk, modify(func(l []string) []string { l[1], l[2] = l[2], l[1]; return l }))
+	require.EqualError(t, err, "line 2: entry 3 is out of sequence, expected entry 2, entries have been deleted or reordered")
+
+	_, err = verifyLines(sk, modify(func(l []string) []string {
+		l[2] = strings.Replace(l[2], "update-config", "read-config", 1)
+		return l
+	}))
+	require.EqualError(t, err, "line 3: entry 3 has been modified")
+
+	// 修改一条日志之后重新计算整条哈希链，检查点的签名仍然会失败。
+	forged := modify(func(l []string) []string {
+		prev := make([]byte, 32)
+		for i, line := range l {
+			record := &Record{}
+			require.NoError(t, json.Unmarshal([]byte(line), record))
+			if record.IsCheckpoint() {
+				record.Hash = fmt.Sprintf("%x", prev)
+			} else {
+				if record.Seq == 2 {
+					record.Entry = strings.Replace(record.Entry, "update-config", "read-config", 1)
+				}
+				record.Prev = fmt.Sprintf("%x", prev)
+				prev = entryHash(prev, record.Seq, record.Entry)
+				record.Hash = fmt.Sprintf("%x", prev)
+			}
+			raw, err := json.Marshal(record)
+			require.NoError(t, err)
+			l[i] = string(raw)
+		}
+		return l
+	})
+	_, err = verifyLines(sk, forged)
+	require.EqualError(t, err, "line 5: invalid checkpoint signature for entry 4")
+
+	// 检查点的签名被替换成 high-S 形式或者无法解析，错误包装了对应的哨兵错误。
+	resign := func(f func(r, s *big.Int) []byte) []string {
+		return modify(func(l []string) []string {
+			record := &Record{}
+			require.NoError(t, json.Unmarshal([]byte(l[4]), record))
+			raw, err := hex.DecodeString(record.Signature)
+			require.NoError(t, err)
+			r, s, err := utils.UnmarshalECDSASignature(raw)
+			require.NoError(t, err)
+			record.Signature = hex.EncodeToString(f(r, s))
+			encoded, err := json.Marshal(record)
+			require.NoError(t, err)
+			l[4] = string(encoded)
+			return l
+		})
+	}
+	_, err = verifyLines(sk, resign(func(r, s *big.Int) []byte {
+		raw, err := utils.MarshalECDSASignature(r, new(big.Int).Sub(sk.Params().N, s))
+		require.NoError(t, err)
+		return raw
+	}))
+	require.ErrorIs(t, err, utils.ErrHighS)
+	require.Contains(t, err.Error(), "line 5: invalid checkpoint signature for entry 4 [invalid S, must be smaller than half the order")
+	_, err = verifyLines(sk, resign(func(r, s *big.Int) []byte {
+		raw, err := utils.MarshalECDSASignature(r, s)
+		require.NoError(t, err)
+		return append(raw, 0)
+	}))
+	require.ErrorIs(t, err, utils.ErrMalformedSignature)
+
+	// 其他密钥签名的日志。
+	_, err = verifyLines(newKey(t), lines)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "line 5: checkpoint for entry 4 is signed by another key")
+
+	// 删除检查点之后修改日志并重新计算哈希链，检查点的间隔超过了 signEvery。
+	stripped := modify(func(l []string) []string {
+		var kept []string
+		prev := make([]byte, 32)
+		for _, line := range l {
+			record := &Record{}
+			require.NoError(t, json.Unmarshal([]byte(line), record))
+			if record.IsCheckpoint() {
+				continue
+			}
+			if record.Seq == 2 {
+				record.Entry = strings.Replace(record.Entry, "update-config", "read-config", 1)
+			}
+			record.Prev = fmt.Sprintf("%x", prev)
+			prev = entryHash(prev, record.Seq, record.Entry)
+			record.Hash = fmt.Sprintf("%x", prev)
+			raw, err := json.Marshal(record)
+			require.NoError(t, err)
+			kept = append(kept, string(raw))
+		}
+		return kept
+	})
+	_, err = verifyLines(sk, stripped)
+	require.EqualError(t, err, "line 5: entry 5 is more than 4 entries after the last checkpoint, checkpoints have been removed")
+
+	_, err = verifyLines(sk, modify(func(l []string) []string { l[0] = "not json"; return l }))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "line 1: malformed audit record")
+}
+
+func TestOpenFile(t *testing.T) {
+	sk := newKey(t)
+	path := filepath.Join(t.TempDir(), "audit.log")
+
+	sink, err := OpenFile(path, sk, 3)
+	require.NoError(t, err)
+	_, err = sink.Write([]byte("first\nsecond\n"))
+	require.NoError(t, err)
+	require.NoError(t, sink.Close())
+
+	sink, err = OpenFile(path, sk, 3)
+	require.NoError(t, err)
+	_, err = sink.Write([]byte("third\n"))
+	require.NoError(t, err)
+	require.NoError(t, sink.Close())
+
+	raw, err := os.ReadFile(path)
+	require.NoError(t, err)
+	report, err := Verify(bytes.NewReader(raw), &sk.PublicKey, 3)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), report.Entries)
+	require.Equal(t, 2, report.Checkpoints)
+
+	require.NoError(t, os.WriteFile(path, bytes.Replace(raw, []byte("second"), []byte("edited"), 1), 0o600))
+	_, err = OpenFile(path, sk, 3)
+	require.EqualError(t, err, fmt.Sprintf("refusing to append to audit log %s [line 2: entry 2 has been modified]", path))
+
+	// 检查点被删除的文件不会被继续写入，否则下一个检查点会对伪造的日志签名。
+	var kept [][]byte
+	for _, line := range bytes.Split(bytes.TrimSuffix(raw, []byte("\n")), []byte("\n")) {
+		if !bytes.Contains(line, []byte(`"sig"`)) {
+			kept = append(kept, line)
+		}
+	}
+	require.NoError(t, os.WriteFile(path, append(bytes.Join(kept, []byte("\n")), '\n'), 0o600))
+	_, err = OpenFile(path, sk, 1)
+	require.EqualError(t, err, fmt.Sprintf("refusing to append to audit log %s [line 2: entry 2 is more than 1 entries after the last checkpoint, checkpoints have been removed]", path))
+
+	_, err = NewSink(&bytes.Buffer{}, nil, 1)
+	require.EqualError(t, err, "nil signer")
+}
@@ -1,14 +1,20 @@
 module github.com/geistwelt/quarkx
 
-go 1.20
+go 1.22.0
 
 require (
+	filippo.io/age v1.0.0
+	github.com/cloudflare/circl v1.6.1
+	github.com/decred/dcrd/dcrec/secp256k1/v4 v4.4.0
 	github.com/go-kit/kit v0.12.0
 	github.com/prometheus/client_golang v1.11.0
 	github.com/stretchr/testify v1.8.4
 	github.com/sykesm/zap-logfmt v0.0.2
 	go.uber.org/zap v1.19.1
+	golang.org/x/crypto v0.17.0
 	google.golang.org/grpc v1.40.0
+	google.golang.org/protobuf v1.27.1
+	gopkg.in/yaml.v3 v3.0.1
 )
 
 require (
@@ -25,7 +31,8 @@ require (
 	github.com/prometheus/procfs v0.7.3 // indirect
 	go.uber.org/atomic v1.9.0 // indirect
 	go.uber.org/multierr v1.7.0 // indirect
-	golang.org/x/sys v0.0.0-20210917161153-d61c044b1678 // indirect
-	google.golang.org/protobuf v1.27.1 // indirect
-	gopkg.in/yaml.v3 v3.0.1 // indirect
+	golang.org/x/net v0.10.0 // indirect
+	golang.org/x/sys v0.15.0 // indirect
+	golang.org/x/text v0.14.0 // indirect
+	google.golang.org/genproto v0.0.0-20210917145530-b395a37504d4 // indirect
 )
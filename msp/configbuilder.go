@@ -0,0 +1,281 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+* From: hyperledger/fabric/msp/configbuilder.go
+ */
+
+package msp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/geistwelt/quarkx/bccsp/keyaudit"
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	cacerts              = "cacerts"
+	admincerts           = "admincerts"
+	signcerts            = "signcerts"
+	keystore             = "keystore"
+	intermediatecerts    = "intermediatecerts"
+	crlsfolder           = "crls"
+	configfilename       = "config.yaml"
+	tlscacerts           = "tlscacerts"
+	tlsintermediatecerts = "tlsintermediatecerts"
+)
+
+// OrganizationalUnitIdentifiersConfiguration 是 config.yaml 中描述一个 OU 的配置，
+// Certificate 是签发该 OU 身份的 CA 证书相对于 MSP 目录的路径。
+type OrganizationalUnitIdentifiersConfiguration struct {
+	Certificate                  string `yaml:"Certificate,omitempty"`
+	OrganizationalUnitIdentifier string `yaml:"OrganizationalUnitIdentifier,omitempty"`
+}
+
+// NodeOUs 是 config.yaml 中 NodeOUs 部分的配置，开启后 MSP 根据证书的 OU 区分
+// client、peer、admin 与 orderer 身份。
+type NodeOUs struct {
+	Enable              bool                                        `yaml:"Enable,omitempty"`
+	ClientOUIdentifier  *OrganizationalUnitIdentifiersConfiguration `yaml:"ClientOUIdentifier,omitempty"`
+	PeerOUIdentifier    *OrganizationalUnitIdentifiersConfiguration `yaml:"PeerOUIdentifier,omitempty"`
+	AdminOUIdentifier   *OrganizationalUnitIdentifiersConfiguration `yaml:"AdminOUIdentifier,omitempty"`
+	OrdererOUIdentifier *OrganizationalUnitIdentifiersConfiguration `yaml:"OrdererOUIdentifier,omitempty"`
+}
+
+// Configuration 是 MSP 目录下 config.yaml 文件的内容。
+type Configuration struct {
+	NodeOUs *NodeOUs `yaml:"NodeOUs,omitempty"`
+}
+
+// Config 是创建 MSP 所需的全部配置，所有证书与 CRL 都是 PEM 格式的。
+type Config struct {
+	Name                 string
+	RootCerts            [][]byte
+	IntermediateCerts    [][]byte
+	Admins               [][]byte
+	RevocationList       [][]byte
+	SigningIdentity      *SigningIdentityInfo
+	NodeOUs              *NodeOUsConfig
+	TLSRootCerts         [][]byte
+	TLSIntermediateCerts [][]byte
+
+	// RevocationChecker 用来检查身份是否被吊销，RevocationList 中的 CRL 会被加入其中。
+	// 为 nil 时只使用 RevocationList 中的 CRL。
+	RevocationChecker *RevocationChecker
+
+	// KeyAuditor 记录签名身份的每一次私钥操作，组件名称为 MSP 的名称。为 nil 时只写入
+	// bccsp.keyaudit 日志器。
+	KeyAuditor *keyaudit.Auditor
+
+	// Entropy 是签名身份签名时使用的随机源，为 nil 时使用 entropy.Reader。
+	Entropy io.Reader
+}
+
+// SigningIdentityInfo 是节点本地的签名身份，PublicSigner 是 PEM 格式的证书。
+type SigningIdentityInfo struct {
+	PublicSigner []byte
+	Signer       crypto.Signer
+}
+
+// NodeOUsConfig 是解析 config.yaml 之后得到的 NodeOUs 配置。
+type NodeOUsConfig struct {
+	Enable              bool
+	ClientOUIdentifier  *OUIdentifierConfig
+	PeerOUIdentifier    *OUIdentifierConfig
+	AdminOUIdentifier   *OUIdentifierConfig
+	OrdererOUIdentifier *OUIdentifierConfig
+}
+
+// OUIdentifierConfig 描述一个 OU，Certificate 为空时任意受信任的 CA 签发的该 OU 都被接受。
+type OUIdentifierConfig struct {
+	Certificate                  []byte
+	OrganizationalUnitIdentifier string
+}
+
+// GetLocalMSPConfig 读取节点本地 MSP 目录的配置，其中包括签名证书与私钥。
+func GetLocalMSPConfig(dir string, ID string) (*Config, error) {
+	conf, err := GetVerifyingMSPConfig(dir, ID)
+	if err != nil {
+		return nil, err
+	}
+
+	signCerts, err := getPemMaterialFromDir(filepath.Join(dir, signcerts))
+	if err != nil || len(signCerts) == 0 {
+		return nil, fmt.Errorf("could not load a valid signer certificate from directory %s [%v]", filepath.Join(dir, signcerts), err)
+	}
+
+	block, _ := pem.Decode(signCerts[0])
+	cert, err := parseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	pub, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported signer public key type [%T]", cert.PublicKey)
+	}
+
+	signer, err := getSignerFromKeystore(filepath.Join(dir, keystore), pub)
+	if err != nil {
+		return nil, err
+	}
+
+	conf.SigningIdentity = &SigningIdentityInfo{PublicSigner: signCerts[0], Signer: signer}
+
+	return conf, nil
+}
+
+// GetVerifyingMSPConfig 读取只用于验证其他身份的 MSP 目录的配置，其中不包含签名身份。
+func GetVerifyingMSPConfig(dir string, ID string) (*Config, error) {
+	caCerts, err := getPemMaterialFromDir(filepath.Join(dir, cacerts))
+	if err != nil {
+		return nil, fmt.Errorf("could not load a valid ca certificate from directory %s [%v]", filepath.Join(dir, cacerts), err)
+	}
+	if len(caCerts) == 0 {
+		return nil, fmt.Errorf("could not load a valid ca certificate from directory %s", filepath.Join(dir, cacerts))
+	}
+
+	conf := &Config{Name: ID, RootCerts: caCerts}
+
+	if conf.Admins, err = getOptionalPemMaterialFromDir(filepath.Join(dir, admincerts)); err != nil {
+		return nil, err
+	}
+	if conf.IntermediateCerts, err = getOptionalPemMaterialFromDir(filepath.Join(dir, intermediatecerts)); err != nil {
+		return nil, err
+	}
+	if conf.RevocationList, err = getOptionalPemMaterialFromDir(filepath.Join(dir, crlsfolder)); err != nil {
+		return nil, err
+	}
+	if conf.TLSRootCerts, err = getOptionalPemMaterialFromDir(filepath.Join(dir, tlscacerts)); err != nil {
+		return nil, err
+	}
+	if conf.TLSIntermediateCerts, err = getOptionalPemMaterialFromDir(filepath.Join(dir, tlsintermediatecerts)); err != nil {
+		return nil, err
+	}
+
+	configFile := filepath.Join(dir, configfilename)
+	raw, err := os.ReadFile(configFile)
+	if err != nil && !os.IsNotExist(err) {
+		return nil, fmt.Errorf("failed to read config file %s [%v]", configFile, err)
+	}
+	if err == nil {
+		configuration := &Configuration{}
+		if err := yaml.Unmarshal(raw, configuration); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal config file %s [%v]", configFile, err)
+		}
+
+		if configuration.NodeOUs != nil && configuration.NodeOUs.Enable {
+			conf.NodeOUs = &NodeOUsConfig{Enable: true}
+			identifiers := []struct {
+				from *OrganizationalUnitIdentifiersConfiguration
+				to   **OUIdentifierConfig
+			}{
+				{configuration.NodeOUs.ClientOUIdentifier, &conf.NodeOUs.ClientOUIdentifier},
+				{configuration.NodeOUs.PeerOUIdentifier, &conf.NodeOUs.PeerOUIdentifier},
+				{configuration.NodeOUs.AdminOUIdentifier, &conf.NodeOUs.AdminOUIdentifier},
+				{configuration.NodeOUs.OrdererOUIdentifier, &conf.NodeOUs.OrdererOUIdentifier},
+			}
+			for _, identifier := range identifiers {
+				if identifier.from == nil {
+					continue
+				}
+				ou := &OUIdentifierConfig{OrganizationalUnitIdentifier: identifier.from.OrganizationalUnitIdentifier}
+				if identifier.from.Certificate != "" {
+					if ou.Certificate, err = readPemFile(filepath.Join(dir, identifier.from.Certificate)); err != nil {
+						return nil, err
+					}
+				}
+				*identifier.to = ou
+			}
+		}
+	}
+
+	return conf, nil
+}
+
+func getSignerFromKeystore(dir string, pub *ecdsa.PublicKey) (crypto.Signer, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read keystore directory %s [%v]", dir, err)
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			continue
+		}
+		sk, err := utils.PEMToPrivateKey(raw)
+		if err != nil {
+			continue
+		}
+		if sk.PublicKey.Equal(pub) {
+			return utils.NewECDSAPrivateKey(sk)
+		}
+	}
+
+	return nil, fmt.Errorf("could not find the private key matching the signer certificate in keystore %s", dir)
+}
+
+func readPemFile(file string) ([]byte, error) {
+	raw, err := os.ReadFile(file)
+	if err != nil {
+		return nil, fmt.Errorf("could not read file %s [%v]", file, err)
+	}
+
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, fmt.Errorf("no pem content for file %s", file)
+	}
+
+	return raw, nil
+}
+
+func getOptionalPemMaterialFromDir(dir string) ([][]byte, error) {
+	content, err := getPemMaterialFromDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+
+	return content, err
+}
+
+// getPemMaterialFromDir 读取目录下所有 PEM 格式的文件，每个文件中的每个 PEM 块
+// 都作为单独的一项返回。
+func getPemMaterialFromDir(dir string) ([][]byte, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read directory %s: %w", dir, err)
+	}
+
+	var content [][]byte
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+		raw, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("could not read file %s [%v]", filepath.Join(dir, entry.Name()), err)
+		}
+
+		for {
+			var block *pem.Block
+			block, raw = pem.Decode(raw)
+			if block == nil {
+				break
+			}
+			content = append(content, pem.EncodeToMemory(block))
+		}
+	}
+
+	return content, nil
+}
@@ -0,0 +1,155 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+* From: hyperledger/fabric/msp/identities.go
+ */
+
+package msp
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"google.golang.org/protobuf/proto"
+)
+
+type identity struct {
+	id   *IdentityIdentifier
+	cert *x509.Certificate
+	pk   *ecdsa.PublicKey
+	msp  *x509msp
+
+	// 身份的校验结果与证书链会被缓存下来，避免重复校验证书链。
+	validationMutex sync.Mutex
+	validated       bool
+	validationErr   error
+	chain           []*x509.Certificate
+}
+
+func newIdentity(cert *x509.Certificate, msp *x509msp) (*identity, error) {
+	pk, ok := cert.PublicKey.(*ecdsa.PublicKey)
+	if !ok {
+		return nil, fmt.Errorf("unsupported public key type [%T]", cert.PublicKey)
+	}
+
+	hash := sha256.Sum256(cert.Raw)
+
+	return &identity{
+		id:   &IdentityIdentifier{Mspid: msp.name, Id: hex.EncodeToString(hash[:])},
+		cert: cert,
+		pk:   pk,
+		msp:  msp,
+	}, nil
+}
+
+func (id *identity) ExpiresAt() time.Time {
+	return id.cert.NotAfter
+}
+
+func (id *identity) GetIdentifier() *IdentityIdentifier {
+	return id.id
+}
+
+func (id *identity) GetMSPIdentifier() string {
+	return id.id.Mspid
+}
+
+func (id *identity) Validate() error {
+	return id.msp.Validate(id)
+}
+
+func (id *identity) GetOrganizationalUnits() []*OUIdentifier {
+	if id.cert == nil {
+		return nil
+	}
+
+	var certifiersIdentifier []byte
+	if chain, err := id.msp.getCertificationChain(id.cert); err == nil {
+		certifiersIdentifier = chainIdentifier(chain[1:])
+	} else {
+		mspLogger.Errorf("failed getting certification chain for [%v]: [%s]", id.id, err)
+	}
+
+	var res []*OUIdentifier
+	for _, unit := range id.cert.Subject.OrganizationalUnit {
+		res = append(res, &OUIdentifier{
+			CertifiersIdentifier:         certifiersIdentifier,
+			OrganizationalUnitIdentifier: unit,
+		})
+	}
+
+	return res
+}
+
+// Verify 用 SHA-256 计算 msg 的摘要，然后验证签名。与 bccsp 的 ECDSA 验签一样，
+// high-S 形式的签名会被拒绝，以防止签名延展性攻击。
+func (id *identity) Verify(msg []byte, sig []byte) error {
+	r, s, err := utils.UnmarshalECDSASignature(sig)
+	if err != nil {
+		return fmt.Errorf("failed unmarshalling signature [%v]", err)
+	}
+
+	if err := utils.CheckLowS(id.pk, s); err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256(msg)
+	if !ecdsa.Verify(id.pk, digest[:], r, s) {
+		return errors.New("the signature is invalid")
+	}
+
+	return nil
+}
+
+func (id *identity) Serialize() ([]byte, error) {
+	pb := &pem.Block{Bytes: id.cert.Raw, Type: "CERTIFICATE"}
+	pemBytes := pem.EncodeToMemory(pb)
+
+	sid := &SerializedIdentity{Mspid: id.id.Mspid, IdBytes: pemBytes}
+	raw, err := proto.Marshal(sid)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal a SerializedIdentity structure for identity %v [%v]", id.id, err)
+	}
+
+	return raw, nil
+}
+
+func (id *identity) Certificate() *x509.Certificate {
+	return id.cert
+}
+
+func (id *identity) SatisfiesPrincipal(principal *MSPRole) error {
+	return id.msp.SatisfiesPrincipal(id, principal)
+}
+
+type signingidentity struct {
+	*identity
+	signer crypto.Signer
+	rand   io.Reader
+}
+
+// Sign 用 SHA-256 计算 msg 的摘要后签名，返回 low-S 形式的签名。
+func (id *signingidentity) Sign(msg []byte) ([]byte, error) {
+	digest := sha256.Sum256(msg)
+
+	sig, err := id.signer.Sign(id.rand, digest[:], nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign [%v]", err)
+	}
+
+	return utils.SignatureToLowS(id.pk, sig)
+}
+
+func (id *signingidentity) GetPublicVersion() Identity {
+	return id.identity
+}
@@ -0,0 +1,154 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.27.1
+// 	protoc        (unknown)
+// source: identities.proto
+
+package msp
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// SerializedIdentity 是身份在网络上传输时的格式，由身份所属 MSP 的标识和 PEM 格式的证书组成。
+type SerializedIdentity struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Mspid   string `protobuf:"bytes,1,opt,name=mspid,proto3" json:"mspid,omitempty"`
+	IdBytes []byte `protobuf:"bytes,2,opt,name=id_bytes,json=idBytes,proto3" json:"id_bytes,omitempty"`
+}
+
+func (x *SerializedIdentity) Reset() {
+	*x = SerializedIdentity{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_identities_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *SerializedIdentity) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*SerializedIdentity) ProtoMessage() {}
+
+func (x *SerializedIdentity) ProtoReflect() protoreflect.Message {
+	mi := &file_identities_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use SerializedIdentity.ProtoReflect.Descriptor instead.
+func (*SerializedIdentity) Descriptor() ([]byte, []int) {
+	return file_identities_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *SerializedIdentity) GetMspid() string {
+	if x != nil {
+		return x.Mspid
+	}
+	return ""
+}
+
+func (x *SerializedIdentity) GetIdBytes() []byte {
+	if x != nil {
+		return x.IdBytes
+	}
+	return nil
+}
+
+var File_identities_proto protoreflect.FileDescriptor
+
+var file_identities_proto_rawDesc = []byte{
+	0x0a, 0x10, 0x69, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x69, 0x65, 0x73, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x12, 0x03, 0x6d, 0x73, 0x70, 0x22, 0x45, 0x0a, 0x12, 0x53, 0x65, 0x72, 0x69, 0x61,
+	0x6c, 0x69, 0x7a, 0x65, 0x64, 0x49, 0x64, 0x65, 0x6e, 0x74, 0x69, 0x74, 0x79, 0x12, 0x14, 0x0a,
+	0x05, 0x6d, 0x73, 0x70, 0x69, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05, 0x6d, 0x73,
+	0x70, 0x69, 0x64, 0x12, 0x19, 0x0a, 0x08, 0x69, 0x64, 0x5f, 0x62, 0x79, 0x74, 0x65, 0x73, 0x18,
+	0x02, 0x20, 0x01, 0x28, 0x0c, 0x52, 0x07, 0x69, 0x64, 0x42, 0x79, 0x74, 0x65, 0x73, 0x42, 0x21,
+	0x5a, 0x1f, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x65, 0x69,
+	0x73, 0x74, 0x77, 0x65, 0x6c, 0x74, 0x2f, 0x71, 0x75, 0x61, 0x72, 0x6b, 0x78, 0x2f, 0x6d, 0x73,
+	0x70, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_identities_proto_rawDescOnce sync.Once
+	file_identities_proto_rawDescData = file_identities_proto_rawDesc
+)
+
+func file_identities_proto_rawDescGZIP() []byte {
+	file_identities_proto_rawDescOnce.Do(func() {
+		file_identities_proto_rawDescData = protoimpl.X.CompressGZIP(file_identities_proto_rawDescData)
+	})
+	return file_identities_proto_rawDescData
+}
+
+var file_identities_proto_msgTypes = make([]protoimpl.MessageInfo, 1)
+var file_identities_proto_goTypes = []interface{}{
+	(*SerializedIdentity)(nil), // 0: msp.SerializedIdentity
+}
+var file_identities_proto_depIdxs = []int32{
+	0, // [0:0] is the sub-list for method output_type
+	0, // [0:0] is the sub-list for method input_type
+	0, // [0:0] is the sub-list for extension type_name
+	0, // [0:0] is the sub-list for extension extendee
+	0, // [0:0] is the sub-list for field type_name
+}
+
+func init() { file_identities_proto_init() }
+func file_identities_proto_init() {
+	if File_identities_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_identities_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*SerializedIdentity); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_identities_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   1,
+			NumExtensions: 0,
+			NumServices:   0,
+		},
+		GoTypes:           file_identities_proto_goTypes,
+		DependencyIndexes: file_identities_proto_depIdxs,
+		MessageInfos:      file_identities_proto_msgTypes,
+	}.Build()
+	File_identities_proto = out.File
+	file_identities_proto_rawDesc = nil
+	file_identities_proto_goTypes = nil
+	file_identities_proto_depIdxs = nil
+}
@@ -0,0 +1,156 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+* From: hyperledger/fabric/msp/msp.go
+ */
+
+package msp
+
+import (
+	"crypto/x509"
+	"fmt"
+	"time"
+)
+
+// MSP 是成员服务提供者，它负责把序列化的身份还原成 Identity，并判断身份是否属于
+// 该 MSP 所代表的组织。
+type MSP interface {
+	// GetIdentifier 返回 MSP 的标识。
+	GetIdentifier() string
+
+	// GetDefaultSigningIdentity 返回节点本地用于签名的身份。
+	GetDefaultSigningIdentity() (SigningIdentity, error)
+
+	// DeserializeIdentity 将 Identity.Serialize 的结果还原成 Identity。
+	DeserializeIdentity(serializedIdentity []byte) (Identity, error)
+
+	// Validate 检查身份的证书链、吊销状态与 NodeOU 是否满足该 MSP 的要求。
+	Validate(id Identity) error
+
+	// IsAdmin 判断身份是否为该 MSP 的管理员。
+	IsAdmin(id Identity) bool
+
+	// SatisfiesPrincipal 检查身份是否满足 principal 所描述的角色，不满足时返回错误。
+	SatisfiesPrincipal(id Identity, principal *MSPRole) error
+
+	// GetRootCerts 返回该 MSP 信任的根证书。
+	GetRootCerts() []*x509.Certificate
+
+	// GetIntermediateCerts 返回该 MSP 信任的中间证书。
+	GetIntermediateCerts() []*x509.Certificate
+
+	// GetTLSRootCerts 返回该组织的 TLS 根证书。
+	GetTLSRootCerts() []*x509.Certificate
+
+	// GetTLSIntermediateCerts 返回该组织的 TLS 中间证书。
+	GetTLSIntermediateCerts() []*x509.Certificate
+}
+
+// Identity 表示一个由 X.509 证书代表的身份。
+type Identity interface {
+	// ExpiresAt 返回身份证书的过期时间。
+	ExpiresAt() time.Time
+
+	// GetIdentifier 返回身份的唯一标识。
+	GetIdentifier() *IdentityIdentifier
+
+	// GetMSPIdentifier 返回身份所属的 MSP 的标识。
+	GetMSPIdentifier() string
+
+	// Validate 通过身份所属的 MSP 校验身份。
+	Validate() error
+
+	// GetOrganizationalUnits 返回身份证书中的 OU，以及签发该证书的证书链的标识。
+	GetOrganizationalUnits() []*OUIdentifier
+
+	// Verify 验证 sig 是否为该身份对 msg 的签名，签名必须是 low-S 形式的。
+	Verify(msg []byte, sig []byte) error
+
+	// Serialize 将身份序列化成 SerializedIdentity。
+	Serialize() ([]byte, error)
+
+	// Certificate 返回身份的证书。
+	Certificate() *x509.Certificate
+
+	// SatisfiesPrincipal 通过身份所属的 MSP 检查身份是否满足 principal。
+	SatisfiesPrincipal(principal *MSPRole) error
+}
+
+// IdentityDeserializer 将序列化的身份还原成 Identity。
+type IdentityDeserializer interface {
+	DeserializeIdentity(serializedIdentity []byte) (Identity, error)
+}
+
+// MSPManager 管理多个 MSP，根据 SerializedIdentity 中的 MSP 标识把身份交给对应的 MSP
+// 还原。
+type MSPManager interface {
+	IdentityDeserializer
+
+	// GetMSPs 返回所有 MSP，键是 MSP 的标识。
+	GetMSPs() map[string]MSP
+}
+
+// SigningIdentity 是持有私钥、可以签名的身份。
+type SigningIdentity interface {
+	Identity
+
+	// Sign 对 msg 进行签名，返回 low-S 形式的签名。
+	Sign(msg []byte) ([]byte, error)
+
+	// GetPublicVersion 返回不含私钥的身份。
+	GetPublicVersion() Identity
+}
+
+// IdentityIdentifier 唯一标识一个身份，Id 是身份证书的 SHA-256 哈希值。
+type IdentityIdentifier struct {
+	Mspid string
+	Id    string
+}
+
+// OUIdentifier 表示身份证书中的一个 OU，CertifiersIdentifier 是签发该身份的证书链
+// 的 SHA-256 哈希值，用来区分不同 CA 签发的同名 OU。
+type OUIdentifier struct {
+	CertifiersIdentifier         []byte
+	OrganizationalUnitIdentifier string
+}
+
+// MSPRoleType 是身份在组织中的角色。
+type MSPRoleType int
+
+const (
+	// MSPRoleMember 表示组织中任意一个有效的身份。
+	MSPRoleMember MSPRoleType = iota
+	// MSPRoleAdmin 表示组织的管理员。
+	MSPRoleAdmin
+	// MSPRoleClient 表示带有 client NodeOU 的身份。
+	MSPRoleClient
+	// MSPRolePeer 表示带有 peer NodeOU 的身份。
+	MSPRolePeer
+	// MSPRoleOrderer 表示带有 orderer NodeOU 的身份。
+	MSPRoleOrderer
+)
+
+var mspRoleNames = map[MSPRoleType]string{
+	MSPRoleMember:  "member",
+	MSPRoleAdmin:   "admin",
+	MSPRoleClient:  "client",
+	MSPRolePeer:    "peer",
+	MSPRoleOrderer: "orderer",
+}
+
+func (r MSPRoleType) String() string {
+	if name, ok := mspRoleNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", int(r))
+}
+
+// MSPRole 是策略中的一个主体，表示某个 MSP 中具有某种角色的身份，例如 Org1MSP.peer。
+type MSPRole struct {
+	MSPIdentifier string
+	Role          MSPRoleType
+}
+
+func (r *MSPRole) String() string {
+	return r.MSPIdentifier + "." + r.Role.String()
+}
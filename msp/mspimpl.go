@@ -0,0 +1,548 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+* From: hyperledger/fabric/msp/mspimpl.go
+ */
+
+package msp
+
+import (
+	"bytes"
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+	"github.com/geistwelt/quarkx/bccsp/keyaudit"
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"github.com/geistwelt/quarkx/common/qlogging"
+	"google.golang.org/protobuf/proto"
+)
+
+var mspLogger = qlogging.MustGetLogger("msp")
+
+// x509msp 是基于 X.509 证书的 MSP 实现。
+type x509msp struct {
+	name string
+
+	rootCerts         []*x509.Certificate
+	intermediateCerts []*x509.Certificate
+	admins            []Identity
+	revocation        *RevocationChecker
+
+	tlsRootCerts         []*x509.Certificate
+	tlsIntermediateCerts []*x509.Certificate
+
+	opts *x509.VerifyOptions
+
+	signer SigningIdentity
+
+	nodeOUs *nodeOUs
+}
+
+type nodeOUs struct {
+	client  *OUIdentifier
+	peer    *OUIdentifier
+	admin   *OUIdentifier
+	orderer *OUIdentifier
+}
+
+// New 根据配置创建一个 MSP。
+func New(conf *Config) (MSP, error) {
+	if conf == nil {
+		return nil, errors.New("setup error: nil conf reference")
+	}
+	if conf.Name == "" {
+		return nil, errors.New("setup error: MSP name must not be empty")
+	}
+
+	msp := &x509msp{name: conf.Name}
+	if err := msp.setupCAs(conf); err != nil {
+		return nil, err
+	}
+	if err := msp.setupCRLs(conf); err != nil {
+		return nil, err
+	}
+	if err := msp.setupTLSCAs(conf); err != nil {
+		return nil, err
+	}
+	if err := msp.setupNodeOUs(conf); err != nil {
+		return nil, err
+	}
+	if err := msp.setupAdmins(conf); err != nil {
+		return nil, err
+	}
+	if err := msp.setupSigningIdentity(conf); err != nil {
+		return nil, err
+	}
+
+	return msp, nil
+}
+
+func (msp *x509msp) setupCAs(conf *Config) error {
+	if len(conf.RootCerts) == 0 {
+		return errors.New("expected at least one CA certificate")
+	}
+
+	msp.opts = &x509.VerifyOptions{
+		Roots:         x509.NewCertPool(),
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+
+	for _, raw := range conf.RootCerts {
+		cert, err := pemToCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse root certificate [%v]", err)
+		}
+		if !cert.IsCA {
+			return fmt.Errorf("root certificate [%s] is not a CA certificate", cert.Subject)
+		}
+		msp.rootCerts = append(msp.rootCerts, cert)
+		msp.opts.Roots.AddCert(cert)
+	}
+
+	for _, raw := range conf.IntermediateCerts {
+		cert, err := pemToCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse intermediate certificate [%v]", err)
+		}
+		if !cert.IsCA {
+			return fmt.Errorf("intermediate certificate [%s] is not a CA certificate", cert.Subject)
+		}
+		msp.intermediateCerts = append(msp.intermediateCerts, cert)
+		msp.opts.Intermediates.AddCert(cert)
+	}
+
+	// 中间证书必须能够链接到某个根证书。
+	for _, cert := range msp.intermediateCerts {
+		if _, err := msp.getCertificationChain(cert); err != nil {
+			return fmt.Errorf("intermediate certificate [%s] is not valid [%v]", cert.Subject, err)
+		}
+	}
+
+	return nil
+}
+
+// setupTLSCAs 解析组织的 TLS 根证书与 TLS 中间证书，它们与身份证书的 CA 相互独立，
+// 由 common/comm 用来校验该组织节点的 TLS 证书。
+func (msp *x509msp) setupTLSCAs(conf *Config) error {
+	opts := x509.VerifyOptions{
+		Roots:         x509.NewCertPool(),
+		Intermediates: x509.NewCertPool(),
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageAny},
+	}
+
+	for _, raw := range conf.TLSRootCerts {
+		cert, err := pemToCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse TLS root certificate [%v]", err)
+		}
+		if !cert.IsCA {
+			return fmt.Errorf("TLS root certificate [%s] is not a CA certificate", cert.Subject)
+		}
+		msp.tlsRootCerts = append(msp.tlsRootCerts, cert)
+		opts.Roots.AddCert(cert)
+	}
+
+	for _, raw := range conf.TLSIntermediateCerts {
+		cert, err := pemToCertificate(raw)
+		if err != nil {
+			return fmt.Errorf("failed to parse TLS intermediate certificate [%v]", err)
+		}
+		if !cert.IsCA {
+			return fmt.Errorf("TLS intermediate certificate [%s] is not a CA certificate", cert.Subject)
+		}
+		msp.tlsIntermediateCerts = append(msp.tlsIntermediateCerts, cert)
+		opts.Intermediates.AddCert(cert)
+	}
+
+	// TLS 中间证书必须能够链接到某个 TLS 根证书。
+	for _, cert := range msp.tlsIntermediateCerts {
+		if _, err := cert.Verify(opts); err != nil {
+			return fmt.Errorf("TLS intermediate certificate [%s] is not valid [%v]", cert.Subject, err)
+		}
+	}
+
+	return nil
+}
+
+func (msp *x509msp) setupCRLs(conf *Config) error {
+	msp.revocation = conf.RevocationChecker
+	if msp.revocation == nil {
+		msp.revocation = NewRevocationChecker(RevocationConfig{})
+	}
+	msp.revocation.AddTrustedCAs(msp.rootCerts...)
+	msp.revocation.AddTrustedCAs(msp.intermediateCerts...)
+
+	for _, raw := range conf.RevocationList {
+		if err := msp.revocation.AddCRL(raw); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (msp *x509msp) setupNodeOUs(conf *Config) error {
+	if conf.NodeOUs == nil || !conf.NodeOUs.Enable {
+		return nil
+	}
+
+	msp.nodeOUs = &nodeOUs{}
+	identifiers := []struct {
+		from *OUIdentifierConfig
+		to   **OUIdentifier
+	}{
+		{conf.NodeOUs.ClientOUIdentifier, &msp.nodeOUs.client},
+		{conf.NodeOUs.PeerOUIdentifier, &msp.nodeOUs.peer},
+		{conf.NodeOUs.AdminOUIdentifier, &msp.nodeOUs.admin},
+		{conf.NodeOUs.OrdererOUIdentifier, &msp.nodeOUs.orderer},
+	}
+	for _, identifier := range identifiers {
+		if identifier.from == nil || identifier.from.OrganizationalUnitIdentifier == "" {
+			continue
+		}
+
+		ou := &OUIdentifier{OrganizationalUnitIdentifier: identifier.from.OrganizationalUnitIdentifier}
+		if len(identifier.from.Certificate) != 0 {
+			cert, err := pemToCertificate(identifier.from.Certificate)
+			if err != nil {
+				return fmt.Errorf("failed to parse NodeOU certificate [%v]", err)
+			}
+			if ou.CertifiersIdentifier, err = msp.getCertificationChainIdentifier(cert); err != nil {
+				return fmt.Errorf("failed computing certifiers identifier for [%s] [%v]", ou.OrganizationalUnitIdentifier, err)
+			}
+		}
+		*identifier.to = ou
+	}
+
+	return nil
+}
+
+func (msp *x509msp) setupAdmins(conf *Config) error {
+	for _, raw := range conf.Admins {
+		id, err := msp.getIdentityFromPEM(raw)
+		if err != nil {
+			return fmt.Errorf("failed to load admin identity [%v]", err)
+		}
+		msp.admins = append(msp.admins, id)
+	}
+
+	return nil
+}
+
+func (msp *x509msp) setupSigningIdentity(conf *Config) error {
+	if conf.SigningIdentity == nil {
+		return nil
+	}
+	if conf.SigningIdentity.Signer == nil {
+		return errors.New("setup error: signing identity has no signer")
+	}
+
+	id, err := msp.getIdentityFromPEM(conf.SigningIdentity.PublicSigner)
+	if err != nil {
+		return fmt.Errorf("failed to load signing identity [%v]", err)
+	}
+
+	pub, ok := conf.SigningIdentity.Signer.Public().(*ecdsa.PublicKey)
+	if !ok || !pub.Equal(id.pk) {
+		return errors.New("setup error: signer does not match the signing certificate")
+	}
+
+	// 私钥以 utils.ECDSAPrivateKey 的形式保存，不会出现在格式化输出与日志中。
+	signer := conf.SigningIdentity.Signer
+	if sk, ok := signer.(*ecdsa.PrivateKey); ok {
+		if signer, err = utils.NewECDSAPrivateKey(sk); err != nil {
+			return fmt.Errorf("setup error: invalid signer [%v]", err)
+		}
+	}
+
+	auditor := conf.KeyAuditor
+	if auditor == nil {
+		auditor = keyaudit.NewAuditor(nil, nil)
+	}
+	signer, err = auditor.Signer(signer, msp.name)
+	if err != nil {
+		return fmt.Errorf("setup error: failed to audit signer [%v]", err)
+	}
+
+	msp.signer = &signingidentity{identity: id, signer: signer, rand: entropy.Or(conf.Entropy)}
+
+	return nil
+}
+
+func (msp *x509msp) GetIdentifier() string {
+	return msp.name
+}
+
+func (msp *x509msp) GetDefaultSigningIdentity() (SigningIdentity, error) {
+	if msp.signer == nil {
+		return nil, errors.New("this MSP does not possess a valid default signing identity")
+	}
+
+	return msp.signer, nil
+}
+
+func (msp *x509msp) GetRootCerts() []*x509.Certificate {
+	return msp.rootCerts
+}
+
+func (msp *x509msp) GetIntermediateCerts() []*x509.Certificate {
+	return msp.intermediateCerts
+}
+
+func (msp *x509msp) GetTLSRootCerts() []*x509.Certificate {
+	return msp.tlsRootCerts
+}
+
+func (msp *x509msp) GetTLSIntermediateCerts() []*x509.Certificate {
+	return msp.tlsIntermediateCerts
+}
+
+func (msp *x509msp) DeserializeIdentity(serializedIdentity []byte) (Identity, error) {
+	sid := &SerializedIdentity{}
+	if err := proto.Unmarshal(serializedIdentity, sid); err != nil {
+		return nil, fmt.Errorf("could not deserialize a SerializedIdentity [%v]", err)
+	}
+
+	if sid.Mspid != msp.name {
+		return nil, fmt.Errorf("expected MSP ID %s, received %s", msp.name, sid.Mspid)
+	}
+
+	return msp.getIdentityFromPEM(sid.IdBytes)
+}
+
+func (msp *x509msp) Validate(id Identity) error {
+	mspLogger.Debugf("MSP %s validating identity", msp.name)
+
+	switch id := id.(type) {
+	case *identity:
+		return msp.validateIdentity(id)
+	case *signingidentity:
+		return msp.validateIdentity(id.identity)
+	default:
+		return errors.New("identity type not recognized")
+	}
+}
+
+func (msp *x509msp) IsAdmin(id Identity) bool {
+	for _, admin := range msp.admins {
+		if bytes.Equal(admin.Certificate().Raw, id.Certificate().Raw) {
+			return true
+		}
+	}
+
+	if msp.nodeOUs != nil && msp.nodeOUs.admin != nil {
+		return msp.hasOUIdentifier(id, msp.nodeOUs.admin)
+	}
+
+	return false
+}
+
+// SatisfiesPrincipal 检查身份是否属于 principal 指定的 MSP、是否有效，以及是否具有
+// principal 指定的角色。client、peer 与 orderer 角色要求该 MSP 开启了 NodeOUs。
+func (msp *x509msp) SatisfiesPrincipal(id Identity, principal *MSPRole) error {
+	if principal.MSPIdentifier != msp.name {
+		return fmt.Errorf("the identity is a member of a different MSP (expected %s, got %s)", principal.MSPIdentifier, msp.name)
+	}
+	if id.GetMSPIdentifier() != msp.name {
+		return fmt.Errorf("the identity is a member of a different MSP (expected %s, got %s)", msp.name, id.GetMSPIdentifier())
+	}
+	if err := msp.Validate(id); err != nil {
+		return fmt.Errorf("the identity is not valid under this MSP [%s] [%v]", msp.name, err)
+	}
+
+	switch principal.Role {
+	case MSPRoleMember:
+		return nil
+	case MSPRoleAdmin:
+		if msp.IsAdmin(id) {
+			return nil
+		}
+		return errors.New("this identity is not an admin")
+	case MSPRoleClient, MSPRolePeer, MSPRoleOrderer:
+	default:
+		return fmt.Errorf("invalid MSP role type %d", int(principal.Role))
+	}
+
+	var ou *OUIdentifier
+	if msp.nodeOUs != nil {
+		switch principal.Role {
+		case MSPRoleClient:
+			ou = msp.nodeOUs.client
+		case MSPRolePeer:
+			ou = msp.nodeOUs.peer
+		case MSPRoleOrderer:
+			ou = msp.nodeOUs.orderer
+		}
+	}
+	if ou == nil {
+		return fmt.Errorf("NodeOUs not activated for role [%s], cannot tell apart identities, MSP: [%s]", principal.Role, msp.name)
+	}
+	if !msp.hasOUIdentifier(id, ou) {
+		return fmt.Errorf("the identity is not a [%s] under this MSP [%s]", principal.Role, msp.name)
+	}
+
+	return nil
+}
+
+func (msp *x509msp) getIdentityFromPEM(raw []byte) (*identity, error) {
+	cert, err := pemToCertificate(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	return newIdentity(cert, msp)
+}
+
+// validateIdentity 依次检查：证书链可以追溯到受信任的根证书、身份证书不是 CA 证书、
+// NodeOU 满足配置要求、证书没有被吊销。前三项的结果会被缓存在身份中，而吊销信息会
+// 随着 CRL 的刷新而变化，因此每次都会重新检查。
+func (msp *x509msp) validateIdentity(id *identity) error {
+	if id.msp != msp {
+		return fmt.Errorf("the identity belongs to MSP %s, not %s", id.GetMSPIdentifier(), msp.name)
+	}
+
+	id.validationMutex.Lock()
+	if !id.validated {
+		id.chain, id.validationErr = msp.doValidateIdentity(id)
+		id.validated = true
+	}
+	chain, err := id.chain, id.validationErr
+	id.validationMutex.Unlock()
+
+	if err != nil {
+		return err
+	}
+
+	return msp.revocation.Check(chain)
+}
+
+func (msp *x509msp) doValidateIdentity(id *identity) ([]*x509.Certificate, error) {
+	if id.cert.IsCA {
+		return nil, errors.New("an X509 certificate with Basic Constraint: Certificate Authority equals true cannot be used as an identity")
+	}
+
+	chain, err := msp.getCertificationChain(id.cert)
+	if err != nil {
+		return nil, fmt.Errorf("could not obtain certification chain [%v]", err)
+	}
+
+	if err := msp.validateIdentityOUs(id, chain); err != nil {
+		return nil, err
+	}
+
+	return chain, nil
+}
+
+func (msp *x509msp) validateIdentityOUs(id *identity, chain []*x509.Certificate) error {
+	if msp.nodeOUs == nil {
+		return nil
+	}
+
+	certifiersIdentifier := chainIdentifier(chain[1:])
+	var matched []string
+	for _, ou := range []*OUIdentifier{msp.nodeOUs.client, msp.nodeOUs.peer, msp.nodeOUs.admin, msp.nodeOUs.orderer} {
+		if ou == nil {
+			continue
+		}
+		for _, name := range id.cert.Subject.OrganizationalUnit {
+			if name != ou.OrganizationalUnitIdentifier {
+				continue
+			}
+			if len(ou.CertifiersIdentifier) != 0 && !bytes.Equal(ou.CertifiersIdentifier, certifiersIdentifier) {
+				return fmt.Errorf("certifiersIdentifier does not match: [%v], MSP: [%s]", id.GetOrganizationalUnits(), msp.name)
+			}
+			matched = append(matched, name)
+		}
+	}
+
+	switch len(matched) {
+	case 0:
+		return fmt.Errorf("the identity does not have an OU in %s, MSP: [%s]", msp.nodeOUNames(), msp.name)
+	case 1:
+		return nil
+	default:
+		return fmt.Errorf("the identity must be a client, a peer, an orderer or an admin identity to be valid, not a combination of them. OUs: %v, MSP: [%s]", matched, msp.name)
+	}
+}
+
+func (msp *x509msp) nodeOUNames() []string {
+	var names []string
+	for _, ou := range []*OUIdentifier{msp.nodeOUs.client, msp.nodeOUs.peer, msp.nodeOUs.admin, msp.nodeOUs.orderer} {
+		if ou != nil {
+			names = append(names, ou.OrganizationalUnitIdentifier)
+		}
+	}
+	return names
+}
+
+func (msp *x509msp) hasOUIdentifier(id Identity, expected *OUIdentifier) bool {
+	for _, ou := range id.GetOrganizationalUnits() {
+		if ou.OrganizationalUnitIdentifier != expected.OrganizationalUnitIdentifier {
+			continue
+		}
+		if len(expected.CertifiersIdentifier) == 0 || bytes.Equal(ou.CertifiersIdentifier, expected.CertifiersIdentifier) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// getCertificationChain 返回从 cert 到根证书的证书链，第一张是 cert 本身。
+func (msp *x509msp) getCertificationChain(cert *x509.Certificate) ([]*x509.Certificate, error) {
+	opts := *msp.opts
+	opts.CurrentTime = time.Now()
+
+	chains, err := cert.Verify(opts)
+	if err != nil {
+		return nil, fmt.Errorf("the supplied identity is not valid: %v", err)
+	}
+	if len(chains) != 1 {
+		return nil, fmt.Errorf("this MSP only supports a single validation chain, got %d", len(chains))
+	}
+
+	return chains[0], nil
+}
+
+// getCertificationChainIdentifier 计算 CA 证书所在证书链的标识。
+func (msp *x509msp) getCertificationChainIdentifier(cert *x509.Certificate) ([]byte, error) {
+	chain, err := msp.getCertificationChain(cert)
+	if err != nil {
+		return nil, err
+	}
+
+	return chainIdentifier(chain), nil
+}
+
+func chainIdentifier(chain []*x509.Certificate) []byte {
+	hash := sha256.New()
+	for _, cert := range chain {
+		hash.Write(cert.Raw)
+	}
+	return hash.Sum(nil)
+}
+
+func pemToCertificate(raw []byte) (*x509.Certificate, error) {
+	block, _ := pem.Decode(raw)
+	if block == nil {
+		return nil, errors.New("failed decoding PEM, block must be different from nil")
+	}
+
+	return parseCertificate(block.Bytes)
+}
+
+func parseCertificate(der []byte) (*x509.Certificate, error) {
+	cert, err := x509.ParseCertificate(der)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse certificate [%v]", err)
+	}
+
+	return cert, nil
+}
@@ -0,0 +1,315 @@
+package msp
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/geistwelt/quarkx/bccsp/keyaudit"
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"github.com/geistwelt/quarkx/common/ca"
+	"github.com/geistwelt/quarkx/common/metrics/metricsfakes"
+	"github.com/geistwelt/quarkx/msp/msptest"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+)
+
+// testOrg 在 msptest.Org 的基础上增加生成 MSP 目录、CRL 等 msp 测试专用的方法。
+type testOrg struct {
+	*msptest.Org
+}
+
+func newTestOrg(t *testing.T, domain string) *testOrg {
+	return &testOrg{Org: msptest.NewOrg(t, domain)}
+}
+
+func writePEM(t *testing.T, file string, blockType string, der []byte) {
+	require.NoError(t, os.MkdirAll(filepath.Dir(file), 0o755))
+	require.NoError(t, os.WriteFile(file, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o644))
+}
+
+// writeMSPDir 按照 Fabric 的目录结构生成一个 MSP 目录。
+func (org *testOrg) writeMSPDir(t *testing.T, signCert *x509.Certificate, signKey *ecdsa.PrivateKey, nodeOUs bool, revoked ...*x509.Certificate) string {
+	dir := t.TempDir()
+	writePEM(t, filepath.Join(dir, cacerts, "ca.pem"), "CERTIFICATE", org.Root.SignCert.Raw)
+	writePEM(t, filepath.Join(dir, intermediatecerts, "ica.pem"), "CERTIFICATE", org.Intermediate.SignCert.Raw)
+	writePEM(t, filepath.Join(dir, tlscacerts, "tlsca.pem"), "CERTIFICATE", org.Root.SignCert.Raw)
+	writePEM(t, filepath.Join(dir, tlsintermediatecerts, "tlsica.pem"), "CERTIFICATE", org.Intermediate.SignCert.Raw)
+
+	if signCert != nil {
+		writePEM(t, filepath.Join(dir, signcerts, "cert.pem"), "CERTIFICATE", signCert.Raw)
+		raw, err := utils.PrivateKeyToPEM(signKey)
+		require.NoError(t, err)
+		require.NoError(t, os.MkdirAll(filepath.Join(dir, keystore), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, keystore, "priv_sk"), raw, 0o600))
+	}
+
+	if len(revoked) != 0 {
+		template := &x509.RevocationList{
+			Number:     big.NewInt(1),
+			ThisUpdate: time.Now().Add(-time.Minute),
+			NextUpdate: time.Now().Add(time.Hour),
+		}
+		for _, cert := range revoked {
+			template.RevokedCertificates = append(template.RevokedCertificates, pkix.RevokedCertificate{
+				SerialNumber:   cert.SerialNumber,
+				RevocationTime: time.Now(),
+			})
+		}
+		der, err := x509.CreateRevocationList(rand.Reader, template, org.Intermediate.SignCert, org.Intermediate.Signer)
+		require.NoError(t, err)
+		writePEM(t, filepath.Join(dir, crlsfolder, "crl.pem"), "X509 CRL", der)
+	}
+
+	if nodeOUs {
+		config := `NodeOUs:
+  Enable: true
+  ClientOUIdentifier:
+    Certificate: intermediatecerts/ica.pem
+    OrganizationalUnitIdentifier: client
+  PeerOUIdentifier:
+    Certificate: intermediatecerts/ica.pem
+    OrganizationalUnitIdentifier: peer
+  AdminOUIdentifier:
+    Certificate: intermediatecerts/ica.pem
+    OrganizationalUnitIdentifier: admin
+  OrdererOUIdentifier:
+    Certificate: intermediatecerts/ica.pem
+    OrganizationalUnitIdentifier: orderer
+`
+		require.NoError(t, os.WriteFile(filepath.Join(dir, configfilename), []byte(config), 0o644))
+	}
+
+	return dir
+}
+
+func TestLocalMSP(t *testing.T) {
+	org := newTestOrg(t, "org1.example.com")
+	peerCert, peerKey := org.NewIdentity(t, "peer0.org1.example.com", ca.PeerOU)
+	dir := org.writeMSPDir(t, peerCert, peerKey, true)
+
+	conf, err := GetLocalMSPConfig(dir, "Org1MSP")
+	require.NoError(t, err)
+	require.True(t, conf.NodeOUs.Enable)
+	require.Equal(t, "peer", conf.NodeOUs.PeerOUIdentifier.OrganizationalUnitIdentifier)
+
+	counter := &metricsfakes.Counter{}
+	counter.WithReturns(counter)
+	provider := &metricsfakes.Provider{}
+	provider.NewCounterReturns(counter)
+	conf.KeyAuditor = keyaudit.NewAuditor(provider, nil)
+	require.NotContains(t, fmt.Sprintf("%+v", *conf.SigningIdentity), peerKey.D.String())
+
+	msp, err := New(conf)
+	require.NoError(t, err)
+	require.Equal(t, "Org1MSP", msp.GetIdentifier())
+	require.Len(t, msp.GetRootCerts(), 1)
+	require.Len(t, msp.GetIntermediateCerts(), 1)
+	require.Equal(t, []*x509.Certificate{org.Root.SignCert}, msp.GetTLSRootCerts())
+	require.Equal(t, []*x509.Certificate{org.Intermediate.SignCert}, msp.GetTLSIntermediateCerts())
+
+	signer, err := msp.GetDefaultSigningIdentity()
+	require.NoError(t, err)
+	require.NoError(t, signer.Validate())
+	require.False(t, msp.IsAdmin(signer))
+
+	msg := []byte("hello, quarkx")
+	for i := 0; i < 10; i++ {
+		sig, err := signer.Sign(msg)
+		require.NoError(t, err)
+		require.NoError(t, signer.GetPublicVersion().Verify(msg, sig))
+	}
+
+	sig, err := signer.Sign(msg)
+	require.NoError(t, err)
+	require.EqualError(t, signer.Verify([]byte("another message"), sig), "the signature is invalid")
+
+	// 每一次签名都以 MSP 的名义记录下来。
+	require.Equal(t, 11, counter.AddCallCount())
+	require.Equal(t, []string{"ski", hex.EncodeToString(utils.SKI(&peerKey.PublicKey)), "component", "Org1MSP", "operation", "sign", "outcome", "success"}, counter.WithArgsForCall(10))
+
+	// 构造一个 high-S 形式的签名，它在数学上是有效的，但必须被拒绝。
+	r, s, err := utils.UnmarshalECDSASignature(sig)
+	require.NoError(t, err)
+	highS, err := utils.MarshalECDSASignature(r, new(big.Int).Sub(elliptic.P256().Params().N, s))
+	require.NoError(t, err)
+	err = signer.Verify(msg, highS)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid S, must be smaller than half the order")
+
+	serialized, err := signer.Serialize()
+	require.NoError(t, err)
+	id, err := msp.DeserializeIdentity(serialized)
+	require.NoError(t, err)
+	require.Equal(t, signer.GetIdentifier(), id.GetIdentifier())
+	require.NoError(t, id.Validate())
+	require.NoError(t, id.Verify(msg, sig))
+
+	other, err := proto.Marshal(&SerializedIdentity{Mspid: "Org2MSP", IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: peerCert.Raw})})
+	require.NoError(t, err)
+	_, err = msp.DeserializeIdentity(other)
+	require.EqualError(t, err, "expected MSP ID Org1MSP, received Org2MSP")
+}
+
+func TestValidateIdentities(t *testing.T) {
+	org := newTestOrg(t, "org1.example.com")
+	revokedCert, _ := org.NewIdentity(t, "revoked@org1.example.com", ca.ClientOU)
+	dir := org.writeMSPDir(t, nil, nil, true, revokedCert)
+
+	conf, err := GetVerifyingMSPConfig(dir, "Org1MSP")
+	require.NoError(t, err)
+	require.Len(t, conf.RevocationList, 1)
+	msp, err := New(conf)
+	require.NoError(t, err)
+
+	_, err = msp.GetDefaultSigningIdentity()
+	require.EqualError(t, err, "this MSP does not possess a valid default signing identity")
+
+	deserialize := func(cert *x509.Certificate) Identity {
+		raw, err := proto.Marshal(&SerializedIdentity{Mspid: "Org1MSP", IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})})
+		require.NoError(t, err)
+		id, err := msp.DeserializeIdentity(raw)
+		require.NoError(t, err)
+		return id
+	}
+
+	adminCert, _ := org.NewIdentity(t, "admin@org1.example.com", ca.AdminOU)
+	admin := deserialize(adminCert)
+	require.NoError(t, msp.Validate(admin))
+	require.True(t, msp.IsAdmin(admin))
+
+	err = msp.Validate(deserialize(revokedCert))
+	require.EqualError(t, err, "the certificate [CN=revoked@org1.example.com,OU=client,C=CN] has been revoked, reason [unspecified], source [crl]")
+
+	noOU, _ := org.NewIdentity(t, "nobody@org1.example.com", "")
+	err = msp.Validate(deserialize(noOU))
+	require.EqualError(t, err, "the identity does not have an OU in [client peer admin orderer], MSP: [Org1MSP]")
+
+	sk := msptest.NewKey(t)
+	both, err := org.Intermediate.SignCertificate("both@org1.example.com", []string{ca.ClientOU, ca.PeerOU}, nil, &sk.PublicKey, x509.KeyUsageDigitalSignature, nil)
+	require.NoError(t, err)
+	err = msp.Validate(deserialize(both))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "not a combination of them")
+
+	// 由根 CA 直接签发的身份，证书链与 NodeOU 配置中的证书不一致。
+	direct, err := org.Root.NewSigningCertificate("direct@org1.example.com", ca.ClientOU, &sk.PublicKey)
+	require.NoError(t, err)
+	err = msp.Validate(deserialize(direct))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "certifiersIdentifier does not match")
+
+	err = msp.Validate(deserialize(org.Intermediate.SignCert))
+	require.EqualError(t, err, "an X509 certificate with Basic Constraint: Certificate Authority equals true cannot be used as an identity")
+
+	stranger := newTestOrg(t, "org2.example.com")
+	strangerCert, _ := stranger.NewIdentity(t, "peer0.org2.example.com", ca.PeerOU)
+	err = msp.Validate(deserialize(strangerCert))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "could not obtain certification chain")
+}
+
+func TestMSPWithoutNodeOUs(t *testing.T) {
+	org := newTestOrg(t, "org1.example.com")
+	dir := org.writeMSPDir(t, nil, nil, false)
+
+	adminCert, _ := org.NewIdentity(t, "admin@org1.example.com", "")
+	writePEM(t, filepath.Join(dir, admincerts, "admin.pem"), "CERTIFICATE", adminCert.Raw)
+
+	conf, err := GetVerifyingMSPConfig(dir, "Org1MSP")
+	require.NoError(t, err)
+	require.Nil(t, conf.NodeOUs)
+	msp, err := New(conf)
+	require.NoError(t, err)
+
+	id, err := msp.(*x509msp).getIdentityFromPEM(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: adminCert.Raw}))
+	require.NoError(t, err)
+	require.NoError(t, id.Validate())
+	require.True(t, msp.IsAdmin(id))
+
+	userCert, _ := org.NewIdentity(t, "user1@org1.example.com", "")
+	user, err := msp.(*x509msp).getIdentityFromPEM(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: userCert.Raw}))
+	require.NoError(t, err)
+	require.NoError(t, user.Validate())
+	require.False(t, msp.IsAdmin(user))
+
+	_, err = GetVerifyingMSPConfig(t.TempDir(), "Org1MSP")
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "could not load a valid ca certificate from directory")
+}
+
+func TestTLSCAs(t *testing.T) {
+	org, other := newTestOrg(t, "org1.example.com"), newTestOrg(t, "org2.example.com")
+	dir := org.writeMSPDir(t, nil, nil, false)
+
+	conf, err := GetVerifyingMSPConfig(dir, "Org1MSP")
+	require.NoError(t, err)
+	require.Len(t, conf.TLSRootCerts, 1)
+	require.Len(t, conf.TLSIntermediateCerts, 1)
+
+	// TLS 中间证书必须链接到该组织的 TLS 根证书。
+	conf.TLSIntermediateCerts = [][]byte{ca.CertToPEM(other.Intermediate.SignCert)}
+	_, err = New(conf)
+	require.EqualError(t, err, "TLS intermediate certificate [CN=ica.org2.example.com,O=ica.org2.example.com,C=CN] is not valid [x509: certificate signed by unknown authority]")
+
+	leaf, _ := org.NewIdentity(t, "peer0.org1.example.com", ca.PeerOU)
+	conf.TLSIntermediateCerts = nil
+	conf.TLSRootCerts = [][]byte{ca.CertToPEM(leaf)}
+	_, err = New(conf)
+	require.EqualError(t, err, "TLS root certificate [CN=peer0.org1.example.com,OU=peer,C=CN] is not a CA certificate")
+}
+
+func TestSatisfiesPrincipal(t *testing.T) {
+	org := newTestOrg(t, "org1.example.com")
+	conf, err := GetVerifyingMSPConfig(org.writeMSPDir(t, nil, nil, true), "Org1MSP")
+	require.NoError(t, err)
+	msp1, err := New(conf)
+	require.NoError(t, err)
+
+	conf, err = GetVerifyingMSPConfig(org.writeMSPDir(t, nil, nil, false), "Org2MSP")
+	require.NoError(t, err)
+	msp2, err := New(conf)
+	require.NoError(t, err)
+
+	mgr, err := NewMSPManager(msp1, msp2)
+	require.NoError(t, err)
+	require.Len(t, mgr.GetMSPs(), 2)
+	_, err = NewMSPManager(msp1, msp1)
+	require.EqualError(t, err, "duplicate MSP identifier Org1MSP")
+
+	deserialize := func(mspID string, cert *x509.Certificate) Identity {
+		raw, err := proto.Marshal(&SerializedIdentity{Mspid: mspID, IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert.Raw})})
+		require.NoError(t, err)
+		id, err := mgr.DeserializeIdentity(raw)
+		require.NoError(t, err)
+		return id
+	}
+
+	peerCert, _ := org.NewIdentity(t, "peer0.org1.example.com", ca.PeerOU)
+	peer := deserialize("Org1MSP", peerCert)
+	require.NoError(t, peer.SatisfiesPrincipal(&MSPRole{MSPIdentifier: "Org1MSP", Role: MSPRoleMember}))
+	require.NoError(t, peer.SatisfiesPrincipal(&MSPRole{MSPIdentifier: "Org1MSP", Role: MSPRolePeer}))
+	require.EqualError(t, peer.SatisfiesPrincipal(&MSPRole{MSPIdentifier: "Org1MSP", Role: MSPRoleClient}), "the identity is not a [client] under this MSP [Org1MSP]")
+	require.EqualError(t, peer.SatisfiesPrincipal(&MSPRole{MSPIdentifier: "Org1MSP", Role: MSPRoleAdmin}), "this identity is not an admin")
+	require.EqualError(t, peer.SatisfiesPrincipal(&MSPRole{MSPIdentifier: "Org2MSP", Role: MSPRoleMember}), "the identity is a member of a different MSP (expected Org2MSP, got Org1MSP)")
+
+	// Org2MSP 没有开启 NodeOUs，无法区分 peer 与 client。
+	other := deserialize("Org2MSP", peerCert)
+	require.NoError(t, other.SatisfiesPrincipal(&MSPRole{MSPIdentifier: "Org2MSP", Role: MSPRoleMember}))
+	require.EqualError(t, other.SatisfiesPrincipal(&MSPRole{MSPIdentifier: "Org2MSP", Role: MSPRolePeer}), "NodeOUs not activated for role [peer], cannot tell apart identities, MSP: [Org2MSP]")
+
+	unknown, err := proto.Marshal(&SerializedIdentity{Mspid: "Org3MSP", IdBytes: pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: peerCert.Raw})})
+	require.NoError(t, err)
+	_, err = mgr.DeserializeIdentity(unknown)
+	require.EqualError(t, err, "MSP Org3MSP is unknown")
+}
@@ -0,0 +1,53 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+* From: hyperledger/fabric/msp/mspmgrimpl.go
+ */
+
+package msp
+
+import (
+	"errors"
+	"fmt"
+
+	"google.golang.org/protobuf/proto"
+)
+
+type mspManager struct {
+	mspsMap map[string]MSP
+}
+
+// NewMSPManager 创建一个管理 msps 的 MSPManager，MSP 的标识不能重复。
+func NewMSPManager(msps ...MSP) (MSPManager, error) {
+	mgr := &mspManager{mspsMap: make(map[string]MSP, len(msps))}
+	for _, msp := range msps {
+		if msp == nil {
+			return nil, errors.New("setup error: nil MSP reference")
+		}
+		id := msp.GetIdentifier()
+		if _, ok := mgr.mspsMap[id]; ok {
+			return nil, fmt.Errorf("duplicate MSP identifier %s", id)
+		}
+		mgr.mspsMap[id] = msp
+	}
+
+	return mgr, nil
+}
+
+func (mgr *mspManager) GetMSPs() map[string]MSP {
+	return mgr.mspsMap
+}
+
+func (mgr *mspManager) DeserializeIdentity(serializedIdentity []byte) (Identity, error) {
+	sid := &SerializedIdentity{}
+	if err := proto.Unmarshal(serializedIdentity, sid); err != nil {
+		return nil, fmt.Errorf("could not deserialize a SerializedIdentity [%v]", err)
+	}
+
+	msp, ok := mgr.mspsMap[sid.Mspid]
+	if !ok {
+		return nil, fmt.Errorf("MSP %s is unknown", sid.Mspid)
+	}
+
+	return msp.DeserializeIdentity(serializedIdentity)
+}
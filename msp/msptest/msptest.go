@@ -0,0 +1,52 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+// Package msptest 提供测试用的组织与身份，供构造 MSP 的测试共用。为了能被 msp 包自身
+// 的测试引用，它不依赖 msp 包。
+package msptest
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/geistwelt/quarkx/common/ca"
+	"github.com/stretchr/testify/require"
+)
+
+// NewKey 生成一个 P-256 私钥。
+func NewKey(t testing.TB) *ecdsa.PrivateKey {
+	sk, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	return sk
+}
+
+// Org 是测试用的组织，包含根 CA 以及由根 CA 签发的中间 CA，组织的身份都由中间 CA 签发。
+type Org struct {
+	Root         *ca.CA
+	Intermediate *ca.CA
+}
+
+// NewOrg 为域名 domain 创建一个测试组织，根 CA 与中间 CA 的名称分别为 ca.<domain> 与
+// ica.<domain>。
+func NewOrg(t testing.TB, domain string) *Org {
+	root, err := ca.NewRootCA("ca."+domain, pkix.Name{Country: []string{"CN"}}, NewKey(t))
+	require.NoError(t, err)
+	intermediate, err := root.NewIntermediateCA("ica."+domain, pkix.Name{}, NewKey(t))
+	require.NoError(t, err)
+
+	return &Org{Root: root, Intermediate: intermediate}
+}
+
+// NewIdentity 由中间 CA 签发一个名称为 name、属于 nodeOU 的签名证书，返回证书与私钥。
+func (org *Org) NewIdentity(t testing.TB, name, nodeOU string) (*x509.Certificate, *ecdsa.PrivateKey) {
+	sk := NewKey(t)
+	cert, err := org.Intermediate.NewSigningCertificate(name, nodeOU, &sk.PublicKey)
+	require.NoError(t, err)
+	return cert, sk
+}
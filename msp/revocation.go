@@ -0,0 +1,455 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+ */
+
+package msp
+
+import (
+	"bytes"
+	"context"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ocsp"
+)
+
+const (
+	defaultRefreshInterval = time.Hour
+	defaultOCSPTimeout     = 5 * time.Second
+	maxRevocationDataSize  = 16 << 20
+)
+
+// ErrStaleRevocationData 表示已有的吊销信息已经过期，并且超出了宽限期，此时无法确定
+// 证书是否被吊销，校验以失败告终。
+var ErrStaleRevocationData = errors.New("revocation data is stale")
+
+// ErrNoRevocationData 表示开启了吊销检查，但是签发者没有有效的吊销信息，此时同样无法
+// 确定证书是否被吊销，校验以失败告终。
+var ErrNoRevocationData = errors.New("no valid revocation data")
+
+// RevocationReason 是 RFC 5280 5.3.1 节定义的证书吊销原因。
+type RevocationReason int
+
+const (
+	Unspecified          RevocationReason = 0
+	KeyCompromise        RevocationReason = 1
+	CACompromise         RevocationReason = 2
+	AffiliationChanged   RevocationReason = 3
+	Superseded           RevocationReason = 4
+	CessationOfOperation RevocationReason = 5
+	CertificateHold      RevocationReason = 6
+	RemoveFromCRL        RevocationReason = 8
+	PrivilegeWithdrawn   RevocationReason = 9
+	AACompromise         RevocationReason = 10
+)
+
+var revocationReasonNames = map[RevocationReason]string{
+	Unspecified:          "unspecified",
+	KeyCompromise:        "keyCompromise",
+	CACompromise:         "cACompromise",
+	AffiliationChanged:   "affiliationChanged",
+	Superseded:           "superseded",
+	CessationOfOperation: "cessationOfOperation",
+	CertificateHold:      "certificateHold",
+	RemoveFromCRL:        "removeFromCRL",
+	PrivilegeWithdrawn:   "privilegeWithdrawn",
+	AACompromise:         "aACompromise",
+}
+
+func (r RevocationReason) String() string {
+	if name, ok := revocationReasonNames[r]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", int(r))
+}
+
+// RevocationError 表示证书已经被吊销，Source 指明吊销信息来自 CRL 还是 OCSP。
+type RevocationError struct {
+	Subject      string
+	SerialNumber *big.Int
+	Reason       RevocationReason
+	RevokedAt    time.Time
+	Source       string
+}
+
+func (e *RevocationError) Error() string {
+	return fmt.Sprintf("the certificate [%s] has been revoked, reason [%s], source [%s]", e.Subject, e.Reason, e.Source)
+}
+
+// RevocationConfig 是吊销检查的配置。加载过某个签发者的 CRL 之后，或者证书的 CRL 分发点
+// 是 CRLSources 中的某个来源时，该签发者必须有有效的 CRL，否则校验失败；其他签发者不受
+// 影响，因此多个组织可以共用一个 RevocationChecker。
+type RevocationConfig struct {
+	// TrustedCAs 是可以签发 CRL 的 CA 证书，签名不能被其中某个 CA 验证的 CRL 会被拒绝。
+	TrustedCAs []*x509.Certificate
+
+	// CRLSources 是需要定期刷新的 CRL 来源，可以是本地文件路径，也可以是 HTTP(S) URL。
+	CRLSources []string
+
+	// RefreshInterval 是刷新 CRLSources 的间隔，默认为 1 小时。
+	RefreshInterval time.Duration
+
+	// GracePeriod 是吊销信息过期（超过 NextUpdate）之后仍然被接受的时长，超过宽限期
+	// 之后，依赖这些信息的校验会失败。
+	GracePeriod time.Duration
+
+	// EnableOCSP 为 true 时，对于 CRL 无法给出结论的证书，会向证书中的 OCSP 服务器查询。
+	EnableOCSP bool
+
+	// OCSPTimeout 是单次 OCSP 查询的超时时间，默认为 5 秒。
+	OCSPTimeout time.Duration
+
+	// HTTPClient 用来下载 CRL 和查询 OCSP，默认为 http.DefaultClient。
+	HTTPClient *http.Client
+}
+
+// RevocationChecker 按签发者缓存 CRL 与 OCSP 响应，并据此检查证书链中的证书是否被吊销。
+type RevocationChecker struct {
+	config RevocationConfig
+	now    func() time.Time
+
+	mutex     sync.RWMutex
+	cas       []*x509.Certificate
+	crls      map[string]*x509.RevocationList
+	ocspCache map[string]*ocsp.Response
+
+	stopOnce sync.Once
+	stop     chan struct{}
+}
+
+// NewRevocationChecker 根据配置创建一个 RevocationChecker，调用 Start 之后才会定期刷新
+// CRLSources。
+func NewRevocationChecker(config RevocationConfig) *RevocationChecker {
+	if config.RefreshInterval <= 0 {
+		config.RefreshInterval = defaultRefreshInterval
+	}
+	if config.OCSPTimeout <= 0 {
+		config.OCSPTimeout = defaultOCSPTimeout
+	}
+	if config.HTTPClient == nil {
+		config.HTTPClient = http.DefaultClient
+	}
+
+	return &RevocationChecker{
+		config:    config,
+		now:       time.Now,
+		cas:       append([]*x509.Certificate{}, config.TrustedCAs...),
+		crls:      make(map[string]*x509.RevocationList),
+		ocspCache: make(map[string]*ocsp.Response),
+		stop:      make(chan struct{}),
+	}
+}
+
+// AddTrustedCAs 添加可以签发 CRL 的 CA 证书。
+func (rc *RevocationChecker) AddTrustedCAs(cas ...*x509.Certificate) {
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	rc.cas = append(rc.cas, cas...)
+}
+
+// AddCRL 添加一个 PEM 或 DER 格式的 CRL，同一个签发者只保留最新的 CRL。CRL 的签名必须
+// 能被某个可信的 CA 验证，否则 CRL 被拒绝，已有的 CRL 保持不变。
+func (rc *RevocationChecker) AddCRL(raw []byte) error {
+	if block, _ := pem.Decode(raw); block != nil {
+		raw = block.Bytes
+	}
+
+	crl, err := x509.ParseRevocationList(raw)
+	if err != nil {
+		return fmt.Errorf("could not parse RevocationList [%v]", err)
+	}
+
+	key := string(crl.RawIssuer)
+
+	rc.mutex.Lock()
+	defer rc.mutex.Unlock()
+
+	if !rc.signedByTrustedCA(crl) {
+		return fmt.Errorf("the CRL of [%s] is not signed by a trusted CA", crl.Issuer)
+	}
+	if current, ok := rc.crls[key]; ok && !isNewerCRL(crl, current) {
+		return nil
+	}
+	rc.crls[key] = crl
+
+	return nil
+}
+
+// signedByTrustedCA 判断 CRL 是否由某个可信的 CA 签发，调用方需要持有锁。
+func (rc *RevocationChecker) signedByTrustedCA(crl *x509.RevocationList) bool {
+	for _, ca := range rc.cas {
+		if isIssuerOf(crl, ca) {
+			return true
+		}
+	}
+	return false
+}
+
+// isIssuerOf 判断 CRL 是否由 issuer 签发并且签名有效。
+func isIssuerOf(crl *x509.RevocationList, issuer *x509.Certificate) bool {
+	if !bytes.Equal(crl.RawIssuer, issuer.RawSubject) {
+		return false
+	}
+	if len(crl.AuthorityKeyId) != 0 && len(issuer.SubjectKeyId) != 0 && !bytes.Equal(crl.AuthorityKeyId, issuer.SubjectKeyId) {
+		return false
+	}
+	return crl.CheckSignatureFrom(issuer) == nil
+}
+
+func isNewerCRL(crl, current *x509.RevocationList) bool {
+	if crl.Number != nil && current.Number != nil {
+		return crl.Number.Cmp(current.Number) > 0
+	}
+	return crl.ThisUpdate.After(current.ThisUpdate)
+}
+
+// Refresh 从所有 CRLSources 下载 CRL，一个来源失败不会影响其他来源。
+func (rc *RevocationChecker) Refresh(ctx context.Context) error {
+	var errs []error
+	for _, source := range rc.config.CRLSources {
+		raw, err := rc.fetch(ctx, source)
+		if err == nil {
+			err = rc.AddCRL(raw)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed refreshing CRL from [%s]: %w", source, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Start 在后台按照 RefreshInterval 定期刷新 CRLSources，直到 Stop 被调用。
+func (rc *RevocationChecker) Start() {
+	refresh := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), rc.config.RefreshInterval)
+		defer cancel()
+		if err := rc.Refresh(ctx); err != nil {
+			mspLogger.Warnf("failed refreshing revocation lists: %s", err)
+		}
+	}
+
+	refresh()
+	go func() {
+		ticker := time.NewTicker(rc.config.RefreshInterval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				refresh()
+			case <-rc.stop:
+				return
+			}
+		}
+	}()
+}
+
+// Stop 停止后台刷新。
+func (rc *RevocationChecker) Stop() {
+	rc.stopOnce.Do(func() { close(rc.stop) })
+}
+
+// Check 检查证书链中除根证书以外的每一张证书是否被吊销，chain[i+1] 是 chain[i] 的签发者。
+func (rc *RevocationChecker) Check(chain []*x509.Certificate) error {
+	for i := 0; i < len(chain)-1; i++ {
+		if err := rc.checkCertificate(chain[i], chain[i+1]); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (rc *RevocationChecker) checkCertificate(cert, issuer *x509.Certificate) error {
+	now := rc.now()
+
+	crl, required := rc.getCRL(cert, issuer)
+	stale := false
+	if crl != nil {
+		for _, revoked := range crl.RevokedCertificateEntries {
+			if revoked.SerialNumber.Cmp(cert.SerialNumber) == 0 {
+				return &RevocationError{
+					Subject:      cert.Subject.String(),
+					SerialNumber: cert.SerialNumber,
+					Reason:       RevocationReason(revoked.ReasonCode),
+					RevokedAt:    revoked.RevocationTime,
+					Source:       "crl",
+				}
+			}
+		}
+
+		stale = !crl.NextUpdate.IsZero() && now.After(crl.NextUpdate.Add(rc.config.GracePeriod))
+		if !stale {
+			return nil
+		}
+	}
+
+	if rc.config.EnableOCSP && len(cert.OCSPServer) != 0 {
+		resp, err := rc.getOCSPResponse(cert, issuer)
+		if err != nil {
+			mspLogger.Warnf("failed querying OCSP for certificate [%s]: %s", cert.Subject, err)
+			return fmt.Errorf("could not determine the revocation status of certificate [%s]: %w", cert.Subject, err)
+		}
+
+		switch resp.Status {
+		case ocsp.Good:
+			return nil
+		case ocsp.Revoked:
+			return &RevocationError{
+				Subject:      cert.Subject.String(),
+				SerialNumber: cert.SerialNumber,
+				Reason:       RevocationReason(resp.RevocationReason),
+				RevokedAt:    resp.RevokedAt,
+				Source:       "ocsp",
+			}
+		default:
+			return fmt.Errorf("could not determine the revocation status of certificate [%s]: the OCSP responder returned unknown: %w", cert.Subject, ErrNoRevocationData)
+		}
+	}
+
+	if stale {
+		return fmt.Errorf("CRL of issuer [%s] expired at %s: %w", issuer.Subject, crl.NextUpdate.Format(time.RFC3339), ErrStaleRevocationData)
+	}
+	if required {
+		return fmt.Errorf("could not determine the revocation status of certificate [%s], issuer [%s]: %w", cert.Subject, issuer.Subject, ErrNoRevocationData)
+	}
+
+	return nil
+}
+
+// getCRL 返回由 issuer 签发且签名有效的 CRL，第二个返回值表示是否要求 issuer 必须有 CRL，
+// 即是否加载过 issuer 的 CRL，或者 cert 的 CRL 分发点是某个配置的 CRL 来源。
+func (rc *RevocationChecker) getCRL(cert, issuer *x509.Certificate) (*x509.RevocationList, bool) {
+	rc.mutex.RLock()
+	crl, ok := rc.crls[string(issuer.RawSubject)]
+	rc.mutex.RUnlock()
+
+	required := ok
+	for _, point := range cert.CRLDistributionPoints {
+		for _, source := range rc.config.CRLSources {
+			if point == source {
+				required = true
+			}
+		}
+	}
+
+	// 同一个主题可能对应多张 CA 证书，例如密钥轮换之后，CRL 必须由本次的 issuer 签发。
+	if !ok || !isIssuerOf(crl, issuer) {
+		return nil, required
+	}
+
+	return crl, required
+}
+
+func (rc *RevocationChecker) getOCSPResponse(cert, issuer *x509.Certificate) (*ocsp.Response, error) {
+	key := hex.EncodeToString(issuer.RawSubject) + ":" + cert.SerialNumber.String()
+	now := rc.now()
+
+	rc.mutex.RLock()
+	cached, ok := rc.ocspCache[key]
+	rc.mutex.RUnlock()
+	if ok && now.Before(cached.NextUpdate) {
+		return cached, nil
+	}
+
+	resp, err := rc.queryOCSP(cert, issuer, now)
+	if err != nil {
+		// 无法从 OCSP 服务器获得新的响应时，宽限期内的缓存响应依然有效。
+		if ok && now.Before(cached.NextUpdate.Add(rc.config.GracePeriod)) {
+			mspLogger.Warnf("failed querying OCSP for certificate [%s], using the cached response that expired at %s: %s", cert.Subject, cached.NextUpdate.Format(time.RFC3339), err)
+			return cached, nil
+		}
+		return nil, err
+	}
+
+	if !resp.NextUpdate.IsZero() {
+		rc.mutex.Lock()
+		rc.ocspCache[key] = resp
+		rc.mutex.Unlock()
+	}
+
+	return resp, nil
+}
+
+func (rc *RevocationChecker) queryOCSP(cert, issuer *x509.Certificate, now time.Time) (*ocsp.Response, error) {
+	req, err := ocsp.CreateRequest(cert, issuer, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed creating OCSP request [%v]", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), rc.config.OCSPTimeout)
+	defer cancel()
+
+	var lastErr error
+	for _, server := range cert.OCSPServer {
+		raw, err := rc.post(ctx, server, req)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+
+		resp, err := ocsp.ParseResponseForCert(raw, cert, issuer)
+		if err != nil {
+			lastErr = fmt.Errorf("invalid OCSP response from [%s] [%v]", server, err)
+			continue
+		}
+		if !resp.NextUpdate.IsZero() && now.After(resp.NextUpdate.Add(rc.config.GracePeriod)) {
+			lastErr = fmt.Errorf("OCSP response from [%s] expired at %s: %w", server, resp.NextUpdate.Format(time.RFC3339), ErrStaleRevocationData)
+			continue
+		}
+
+		return resp, nil
+	}
+
+	return nil, lastErr
+}
+
+func (rc *RevocationChecker) fetch(ctx context.Context, source string) ([]byte, error) {
+	if !strings.HasPrefix(source, "http://") && !strings.HasPrefix(source, "https://") {
+		return os.ReadFile(source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, source, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return rc.do(req)
+}
+
+func (rc *RevocationChecker) post(ctx context.Context, server string, body []byte) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, server, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/ocsp-request")
+	req.Header.Set("Accept", "application/ocsp-response")
+
+	return rc.do(req)
+}
+
+func (rc *RevocationChecker) do(req *http.Request) ([]byte, error) {
+	resp, err := rc.config.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected HTTP status [%s] from [%s]", resp.Status, req.URL)
+	}
+
+	return io.ReadAll(io.LimitReader(resp.Body, maxRevocationDataSize))
+}
@@ -0,0 +1,299 @@
+package msp
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/geistwelt/quarkx/common/ca"
+	"github.com/geistwelt/quarkx/msp/msptest"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ocsp"
+)
+
+// newCRL 由组织的中间 CA 签发一个 CRL，revoked 中的证书以 reason 为吊销原因。
+func (org *testOrg) newCRL(t *testing.T, number int64, thisUpdate, nextUpdate time.Time, reason RevocationReason, revoked ...*x509.Certificate) []byte {
+	return signCRL(t, org.Intermediate.SignCert, org.Intermediate.Signer, number, thisUpdate, nextUpdate, reason, revoked...)
+}
+
+func (org *testOrg) trustedCAs() []*x509.Certificate {
+	return []*x509.Certificate{org.Root.SignCert, org.Intermediate.SignCert}
+}
+
+func signCRL(t *testing.T, issuer *x509.Certificate, signer crypto.Signer, number int64, thisUpdate, nextUpdate time.Time, reason RevocationReason, revoked ...*x509.Certificate) []byte {
+	template := &x509.RevocationList{
+		Number:     big.NewInt(number),
+		ThisUpdate: thisUpdate,
+		NextUpdate: nextUpdate,
+	}
+	for _, cert := range revoked {
+		template.RevokedCertificateEntries = append(template.RevokedCertificateEntries, x509.RevocationListEntry{
+			SerialNumber:   cert.SerialNumber,
+			RevocationTime: thisUpdate,
+			ReasonCode:     int(reason),
+		})
+	}
+	der, err := x509.CreateRevocationList(rand.Reader, template, issuer, signer)
+	require.NoError(t, err)
+
+	return pem.EncodeToMemory(&pem.Block{Type: "X509 CRL", Bytes: der})
+}
+
+// newOCSPIdentity 由组织的中间 CA 签发一个带有 OCSP 服务器地址的身份证书。
+func (org *testOrg) newOCSPIdentity(t *testing.T, name, server string) *x509.Certificate {
+	return org.newExtendedIdentity(t, name, func(template *x509.Certificate) { template.OCSPServer = []string{server} })
+}
+
+// newCRLDPIdentity 由组织的中间 CA 签发一个带有 CRL 分发点的身份证书。
+func (org *testOrg) newCRLDPIdentity(t *testing.T, name, point string) *x509.Certificate {
+	return org.newExtendedIdentity(t, name, func(template *x509.Certificate) { template.CRLDistributionPoints = []string{point} })
+}
+
+func (org *testOrg) newExtendedIdentity(t *testing.T, name string, extend func(*x509.Certificate)) *x509.Certificate {
+	sk := msptest.NewKey(t)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(time.Now().UnixNano()),
+		Subject:      pkix.Name{CommonName: name, OrganizationalUnit: []string{ca.ClientOU}},
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+	}
+	extend(template)
+	der, err := x509.CreateCertificate(rand.Reader, template, org.Intermediate.SignCert, &sk.PublicKey, org.Intermediate.Signer)
+	require.NoError(t, err)
+	cert, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+
+	return cert
+}
+
+func (org *testOrg) chain(cert *x509.Certificate) []*x509.Certificate {
+	return []*x509.Certificate{cert, org.Intermediate.SignCert, org.Root.SignCert}
+}
+
+func TestRevocationCheckerCRL(t *testing.T) {
+	org := newTestOrg(t, "org1.example.com")
+	good, _ := org.NewIdentity(t, "good@org1.example.com", ca.ClientOU)
+	revoked, _ := org.NewIdentity(t, "revoked@org1.example.com", ca.ClientOU)
+
+	now := time.Now()
+	checker := NewRevocationChecker(RevocationConfig{GracePeriod: time.Hour, TrustedCAs: org.trustedCAs()})
+	checker.now = func() time.Time { return now }
+
+	require.NoError(t, checker.Check(org.chain(revoked)))
+
+	require.NoError(t, checker.AddCRL(org.newCRL(t, 2, now.Add(-time.Minute), now.Add(time.Hour), KeyCompromise, revoked)))
+	// 只有中间 CA 有 CRL，与 Fabric 的 crls 目录一样，根 CA 签发的中间 CA 证书不需要 CRL。
+	require.NoError(t, checker.Check(org.chain(good)))
+
+	err := checker.Check(org.chain(revoked))
+	var revocationErr *RevocationError
+	require.True(t, errors.As(err, &revocationErr))
+	require.Equal(t, KeyCompromise, revocationErr.Reason)
+	require.Equal(t, "crl", revocationErr.Source)
+	require.Equal(t, 0, revoked.SerialNumber.Cmp(revocationErr.SerialNumber))
+	require.EqualError(t, err, "the certificate [CN=revoked@org1.example.com,OU=client,C=CN] has been revoked, reason [keyCompromise], source [crl]")
+
+	// 较旧的 CRL 不会替换已有的 CRL。
+	require.NoError(t, checker.AddCRL(org.newCRL(t, 1, now.Add(-time.Hour), now.Add(time.Hour), Unspecified)))
+	require.Error(t, checker.Check(org.chain(revoked)))
+
+	require.Error(t, checker.AddCRL([]byte("not a crl")))
+
+	// 不由可信 CA 签发的 CRL 被拒绝。
+	stranger := newTestOrg(t, "org2.example.com")
+	require.EqualError(t, checker.AddCRL(stranger.newCRL(t, 3, now, now.Add(time.Hour), Unspecified)), "the CRL of [CN=ica.org2.example.com,O=ica.org2.example.com,C=CN] is not signed by a trusted CA")
+
+	// 共用同一个 RevocationChecker 时，Org1 的 CRL 不影响没有 CRL 的 Org2。
+	strangerCert, _ := stranger.NewIdentity(t, "user1@org2.example.com", ca.ClientOU)
+	require.NoError(t, checker.Check(stranger.chain(strangerCert)))
+
+	// 加载过 CRL 的签发者必须一直有有效的 CRL，例如 CA 密钥轮换之后旧的 CRL 不再适用。
+	rotated, err := org.Root.NewIntermediateCA("ica.org1.example.com", pkix.Name{}, msptest.NewKey(t))
+	require.NoError(t, err)
+	rotatedCert, err := rotated.NewSigningCertificate("user2@org1.example.com", ca.ClientOU, &msptest.NewKey(t).PublicKey)
+	require.NoError(t, err)
+	require.ErrorIs(t, checker.Check([]*x509.Certificate{rotatedCert, rotated.SignCert, org.Root.SignCert}), ErrNoRevocationData)
+}
+
+func TestRevocationCheckerForgedCRL(t *testing.T) {
+	org := newTestOrg(t, "org1.example.com")
+	revoked, _ := org.NewIdentity(t, "revoked@org1.example.com", ca.ClientOU)
+
+	now := time.Now()
+	checker := NewRevocationChecker(RevocationConfig{TrustedCAs: org.trustedCAs()})
+	require.NoError(t, checker.AddCRL(org.newCRL(t, 1, now.Add(-time.Minute), now.Add(time.Hour), KeyCompromise, revoked)))
+
+	// 伪造一张与中间 CA 主题和密钥标识都相同的 CA 证书，用它签发一个编号更大的空 CRL。
+	sk := msptest.NewKey(t)
+	template := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               org.Intermediate.SignCert.Subject,
+		SubjectKeyId:          org.Intermediate.SignCert.SubjectKeyId,
+		NotBefore:             now.Add(-time.Hour),
+		NotAfter:              now.Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &sk.PublicKey, sk)
+	require.NoError(t, err)
+	forger, err := x509.ParseCertificate(der)
+	require.NoError(t, err)
+	forged := signCRL(t, forger, sk, 100, now, now.Add(time.Hour), Unspecified)
+
+	require.EqualError(t, checker.AddCRL(forged), "the CRL of [CN=ica.org1.example.com,O=ica.org1.example.com,C=CN] is not signed by a trusted CA")
+	require.EqualError(t, checker.Check(org.chain(revoked)), "the certificate [CN=revoked@org1.example.com,OU=client,C=CN] has been revoked, reason [keyCompromise], source [crl]")
+}
+
+func TestRevocationCheckerStaleCRL(t *testing.T) {
+	org := newTestOrg(t, "org1.example.com")
+	cert, _ := org.NewIdentity(t, "peer0.org1.example.com", ca.PeerOU)
+
+	now := time.Now()
+	checker := NewRevocationChecker(RevocationConfig{GracePeriod: 10 * time.Minute, TrustedCAs: org.trustedCAs()})
+	require.NoError(t, checker.AddCRL(org.newCRL(t, 1, now.Add(-2*time.Hour), now.Add(-time.Hour), Unspecified)))
+
+	checker.now = func() time.Time { return now.Add(-55 * time.Minute) }
+	require.NoError(t, checker.Check(org.chain(cert)))
+
+	checker.now = func() time.Time { return now }
+	err := checker.Check(org.chain(cert))
+	require.Error(t, err)
+	require.True(t, errors.Is(err, ErrStaleRevocationData))
+}
+
+func TestRevocationCheckerRefresh(t *testing.T) {
+	org := newTestOrg(t, "org1.example.com")
+	var crl []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/crl.pem" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(crl)
+	}))
+	defer server.Close()
+
+	good := org.newCRLDPIdentity(t, "good@org1.example.com", server.URL+"/crl.pem")
+	revoked := org.newCRLDPIdentity(t, "revoked@org1.example.com", server.URL+"/crl.pem")
+	now := time.Now()
+	crl = org.newCRL(t, 1, now.Add(-time.Minute), now.Add(time.Hour), Superseded, revoked)
+
+	file := filepath.Join(t.TempDir(), "crl.pem")
+	require.NoError(t, os.WriteFile(file, crl, 0o644))
+
+	checker := NewRevocationChecker(RevocationConfig{CRLSources: []string{server.URL + "/crl.pem"}, TrustedCAs: org.trustedCAs()})
+	// 证书的 CRL 分发点是配置的来源，但还没有下载到 CRL 时，校验失败而不是放行。
+	require.ErrorIs(t, checker.Check(org.chain(good)), ErrNoRevocationData)
+	require.NoError(t, checker.Refresh(context.Background()))
+	require.NoError(t, checker.Check(org.chain(good)))
+	require.EqualError(t, checker.Check(org.chain(revoked)), "the certificate [CN=revoked@org1.example.com,OU=client] has been revoked, reason [superseded], source [crl]")
+
+	checker = NewRevocationChecker(RevocationConfig{TrustedCAs: org.trustedCAs(), CRLSources: []string{file, server.URL + "/missing", filepath.Join(t.TempDir(), "missing")}})
+	err := checker.Refresh(context.Background())
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "unexpected HTTP status [404 Not Found]")
+	require.Contains(t, err.Error(), "no such file or directory")
+	require.Error(t, checker.Check(org.chain(revoked)))
+
+	// 通过 MSP 配置传入的 RevocationChecker 会被用于身份校验。
+	dir := org.writeMSPDir(t, nil, nil, true)
+	conf, err := GetVerifyingMSPConfig(dir, "Org1MSP")
+	require.NoError(t, err)
+	conf.RevocationChecker = checker
+	msp, err := New(conf)
+	require.NoError(t, err)
+	id, err := msp.(*x509msp).getIdentityFromPEM(pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: revoked.Raw}))
+	require.NoError(t, err)
+	require.Error(t, id.Validate())
+}
+
+func TestRevocationCheckerOCSP(t *testing.T) {
+	org := newTestOrg(t, "org1.example.com")
+
+	var requests int
+	var down bool
+	statuses := map[string]int{}
+	responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/unavailable" || down {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		requests++
+		raw, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		req, err := ocsp.ParseRequest(raw)
+		require.NoError(t, err)
+
+		now := time.Now()
+		template := ocsp.Response{
+			Status:       statuses[req.SerialNumber.String()],
+			SerialNumber: req.SerialNumber,
+			ThisUpdate:   now.Add(-time.Minute),
+			NextUpdate:   now.Add(time.Hour),
+		}
+		if template.Status == ocsp.Revoked {
+			template.RevokedAt = now.Add(-time.Minute)
+			template.RevocationReason = ocsp.CessationOfOperation
+		}
+		resp, err := ocsp.CreateResponse(org.Intermediate.SignCert, org.Intermediate.SignCert, template, org.Intermediate.Signer)
+		require.NoError(t, err)
+		w.Header().Set("Content-Type", "application/ocsp-response")
+		w.Write(resp)
+	}))
+	defer responder.Close()
+
+	good := org.newOCSPIdentity(t, "good@org1.example.com", responder.URL)
+	revoked := org.newOCSPIdentity(t, "revoked@org1.example.com", responder.URL)
+	statuses[good.SerialNumber.String()] = ocsp.Good
+	statuses[revoked.SerialNumber.String()] = ocsp.Revoked
+
+	checker := NewRevocationChecker(RevocationConfig{EnableOCSP: true})
+	require.NoError(t, checker.Check(org.chain(good)))
+	require.NoError(t, checker.Check(org.chain(good)))
+	require.Equal(t, 1, requests, "the OCSP response should be cached")
+
+	// OCSP 服务器不可用时，宽限期内继续使用缓存的响应，超过宽限期之后校验失败。
+	graceful := NewRevocationChecker(RevocationConfig{EnableOCSP: true, GracePeriod: 30 * time.Minute})
+	require.NoError(t, graceful.Check(org.chain(good)))
+	down = true
+	now := time.Now()
+	graceful.now = func() time.Time { return now.Add(80 * time.Minute) }
+	require.NoError(t, graceful.Check(org.chain(good)))
+	graceful.now = func() time.Time { return now.Add(2 * time.Hour) }
+	require.Error(t, graceful.Check(org.chain(good)))
+	down = false
+
+	err := checker.Check(org.chain(revoked))
+	var revocationErr *RevocationError
+	require.True(t, errors.As(err, &revocationErr))
+	require.Equal(t, CessationOfOperation, revocationErr.Reason)
+	require.Equal(t, "ocsp", revocationErr.Source)
+
+	// 响应者不知道证书的状态时，校验失败而不是放行。
+	unknown := org.newOCSPIdentity(t, "unknown@org1.example.com", responder.URL)
+	statuses[unknown.SerialNumber.String()] = ocsp.Unknown
+	require.ErrorIs(t, checker.Check(org.chain(unknown)), ErrNoRevocationData)
+
+	// OCSP 服务器不可用时，校验失败而不是放行。
+	unreachable := org.newOCSPIdentity(t, "unreachable@org1.example.com", responder.URL+"/unavailable")
+	err = checker.Check(org.chain(unreachable))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "could not determine the revocation status")
+
+	// 未开启 OCSP 时不会查询。
+	require.NoError(t, NewRevocationChecker(RevocationConfig{}).Check(org.chain(unreachable)))
+}
@@ -0,0 +1,95 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+* From: hyperledger/fabric/protoutil/blockutils.go
+ */
+
+package protoutil
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"errors"
+	"fmt"
+	"hash"
+	"math/big"
+
+	"github.com/geistwelt/quarkx/common/merkle"
+	"github.com/geistwelt/quarkx/protos/common"
+)
+
+// NewBlock 创建一个编号为 seqNum、前一个区块哈希为 previousHash 的空区块。
+func NewBlock(seqNum uint64, previousHash []byte) *common.Block {
+	return &common.Block{
+		Header:   &common.BlockHeader{Number: seqNum, PreviousHash: previousHash},
+		Data:     &common.BlockData{},
+		Metadata: &common.BlockMetadata{},
+	}
+}
+
+type asn1Header struct {
+	Number       *big.Int
+	PreviousHash []byte
+	DataHash     []byte
+}
+
+// BlockHeaderBytes 返回区块头的 ASN.1 编码，它是计算区块哈希时使用的规范编码。
+func BlockHeaderBytes(b *common.BlockHeader) []byte {
+	asn1Header := asn1Header{
+		PreviousHash: b.PreviousHash,
+		DataHash:     b.DataHash,
+		Number:       new(big.Int).SetUint64(b.Number),
+	}
+	result, err := asn1.Marshal(asn1Header)
+	if err != nil {
+		// 对这样的结构进行编码不会失败。
+		panic(err)
+	}
+	return result
+}
+
+// BlockHeaderHash 用 hashFunc 计算区块头的哈希值。
+func BlockHeaderHash(b *common.BlockHeader, hashFunc func() hash.Hash) []byte {
+	h := hashFunc()
+	h.Write(BlockHeaderBytes(b))
+	return h.Sum(nil)
+}
+
+// BlockDataHash 计算区块中所有交易组成的宽度为 width 的 Merkle 树的根，没有交易时
+// 返回空输入的哈希值。
+func BlockDataHash(b *common.BlockData, width int, hashFunc func() hash.Hash) ([]byte, error) {
+	if len(b.GetData()) == 0 {
+		return hashFunc().Sum(nil), nil
+	}
+
+	return merkle.Root(width, hashFunc, b.Data)
+}
+
+// TxInclusionProof 生成区块中第 index 个交易的包含证明，轻节点只需要区块头即可验证。
+func TxInclusionProof(block *common.Block, index int, width int, hashFunc func() hash.Hash) (*merkle.Proof, error) {
+	if len(block.GetData().GetData()) == 0 {
+		return nil, errors.New("block has no transactions")
+	}
+
+	tree, err := merkle.New(width, hashFunc, block.Data.Data)
+	if err != nil {
+		return nil, err
+	}
+	if len(block.GetHeader().GetDataHash()) != 0 && !bytes.Equal(block.Header.DataHash, tree.Root()) {
+		return nil, fmt.Errorf("block [%d] data hash does not match its transactions", block.Header.Number)
+	}
+
+	return tree.Proof(index)
+}
+
+// VerifyTxInclusion 检查 proof 是否证明 tx 包含在区块头为 header 的区块中。
+func VerifyTxInclusion(header *common.BlockHeader, tx []byte, proof *merkle.Proof, width int, hashFunc func() hash.Hash) error {
+	if header == nil {
+		return errors.New("nil block header")
+	}
+	if err := merkle.Verify(width, hashFunc, header.DataHash, tx, proof); err != nil {
+		return fmt.Errorf("transaction is not included in block [%d] [%v]", header.Number, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,143 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+* From: hyperledger/fabric/protoutil/commonutils.go
+ */
+
+package protoutil
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/geistwelt/quarkx/bccsp/entropy"
+	"github.com/geistwelt/quarkx/protos/common"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// NonceSize 是 SignatureHeader 中随机数的字节长度。
+const NonceSize = 24
+
+// Signer 是可以签名并序列化自身的身份，msp.SigningIdentity 满足该接口。
+type Signer interface {
+	// Sign 对 msg 进行签名，返回 low-S 形式的签名。
+	Sign(msg []byte) ([]byte, error)
+
+	// Serialize 返回序列化之后的身份。
+	Serialize() ([]byte, error)
+}
+
+// Marshal 以确定性的方式序列化 pb，同一个消息总是得到相同的字节。
+func Marshal(pb proto.Message) ([]byte, error) {
+	return proto.MarshalOptions{Deterministic: true}.Marshal(pb)
+}
+
+// MarshalOrPanic 序列化 pb，失败时 panic，只应该用于不会失败的场景。
+func MarshalOrPanic(pb proto.Message) []byte {
+	raw, err := Marshal(pb)
+	if err != nil {
+		panic(err)
+	}
+	return raw
+}
+
+// CreateNonce 从 entropy.Reader 中读取一个 NonceSize 字节的随机数。
+func CreateNonce() ([]byte, error) {
+	return CreateNonceWithRand(entropy.Reader)
+}
+
+// CreateNonceWithRand 从 rand 中读取一个 NonceSize 字节的随机数。
+func CreateNonceWithRand(rand io.Reader) ([]byte, error) {
+	nonce := make([]byte, NonceSize)
+	if _, err := io.ReadFull(rand, nonce); err != nil {
+		return nil, fmt.Errorf("error generating random nonce [%v]", err)
+	}
+	return nonce, nil
+}
+
+// ComputeTxID 计算 SHA-256(nonce || creator) 的十六进制编码作为交易 ID。
+func ComputeTxID(nonce, creator []byte) string {
+	hash := sha256.New()
+	hash.Write(nonce)
+	hash.Write(creator)
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// CheckTxID 检查 txID 是否由 nonce 与 creator 计算得到。
+func CheckTxID(txID string, nonce, creator []byte) error {
+	if computed := ComputeTxID(nonce, creator); txID != computed {
+		return fmt.Errorf("invalid txid. got [%s], expected [%s]", txID, computed)
+	}
+	return nil
+}
+
+// MakeChannelHeader 创建一个时间戳为当前时间的 ChannelHeader。
+func MakeChannelHeader(headerType common.HeaderType, version int32, channelID string, txID string, epoch uint64) *common.ChannelHeader {
+	return &common.ChannelHeader{
+		Type:      int32(headerType),
+		Version:   version,
+		Timestamp: timestamppb.New(time.Now().UTC()),
+		ChannelId: channelID,
+		TxId:      txID,
+		Epoch:     epoch,
+	}
+}
+
+// MakeSignatureHeader 创建一个 SignatureHeader。
+func MakeSignatureHeader(creator []byte, nonce []byte) *common.SignatureHeader {
+	return &common.SignatureHeader{Creator: creator, Nonce: nonce}
+}
+
+// MakePayloadHeader 由 ChannelHeader 与 SignatureHeader 创建 Header。
+func MakePayloadHeader(ch *common.ChannelHeader, sh *common.SignatureHeader) (*common.Header, error) {
+	chBytes, err := Marshal(ch)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling ChannelHeader [%v]", err)
+	}
+	shBytes, err := Marshal(sh)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling SignatureHeader [%v]", err)
+	}
+
+	return &common.Header{ChannelHeader: chBytes, SignatureHeader: shBytes}, nil
+}
+
+// UnmarshalEnvelope 反序列化 Envelope。
+func UnmarshalEnvelope(raw []byte) (*common.Envelope, error) {
+	env := &common.Envelope{}
+	if err := proto.Unmarshal(raw, env); err != nil {
+		return nil, fmt.Errorf("error unmarshaling Envelope [%v]", err)
+	}
+	return env, nil
+}
+
+// UnmarshalPayload 反序列化 Payload。
+func UnmarshalPayload(raw []byte) (*common.Payload, error) {
+	payload := &common.Payload{}
+	if err := proto.Unmarshal(raw, payload); err != nil {
+		return nil, fmt.Errorf("error unmarshaling Payload [%v]", err)
+	}
+	return payload, nil
+}
+
+// UnmarshalChannelHeader 反序列化 ChannelHeader。
+func UnmarshalChannelHeader(raw []byte) (*common.ChannelHeader, error) {
+	ch := &common.ChannelHeader{}
+	if err := proto.Unmarshal(raw, ch); err != nil {
+		return nil, fmt.Errorf("error unmarshaling ChannelHeader [%v]", err)
+	}
+	return ch, nil
+}
+
+// UnmarshalSignatureHeader 反序列化 SignatureHeader。
+func UnmarshalSignatureHeader(raw []byte) (*common.SignatureHeader, error) {
+	sh := &common.SignatureHeader{}
+	if err := proto.Unmarshal(raw, sh); err != nil {
+		return nil, fmt.Errorf("error unmarshaling SignatureHeader [%v]", err)
+	}
+	return sh, nil
+}
@@ -0,0 +1,176 @@
+package protoutil
+
+import (
+	"crypto/elliptic"
+	"crypto/sha256"
+	"math/big"
+	"testing"
+
+	"github.com/geistwelt/quarkx/bccsp/utils"
+	"github.com/geistwelt/quarkx/common/ca"
+	"github.com/geistwelt/quarkx/msp"
+	"github.com/geistwelt/quarkx/msp/msptest"
+	"github.com/geistwelt/quarkx/protos/common"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+	"google.golang.org/protobuf/proto"
+)
+
+func newMSP(t *testing.T) msp.MSP {
+	org := msptest.NewOrg(t, "org1.example.com")
+	cert, sk := org.NewIdentity(t, "user1@org1.example.com", ca.ClientOU)
+
+	m, err := msp.New(&msp.Config{
+		Name:              "Org1MSP",
+		RootCerts:         [][]byte{ca.CertToPEM(org.Root.SignCert)},
+		IntermediateCerts: [][]byte{ca.CertToPEM(org.Intermediate.SignCert)},
+		SigningIdentity:   &msp.SigningIdentityInfo{PublicSigner: ca.CertToPEM(cert), Signer: sk},
+	})
+	require.NoError(t, err)
+
+	return m
+}
+
+func TestSignedEnvelope(t *testing.T) {
+	m := newMSP(t)
+	signer, err := m.GetDefaultSigningIdentity()
+	require.NoError(t, err)
+
+	data := &common.SignatureHeader{Nonce: []byte("data")}
+	env, err := CreateSignedEnvelope(common.HeaderType_ENDORSER_TRANSACTION, "mychannel", signer, data, 1, 2)
+	require.NoError(t, err)
+
+	raw, err := Marshal(env)
+	require.NoError(t, err)
+	env, err = UnmarshalEnvelope(raw)
+	require.NoError(t, err)
+
+	verified, err := VerifyEnvelope(env, m)
+	require.NoError(t, err)
+	require.Equal(t, int32(common.HeaderType_ENDORSER_TRANSACTION), verified.ChannelHeader.Type)
+	require.Equal(t, "mychannel", verified.ChannelHeader.ChannelId)
+	require.Equal(t, int32(1), verified.ChannelHeader.Version)
+	require.Equal(t, uint64(2), verified.ChannelHeader.Epoch)
+	require.Len(t, verified.SignatureHeader.Nonce, NonceSize)
+	require.Equal(t, ComputeTxID(verified.SignatureHeader.Nonce, verified.SignatureHeader.Creator), verified.ChannelHeader.TxId)
+	require.Equal(t, signer.GetIdentifier(), verified.Creator.GetIdentifier())
+	require.Equal(t, MarshalOrPanic(data), verified.Payload.Data)
+
+	// 签名必须是 low-S 形式的。
+	r, s, err := utils.UnmarshalECDSASignature(env.Signature)
+	require.NoError(t, err)
+	highS, err := utils.MarshalECDSASignature(r, new(big.Int).Sub(elliptic.P256().Params().N, s))
+	require.NoError(t, err)
+	_, err = VerifyEnvelope(&common.Envelope{Payload: env.Payload, Signature: highS}, m)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid S, must be smaller than half the order")
+
+	// 篡改 Payload 之后签名无效。
+	payload, err := UnmarshalPayload(env.Payload)
+	require.NoError(t, err)
+	payload.Data = []byte("tampered")
+	_, err = VerifyEnvelope(&common.Envelope{Payload: MarshalOrPanic(payload), Signature: env.Signature}, m)
+	require.EqualError(t, err, "creator's signature over the payload is not valid [the signature is invalid]")
+
+	// 交易 ID 必须由随机数与创建者计算得到。
+	ch, err := UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	require.NoError(t, err)
+	ch.TxId = "forged"
+	payload.Header.ChannelHeader = MarshalOrPanic(ch)
+	_, err = VerifyEnvelope(&common.Envelope{Payload: MarshalOrPanic(payload), Signature: env.Signature}, m)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid txid. got [forged]")
+
+	sd, err := EnvelopeAsSignedData(env)
+	require.NoError(t, err)
+	require.Equal(t, []*SignedData{{Data: env.Payload, Identity: verified.SignatureHeader.Creator, Signature: env.Signature}}, sd)
+
+	hash, err := EnvelopeHash(env, sha256.New)
+	require.NoError(t, err)
+	expected := sha256.Sum256(env.Payload)
+	require.Equal(t, expected[:], hash)
+}
+
+func TestNonCanonicalEncoding(t *testing.T) {
+	m := newMSP(t)
+	signer, err := m.GetDefaultSigningIdentity()
+	require.NoError(t, err)
+
+	env, err := CreateSignedEnvelopeWithData(common.HeaderType_MESSAGE, "mychannel", signer, []byte("data"), 0, 0)
+	require.NoError(t, err)
+
+	// 在 Payload 中重复一次 data 字段，解码后得到相同的消息，但编码不同。
+	payload := protowire.AppendTag(env.Payload, 2, protowire.BytesType)
+	payload = protowire.AppendBytes(payload, []byte("data"))
+	decoded := &common.Payload{}
+	require.NoError(t, proto.Unmarshal(payload, decoded))
+	require.Equal(t, []byte("data"), decoded.Data)
+
+	// 签名与哈希都以签名者实际签名的字节为准，两种编码是两个不同的 Envelope。
+	sig, err := signer.Sign(payload)
+	require.NoError(t, err)
+	reencoded := &common.Envelope{Payload: payload, Signature: sig}
+	_, err = VerifyEnvelope(reencoded, m)
+	require.NoError(t, err)
+	_, err = VerifyEnvelope(&common.Envelope{Payload: MarshalOrPanic(decoded), Signature: sig}, m)
+	require.EqualError(t, err, "creator's signature over the payload is not valid [the signature is invalid]")
+
+	hash, err := EnvelopeHash(reencoded, sha256.New)
+	require.NoError(t, err)
+	expected := sha256.Sum256(payload)
+	require.Equal(t, expected[:], hash)
+	canonical, err := EnvelopeHash(env, sha256.New)
+	require.NoError(t, err)
+	require.NotEqual(t, canonical, hash)
+	_, err = EnvelopeHash(nil, sha256.New)
+	require.EqualError(t, err, "nil envelope")
+
+	_, err = VerifyEnvelope(nil, m)
+	require.EqualError(t, err, "nil envelope")
+	_, err = VerifyEnvelope(&common.Envelope{Payload: MarshalOrPanic(&common.Payload{Data: []byte("data")})}, m)
+	require.EqualError(t, err, "missing header in Payload")
+}
+
+func TestBlockHashes(t *testing.T) {
+	m := newMSP(t)
+	signer, err := m.GetDefaultSigningIdentity()
+	require.NoError(t, err)
+
+	block := NewBlock(1, []byte("previous"))
+	emptyHash, err := BlockDataHash(block.Data, 4, sha256.New)
+	require.NoError(t, err)
+	expected := sha256.Sum256(nil)
+	require.Equal(t, expected[:], emptyHash)
+	_, err = TxInclusionProof(block, 0, 4, sha256.New)
+	require.EqualError(t, err, "block has no transactions")
+
+	for i := 0; i < 10; i++ {
+		env, err := CreateSignedEnvelopeWithData(common.HeaderType_MESSAGE, "mychannel", signer, []byte{byte(i)}, 0, 0)
+		require.NoError(t, err)
+		block.Data.Data = append(block.Data.Data, MarshalOrPanic(env))
+	}
+	block.Header.DataHash, err = BlockDataHash(block.Data, 4, sha256.New)
+	require.NoError(t, err)
+
+	headerHash := BlockHeaderHash(block.Header, sha256.New)
+	require.Len(t, headerHash, sha256.Size)
+	next := NewBlock(2, headerHash)
+	require.Equal(t, headerHash, next.Header.PreviousHash)
+
+	// 轻节点只持有区块头，通过包含证明验证交易。
+	header := &common.BlockHeader{Number: block.Header.Number, PreviousHash: block.Header.PreviousHash, DataHash: block.Header.DataHash}
+	for i, tx := range block.Data.Data {
+		proof, err := TxInclusionProof(block, i, 4, sha256.New)
+		require.NoError(t, err)
+		require.NoError(t, VerifyTxInclusion(header, tx, proof, 4, sha256.New))
+		require.Error(t, VerifyTxInclusion(header, tx, proof, 2, sha256.New))
+	}
+
+	proof, err := TxInclusionProof(block, 0, 4, sha256.New)
+	require.NoError(t, err)
+	require.EqualError(t, VerifyTxInclusion(header, []byte("forged"), proof, 4, sha256.New), "transaction is not included in block [1] [merkle root mismatch]")
+
+	block.Data.Data[0] = []byte("tampered")
+	_, err = TxInclusionProof(block, 0, 4, sha256.New)
+	require.EqualError(t, err, "block [1] data hash does not match its transactions")
+}
@@ -0,0 +1,15 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+* From: hyperledger/fabric/protoutil/signeddata.go
+ */
+
+package protoutil
+
+// SignedData 是一条待验证的签名，Identity 是序列化的签名者身份。它定义在 protoutil 中，
+// 以便 common/policies 等上层的包使用，而 protoutil 不依赖它们。
+type SignedData struct {
+	Data      []byte
+	Identity  []byte
+	Signature []byte
+}
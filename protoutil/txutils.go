@@ -0,0 +1,161 @@
+/**
+* Author: Xiangyu Wu
+* Date: 2026-10-19
+* From: hyperledger/fabric/protoutil/txutils.go
+ */
+
+package protoutil
+
+import (
+	"errors"
+	"fmt"
+	"hash"
+
+	"github.com/geistwelt/quarkx/msp"
+	"github.com/geistwelt/quarkx/protos/common"
+	"google.golang.org/protobuf/proto"
+)
+
+// VerifiedEnvelope 是通过了 VerifyEnvelope 校验的 Envelope 的内容。
+type VerifiedEnvelope struct {
+	Payload         *common.Payload
+	ChannelHeader   *common.ChannelHeader
+	SignatureHeader *common.SignatureHeader
+	Creator         msp.Identity
+}
+
+// CreateSignedEnvelope 创建一个由 signer 签名的 Envelope，dataMsg 序列化之后作为 Payload
+// 的 data，交易 ID 由随机数与 signer 的身份计算得到。
+func CreateSignedEnvelope(txType common.HeaderType, channelID string, signer Signer, dataMsg proto.Message, msgVersion int32, epoch uint64) (*common.Envelope, error) {
+	data, err := Marshal(dataMsg)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling data [%v]", err)
+	}
+
+	return CreateSignedEnvelopeWithData(txType, channelID, signer, data, msgVersion, epoch)
+}
+
+// CreateSignedEnvelopeWithData 与 CreateSignedEnvelope 相同，但 Payload 的 data 直接使用
+// 已经序列化好的 data。
+func CreateSignedEnvelopeWithData(txType common.HeaderType, channelID string, signer Signer, data []byte, msgVersion int32, epoch uint64) (*common.Envelope, error) {
+	if signer == nil {
+		return nil, errors.New("nil signer")
+	}
+
+	creator, err := signer.Serialize()
+	if err != nil {
+		return nil, fmt.Errorf("error serializing signer [%v]", err)
+	}
+	nonce, err := CreateNonce()
+	if err != nil {
+		return nil, err
+	}
+
+	ch := MakeChannelHeader(txType, msgVersion, channelID, ComputeTxID(nonce, creator), epoch)
+	header, err := MakePayloadHeader(ch, MakeSignatureHeader(creator, nonce))
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := Marshal(&common.Payload{Header: header, Data: data})
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling Payload [%v]", err)
+	}
+
+	sig, err := signer.Sign(payload)
+	if err != nil {
+		return nil, fmt.Errorf("error signing Payload [%v]", err)
+	}
+
+	return &common.Envelope{Payload: payload, Signature: sig}, nil
+}
+
+// UnpackEnvelope 解析 Envelope 中的 Payload 与两个头部，但不验证签名。
+func UnpackEnvelope(env *common.Envelope) (*common.Payload, *common.ChannelHeader, *common.SignatureHeader, error) {
+	if env == nil {
+		return nil, nil, nil, errors.New("nil envelope")
+	}
+
+	payload, err := UnmarshalPayload(env.Payload)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	if payload.Header == nil {
+		return nil, nil, nil, errors.New("missing header in Payload")
+	}
+
+	ch, err := UnmarshalChannelHeader(payload.Header.ChannelHeader)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	sh, err := UnmarshalSignatureHeader(payload.Header.SignatureHeader)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	return payload, ch, sh, nil
+}
+
+// VerifyEnvelope 解析 Envelope，检查交易 ID 与随机数、创建者的身份是否有效，并验证创建者
+// 对 Payload 的签名，签名必须是 low-S 形式的。
+func VerifyEnvelope(env *common.Envelope, deserializer msp.IdentityDeserializer) (*VerifiedEnvelope, error) {
+	payload, ch, sh, err := UnpackEnvelope(env)
+	if err != nil {
+		return nil, err
+	}
+
+	if ch.Timestamp == nil {
+		return nil, errors.New("missing timestamp in ChannelHeader")
+	}
+	if err := ch.Timestamp.CheckValid(); err != nil {
+		return nil, fmt.Errorf("invalid timestamp in ChannelHeader [%v]", err)
+	}
+	if len(sh.Nonce) == 0 {
+		return nil, errors.New("empty nonce in SignatureHeader")
+	}
+	if len(sh.Creator) == 0 {
+		return nil, errors.New("empty creator in SignatureHeader")
+	}
+	if err := CheckTxID(ch.TxId, sh.Nonce, sh.Creator); err != nil {
+		return nil, err
+	}
+
+	creator, err := deserializer.DeserializeIdentity(sh.Creator)
+	if err != nil {
+		return nil, fmt.Errorf("failed to deserialize creator identity [%v]", err)
+	}
+	if err := creator.Validate(); err != nil {
+		return nil, fmt.Errorf("creator certificate is not valid [%v]", err)
+	}
+	if err := creator.Verify(env.Payload, env.Signature); err != nil {
+		return nil, fmt.Errorf("creator's signature over the payload is not valid [%v]", err)
+	}
+
+	return &VerifiedEnvelope{Payload: payload, ChannelHeader: ch, SignatureHeader: sh, Creator: creator}, nil
+}
+
+// EnvelopeAsSignedData 将 Envelope 转换成可以交给签名策略评估的 SignedData。
+func EnvelopeAsSignedData(env *common.Envelope) ([]*SignedData, error) {
+	_, _, sh, err := UnpackEnvelope(env)
+	if err != nil {
+		return nil, err
+	}
+
+	return []*SignedData{{
+		Data:      env.Payload,
+		Identity:  sh.Creator,
+		Signature: env.Signature,
+	}}, nil
+}
+
+// EnvelopeHash 用 hashFunc 计算 Envelope 中被签名的 Payload 字节的哈希值。protobuf 的
+// 编码不唯一，哈希值直接取自签名者签名的字节，而不是重新序列化之后的结果。
+func EnvelopeHash(env *common.Envelope, hashFunc func() hash.Hash) ([]byte, error) {
+	if env == nil {
+		return nil, errors.New("nil envelope")
+	}
+
+	h := hashFunc()
+	h.Write(env.Payload)
+	return h.Sum(nil), nil
+}